@@ -138,7 +138,10 @@ func buildOrderRequest(flags *parsedFlags) common.OrderRequest {
 
 // executePreview generates and displays an order preview
 func executePreview(ctx context.Context, cfg *config.Config, adjuster *common.PriceAdjuster, req common.OrderRequest) error {
-	orderService := order.NewOrderServiceWithPrime(cfg, adjuster, nil)
+	orderService, err := order.NewOrderServiceWithPrime(cfg, adjuster, nil, nil)
+	if err != nil {
+		return err
+	}
 
 	response, err := orderService.GeneratePreview(ctx, req)
 	if err != nil {
@@ -150,7 +153,10 @@ func executePreview(ctx context.Context, cfg *config.Config, adjuster *common.Pr
 
 // executeOrder places an actual order and stores metadata
 func executeOrder(ctx context.Context, cfg *config.Config, adjuster *common.PriceAdjuster, req common.OrderRequest, unitType string, quantity decimal.Decimal) error {
-	orderService := order.NewOrderServiceWithPrime(cfg, adjuster, nil)
+	orderService, err := order.NewOrderServiceWithPrime(cfg, adjuster, nil, nil)
+	if err != nil {
+		return err
+	}
 
 	response, err := orderService.PlaceOrder(ctx, req)
 	if err != nil {
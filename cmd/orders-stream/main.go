@@ -17,6 +17,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -27,6 +28,7 @@ import (
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/database"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/prime"
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
@@ -97,32 +99,36 @@ func run() error {
 	// Create database handler
 	handler := websocket.NewDbOrderHandler(db, priceAdjuster, metadataStore)
 
-	// Create orders websocket config
-	wsConfig := websocket.OrdersConfig{
-		CommonConfig: websocket.CommonConfig{
-			Url:              cfg.MarketData.WebSocketUrl,
-			AccessKey:        cfg.Prime.AccessKey,
-			Passphrase:       cfg.Prime.Passphrase,
-			SigningKey:       cfg.Prime.SigningKey,
-			ServiceAccountId: cfg.Prime.ServiceAccountId,
-			Products:         productIds,
-			ReconnectDelay:   cfg.MarketData.ReconnectDelay,
-		},
-		PortfolioId: cfg.Prime.Portfolio,
+	// Create REST backfiller to recover order states missed during a sequence gap
+	ordersSvc, err := prime.NewOrdersService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create orders service: %w", err)
 	}
+	backfiller := websocket.NewRestOrderBackfiller(ordersSvc, cfg.Prime.Portfolio, db, handler)
+
+	// Create orders websocket config
+	wsConfig := websocket.NewOrdersConfig(cfg, productIds)
+	wsConfig.Backfiller = backfiller
 
 	// Create and start websocket client
 	wsClient := websocket.NewOrdersClient(wsConfig, handler)
-	if err := wsClient.Start(); err != nil {
+	if err := wsClient.Start(context.Background()); err != nil {
 		return fmt.Errorf("failed to start websocket client: %w", err)
 	}
 
 	zap.L().Info("Orders websocket client started. Press Ctrl+C to stop.")
 
-	// Wait for interrupt signal
+	// Wait for an interrupt signal, or for the client to stop on its own after giving up
+	// reconnecting or hitting a fatal error such as rejected credentials
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	<-sigChan
+	select {
+	case <-sigChan:
+	case <-wsClient.Done():
+		if err := wsClient.Err(); err != nil {
+			return fmt.Errorf("orders connection lost: %w", err)
+		}
+	}
 
 	zap.L().Info("Shutting down orders websocket client...")
 	wsClient.Stop()
@@ -0,0 +1,129 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
+	"github.com/shopspring/decimal"
+)
+
+// marketDataGateTimeout bounds how long --check-market-data waits for the first order book snapshot
+const marketDataGateTimeout = 15 * time.Second
+
+// marketDataHealth builds the book health thresholds from the market data configuration
+// RecoverAfter is left unset: each command checks a product once or twice and exits, so a tripped
+// breaker never gets the chance to recover within the process
+func marketDataHealth(cfg *config.Config) (websocket.HealthConfig, error) {
+	health := websocket.HealthConfig{
+		StaleAfter:          cfg.MarketData.StaleAfter,
+		StaleAfterByProduct: cfg.MarketData.StaleAfterByProduct,
+	}
+	if cfg.MarketData.MaxSpreadBps != "" {
+		maxSpread, err := decimal.NewFromString(cfg.MarketData.MaxSpreadBps)
+		if err != nil {
+			return websocket.HealthConfig{}, fmt.Errorf("invalid MARKET_DATA_MAX_SPREAD_BPS: %w", err)
+		}
+		health.MaxSpreadBps = maxSpread
+	}
+	return health, nil
+}
+
+// marketDataGate is a trading gate that streams market data for each product it is asked about
+// The feed starts on the first check so commands only subscribe to the product they trade
+type marketDataGate struct {
+	ctx     context.Context
+	cfg     *config.Config
+	store   *websocket.OrderBookStore
+	breaker *websocket.CircuitBreaker
+
+	mu     sync.Mutex
+	client *websocket.MarketDataClient
+}
+
+// newMarketDataGate creates a gate using the configured health thresholds
+func newMarketDataGate(ctx context.Context, cfg *config.Config) (*marketDataGate, error) {
+	health, err := marketDataHealth(cfg)
+	if err != nil {
+		return nil, err
+	}
+	store := websocket.NewOrderBookStore()
+	return &marketDataGate{
+		ctx:     ctx,
+		cfg:     cfg,
+		store:   store,
+		breaker: websocket.NewCircuitBreaker(store, health),
+	}, nil
+}
+
+// CheckTrading subscribes to the product if needed, waits for its book and consults the breaker
+func (g *marketDataGate) CheckTrading(product string) error {
+	client, err := g.subscribe(product)
+	if err != nil {
+		return err
+	}
+	if err := waitForOrderBook(client, g.store, product, marketDataGateTimeout); err != nil {
+		return err
+	}
+	return g.breaker.CheckTrading(product)
+}
+
+// subscribe starts the market data feed for product, or adds product to a running feed
+func (g *marketDataGate) subscribe(product string) (*websocket.MarketDataClient, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.client == nil {
+		client := websocket.NewMarketDataClient(websocket.NewMarketDataConfig(g.cfg, []string{product}), g.store)
+		if err := client.Start(g.ctx); err != nil {
+			return nil, fmt.Errorf("failed to start market data: %w", err)
+		}
+		g.client = client
+		return client, nil
+	}
+	if slices.Contains(g.client.Products(), product) {
+		return g.client, nil
+	}
+	return g.client, g.client.AddProducts(product)
+}
+
+// Close stops the market data feed, if one was started
+func (g *marketDataGate) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.client != nil {
+		g.client.Stop()
+		g.client = nil
+	}
+}
+
+// displayHealthWarning prints a banner when a streamed book should not be trusted
+func displayHealthWarning(health websocket.BookHealth) {
+	if health.Healthy() {
+		return
+	}
+	fmt.Printf("  ⚠ %s MARKET DATA UNHEALTHY: %s\n", health.Product, strings.Join(health.Problems(), ", "))
+	fmt.Printf("  ⚠ Prices below may not be live; orders and RFQs with --check-market-data will be refused\n")
+}
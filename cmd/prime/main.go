@@ -45,4 +45,9 @@ func init() {
 	rootCmd.AddCommand(ordersStreamCmd)
 	rootCmd.AddCommand(orderCmd)
 	rootCmd.AddCommand(rfqCmd)
+	rootCmd.AddCommand(mockWsCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(quoteCmd)
+	rootCmd.AddCommand(mdGatewayCmd)
+	rootCmd.AddCommand(mdCmd)
 }
@@ -0,0 +1,432 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/database"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	mdHistorySymbol    string
+	mdHistoryFrom      string
+	mdHistoryTo        string
+	mdHistorySnapshots bool
+	mdHistoryFormat    string
+
+	mdCandlesSymbol   string
+	mdCandlesInterval time.Duration
+	mdCandlesPrice    string
+	mdCandlesFrom     string
+	mdCandlesTo       string
+	mdCandlesFormat   string
+)
+
+// candlePersistInterval is how often streamed candles are written to the database
+const candlePersistInterval = 5 * time.Second
+
+var mdCmd = &cobra.Command{
+	Use:   "md",
+	Short: "Query recorded market data",
+}
+
+var mdHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show recorded mid/spread samples or book snapshots for a product",
+	Long: `Reads market data recorded by prime stream --history or prime md-gateway --history from the local database.
+
+--from and --to accept RFC3339 times ("2026-10-18T13:00:00Z"), local times ("2026-10-18 13:00:00", "2026-10-18 13:00",
+"2026-10-18") or a duration meaning that long ago ("30m", "2h").`,
+	Example: `  prime md history --symbol BTC-USD --from 1h
+  prime md history --symbol BTC-USD --from "2026-10-18 13:00" --to "2026-10-18 13:15" --snapshots
+  prime md history --symbol ETH-USD --from 24h --format csv > eth-usd.csv`,
+	RunE: runMdHistory,
+}
+
+func init() {
+	mdHistoryCmd.Flags().StringVar(&mdHistorySymbol, "symbol", "", "Product symbol (e.g., BTC-USD) (required)")
+	mdHistoryCmd.Flags().StringVar(&mdHistoryFrom, "from", "1h", "Start of the range (time, or a duration ago)")
+	mdHistoryCmd.Flags().StringVar(&mdHistoryTo, "to", "", "End of the range (time, or a duration ago); defaults to now")
+	mdHistoryCmd.Flags().BoolVar(&mdHistorySnapshots, "snapshots", false, "Show top-of-book snapshots instead of mid/spread samples")
+	mdHistoryCmd.Flags().StringVar(&mdHistoryFormat, "format", "table", "Output format: table or csv")
+	mdHistoryCmd.MarkFlagRequired("symbol")
+
+	mdCandlesCmd.Flags().StringVar(&mdCandlesSymbol, "symbol", "", "Product symbol (e.g., BTC-USD) (required)")
+	mdCandlesCmd.Flags().DurationVar(&mdCandlesInterval, "interval", time.Minute, "Candle interval: 1m, 5m or 1h")
+	mdCandlesCmd.Flags().StringVar(&mdCandlesPrice, "price", websocket.CandleSourceMid, "Price to chart: mid (raw Prime mid), bid or ask (fee-adjusted)")
+	mdCandlesCmd.Flags().StringVar(&mdCandlesFrom, "from", "24h", "Start of the range (time, or a duration ago)")
+	mdCandlesCmd.Flags().StringVar(&mdCandlesTo, "to", "", "End of the range (time, or a duration ago); defaults to now")
+	mdCandlesCmd.Flags().StringVar(&mdCandlesFormat, "format", "json", "Output format: json or csv")
+	mdCandlesCmd.MarkFlagRequired("symbol")
+
+	mdCmd.AddCommand(mdHistoryCmd)
+	mdCmd.AddCommand(mdCandlesCmd)
+}
+
+var mdCandlesCmd = &cobra.Command{
+	Use:   "candles",
+	Short: "Export OHLC candles built from the streamed order book",
+	Long: `Reads candles built by prime stream --candles or prime md-gateway --candles from the local database.
+Candles are aggregated from order book updates at 1m, 5m and 1h intervals for the raw mid and the fee-adjusted
+bid and ask, so no extra Prime API calls are made. The candle still forming is included.`,
+	Example: `  prime md candles --symbol BTC-USD --interval 5m --from 6h
+  prime md candles --symbol BTC-USD --interval 1h --price ask --format csv > btc-ask.csv`,
+	RunE: runMdCandles,
+}
+
+func runMdHistory(cmd *cobra.Command, args []string) error {
+	format := strings.ToLower(strings.TrimSpace(mdHistoryFormat))
+	if format != "table" && format != "csv" {
+		return fmt.Errorf("--format must be 'table' or 'csv', got: %s", mdHistoryFormat)
+	}
+
+	now := time.Now()
+	from, err := parseHistoryTime(mdHistoryFrom, now)
+	if err != nil {
+		return fmt.Errorf("invalid --from: %w", err)
+	}
+	to := now
+	if mdHistoryTo != "" {
+		if to, err = parseHistoryTime(mdHistoryTo, now); err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+	}
+	if to.Before(from) {
+		return fmt.Errorf("--to (%s) is before --from (%s)", to.Format(time.RFC3339), from.Format(time.RFC3339))
+	}
+	product := strings.ToUpper(strings.TrimSpace(mdHistorySymbol))
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	config.SetupLogger(cfg.Server.LogLevel, cfg.Server.LogJson)
+	defer zap.L().Sync()
+
+	db, err := database.NewOrdersDb(cfg.Database.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if mdHistorySnapshots {
+		snapshots, err := db.ListMarketSnapshots(product, from, to)
+		if err != nil {
+			return err
+		}
+		if format == "csv" {
+			return writeSnapshotsCsv(snapshots)
+		}
+		displaySnapshots(product, snapshots)
+		return nil
+	}
+
+	samples, err := db.ListMarketSamples(product, from, to)
+	if err != nil {
+		return err
+	}
+	if format == "csv" {
+		return writeSamplesCsv(samples)
+	}
+	displaySamples(product, samples)
+	return nil
+}
+
+func runMdCandles(cmd *cobra.Command, args []string) error {
+	format := strings.ToLower(strings.TrimSpace(mdCandlesFormat))
+	if format != "json" && format != "csv" {
+		return fmt.Errorf("--format must be 'json' or 'csv', got: %s", mdCandlesFormat)
+	}
+	source := strings.ToLower(strings.TrimSpace(mdCandlesPrice))
+	if !slices.Contains(websocket.CandleSources, source) {
+		return fmt.Errorf("--price must be one of %s, got: %s", strings.Join(websocket.CandleSources, ", "), mdCandlesPrice)
+	}
+	if !slices.Contains(websocket.DefaultCandleIntervals, mdCandlesInterval) {
+		return fmt.Errorf("--interval must be 1m, 5m or 1h, got: %s", intervalLabel(mdCandlesInterval))
+	}
+
+	now := time.Now()
+	from, err := parseHistoryTime(mdCandlesFrom, now)
+	if err != nil {
+		return fmt.Errorf("invalid --from: %w", err)
+	}
+	to := now
+	if mdCandlesTo != "" {
+		if to, err = parseHistoryTime(mdCandlesTo, now); err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+	}
+	product := strings.ToUpper(strings.TrimSpace(mdCandlesSymbol))
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	config.SetupLogger(cfg.Server.LogLevel, cfg.Server.LogJson)
+	defer zap.L().Sync()
+
+	db, err := database.NewOrdersDb(cfg.Database.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	// Include the candle containing --from, not just those starting after it
+	candles, err := db.ListCandles(product, source, mdCandlesInterval, from.Truncate(mdCandlesInterval), to)
+	if err != nil {
+		return err
+	}
+	if format == "csv" {
+		return writeCandlesCsv(candles)
+	}
+	return writeCandlesJson(candles)
+}
+
+// candleJson is the JSON form of a stored candle
+type candleJson struct {
+	Product  string `json:"product_id"`
+	Source   string `json:"price"`
+	Interval string `json:"interval"`
+	Start    string `json:"start"`
+	Open     string `json:"open"`
+	High     string `json:"high"`
+	Low      string `json:"low"`
+	Close    string `json:"close"`
+}
+
+// intervalLabel formats an interval the way --interval takes it, e.g. "5m" rather than "5m0s"
+func intervalLabel(interval time.Duration) string {
+	label := interval.String()
+	if strings.HasSuffix(label, "m0s") {
+		label = strings.TrimSuffix(label, "0s")
+	}
+	if strings.HasSuffix(label, "h0m") {
+		label = strings.TrimSuffix(label, "0m")
+	}
+	return label
+}
+
+// writeCandlesJson writes candles to stdout as an indented JSON array
+func writeCandlesJson(candles []database.CandleRecord) error {
+	out := make([]candleJson, len(candles))
+	for i, candle := range candles {
+		out[i] = candleJson{
+			Product:  candle.ProductId,
+			Source:   candle.Source,
+			Interval: intervalLabel(candle.Interval),
+			Start:    candle.StartTime.UTC().Format(time.RFC3339),
+			Open:     candle.Open,
+			High:     candle.High,
+			Low:      candle.Low,
+			Close:    candle.Close,
+		}
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// writeCandlesCsv writes candles to stdout as CSV
+func writeCandlesCsv(candles []database.CandleRecord) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"start", "product_id", "price", "interval", "open", "high", "low", "close"})
+	for _, candle := range candles {
+		w.Write([]string{
+			candle.StartTime.UTC().Format(time.RFC3339), candle.ProductId, candle.Source, intervalLabel(candle.Interval),
+			candle.Open, candle.High, candle.Low, candle.Close,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// historyTimeLayouts are the local time formats accepted by --from and --to
+var historyTimeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// parseHistoryTime parses an RFC3339 time, a local time or a duration before now
+func parseHistoryTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range historyTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time or a duration", value)
+}
+
+// displaySamples prints mid/spread samples as a table
+func displaySamples(product string, samples []database.MarketSample) {
+	if len(samples) == 0 {
+		fmt.Printf("No %s samples recorded in this range\n", product)
+		return
+	}
+
+	fmt.Printf("%s: %d samples\n\n", product, len(samples))
+	fmt.Printf("  %-24s %-15s %-15s %-15s %-11s %s\n", "RECORDED AT", "BID", "ASK", "MID", "SPREAD BPS", "BOOK AGE")
+	fmt.Printf("  %-24s %-15s %-15s %-15s %-11s %s\n", "-----------", "---", "---", "---", "----------", "--------")
+	for _, sample := range samples {
+		fmt.Printf("  %-24s %-15s %-15s %-15s %-11s %s\n",
+			sample.RecordedAt.Local().Format("2006-01-02 15:04:05.000"),
+			sample.BestBid, sample.BestAsk, sample.Mid, sample.SpreadBps,
+			sample.RecordedAt.Sub(sample.BookTime).Round(time.Millisecond))
+	}
+}
+
+// displaySnapshots prints each snapshot's levels, asks above bids
+func displaySnapshots(product string, snapshots []database.MarketSnapshot) {
+	if len(snapshots) == 0 {
+		fmt.Printf("No %s snapshots recorded in this range\n", product)
+		return
+	}
+
+	for _, snapshot := range snapshots {
+		fmt.Printf("\n%s @ %s (sequence %d, book updated %s)\n", product,
+			snapshot.RecordedAt.Local().Format("2006-01-02 15:04:05.000"), snapshot.Sequence,
+			snapshot.BookTime.Local().Format("15:04:05.000"))
+		fmt.Printf("  %-15s %-15s\n", "ASK PRICE", "ASK SIZE")
+		for i := len(snapshot.Asks) - 1; i >= 0; i-- {
+			fmt.Printf("  %-15s %-15s\n", snapshot.Asks[i][0], snapshot.Asks[i][1])
+		}
+		fmt.Printf("  %-15s %-15s\n", "BID PRICE", "BID SIZE")
+		for _, bid := range snapshot.Bids {
+			fmt.Printf("  %-15s %-15s\n", bid[0], bid[1])
+		}
+	}
+}
+
+// writeSamplesCsv writes samples to stdout as CSV
+func writeSamplesCsv(samples []database.MarketSample) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"recorded_at", "product_id", "best_bid", "best_ask", "mid", "spread_bps", "book_time"})
+	for _, sample := range samples {
+		w.Write([]string{
+			sample.RecordedAt.UTC().Format(time.RFC3339Nano), sample.ProductId,
+			sample.BestBid, sample.BestAsk, sample.Mid, sample.SpreadBps,
+			sample.BookTime.UTC().Format(time.RFC3339Nano),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeSnapshotsCsv writes one row per snapshot level to stdout as CSV
+func writeSnapshotsCsv(snapshots []database.MarketSnapshot) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"recorded_at", "product_id", "sequence", "side", "level", "price", "size"})
+	for _, snapshot := range snapshots {
+		recordedAt := snapshot.RecordedAt.UTC().Format(time.RFC3339Nano)
+		sequence := fmt.Sprintf("%d", snapshot.Sequence)
+		for i, level := range snapshot.Bids {
+			w.Write([]string{recordedAt, snapshot.ProductId, sequence, "bid", fmt.Sprintf("%d", i+1), level[0], level[1]})
+		}
+		for i, level := range snapshot.Asks {
+			w.Write([]string{recordedAt, snapshot.ProductId, sequence, "ask", fmt.Sprintf("%d", i+1), level[0], level[1]})
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// startCandles builds candles from every update to the books in store and persists them until the returned stop is called
+func startCandles(ctx context.Context, cfg *config.Config, store *websocket.OrderBookStore, adjuster *common.PriceAdjuster) (func(), error) {
+	db, err := database.NewOrdersDb(cfg.Database.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	aggregator := websocket.NewCandleAggregator(websocket.CandleConfig{Adjuster: adjuster})
+	store.AddUpdateListener(aggregator.OnBookUpdate)
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		aggregator.Run(ctx, db, candlePersistInterval)
+	}()
+
+	zap.L().Info("Building candles", zap.String("database", cfg.Database.Path))
+
+	return func() {
+		cancel()
+		wg.Wait()
+		db.Close()
+	}, nil
+}
+
+// startHistory records the books in store to the configured database until the returned stop is called
+func startHistory(ctx context.Context, cfg *config.Config, store *websocket.OrderBookStore, products func() []string) (func(), error) {
+	if cfg.MarketData.HistorySampleInterval <= 0 {
+		return nil, fmt.Errorf("MARKET_DATA_HISTORY_SAMPLE_INTERVAL must be positive")
+	}
+
+	db, err := database.NewOrdersDb(cfg.Database.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	recorder := websocket.NewHistoryRecorder(store, db, websocket.HistoryConfig{
+		SampleInterval:   cfg.MarketData.HistorySampleInterval,
+		SnapshotInterval: cfg.MarketData.HistorySnapshotInterval,
+		Depth:            cfg.MarketData.HistoryDepth,
+		Retention:        cfg.MarketData.HistoryRetention,
+	})
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		recorder.Run(ctx, products)
+	}()
+
+	zap.L().Info("Recording market data history",
+		zap.String("database", cfg.Database.Path),
+		zap.Duration("sample_interval", cfg.MarketData.HistorySampleInterval),
+		zap.Duration("snapshot_interval", cfg.MarketData.HistorySnapshotInterval),
+		zap.Duration("retention", cfg.MarketData.HistoryRetention))
+
+	return func() {
+		cancel()
+		wg.Wait()
+		db.Close()
+	}, nil
+}
@@ -0,0 +1,213 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/gateway"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	mdGatewayAddr        string
+	mdGatewaySymbols     string
+	mdGatewayCustomers   string
+	mdGatewayDepth       int
+	mdGatewayInterval    time.Duration
+	mdGatewayMinInterval time.Duration
+	mdGatewayExposeRaw   bool
+	mdGatewayHistory     bool
+	mdGatewayCandles     bool
+	mdGatewayOrigins     string
+	mdGatewayQueryKey    bool
+)
+
+var mdGatewayCmd = &cobra.Command{
+	Use:   "md-gateway",
+	Short: "Serve fee-adjusted order books to local WebSocket and SSE clients",
+	Long: `Streams Prime market data and re-broadcasts each book with your markup applied, for front-ends that need fee-inclusive prices.
+
+  WebSocket: ws://<addr>/ws, then send {"type":"subscribe","product_ids":["BTC-USD"],"interval":"500ms"}
+  SSE:       http://<addr>/sse?product_ids=BTC-USD,ETH-USD&interval=500ms
+
+Clients present an API key from --customers (Authorization: Bearer <key>) to get that customer's markup;
+clients without a key get FEE_PERCENT. ?api_key=<key> is only accepted with --allow-query-key.
+Browsers may only connect from the gateway's own origin or one listed in --allowed-origins.
+Raw Prime prices are never sent unless --expose-raw is set.`,
+	Example: `  prime md-gateway --symbols BTC-USD,ETH-USD
+  prime md-gateway --addr 127.0.0.1:9100 --customers customers.json --min-interval 250ms`,
+	RunE: runMdGateway,
+}
+
+func init() {
+	mdGatewayCmd.Flags().StringVar(&mdGatewayAddr, "addr", "127.0.0.1:8780", "Address to listen on")
+	mdGatewayCmd.Flags().StringVar(&mdGatewaySymbols, "symbols", "", "Comma-separated products clients may subscribe to (defaults to MARKET_DATA_PRODUCTS)")
+	mdGatewayCmd.Flags().StringVar(&mdGatewayCustomers, "customers", "", `Customer markups (JSON): [{"id":"acme","api_key":"...","markup":"0.003"}]`)
+	mdGatewayCmd.Flags().IntVar(&mdGatewayDepth, "depth", gateway.DefaultDepth, "Levels per side sent to clients")
+	mdGatewayCmd.Flags().DurationVar(&mdGatewayInterval, "interval", gateway.DefaultInterval, "Update interval for clients that don't ask for one")
+	mdGatewayCmd.Flags().DurationVar(&mdGatewayMinInterval, "min-interval", gateway.DefaultMinInterval, "Fastest update interval a client may ask for")
+	mdGatewayCmd.Flags().BoolVar(&mdGatewayExposeRaw, "expose-raw", false, "Also send raw Prime prices to clients")
+	mdGatewayCmd.Flags().StringVar(&mdGatewayOrigins, "allowed-origins", "", "Comma-separated browser origins allowed to connect (e.g., https://app.example.com); * allows any")
+	mdGatewayCmd.Flags().BoolVar(&mdGatewayQueryKey, "allow-query-key", false, "Also accept API keys in the ?api_key= query parameter (they may be logged by proxies)")
+	mdGatewayCmd.Flags().BoolVar(&mdGatewayHistory, "history", false, "Record mid/spread samples and book snapshots to the database for prime md history")
+	mdGatewayCmd.Flags().BoolVar(&mdGatewayCandles, "candles", false, "Build 1m/5m/1h candles (bid/ask at FEE_PERCENT) and store them for prime md candles")
+}
+
+func runMdGateway(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Setup logger
+	config.SetupLogger(cfg.Server.LogLevel, cfg.Server.LogJson)
+	defer zap.L().Sync()
+
+	products := cfg.MarketData.Products
+	if mdGatewaySymbols != "" {
+		products = nil
+		for _, product := range strings.Split(mdGatewaySymbols, ",") {
+			if product = strings.ToUpper(strings.TrimSpace(product)); product != "" {
+				products = append(products, product)
+			}
+		}
+	}
+	if len(products) == 0 {
+		return fmt.Errorf("at least one product symbol is required")
+	}
+
+	markup, err := decimal.NewFromString(cfg.Fees.Percent)
+	if err != nil {
+		return fmt.Errorf("invalid FEE_PERCENT: %w", err)
+	}
+
+	var customers []gateway.Customer
+	if mdGatewayCustomers != "" {
+		if customers, err = gateway.LoadCustomers(mdGatewayCustomers); err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	store := websocket.NewOrderBookStore()
+
+	// Register before the feed starts so candles see the initial snapshots
+	if mdGatewayCandles {
+		adjuster := common.NewPriceAdjuster(common.NewFeeStrategy(markup))
+		stopCandles, err := startCandles(ctx, cfg, store, adjuster)
+		if err != nil {
+			return err
+		}
+		defer stopCandles()
+	}
+
+	wsClient := websocket.NewMarketDataClient(websocket.NewMarketDataConfig(cfg, products), store)
+
+	if err := wsClient.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start market data: %w", err)
+	}
+	defer wsClient.Stop()
+
+	if mdGatewayHistory {
+		stopHistory, err := startHistory(ctx, cfg, store, wsClient.Products)
+		if err != nil {
+			return err
+		}
+		defer stopHistory()
+	}
+
+	var origins []string
+	for _, origin := range strings.Split(mdGatewayOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	server := gateway.NewServer(gateway.Config{
+		Products:       products,
+		Markup:         markup,
+		Customers:      customers,
+		Depth:          mdGatewayDepth,
+		Interval:       mdGatewayInterval,
+		MinInterval:    mdGatewayMinInterval,
+		ExposeRaw:      mdGatewayExposeRaw,
+		AllowedOrigins: origins,
+		AllowQueryKey:  mdGatewayQueryKey,
+	}, store)
+
+	listener, err := net.Listen("tcp", mdGatewayAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", mdGatewayAddr, err)
+	}
+
+	httpServer := &http.Server{Handler: server}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.Serve(listener)
+	}()
+
+	zap.L().Info("Market data gateway listening",
+		zap.String("addr", listener.Addr().String()),
+		zap.Strings("products", products),
+		zap.Int("customers", len(customers)),
+		zap.Bool("expose_raw", mdGatewayExposeRaw))
+	fmt.Printf("Serving fee-adjusted books for %v\n  WebSocket: ws://%s/ws\n  SSE:       http://%s/sse?product_ids=%s\n",
+		products, listener.Addr(), listener.Addr(), strings.Join(products, ","))
+	if mdGatewayExposeRaw {
+		fmt.Printf("WARNING: raw Prime prices are included in every update (--expose-raw)\n")
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+	case <-wsClient.Done():
+		if err := connectionLost(wsClient.Err()); err != nil {
+			runErr = fmt.Errorf("market data connection lost: %w", err)
+		}
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			runErr = fmt.Errorf("market data gateway failed: %w", err)
+		}
+	}
+
+	zap.L().Info("Shutting down market data gateway...")
+	server.Close()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && runErr == nil {
+		runErr = err
+	}
+	return runErr
+}
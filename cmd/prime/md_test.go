@@ -0,0 +1,72 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHistoryTime(t *testing.T) {
+	now := time.Date(2026, 10, 18, 13, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2026-10-18T13:00:00Z", want: time.Date(2026, 10, 18, 13, 0, 0, 0, time.UTC)},
+		{value: "2026-10-18T13:00:00.5+02:00", want: time.Date(2026, 10, 18, 11, 0, 0, 500_000_000, time.UTC)},
+		{value: "2026-10-18 13:00", want: time.Date(2026, 10, 18, 13, 0, 0, 0, time.Local)},
+		{value: "2026-10-18", want: time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local)},
+		{value: "30m", want: now.Add(-30 * time.Minute)},
+		{value: " 2h ", want: now.Add(-2 * time.Hour)},
+		{value: "-1h", wantErr: true},
+		{value: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseHistoryTime(tt.value, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseHistoryTime(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !got.Equal(tt.want) {
+			t.Errorf("parseHistoryTime(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestIntervalLabel(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		want     string
+	}{
+		{time.Minute, "1m"},
+		{5 * time.Minute, "5m"},
+		{time.Hour, "1h"},
+		{90 * time.Minute, "1h30m"},
+		{30 * time.Second, "30s"},
+		{time.Minute + 30*time.Second, "1m30s"},
+	}
+
+	for _, tt := range tests {
+		if got := intervalLabel(tt.interval); got != tt.want {
+			t.Errorf("intervalLabel(%s) = %q, want %q", tt.interval, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/mockws"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	mockWsAddr     string
+	mockWsScenario string
+)
+
+var mockWsCmd = &cobra.Command{
+	Use:   "mock-ws",
+	Short: "Run a local Prime WebSocket emulator",
+	Long: `Serves the l2_data, orders and heartbeats channels locally so that stream and orders-stream can be demoed or tested offline.
+Subscriptions must be signed with the credentials from your configuration. Book updates and order lifecycles are generated from a scenario file.`,
+	Example: `  prime mock-ws
+  prime mock-ws --addr 127.0.0.1:9000 --scenario scenario.json`,
+	RunE: runMockWs,
+}
+
+func init() {
+	mockWsCmd.Flags().StringVar(&mockWsAddr, "addr", "127.0.0.1:8765", "Address to listen on")
+	mockWsCmd.Flags().StringVar(&mockWsScenario, "scenario", "", "Scenario file (JSON); uses the built-in scenario when empty")
+}
+
+func runMockWs(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Setup logger
+	config.SetupLogger(cfg.Server.LogLevel, cfg.Server.LogJson)
+	defer zap.L().Sync()
+
+	scenario := mockws.DefaultScenario()
+	if mockWsScenario != "" {
+		scenario, err = mockws.LoadScenario(mockWsScenario)
+		if err != nil {
+			return fmt.Errorf("failed to load scenario: %w", err)
+		}
+	}
+
+	server := mockws.NewServer(mockws.Credentials{
+		AccessKey:        cfg.Prime.AccessKey,
+		Passphrase:       cfg.Prime.Passphrase,
+		SigningKey:       cfg.Prime.SigningKey,
+		ServiceAccountId: cfg.Prime.ServiceAccountId,
+		PortfolioId:      cfg.Prime.Portfolio,
+	}, scenario)
+
+	listener, err := net.Listen("tcp", mockWsAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", mockWsAddr, err)
+	}
+
+	httpServer := &http.Server{Handler: server}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.Serve(listener)
+	}()
+
+	products := make([]string, 0, len(scenario.Products))
+	for _, p := range scenario.Products {
+		products = append(products, p.ProductId)
+	}
+	zap.L().Info("Mock Prime websocket listening",
+		zap.String("addr", listener.Addr().String()),
+		zap.Strings("products", products),
+		zap.Int("orders", len(scenario.Orders)))
+	fmt.Printf("Point clients at the emulator with:\n  MARKET_DATA_WEBSOCKET_URL=ws://%s\n", listener.Addr().String())
+
+	// Wait for interrupt signal
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case <-sigChan:
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("mock websocket server failed: %w", err)
+		}
+	}
+
+	zap.L().Info("Shutting down mock websocket server...")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(ctx)
+}
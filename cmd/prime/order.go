@@ -40,6 +40,12 @@ var (
 	orderType   string
 	orderPrice  string
 	orderMode   string
+
+	orderMaxSlippageBps string
+	orderReferencePrice string
+	orderPreviewId      string
+	orderPaper          bool
+	orderCheckMarket    bool
 )
 
 var orderCmd = &cobra.Command{
@@ -53,22 +59,35 @@ var orderCmd = &cobra.Command{
   prime order --symbol BTC-USD --side sell --qty 0.5 --unit base --mode execute
 
   # Execute a limit buy at $50,000
-  prime order --symbol BTC-USD --side buy --qty 1000 --type limit --price 50000 --mode execute`,
+  prime order --symbol BTC-USD --side buy --qty 1000 --type limit --price 50000 --mode execute
+
+  # Execute only if the all-in price is within 75 bps of the book mid
+  prime order --symbol BTC-USD --side buy --qty 1000 --mode execute --max-slippage-bps 75
+
+  # Execute a previous preview with its locked fee terms
+  prime order --mode execute --preview-id 3f1c2a9e-...
+
+  # Paper trade: simulate fills against the live order book without sending an order
+  prime order --symbol BTC-USD --side buy --qty 1000 --mode execute --paper
+
+  # Refuse the order if the BTC-USD book is stale, crossed or too wide
+  prime order --symbol BTC-USD --side buy --qty 1000 --mode execute --check-market-data`,
 	RunE: runOrder,
 }
 
 func init() {
-	orderCmd.Flags().StringVar(&orderSymbol, "symbol", "", "Product symbol (e.g., BTC-USD) [required]")
-	orderCmd.Flags().StringVar(&orderSide, "side", "", "Order side: buy or sell [required]")
-	orderCmd.Flags().StringVar(&orderQty, "qty", "", "Order quantity (interpreted based on --unit) [required]")
+	orderCmd.Flags().StringVar(&orderSymbol, "symbol", "", "Product symbol (e.g., BTC-USD) [required unless --preview-id]")
+	orderCmd.Flags().StringVar(&orderSide, "side", "", "Order side: buy or sell [required unless --preview-id]")
+	orderCmd.Flags().StringVar(&orderQty, "qty", "", "Order quantity (interpreted based on --unit) [required unless --preview-id]")
 	orderCmd.Flags().StringVar(&orderUnit, "unit", "", "Unit for quantity: 'base' (e.g., BTC) or 'quote' (e.g., USD). Defaults: buy=quote, sell=base")
 	orderCmd.Flags().StringVar(&orderType, "type", "market", "Order type: market or limit")
 	orderCmd.Flags().StringVar(&orderPrice, "price", "", "Limit price (required for limit orders)")
 	orderCmd.Flags().StringVar(&orderMode, "mode", "execute", "Execution mode: 'preview' (simulate) or 'execute' (place actual order)")
-
-	orderCmd.MarkFlagRequired("symbol")
-	orderCmd.MarkFlagRequired("side")
-	orderCmd.MarkFlagRequired("qty")
+	orderCmd.Flags().StringVar(&orderMaxSlippageBps, "max-slippage-bps", "", "Preview before executing and abort if the all-in price, including your markup, is worse than the reference by more than this many bps")
+	orderCmd.Flags().StringVar(&orderReferencePrice, "reference-price", "", "Reference price for the slippage guard (defaults to the current book mid)")
+	orderCmd.Flags().StringVar(&orderPreviewId, "preview-id", "", "Execute a persisted preview with the fee terms it locked (execute mode only)")
+	orderCmd.Flags().BoolVar(&orderPaper, "paper", false, "Simulate execution against the live order book instead of placing a Prime order")
+	orderCmd.Flags().BoolVar(&orderCheckMarket, "check-market-data", false, "Stream the product's order book and refuse the order while it is stale, crossed or too wide (always on with --paper)")
 }
 
 // parsedOrderFlags holds the validated and normalized command line flags
@@ -83,6 +102,30 @@ type parsedOrderFlags struct {
 }
 
 func runOrder(cmd *cobra.Command, args []string) error {
+	// Executing a persisted preview replaces all order flags
+	if orderPreviewId != "" {
+		if err := validatePreviewIdFlags(cmd, orderMode); err != nil {
+			return err
+		}
+
+		cfg, adjuster, err := loadOrderConfigAndSetup()
+		if err != nil {
+			return err
+		}
+		defer zap.L().Sync()
+
+		ctx := context.Background()
+		gate, err := orderTradingGate(ctx, cfg, orderCheckMarket)
+		if err != nil {
+			return err
+		}
+		if gate != nil {
+			defer gate.Close()
+		}
+
+		return executePreviewedOrder(ctx, cfg, adjuster, orderPreviewId, gate)
+	}
+
 	// Parse and validate command line flags
 	flags, err := parseAndValidateOrderFlags(orderSymbol, orderSide, orderQty, orderUnit, orderType, orderPrice, orderMode)
 	if err != nil {
@@ -96,14 +139,39 @@ func runOrder(cmd *cobra.Command, args []string) error {
 	}
 	defer zap.L().Sync()
 
+	// Parse optional slippage guard (execute mode only)
+	guard, err := parseSlippageGuard(orderMaxSlippageBps, orderReferencePrice, flags.isPreview)
+	if err != nil {
+		return err
+	}
+
 	req := buildOrderRequest(flags)
 
 	// Execute based on mode (preview or actual order)
 	ctx := context.Background()
+	if orderPaper {
+		return executePaperOrder(ctx, cfg, adjuster, req, flags, guard)
+	}
 	if flags.isPreview {
 		return executePreview(ctx, cfg, adjuster, req)
 	}
-	return executeOrder(ctx, cfg, adjuster, req, flags.unitType, flags.quantity)
+
+	gate, err := orderTradingGate(ctx, cfg, orderCheckMarket)
+	if err != nil {
+		return err
+	}
+	if gate != nil {
+		defer gate.Close()
+	}
+	return executeOrder(ctx, cfg, adjuster, req, flags.unitType, flags.quantity, guard, gate)
+}
+
+// orderTradingGate returns a market data gate when --check-market-data is set, nil otherwise
+func orderTradingGate(ctx context.Context, cfg *config.Config, enabled bool) (*marketDataGate, error) {
+	if !enabled {
+		return nil, nil
+	}
+	return newMarketDataGate(ctx, cfg)
 }
 
 func parseAndValidateOrderFlags(symbol, side, qty, unit, oType, price, mode string) (*parsedOrderFlags, error) {
@@ -195,6 +263,59 @@ func parseAndValidateOrderFlags(symbol, side, qty, unit, oType, price, mode stri
 	}, nil
 }
 
+// validatePreviewIdFlags ensures --preview-id is only combined with execute mode
+// The order parameters and fee terms come from the preview itself
+func validatePreviewIdFlags(cmd *cobra.Command, mode string) error {
+	if !strings.EqualFold(mode, "execute") {
+		return fmt.Errorf("--preview-id requires --mode execute")
+	}
+
+	for _, name := range []string{"symbol", "side", "qty", "unit", "type", "price", "max-slippage-bps", "reference-price", "paper"} {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s cannot be combined with --preview-id", name)
+		}
+	}
+
+	return nil
+}
+
+// parseSlippageGuard validates the slippage flags; returns nil when no guard was requested
+func parseSlippageGuard(maxSlippageBps, referencePrice string, isPreview bool) (*order.SlippageGuard, error) {
+	if maxSlippageBps == "" {
+		if referencePrice != "" {
+			return nil, fmt.Errorf("--reference-price requires --max-slippage-bps")
+		}
+		return nil, nil
+	}
+
+	if isPreview {
+		return nil, fmt.Errorf("--max-slippage-bps only applies to --mode execute")
+	}
+
+	maxBps, err := decimal.NewFromString(maxSlippageBps)
+	if err != nil {
+		return nil, fmt.Errorf("invalid max slippage: %w", err)
+	}
+	if maxBps.IsNegative() {
+		return nil, fmt.Errorf("--max-slippage-bps cannot be negative")
+	}
+
+	guard := &order.SlippageGuard{MaxSlippageBps: maxBps}
+
+	if referencePrice != "" {
+		refPrice, err := decimal.NewFromString(referencePrice)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reference price: %w", err)
+		}
+		if refPrice.IsZero() || refPrice.IsNegative() {
+			return nil, fmt.Errorf("--reference-price must be positive")
+		}
+		guard.ReferencePrice = refPrice
+	}
+
+	return guard, nil
+}
+
 func loadOrderConfigAndSetup() (*config.Config, *common.PriceAdjuster, error) {
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -234,14 +355,33 @@ func buildOrderRequest(flags *parsedOrderFlags) common.OrderRequest {
 }
 
 func executePreview(ctx context.Context, cfg *config.Config, adjuster *common.PriceAdjuster, req common.OrderRequest) error {
-	orderService := order.NewOrderServiceWithPrime(cfg, adjuster, nil)
+	// Open database so the preview's fee terms can be locked for execution
+	db, err := database.NewOrdersDb(cfg.Database.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	orderService, err := order.NewOrderServiceWithPrime(cfg, adjuster, nil, db)
+	if err != nil {
+		return err
+	}
 
 	response, err := orderService.GeneratePreview(ctx, req)
 	if err != nil {
 		return fmt.Errorf("failed to generate preview: %w", err)
 	}
 
-	return outputPreview(response)
+	if err := outputPreview(response); err != nil {
+		return err
+	}
+
+	if response.PreviewId != "" {
+		fmt.Printf("Note: Preview expires at %s. To execute with these fee terms, run:\n", response.ExpiresAt.Format(time.RFC3339))
+		fmt.Printf("  prime order --mode execute --preview-id %s\n", response.PreviewId)
+	}
+
+	return nil
 }
 
 func outputPreview(resp *common.OrderPreviewResponse) error {
@@ -255,22 +395,88 @@ func outputPreview(resp *common.OrderPreviewResponse) error {
 	return nil
 }
 
-func executeOrder(ctx context.Context, cfg *config.Config, adjuster *common.PriceAdjuster, req common.OrderRequest, unitType string, quantity decimal.Decimal) error {
-	orderService := order.NewOrderServiceWithPrime(cfg, adjuster, nil)
+func executeOrder(ctx context.Context, cfg *config.Config, adjuster *common.PriceAdjuster, req common.OrderRequest, unitType string, quantity decimal.Decimal, guard *order.SlippageGuard, gate *marketDataGate) error {
+	orderService, err := order.NewOrderServiceWithPrime(cfg, adjuster, nil, nil)
+	if err != nil {
+		return err
+	}
+	if gate != nil {
+		orderService.SetTradingGate(gate)
+	}
+
+	response, err := submitOrder(ctx, cfg, cfg.Database.Path, orderService, adjuster, req, unitType, quantity, guard)
+	if err != nil {
+		return err
+	}
+
+	displayOrderSubmitted(cfg, response)
+	return nil
+}
+
+// submitOrder applies the optional slippage guard, places the order and stores its fee metadata in the database at dbPath
+func submitOrder(ctx context.Context, cfg *config.Config, dbPath string, orderService *order.OrderService, adjuster *common.PriceAdjuster, req common.OrderRequest, unitType string, quantity decimal.Decimal, guard *order.SlippageGuard) (*common.OrderResponse, error) {
+	// Preview first and abort if the all-in price has moved beyond tolerance
+	if guard != nil {
+		check, err := orderService.CheckSlippage(ctx, req, *guard)
+		if err != nil {
+			return nil, fmt.Errorf("slippage guard aborted order: %w", err)
+		}
+		fmt.Printf("Slippage check passed: effective price %s vs reference %s (%s bps, max %s bps)\n",
+			common.RoundPrice(check.EffectivePrice),
+			common.RoundPrice(check.ReferencePrice),
+			check.SlippageBps.StringFixed(2),
+			guard.MaxSlippageBps.String())
+	}
 
 	response, err := orderService.PlaceOrder(ctx, req)
 	if err != nil {
-		return fmt.Errorf("failed to place order: %w", err)
+		return nil, fmt.Errorf("failed to place order: %w", err)
 	}
 
 	// Store metadata in database for websocket to pick up
 	if unitType == "quote" && !quantity.IsZero() {
-		if err := storeOrderMetadata(cfg, response, req, adjuster); err != nil {
+		if err := storeOrderMetadata(dbPath, response, req, adjuster); err != nil {
 			zap.L().Warn("Failed to store order metadata", zap.Error(err))
 		}
 	}
 
-	// Display success message
+	return response, nil
+}
+
+func executePreviewedOrder(ctx context.Context, cfg *config.Config, adjuster *common.PriceAdjuster, previewId string, gate *marketDataGate) error {
+	db, err := database.NewOrdersDb(cfg.Database.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	orderService, err := order.NewOrderServiceWithPrime(cfg, adjuster, nil, db)
+	if err != nil {
+		return err
+	}
+	if gate != nil {
+		orderService.SetTradingGate(gate)
+	}
+
+	locked, err := orderService.PlacePreviewedOrder(ctx, previewId)
+	if err != nil {
+		return fmt.Errorf("failed to place previewed order: %w", err)
+	}
+
+	// Store metadata using the locked fee terms, not the current configuration
+	if locked.Request.Unit == "quote" && !locked.Request.QuoteValue.IsZero() {
+		lockedAdjuster := common.NewPriceAdjuster(locked.FeeStrategy)
+		if err := storeOrderMetadata(cfg.Database.Path, locked.Response, locked.Request, lockedAdjuster); err != nil {
+			zap.L().Warn("Failed to store order metadata", zap.Error(err))
+		}
+	}
+
+	fmt.Printf("\nExecuted preview %s with locked fee rate %s%%\n", locked.PreviewId, common.ToPercentageDisplay(locked.FeeStrategy.Percent).String())
+	displayOrderSubmitted(cfg, locked.Response)
+	return nil
+}
+
+func displayOrderSubmitted(cfg *config.Config, response *common.OrderResponse) {
 	fmt.Printf("\n=== Order Submitted ===\n")
 	fmt.Printf("Order Id: %s\n", response.OrderId)
 	fmt.Printf("Client Order Id: %s\n", response.ClientOrderId)
@@ -279,13 +485,11 @@ func executeOrder(ctx context.Context, cfg *config.Config, adjuster *common.Pric
 	fmt.Printf("Order state will be tracked in: %s\n", cfg.Database.Path)
 	fmt.Println("\nTo monitor orders in real-time, run:")
 	fmt.Println("  prime orders-stream")
-
-	return nil
 }
 
-func storeOrderMetadata(cfg *config.Config, response *common.OrderResponse, req common.OrderRequest, adjuster *common.PriceAdjuster) error {
+func storeOrderMetadata(dbPath string, response *common.OrderResponse, req common.OrderRequest, adjuster *common.PriceAdjuster) error {
 	// Open database
-	db, err := database.NewOrdersDb(cfg.Database.Path)
+	db, err := database.NewOrdersDb(dbPath)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
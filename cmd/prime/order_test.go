@@ -17,9 +17,17 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/database"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/order"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/prime/primetest"
 	"github.com/shopspring/decimal"
 )
 
@@ -366,3 +374,167 @@ func TestParseAndValidateOrderFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSlippageGuard(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxBps      string
+		refPrice    string
+		isPreview   bool
+		wantNil     bool
+		wantErr     bool
+		errContains string
+		validate    func(*testing.T, *order.SlippageGuard)
+	}{
+		{
+			name:    "no guard requested",
+			wantNil: true,
+		},
+		{
+			name:   "guard with book mid reference",
+			maxBps: "50",
+			validate: func(t *testing.T, guard *order.SlippageGuard) {
+				if !guard.MaxSlippageBps.Equal(decimal.NewFromInt(50)) {
+					t.Errorf("expected max slippage 50, got %s", guard.MaxSlippageBps)
+				}
+				if !guard.ReferencePrice.IsZero() {
+					t.Errorf("expected zero reference price, got %s", guard.ReferencePrice)
+				}
+			},
+		},
+		{
+			name:     "guard with explicit reference",
+			maxBps:   "25.5",
+			refPrice: "50000",
+			validate: func(t *testing.T, guard *order.SlippageGuard) {
+				if !guard.ReferencePrice.Equal(decimal.NewFromInt(50000)) {
+					t.Errorf("expected reference price 50000, got %s", guard.ReferencePrice)
+				}
+			},
+		},
+		{
+			name:        "reference without max",
+			refPrice:    "50000",
+			wantErr:     true,
+			errContains: "--reference-price requires --max-slippage-bps",
+		},
+		{
+			name:        "guard in preview mode",
+			maxBps:      "50",
+			isPreview:   true,
+			wantErr:     true,
+			errContains: "only applies to --mode execute",
+		},
+		{
+			name:        "negative max",
+			maxBps:      "-1",
+			wantErr:     true,
+			errContains: "cannot be negative",
+		},
+		{
+			name:        "invalid max",
+			maxBps:      "abc",
+			wantErr:     true,
+			errContains: "invalid max slippage",
+		},
+		{
+			name:        "zero reference",
+			maxBps:      "50",
+			refPrice:    "0",
+			wantErr:     true,
+			errContains: "--reference-price must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard, err := parseSlippageGuard(tt.maxBps, tt.refPrice, tt.isPreview)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error containing '%s', got nil", tt.errContains)
+					return
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("expected error containing '%s', got '%s'", tt.errContains, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if tt.wantNil {
+				if guard != nil {
+					t.Errorf("expected nil guard, got %+v", guard)
+				}
+				return
+			}
+
+			if guard == nil {
+				t.Error("expected non-nil guard")
+				return
+			}
+
+			if tt.validate != nil {
+				tt.validate(t, guard)
+			}
+		})
+	}
+}
+
+func TestExecuteOrder_Offline(t *testing.T) {
+	server := primetest.NewServer()
+	defer server.Close()
+
+	cfg := &config.Config{Database: config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "orders.db")}}
+	server.Configure(cfg)
+
+	feeStrategy, err := common.CreateFeeStrategy("0.002")
+	if err != nil {
+		t.Fatalf("CreateFeeStrategy() error = %v", err)
+	}
+	adjuster := common.NewPriceAdjuster(feeStrategy)
+
+	flags, err := parseAndValidateOrderFlags("BTC-USD", "buy", "1000", "", "market", "", "execute")
+	if err != nil {
+		t.Fatalf("parseAndValidateOrderFlags() error = %v", err)
+	}
+	req := buildOrderRequest(flags)
+
+	// A tight guard aborts before anything reaches Prime
+	tight := &order.SlippageGuard{MaxSlippageBps: decimal.NewFromInt(10)}
+	err = executeOrder(context.Background(), cfg, adjuster, req, flags.unitType, flags.quantity, tight, nil)
+	if !errors.Is(err, order.ErrSlippageExceeded) {
+		t.Fatalf("executeOrder() error = %v, want ErrSlippageExceeded", err)
+	}
+	if len(server.Orders()) != 0 {
+		t.Fatal("aborted order must not be placed")
+	}
+
+	loose := &order.SlippageGuard{MaxSlippageBps: decimal.NewFromInt(100)}
+	if err := executeOrder(context.Background(), cfg, adjuster, req, flags.unitType, flags.quantity, loose, nil); err != nil {
+		t.Fatalf("executeOrder() error = %v", err)
+	}
+
+	placed := server.Orders()
+	if len(placed) != 1 {
+		t.Fatalf("expected 1 order on server, got %d", len(placed))
+	}
+
+	db, err := database.NewOrdersDb(cfg.Database.Path)
+	if err != nil {
+		t.Fatalf("NewOrdersDb() error = %v", err)
+	}
+	defer db.Close()
+
+	record, err := db.GetOrder(placed[0].Id)
+	if err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+	if record.MarkupAmount != "2" {
+		t.Errorf("MarkupAmount = %s, want 2", record.MarkupAmount)
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/database"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/prime"
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
@@ -33,6 +34,7 @@ import (
 
 var (
 	ordersStreamSymbols string
+	ordersStreamRecord  string
 )
 
 var ordersStreamCmd = &cobra.Command{
@@ -40,12 +42,14 @@ var ordersStreamCmd = &cobra.Command{
 	Short: "Stream live order updates",
 	Long:  `Connects to Coinbase Prime WebSocket and monitors order execution updates, storing them in a local database.`,
 	Example: `  prime orders-stream --symbols BTC-USD,ETH-USD
-  prime orders-stream --symbols BTC-USD`,
+  prime orders-stream --symbols BTC-USD
+  prime orders-stream --symbols BTC-USD --record session.jsonl.gz`,
 	RunE: runOrdersStream,
 }
 
 func init() {
 	ordersStreamCmd.Flags().StringVar(&ordersStreamSymbols, "symbols", "BTC-USD,ETH-USD", "Comma-separated list of product symbols to subscribe to")
+	ordersStreamCmd.Flags().StringVar(&ordersStreamRecord, "record", "", "Record every raw websocket frame to this gzip-compressed JSONL file for prime replay")
 }
 
 func runOrdersStream(cmd *cobra.Command, args []string) error {
@@ -97,35 +101,51 @@ func runOrdersStream(cmd *cobra.Command, args []string) error {
 	// Create database handler
 	handler := websocket.NewDbOrderHandler(db, priceAdjuster, metadataStore)
 
-	// Create orders websocket config
-	wsConfig := websocket.OrdersConfig{
-		CommonConfig: websocket.CommonConfig{
-			Url:              cfg.MarketData.WebSocketUrl,
-			AccessKey:        cfg.Prime.AccessKey,
-			Passphrase:       cfg.Prime.Passphrase,
-			SigningKey:       cfg.Prime.SigningKey,
-			ServiceAccountId: cfg.Prime.ServiceAccountId,
-			Products:         productIds,
-			ReconnectDelay:   cfg.MarketData.ReconnectDelay,
-		},
-		PortfolioId: cfg.Prime.Portfolio,
+	// Create REST backfiller to recover order states missed during a sequence gap
+	ordersSvc, err := prime.NewOrdersService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create orders service: %w", err)
+	}
+	backfiller := websocket.NewRestOrderBackfiller(ordersSvc, cfg.Prime.Portfolio, db, handler)
+
+	recorder, err := openRecorder(ordersStreamRecord)
+	if err != nil {
+		return err
 	}
+	defer closeRecorder(recorder)
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Create orders websocket config
+	wsConfig := websocket.NewOrdersConfig(cfg, productIds)
+	wsConfig.Recorder = recorder
+	wsConfig.Backfiller = backfiller
 
 	// Create and start websocket client
 	wsClient := websocket.NewOrdersClient(wsConfig, handler)
-	if err := wsClient.Start(); err != nil {
+	if err := wsClient.Start(ctx); err != nil {
 		return fmt.Errorf("failed to start websocket client: %w", err)
 	}
 
 	zap.L().Info("Orders websocket client started. Press Ctrl+C to stop.")
 
-	// Wait for interrupt signal
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	<-sigChan
-
-	zap.L().Info("Shutting down orders websocket client...")
-	wsClient.Stop()
+	// Ctrl+C cancels ctx; Done is closed once every queued update has been stored
+	<-wsClient.Done()
+	if err := connectionLost(wsClient.Err()); err != nil {
+		return fmt.Errorf("orders connection lost: %w", err)
+	}
+	zap.L().Info("Orders websocket client stopped")
+
+	if stats, ok := wsClient.QueueStats(); ok {
+		zap.L().Info("Orders queue stats",
+			zap.Uint64("processed", stats.Processed),
+			zap.Int("depth", stats.Depth),
+			zap.Int("spilled", stats.Spilled),
+			zap.Int("high_water", stats.HighWater),
+			zap.Duration("avg_wait", stats.AvgWait),
+			zap.Duration("max_wait", stats.MaxWait))
+	}
 
 	return nil
 }
@@ -0,0 +1,155 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/database"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/order"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/paper"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
+	"github.com/shopspring/decimal"
+)
+
+// paperBookTimeout bounds how long --paper waits for the first order book snapshot
+const paperBookTimeout = 15 * time.Second
+
+// executePaperOrder streams the product's live book and simulates the order against it
+// Fills are written through the same DbOrderHandler the orders websocket uses, so settlement runs unchanged,
+// but to the paper database so simulated orders never appear among real ones
+func executePaperOrder(ctx context.Context, cfg *config.Config, adjuster *common.PriceAdjuster, req common.OrderRequest, flags *parsedOrderFlags, guard *order.SlippageGuard) error {
+	db, err := database.NewOrdersDb(cfg.Database.PaperPath)
+	if err != nil {
+		return fmt.Errorf("failed to open paper database: %w", err)
+	}
+	defer db.Close()
+
+	store := websocket.NewOrderBookStore()
+	wsClient := websocket.NewMarketDataClient(websocket.NewMarketDataConfig(cfg, []string{req.Product}), store)
+	if err := wsClient.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start market data: %w", err)
+	}
+	defer wsClient.Stop()
+
+	fmt.Printf("PAPER TRADING: waiting for %s order book...\n", req.Product)
+	if err := waitForOrderBook(wsClient, store, req.Product, paperBookTimeout); err != nil {
+		return err
+	}
+
+	orderService, simulator, err := newPaperOrderService(cfg, adjuster, db, store)
+	if err != nil {
+		return err
+	}
+
+	if flags.isPreview {
+		response, err := orderService.GeneratePreview(ctx, req)
+		if err != nil {
+			return fmt.Errorf("failed to generate preview: %w", err)
+		}
+		return outputPreview(response)
+	}
+
+	response, err := submitOrder(ctx, cfg, cfg.Database.PaperPath, orderService, adjuster, req, flags.unitType, flags.quantity, guard)
+	if err != nil {
+		return err
+	}
+
+	// Deliver the synthetic fills only after metadata is stored so settlement sees the markup hold
+	if err := simulator.PublishUpdates(); err != nil {
+		return err
+	}
+
+	return displayPaperResult(db, cfg.Database.PaperPath, response)
+}
+
+// newPaperOrderService builds an order service whose Prime calls are served by the paper simulator
+func newPaperOrderService(cfg *config.Config, adjuster *common.PriceAdjuster, db *database.OrdersDb, store *websocket.OrderBookStore) (*order.OrderService, *paper.Simulator, error) {
+	commissionRate, err := decimal.NewFromString(cfg.Order.PaperCommissionRate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid PAPER_COMMISSION_RATE: %w", err)
+	}
+
+	health, err := marketDataHealth(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	handler := websocket.NewDbOrderHandler(db, adjuster, websocket.NewMetadataStore())
+	simulator := paper.NewSimulator(store, handler, commissionRate)
+	orderService := order.NewOrderService(cfg, adjuster, simulator, nil, nil)
+
+	// Simulated fills are only meaningful against a live book, so the breaker always applies
+	orderService.SetTradingGate(websocket.NewCircuitBreaker(store, health))
+	return orderService, simulator, nil
+}
+
+// waitForOrderBook blocks until both sides of the product's book are populated
+// Returns early with the client's error if the feed stops, e.g. because credentials were rejected
+func waitForOrderBook(client *websocket.MarketDataClient, store *websocket.OrderBookStore, product string, timeout time.Duration) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if book, ok := store.Get(product); ok {
+			_, hasBid := book.GetBestBid()
+			_, hasAsk := book.GetBestAsk()
+			if hasBid && hasAsk {
+				return nil
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-client.Done():
+			if err := connectionLost(client.Err()); err != nil {
+				return fmt.Errorf("market data connection lost: %w", err)
+			}
+			return fmt.Errorf("market data stopped before the %s order book arrived", product)
+		case <-deadline.C:
+			return fmt.Errorf("timed out after %s waiting for %s order book", timeout, product)
+		}
+	}
+}
+
+// displayPaperResult prints the settled state of a simulated order
+func displayPaperResult(db *database.OrdersDb, dbPath string, response *common.OrderResponse) error {
+	record, err := db.GetOrder(response.OrderId)
+	if err != nil {
+		return fmt.Errorf("failed to load paper order: %w", err)
+	}
+	if record == nil {
+		return fmt.Errorf("paper order %s was not recorded", response.OrderId)
+	}
+
+	fmt.Printf("\n=== Paper Order Simulated ===\n")
+	fmt.Printf("Order Id: %s\n", record.OrderId)
+	fmt.Printf("Product: %s | Side: %s | Type: %s\n", record.ProductId, record.Side, record.OrderType)
+	fmt.Printf("Status: %s\n", record.Status)
+	fmt.Printf("Filled: %s @ %s (Prime commission %s)\n", record.CumQty, record.AvgPx, record.Commission)
+	if record.FeeSettled {
+		fmt.Printf("Markup held: %s | Earned: %s | Rebate: %s\n", record.MarkupAmount, record.ActualEarnedFee, record.RebateAmount)
+	}
+	fmt.Printf("\nNo order was sent to Prime. Paper orders are kept in %s.\n", dbPath)
+	return nil
+}
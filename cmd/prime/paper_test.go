@@ -0,0 +1,87 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/database"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
+	"github.com/shopspring/decimal"
+)
+
+func TestPaperOrder_SettlesFees(t *testing.T) {
+	cfg := &config.Config{
+		Prime:    config.PrimeConfig{Portfolio: "paper-portfolio"},
+		Order:    config.OrderConfig{PaperCommissionRate: "0.001"},
+		Database: config.DatabaseConfig{PaperPath: filepath.Join(t.TempDir(), "paper_orders.db")},
+	}
+
+	db, err := database.NewOrdersDb(cfg.Database.PaperPath)
+	if err != nil {
+		t.Fatalf("NewOrdersDb() error = %v", err)
+	}
+	defer db.Close()
+
+	store := websocket.NewOrderBookStore()
+	store.GetOrCreate("BTC-USD").Update(
+		[]common.PriceLevel{{Price: decimal.NewFromInt(99990), Size: decimal.NewFromInt(1)}},
+		[]common.PriceLevel{{Price: decimal.NewFromInt(100000), Size: decimal.NewFromInt(1)}},
+		1,
+	)
+
+	feeStrategy, _ := common.CreateFeeStrategy("0.005")
+	adjuster := common.NewPriceAdjuster(feeStrategy)
+
+	flags, err := parseAndValidateOrderFlags("BTC-USD", "buy", "1000", "", "market", "", "execute")
+	if err != nil {
+		t.Fatalf("parseAndValidateOrderFlags() error = %v", err)
+	}
+	req := buildOrderRequest(flags)
+
+	orderService, simulator, err := newPaperOrderService(cfg, adjuster, db, store)
+	if err != nil {
+		t.Fatalf("newPaperOrderService() error = %v", err)
+	}
+
+	response, err := submitOrder(context.Background(), cfg, cfg.Database.PaperPath, orderService, adjuster, req, flags.unitType, flags.quantity, nil)
+	if err != nil {
+		t.Fatalf("submitOrder() error = %v", err)
+	}
+	if err := simulator.PublishUpdates(); err != nil {
+		t.Fatalf("PublishUpdates() error = %v", err)
+	}
+
+	record, err := db.GetOrder(response.OrderId)
+	if err != nil || record == nil {
+		t.Fatalf("GetOrder() = %v, %v", record, err)
+	}
+
+	if record.Status != common.OrderStatusFilled {
+		t.Errorf("Status = %s, want FILLED", record.Status)
+	}
+	if record.MarkupAmount != "5" {
+		t.Errorf("MarkupAmount = %s, want 5", record.MarkupAmount)
+	}
+	if !record.FeeSettled {
+		t.Error("expected fee to be settled")
+	}
+}
@@ -0,0 +1,220 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/prime"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// quoteBookTimeout bounds how long prime quote waits for the first order book snapshot
+const quoteBookTimeout = 15 * time.Second
+
+var (
+	quoteSymbol string
+	quoteQty    string
+	quoteUnit   string
+	quoteSide   string
+
+	quoteIncludeCommission bool
+	quoteVia               string
+)
+
+var quoteCmd = &cobra.Command{
+	Use:   "quote",
+	Short: "Show the all-in executable price for a size from the live order book",
+	Long: `Subscribes to the product's order book, walks it for the requested size and prints the VWAP, worst price,
+available liquidity and the price including our markup. Nothing is sent to Prime's order preview endpoint.
+With --include-commission, the portfolio's Prime commission rate is fetched once and added to the all-in price.
+
+Quote sizes include the markup, the same way quote-denominated orders do.`,
+	Example: `  prime quote --symbol BTC-USD --qty 2.5
+  prime quote --symbol BTC-USD --qty 100000 --unit quote --side buy
+  prime quote --symbol ETH-USD --qty 50 --include-commission
+  prime quote --symbol ETH-BTC --qty 10 --via USD`,
+	RunE: runQuote,
+}
+
+func init() {
+	quoteCmd.Flags().StringVar(&quoteSymbol, "symbol", "", "Product symbol (e.g., BTC-USD) (required)")
+	quoteCmd.Flags().StringVar(&quoteQty, "qty", "", "Size to quote, interpreted based on --unit (required)")
+	quoteCmd.Flags().StringVar(&quoteUnit, "unit", "base", "Unit for --qty: 'base' (e.g., BTC) or 'quote' (e.g., USD)")
+	quoteCmd.Flags().StringVar(&quoteSide, "side", "both", "Side to quote: buy, sell or both")
+	quoteCmd.Flags().BoolVar(&quoteIncludeCommission, "include-commission", false, "Add the portfolio's Prime commission rate (fetched once from Prime) to the all-in price")
+	quoteCmd.Flags().StringVar(&quoteVia, "via", "", "Price --symbol as a synthetic cross through this currency (e.g., USD); the result is indicative only")
+	quoteCmd.MarkFlagRequired("symbol")
+	quoteCmd.MarkFlagRequired("qty")
+}
+
+func runQuote(cmd *cobra.Command, args []string) error {
+	size, unit, err := parseSizeFlags(quoteQty, quoteUnit)
+	if err != nil {
+		return err
+	}
+	sides, err := parseQuoteSides(quoteSide)
+	if err != nil {
+		return err
+	}
+	product := strings.ToUpper(strings.TrimSpace(quoteSymbol))
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	config.SetupLogger(cfg.Server.LogLevel, cfg.Server.LogJson)
+	defer zap.L().Sync()
+
+	feeStrategy, err := common.CreateFeeStrategy(cfg.Fees.Percent)
+	if err != nil {
+		return fmt.Errorf("failed to create fee strategy: %w", err)
+	}
+	adjuster := common.NewPriceAdjuster(feeStrategy)
+	if quoteIncludeCommission {
+		if err := includeCommission(cmd.Context(), cfg, adjuster); err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	store := websocket.NewOrderBookStore()
+	streamed := []string{product}
+	if quoteVia != "" {
+		if streamed, err = registerSynthetics(store, product, quoteVia); err != nil {
+			return err
+		}
+	}
+
+	wsClient := websocket.NewMarketDataClient(websocket.NewMarketDataConfig(cfg, streamed), store)
+	if err := wsClient.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start market data: %w", err)
+	}
+	defer wsClient.Stop()
+
+	fmt.Printf("Waiting for %s order book...\n", product)
+	if err := waitForOrderBook(wsClient, store, product, quoteBookTimeout); err != nil {
+		return err
+	}
+
+	book, _ := store.Get(product)
+	return displayExecution(book, sides, size, unit, adjuster)
+}
+
+// includeCommission sets the adjuster's commission rate from the portfolio's Prime commission
+func includeCommission(ctx context.Context, cfg *config.Config, adjuster *common.PriceAdjuster) error {
+	service, err := prime.NewCommissionService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create commission service: %w", err)
+	}
+
+	rate, err := prime.NewCommissionRate(service, cfg.Prime.Portfolio).Get(ctx)
+	if err != nil {
+		return err
+	}
+	adjuster.CommissionRate = rate
+	zap.L().Info("Including Prime commission in all-in prices", zap.String("rate", rate.String()))
+	return nil
+}
+
+// parseSizeFlags validates a size and its unit ("base" or "quote")
+func parseSizeFlags(size, unit string) (decimal.Decimal, string, error) {
+	unit = strings.ToLower(strings.TrimSpace(unit))
+	if unit != "base" && unit != "quote" {
+		return decimal.Zero, "", fmt.Errorf("--unit must be 'base' or 'quote', got: %s", unit)
+	}
+
+	qty, err := decimal.NewFromString(strings.TrimSpace(size))
+	if err != nil {
+		return decimal.Zero, "", fmt.Errorf("invalid size %q: %w", size, err)
+	}
+	if !qty.IsPositive() {
+		return decimal.Zero, "", fmt.Errorf("size must be positive, got: %s", size)
+	}
+	return qty, unit, nil
+}
+
+// parseQuoteSides expands --side into the sides to quote
+func parseQuoteSides(side string) ([]string, error) {
+	switch strings.ToUpper(strings.TrimSpace(side)) {
+	case "BUY":
+		return []string{"BUY"}, nil
+	case "SELL":
+		return []string{"SELL"}, nil
+	case "BOTH":
+		return []string{"BUY", "SELL"}, nil
+	}
+	return nil, fmt.Errorf("--side must be 'buy', 'sell' or 'both', got: %s", side)
+}
+
+// displayExecution prints the fee-inclusive executable price for size on each side of the book
+func displayExecution(book *websocket.OrderBook, sides []string, size decimal.Decimal, unit string, adjuster *common.PriceAdjuster) error {
+	precision := common.GetProductQuotePrecision(book.Product)
+
+	fees := fmt.Sprintf("markup %s%%", common.ToPercentageDisplay(adjuster.FeeStrategy.Percent).String())
+	if !adjuster.CommissionRate.IsZero() {
+		fees += fmt.Sprintf(", Prime commission %s%%", common.ToPercentageDisplay(adjuster.CommissionRate).String())
+	}
+	fmt.Printf("\n  EXECUTABLE FOR %s %s (%s)\n", size.String(), strings.ToUpper(unit), fees)
+	if label := syntheticLabel(book); label != "" {
+		fmt.Printf("  %s: not directly tradable on Prime; markup is applied once to the cross\n", label)
+	}
+	fmt.Printf("  %-5s %-15s %-15s %-15s %-15s %-15s %-18s %-15s %s\n", "SIDE", "RAW VWAP", "RAW WORST", "MARKUP PRICE", "ALL-IN PRICE", "ALL-IN WORST", "ALL-IN TOTAL", "BASE FILLED", "LEVELS")
+	fmt.Printf("  %-5s %-15s %-15s %-15s %-15s %-15s %-18s %-15s %s\n", "----", "--------", "---------", "------------", "------------", "------------", "------------", "-----------", "------")
+
+	var short []string
+	for _, side := range sides {
+		estimate, err := book.EstimateExecution(side, unit, size, adjuster)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("  %-5s %-15s %-15s %-15s %-15s %-15s %-18s %-15s %d\n",
+			estimate.Side,
+			estimate.Walk.Vwap.StringFixed(precision),
+			estimate.Walk.WorstPrice.StringFixed(precision),
+			estimate.MarkupPrice.StringFixed(precision),
+			estimate.AllInPrice.StringFixed(precision),
+			estimate.AllInWorstPrice.StringFixed(precision),
+			estimate.AllInTotal.StringFixed(precision),
+			estimate.Walk.BaseQty.StringFixed(8),
+			estimate.Walk.Levels)
+
+		if !estimate.Walk.Complete {
+			short = append(short, strings.ToLower(estimate.Side))
+		}
+	}
+
+	for _, side := range short {
+		fmt.Printf("  Not enough liquidity to %s the full size; totals cover the available depth only\n", side)
+	}
+	fmt.Printf("\n")
+	return nil
+}
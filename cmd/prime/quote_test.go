@@ -0,0 +1,103 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/prime/primetest"
+	"github.com/shopspring/decimal"
+)
+
+func TestParseSizeFlags(t *testing.T) {
+	tests := []struct {
+		size     string
+		unit     string
+		wantSize string
+		wantUnit string
+		wantErr  bool
+	}{
+		{size: "2.5", unit: "base", wantSize: "2.5", wantUnit: "base"},
+		{size: " 1000 ", unit: "QUOTE", wantSize: "1000", wantUnit: "quote"},
+		{size: "0", unit: "base", wantErr: true},
+		{size: "-1", unit: "base", wantErr: true},
+		{size: "abc", unit: "base", wantErr: true},
+		{size: "1", unit: "lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size+"/"+tt.unit, func(t *testing.T) {
+			size, unit, err := parseSizeFlags(tt.size, tt.unit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSizeFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if size.String() != tt.wantSize || unit != tt.wantUnit {
+				t.Errorf("parseSizeFlags() = %s %s, want %s %s", size, unit, tt.wantSize, tt.wantUnit)
+			}
+		})
+	}
+}
+
+func TestParseQuoteSides(t *testing.T) {
+	tests := []struct {
+		side    string
+		want    string
+		wantErr bool
+	}{
+		{side: "buy", want: "BUY"},
+		{side: "SELL", want: "SELL"},
+		{side: "both", want: "BUY,SELL"},
+		{side: "hold", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		sides, err := parseQuoteSides(tt.side)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parseQuoteSides(%q) error = %v, wantErr %v", tt.side, err, tt.wantErr)
+		}
+		if got := strings.Join(sides, ","); got != tt.want {
+			t.Errorf("parseQuoteSides(%q) = %s, want %s", tt.side, got, tt.want)
+		}
+	}
+}
+
+func TestIncludeCommission(t *testing.T) {
+	server := primetest.NewServer()
+	defer server.Close()
+	server.SetCommissionRate("0.0025")
+
+	cfg := &config.Config{}
+	server.Configure(cfg)
+
+	adjuster := common.NewPriceAdjuster(common.NewFeeStrategy(decimal.RequireFromString("0.005")))
+	if err := includeCommission(context.Background(), cfg, adjuster); err != nil {
+		t.Fatalf("includeCommission() error = %v", err)
+	}
+	if adjuster.CommissionRate.String() != "0.0025" {
+		t.Errorf("CommissionRate = %s, want 0.0025", adjuster.CommissionRate)
+	}
+	if got := adjuster.AllInAskPrice(decimal.NewFromInt(1000)); !got.Equal(decimal.RequireFromString("1007.5")) {
+		t.Errorf("AllInAskPrice(1000) = %s, want 1007.5", got)
+	}
+}
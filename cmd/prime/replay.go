@@ -0,0 +1,164 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/database"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	replayFile  string
+	replaySpeed float64
+	replayDb    string
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay a recorded websocket session",
+	Long: `Feeds a session recorded with --record back through the market data and order handlers, exactly as it was received.
+Order updates are written to the --db database, so settlement can be reproduced without touching the live one. Copy your
+orders database there first to include the fee terms stored when the orders were placed.`,
+	Example: `  prime replay --file session.jsonl.gz
+  prime replay --file session.jsonl.gz --speed 10 --db dispute.db
+  prime replay --file session.jsonl.gz --speed 0`,
+	RunE: runReplay,
+}
+
+func init() {
+	replayCmd.Flags().StringVar(&replayFile, "file", "", "Recording to replay (required)")
+	replayCmd.Flags().Float64Var(&replaySpeed, "speed", 1, "Playback speed: 1 for original timing, 10 for ten times faster, 0 for no delays")
+	replayCmd.Flags().StringVar(&replayDb, "db", "replay.db", "Database that replayed order updates are written to")
+	replayCmd.MarkFlagRequired("file")
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Setup logger
+	config.SetupLogger(cfg.Server.LogLevel, cfg.Server.LogJson)
+	defer zap.L().Sync()
+
+	source, err := websocket.NewReplaySource(replayFile)
+	if err != nil {
+		return err
+	}
+
+	feeStrategy, err := common.CreateFeeStrategy(cfg.Fees.Percent)
+	if err != nil {
+		return fmt.Errorf("failed to create fee strategy: %w", err)
+	}
+	adjuster := common.NewPriceAdjuster(feeStrategy)
+
+	// Clients are only used as channel handlers; they never connect
+	var handlers []websocket.ChannelHandler
+
+	store := websocket.NewOrderBookStore()
+	bookProducts := source.Products("l2_data")
+	if len(bookProducts) > 0 {
+		handlers = append(handlers, websocket.NewMarketDataClient(websocket.MarketDataConfig{
+			CommonConfig: websocket.CommonConfig{Products: bookProducts},
+			MaxLevels:    cfg.MarketData.MaxLevels,
+		}, store))
+	}
+
+	orderProducts := source.Products("orders")
+	if len(orderProducts) > 0 {
+		db, err := database.NewOrdersDb(replayDb)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer db.Close()
+
+		handler := websocket.NewDbOrderHandler(db, adjuster, websocket.NewMetadataStore())
+		handlers = append(handlers, websocket.NewOrdersClient(websocket.OrdersConfig{
+			CommonConfig: websocket.CommonConfig{Products: orderProducts},
+		}, handler))
+	}
+
+	if source.Len() > 0 {
+		first, last := source.Span()
+		fmt.Printf("Replaying %d frames recorded %s to %s at speed %g\n\n",
+			source.Len(),
+			first.Format("2006-01-02 15:04:05"),
+			last.Format("15:04:05"),
+			replaySpeed)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	stats, err := source.Replay(ctx, replaySpeed, handlers...)
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("replay failed: %w", err)
+	}
+
+	for _, product := range bookProducts {
+		if book, ok := store.Get(product); ok {
+			displayOrderBook(product, book.Snapshot(), adjuster)
+		}
+	}
+
+	fmt.Printf("Frames: %d, l2_data messages: %d, orders messages: %d, stale: %d, sequence gaps: %d\n",
+		stats.Frames, stats.Delivered["l2_data"], stats.Delivered["orders"], stats.Stale, stats.Gaps)
+	if len(orderProducts) > 0 {
+		fmt.Printf("Order updates written to %s\n", replayDb)
+	}
+	if ctx.Err() != nil {
+		fmt.Printf("Replay interrupted\n")
+	}
+	return nil
+}
+
+// openRecorder creates a frame recorder for --record, or returns nil when path is empty
+func openRecorder(path string) (*websocket.FrameRecorder, error) {
+	if path == "" {
+		return nil, nil
+	}
+	recorder, err := websocket.NewFrameRecorder(path)
+	if err != nil {
+		return nil, err
+	}
+	zap.L().Info("Recording websocket frames", zap.String("file", path))
+	return recorder, nil
+}
+
+// closeRecorder flushes a recorder opened by openRecorder
+func closeRecorder(recorder *websocket.FrameRecorder) {
+	if recorder == nil {
+		return
+	}
+	if err := recorder.Close(); err != nil {
+		zap.L().Error("Failed to close recording", zap.Error(err))
+		return
+	}
+	zap.L().Info("Recording saved", zap.Int("frames", recorder.Frames()))
+}
@@ -22,11 +22,10 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/coinbase-samples/prime-sdk-go/client"
-	"github.com/coinbase-samples/prime-sdk-go/credentials"
 	"github.com/coinbase-samples/prime-sdk-go/orders"
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/prime"
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/rfq"
 	"github.com/shopspring/decimal"
 	"github.com/spf13/cobra"
@@ -34,12 +33,13 @@ import (
 )
 
 var (
-	rfqSymbol     string
-	rfqSide       string
-	rfqQty        string
-	rfqUnit       string
-	rfqPrice      string
-	rfqAutoAccept bool
+	rfqSymbol      string
+	rfqSide        string
+	rfqQty         string
+	rfqUnit        string
+	rfqPrice       string
+	rfqAutoAccept  bool
+	rfqCheckMarket bool
 )
 
 var rfqCmd = &cobra.Command{
@@ -50,7 +50,10 @@ var rfqCmd = &cobra.Command{
   prime rfq --symbol BTC-USD --side buy --qty 10000 --price 50000
 
   # Create and auto-accept RFQ
-  prime rfq --symbol BTC-USD --side buy --qty 10000 --price 50000 --auto-accept`,
+  prime rfq --symbol BTC-USD --side buy --qty 10000 --price 50000 --auto-accept
+
+  # Refuse to quote or accept while the BTC-USD book is stale, crossed or too wide
+  prime rfq --symbol BTC-USD --side buy --qty 10000 --price 50000 --auto-accept --check-market-data`,
 	RunE: runRfq,
 }
 
@@ -61,6 +64,7 @@ func init() {
 	rfqCmd.Flags().StringVar(&rfqUnit, "unit", "", "Unit for quantity: 'base' (e.g., BTC) or 'quote' (e.g., USD). Defaults: buy=quote, sell=base")
 	rfqCmd.Flags().StringVar(&rfqPrice, "price", "", "Limit price [required for RFQ]")
 	rfqCmd.Flags().BoolVar(&rfqAutoAccept, "auto-accept", false, "Automatically accept the quote (default: false, just show quote)")
+	rfqCmd.Flags().BoolVar(&rfqCheckMarket, "check-market-data", false, "Stream the product's order book and refuse to quote or accept while it is stale, crossed or too wide")
 
 	rfqCmd.MarkFlagRequired("symbol")
 	rfqCmd.MarkFlagRequired("side")
@@ -99,6 +103,16 @@ func runRfq(cmd *cobra.Command, args []string) error {
 
 	ctx := context.Background()
 
+	// Optionally refuse trading while the product's market data is unhealthy
+	if rfqCheckMarket {
+		gate, err := newMarketDataGate(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		defer gate.Close()
+		rfqService.SetTradingGate(gate)
+	}
+
 	// Create quote
 	quoteResp, err := rfqService.CreateQuote(ctx, req)
 	if err != nil {
@@ -216,18 +230,11 @@ func loadRfqConfigAndSetup() (*config.Config, *common.PriceAdjuster, orders.Orde
 	adjuster := common.NewPriceAdjuster(feeStrategy)
 
 	// Create Prime client
-	creds := &credentials.Credentials{
-		AccessKey:    cfg.Prime.AccessKey,
-		Passphrase:   cfg.Prime.Passphrase,
-		SigningKey:   cfg.Prime.SigningKey,
-		PortfolioId:  cfg.Prime.Portfolio,
-		SvcAccountId: cfg.Prime.ServiceAccountId,
+	primeClient, err := prime.NewOrdersService(cfg)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create Prime client: %w", err)
 	}
 
-	httpClient, _ := client.DefaultHttpClient()
-	restClient := client.NewRestClient(creds, httpClient)
-	primeClient := orders.NewOrdersService(restClient)
-
 	return cfg, adjuster, primeClient, nil
 }
 
@@ -17,7 +17,11 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"strings"
@@ -34,19 +38,59 @@ import (
 
 var (
 	streamSymbols string
+	streamRecord  string
+	streamSize    string
+	streamUnit    string
+
+	streamSynthetic string
+	streamVia       string
+	streamHistory   bool
+	streamCandles   bool
+
+	streamIncludeCommission bool
+
+	streamOutput string
+	streamDepth  int
 )
 
 var streamCmd = &cobra.Command{
 	Use:   "stream",
 	Short: "Stream live market data for products",
-	Long:  `Connects to Coinbase Prime WebSocket and displays live order book updates for specified products.`,
+	Long: `Connects to Coinbase Prime WebSocket and displays live order book updates for specified products.
+
+While streaming, type "add ETH-USD,SOL-USD" or "remove BTC-USD" and press Enter to change products without restarting.
+
+--output json, jsonl or csv writes one record per product per tick to stdout instead of the book display, with raw and
+fee-adjusted levels, spread and timestamps; status messages go to stderr. json writes a single array that is only
+closed when the stream stops or loses its connection, so it can't be parsed while streaming and a killed process leaves
+it unterminated; use jsonl, one object per line, to read records live. --depth is capped at MARKET_DATA_MAX_LEVELS. The screen is only cleared
+between ticks when stdout is a terminal.`,
 	Example: `  prime stream --symbols BTC-USD,ETH-USD
-  prime stream --symbols BTC-USD`,
+  prime stream --symbols BTC-USD
+  prime stream --symbols BTC-USD --record session.jsonl.gz
+  prime stream --symbols BTC-USD --size 2.5
+  prime stream --symbols BTC-USD --size 250000 --unit quote
+  prime stream --symbols BTC-USD --size 2.5 --include-commission
+  prime stream --symbols BTC-USD --synthetic ETH-BTC,SOL-EUR
+  prime stream --symbols BTC-USD,ETH-USD --history
+  prime stream --symbols BTC-USD --candles
+  prime stream --symbols BTC-USD,ETH-USD --output jsonl | jq .mid
+  prime stream --symbols BTC-USD --output csv --depth 5 > btc-usd.csv`,
 	RunE: runStream,
 }
 
 func init() {
 	streamCmd.Flags().StringVar(&streamSymbols, "symbols", "BTC-USD,ETH-USD", "Comma-separated list of product symbols to stream")
+	streamCmd.Flags().StringVar(&streamRecord, "record", "", "Record every raw websocket frame to this gzip-compressed JSONL file for prime replay")
+	streamCmd.Flags().StringVar(&streamSize, "size", "", "Also show the all-in executable price for this size, walked through the full book depth")
+	streamCmd.Flags().StringVar(&streamUnit, "unit", "base", "Unit for --size: 'base' (e.g., BTC) or 'quote' (e.g., USD)")
+	streamCmd.Flags().BoolVar(&streamIncludeCommission, "include-commission", false, "Add the portfolio's Prime commission rate (fetched once from Prime) to all-in prices")
+	streamCmd.Flags().StringVar(&streamSynthetic, "synthetic", "", "Comma-separated cross products (e.g., ETH-BTC) to price from two legs through --via; indicative only")
+	streamCmd.Flags().StringVar(&streamVia, "via", defaultCrossCurrency, "Currency synthetic products are crossed through (ETH-BTC via USD uses ETH-USD and BTC-USD)")
+	streamCmd.Flags().BoolVar(&streamHistory, "history", false, "Record mid/spread samples and book snapshots to the database for prime md history")
+	streamCmd.Flags().BoolVar(&streamCandles, "candles", false, "Build 1m/5m/1h candles from book updates and store them for prime md candles")
+	streamCmd.Flags().StringVar(&streamOutput, "output", streamOutputText, "Output format: text, json, jsonl or csv (json is valid only once the stream stops; use jsonl to read records live)")
+	streamCmd.Flags().IntVar(&streamDepth, "depth", 10, "Levels per side in json, jsonl and csv records")
 }
 
 func runStream(cmd *cobra.Command, args []string) error {
@@ -69,15 +113,56 @@ func runStream(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Synthetic crosses are composed in the store from their streamed legs
+	store := websocket.NewOrderBookStore()
+	if streamSynthetic != "" {
+		legs, err := registerSynthetics(store, streamSynthetic, streamVia)
+		if err != nil {
+			return err
+		}
+		products = mergeProducts(products, legs...)
+	}
+
 	if len(products) == 0 {
 		return fmt.Errorf("at least one product symbol is required")
 	}
 
-	fmt.Printf("Starting market data stream for %v\n", products)
-	fmt.Printf("Display updates every 5 seconds. Press Ctrl+C to stop.\n\n")
+	output, err := parseStreamOutput(streamOutput)
+	if err != nil {
+		return err
+	}
+	if streamDepth <= 0 {
+		return fmt.Errorf("--depth must be positive, got: %d", streamDepth)
+	}
+
+	// Structured output owns stdout; the screen is only cleared for a terminal
+	status := io.Writer(os.Stdout)
+	var records *streamWriter
+	if output != streamOutputText {
+		status = os.Stderr
+		depth := clampDepth(streamDepth, cfg.MarketData.MaxLevels, os.Stderr)
+		records = newStreamWriter(output, os.Stdout, depth, streamSize != "")
+		defer func() {
+			if err := records.Close(); err != nil {
+				zap.L().Warn("Failed to close stream output", zap.Error(err))
+			}
+		}()
+	}
+	clearScreen := output == streamOutputText && isTerminal(os.Stdout)
+
+	var size decimal.Decimal
+	var sizeUnit string
+	if streamSize != "" {
+		if size, sizeUnit, err = parseSizeFlags(streamSize, streamUnit); err != nil {
+			return err
+		}
+	}
 
-	// Initialize components
-	store := websocket.NewOrderBookStore()
+	fmt.Fprintf(status, "Starting market data stream for %v\n", products)
+	if synthetics := store.Synthetics(); len(synthetics) > 0 {
+		fmt.Fprintf(status, "Synthetic crosses via %s (indicative only): %v\n", strings.ToUpper(streamVia), synthetics)
+	}
+	fmt.Fprintf(status, "Display updates every 5 seconds. Type \"add <symbols>\" or \"remove <symbols>\" to change products. Press Ctrl+C to stop.\n\n")
 
 	// Create fee strategy
 	feeStrategy, err := common.CreateFeeStrategy(cfg.Fees.Percent)
@@ -86,34 +171,60 @@ func runStream(cmd *cobra.Command, args []string) error {
 	}
 
 	adjuster := common.NewPriceAdjuster(feeStrategy)
+	if streamIncludeCommission {
+		if err := includeCommission(cmd.Context(), cfg, adjuster); err != nil {
+			return err
+		}
+	}
 
-	// Start market data feed
-	wsConfig := websocket.MarketDataConfig{
-		CommonConfig: websocket.CommonConfig{
-			Url:              cfg.MarketData.WebSocketUrl,
-			AccessKey:        cfg.Prime.AccessKey,
-			Passphrase:       cfg.Prime.Passphrase,
-			SigningKey:       cfg.Prime.SigningKey,
-			ServiceAccountId: cfg.Prime.ServiceAccountId,
-			Products:         products,
-			ReconnectDelay:   cfg.MarketData.ReconnectDelay,
-		},
-		Portfolio: cfg.Prime.Portfolio,
-		MaxLevels: cfg.MarketData.MaxLevels,
+	health, err := marketDataHealth(cfg)
+	if err != nil {
+		return err
+	}
+
+	recorder, err := openRecorder(streamRecord)
+	if err != nil {
+		return err
+	}
+	defer closeRecorder(recorder)
+
+	// Stop on Ctrl+C; the client drains queued updates before Done is closed
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Register before the feed starts so candles see the initial snapshots
+	if streamCandles {
+		stopCandles, err := startCandles(ctx, cfg, store, adjuster)
+		if err != nil {
+			return err
+		}
+		defer stopCandles()
 	}
+
+	// Start market data feed
+	wsConfig := websocket.NewMarketDataConfig(cfg, products)
+	wsConfig.Recorder = recorder
 	wsClient := websocket.NewMarketDataClient(wsConfig, store)
 
-	if err := wsClient.Start(); err != nil {
+	if err := wsClient.Start(ctx); err != nil {
 		return fmt.Errorf("failed to start market data: %w", err)
 	}
 	defer wsClient.Stop()
 
+	if streamHistory {
+		stopHistory, err := startHistory(ctx, cfg, store, func() []string {
+			return append(wsClient.Products(), store.Synthetics()...)
+		})
+		if err != nil {
+			return err
+		}
+		defer stopHistory()
+	}
+
 	// Wait a moment for initial snapshot
 	time.Sleep(cfg.MarketData.InitialWaitTime)
 
-	// Setup signal handling
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go watchProductCommands(os.Stdin, wsClient, status)
 
 	// Print updates periodically
 	ticker := time.NewTicker(cfg.MarketData.DisplayUpdateRate)
@@ -122,11 +233,20 @@ func runStream(cmd *cobra.Command, args []string) error {
 	for {
 		select {
 		case <-ticker.C:
+			if records != nil {
+				if err := writeBookRecords(records, store, append(wsClient.Products(), store.Synthetics()...), health, size, sizeUnit, adjuster); err != nil {
+					return fmt.Errorf("failed to write %s output: %w", output, err)
+				}
+				continue
+			}
+
 			// Clear screen for cleaner display
-			fmt.Print("\033[2J\033[H")
+			if clearScreen {
+				fmt.Print("\033[2J\033[H")
+			}
 
 			hasData := false
-			for _, product := range products {
+			for _, product := range append(wsClient.Products(), store.Synthetics()...) {
 				book, exists := store.Get(product)
 				if !exists {
 					continue
@@ -138,7 +258,13 @@ func runStream(cmd *cobra.Command, args []string) error {
 				}
 
 				hasData = true
+				displayHealthWarning(websocket.CheckBookHealth(product, book, health, time.Now()))
 				displayOrderBook(product, snapshot, adjuster)
+				if sizeUnit != "" {
+					if err := displayExecution(book, []string{"BUY", "SELL"}, size, sizeUnit, adjuster); err != nil {
+						fmt.Printf("  %v\n\n", err)
+					}
+				}
 			}
 
 			if !hasData {
@@ -146,19 +272,120 @@ func runStream(cmd *cobra.Command, args []string) error {
 				fmt.Printf("Last update check: %s\n", time.Now().Format("15:04:05"))
 			}
 
-		case <-sigChan:
-			fmt.Printf("\nShutting down...\n")
+		case <-wsClient.Done():
+			if err := connectionLost(wsClient.Err()); err != nil {
+				return fmt.Errorf("market data connection lost: %w", err)
+			}
+			fmt.Fprintf(status, "\nShutting down...\n")
 			return nil
 		}
 	}
 }
 
+// writeBookRecords writes a record for each product whose book has both sides
+func writeBookRecords(w *streamWriter, store *websocket.OrderBookStore, products []string, health websocket.HealthConfig, size decimal.Decimal, sizeUnit string, adjuster *common.PriceAdjuster) error {
+	now := time.Now()
+	for _, product := range products {
+		book, exists := store.Get(product)
+		if !exists {
+			continue
+		}
+
+		snapshot := book.Snapshot()
+		if len(snapshot.Bids) == 0 || len(snapshot.Asks) == 0 {
+			continue
+		}
+
+		record := newBookRecord(snapshot, adjuster, w.depth, websocket.CheckBookHealth(product, book, health, now), now)
+		if sizeUnit != "" {
+			record.addExecution(book, size, sizeUnit, adjuster)
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// connectionLost returns the error that stopped a client on its own, after exhausting reconnect
+// attempts or on a fatal error such as rejected credentials; a cancelled context is a normal shutdown
+func connectionLost(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// productUpdater changes a running client's subscribed products
+type productUpdater interface {
+	AddProducts(products ...string) error
+	RemoveProducts(products ...string) error
+}
+
+// watchProductCommands applies "add" and "remove" commands read line by line until r is closed, confirming each on out
+func watchProductCommands(r io.Reader, client productUpdater, out io.Writer) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		action, products, err := parseProductCommand(scanner.Text())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			continue
+		}
+
+		switch action {
+		case "add":
+			err = client.AddProducts(products...)
+		case "remove":
+			err = client.RemoveProducts(products...)
+		default:
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to %s %v: %v\n", action, products, err)
+			continue
+		}
+		fmt.Fprintf(out, "Subscriptions updated: %s %s\n", action, strings.Join(products, ","))
+	}
+}
+
+// parseProductCommand parses "add BTC-USD,ETH-USD" or "remove SOL-USD"; blank lines return an empty action
+func parseProductCommand(line string) (string, []string, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil, nil
+	}
+
+	action := strings.ToLower(fields[0])
+	if action != "add" && action != "remove" {
+		return "", nil, fmt.Errorf("unknown command %q; use \"add <symbols>\" or \"remove <symbols>\"", fields[0])
+	}
+
+	var products []string
+	for _, field := range fields[1:] {
+		for _, product := range strings.Split(field, ",") {
+			if product = strings.ToUpper(strings.TrimSpace(product)); product != "" {
+				products = append(products, product)
+			}
+		}
+	}
+	if len(products) == 0 {
+		return "", nil, fmt.Errorf("%s requires at least one product symbol", action)
+	}
+	return action, products, nil
+}
+
 func displayOrderBook(product string, snapshot common.OrderBookSnapshot, adjuster *common.PriceAdjuster) {
 	// Display header
 	fmt.Printf("\n═══════════════════════════════════════════════════════════════\n")
 	fmt.Printf("  %s Order Book @ %s\n", product, snapshot.UpdateTime.Format("15:04:05"))
+	if snapshot.Indicative {
+		fmt.Printf("  SYNTHETIC CROSS - INDICATIVE ONLY, NOT DIRECTLY TRADABLE\n")
+	}
 	fmt.Printf("═══════════════════════════════════════════════════════════════\n\n")
 
+	// Crosses such as ETH-BTC need more decimals than USD books
+	precision := common.GetProductQuotePrecision(product)
+
 	// Determine how many levels to show (max 10)
 	maxLevels := 10
 	bidLevels := len(snapshot.Bids)
@@ -170,16 +397,31 @@ func displayOrderBook(product string, snapshot common.OrderBookSnapshot, adjuste
 		askLevels = maxLevels
 	}
 
+	// With a commission rate, show the markup-only and all-in prices side by side
+	withCommission := !adjuster.CommissionRate.IsZero()
+	adjHeader := "ADJ PRICE"
+	if withCommission {
+		adjHeader = "MARKUP PRICE"
+	}
+	printRow := func(size, price, adj, allIn string) {
+		if withCommission {
+			fmt.Printf("  %-15s %-15s %-15s %-15s\n", size, price, adj, allIn)
+			return
+		}
+		fmt.Printf("  %-15s %-15s %-15s\n", size, price, adj)
+	}
+
 	// Show asks in reverse order (highest to lowest)
-	fmt.Printf("  %-15s %-15s %-15s\n", "ASK SIZE", "ASK PRICE", "ADJ PRICE")
-	fmt.Printf("  %-15s %-15s %-15s\n", "--------", "---------", "---------")
+	printRow("ASK SIZE", "ASK PRICE", adjHeader, "ALL-IN PRICE")
+	printRow("--------", "---------", strings.Repeat("-", len(adjHeader)), "------------")
 	for i := askLevels - 1; i >= 0; i-- {
 		ask := snapshot.Asks[i]
 		adjAsk := adjuster.AdjustAskPrice(ask.Price, decimal.NewFromInt(1))
-		fmt.Printf("  %-15s %-15s %-15s\n",
+		printRow(
 			ask.Size.StringFixed(4),
-			ask.Price.StringFixed(2),
-			adjAsk.StringFixed(2))
+			ask.Price.StringFixed(precision),
+			adjAsk.StringFixed(precision),
+			adjuster.AllInAskPrice(ask.Price).StringFixed(precision))
 	}
 
 	// Show spread
@@ -190,19 +432,20 @@ func displayOrderBook(product string, snapshot common.OrderBookSnapshot, adjuste
 
 		fmt.Printf("\n  %-15s %-15s\n", "", "SPREAD")
 		fmt.Printf("  %-15s %-15s\n", "", "------")
-		fmt.Printf("  %-15s %s\n\n", "", spread.StringFixed(2))
+		fmt.Printf("  %-15s %s\n\n", "", spread.StringFixed(precision))
 	}
 
 	// Show bids
-	fmt.Printf("  %-15s %-15s %-15s\n", "BID SIZE", "BID PRICE", "ADJ PRICE")
-	fmt.Printf("  %-15s %-15s %-15s\n", "--------", "---------", "---------")
+	printRow("BID SIZE", "BID PRICE", adjHeader, "ALL-IN PRICE")
+	printRow("--------", "---------", strings.Repeat("-", len(adjHeader)), "------------")
 	for i := 0; i < bidLevels; i++ {
 		bid := snapshot.Bids[i]
 		adjBid := adjuster.AdjustBidPrice(bid.Price, decimal.NewFromInt(1))
-		fmt.Printf("  %-15s %-15s %-15s\n",
+		printRow(
 			bid.Size.StringFixed(4),
-			bid.Price.StringFixed(2),
-			adjBid.StringFixed(2))
+			bid.Price.StringFixed(precision),
+			adjBid.StringFixed(precision),
+			adjuster.AllInBidPrice(bid.Price).StringFixed(precision))
 	}
 
 	fmt.Printf("\n")
@@ -0,0 +1,285 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
+	"github.com/shopspring/decimal"
+)
+
+// Stream output formats
+const (
+	streamOutputText  = "text"  // Fixed-width book redrawn each tick
+	streamOutputJson  = "json"  // A JSON array of indented objects, one per product per tick; closed when the stream stops
+	streamOutputJsonl = "jsonl" // One JSON object per line per product per tick
+	streamOutputCsv   = "csv"   // One row per product per tick, after a header row
+)
+
+// bookRecordLevel is one book level with its raw and fee-adjusted prices
+type bookRecordLevel struct {
+	Price         string `json:"price"`
+	Size          string `json:"size"`
+	AdjustedPrice string `json:"adjusted_price"` // With our markup
+	AllInPrice    string `json:"all_in_price"`   // With markup and Prime commission; equals adjusted_price without a commission rate
+}
+
+// bookRecordExecution is the estimated all-in price for --size on one side
+type bookRecordExecution struct {
+	Side       string `json:"side"`
+	Size       string `json:"size"`
+	Unit       string `json:"unit"`
+	Vwap       string `json:"vwap,omitempty"`
+	AllInPrice string `json:"all_in_price,omitempty"`
+	AllInTotal string `json:"all_in_total,omitempty"`
+	Filled     string `json:"filled,omitempty"` // Base quantity the book can fill
+	Complete   bool   `json:"complete"`         // False when the book is too thin for the size
+	Error      string `json:"error,omitempty"`  // Set when no estimate could be made
+}
+
+// bookRecord is one product's book at one tick
+type bookRecord struct {
+	Time           time.Time             `json:"time"` // When the record was written
+	ProductId      string                `json:"product_id"`
+	Sequence       uint64                `json:"sequence"`
+	BookTime       time.Time             `json:"book_time"` // Time of the book's last update
+	Indicative     bool                  `json:"indicative,omitempty"`
+	Mid            string                `json:"mid"`
+	Spread         string                `json:"spread"`
+	SpreadBps      string                `json:"spread_bps"`
+	AdjustedSpread string                `json:"adjusted_spread"`
+	Healthy        bool                  `json:"healthy"`
+	Problems       []string              `json:"problems,omitempty"`
+	Bids           []bookRecordLevel     `json:"bids"`
+	Asks           []bookRecordLevel     `json:"asks"`
+	Execution      []bookRecordExecution `json:"execution,omitempty"`
+}
+
+// parseStreamOutput validates --output
+func parseStreamOutput(value string) (string, error) {
+	switch format := strings.ToLower(strings.TrimSpace(value)); format {
+	case streamOutputText, streamOutputJson, streamOutputJsonl, streamOutputCsv:
+		return format, nil
+	default:
+		return "", fmt.Errorf("--output must be text, json, jsonl or csv, got: %s", value)
+	}
+}
+
+// isTerminal reports whether f is an interactive terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// newBookRecord builds the record for a snapshot with both sides present, keeping up to depth levels per side
+func newBookRecord(snapshot common.OrderBookSnapshot, adjuster *common.PriceAdjuster, depth int, health websocket.BookHealth, now time.Time) bookRecord {
+	one := decimal.NewFromInt(1)
+	bestBid, bestAsk := snapshot.Bids[0].Price, snapshot.Asks[0].Price
+
+	record := bookRecord{
+		Time:           now.UTC(),
+		ProductId:      snapshot.Product,
+		Sequence:       snapshot.Sequence,
+		BookTime:       snapshot.UpdateTime.UTC(),
+		Indicative:     snapshot.Indicative,
+		Mid:            common.CalculateMidPrice(bestBid, bestAsk).String(),
+		Spread:         bestAsk.Sub(bestBid).String(),
+		SpreadBps:      health.SpreadBps.StringFixed(4),
+		AdjustedSpread: adjuster.AdjustAskPrice(bestAsk, one).Sub(adjuster.AdjustBidPrice(bestBid, one)).String(),
+		Healthy:        health.Healthy(),
+		Problems:       health.Problems(),
+	}
+
+	for i, bid := range snapshot.Bids {
+		if i == depth {
+			break
+		}
+		record.Bids = append(record.Bids, bookRecordLevel{
+			Price:         bid.Price.String(),
+			Size:          bid.Size.String(),
+			AdjustedPrice: adjuster.AdjustBidPrice(bid.Price, one).String(),
+			AllInPrice:    adjuster.AllInBidPrice(bid.Price).String(),
+		})
+	}
+	for i, ask := range snapshot.Asks {
+		if i == depth {
+			break
+		}
+		record.Asks = append(record.Asks, bookRecordLevel{
+			Price:         ask.Price.String(),
+			Size:          ask.Size.String(),
+			AdjustedPrice: adjuster.AdjustAskPrice(ask.Price, one).String(),
+			AllInPrice:    adjuster.AllInAskPrice(ask.Price).String(),
+		})
+	}
+	return record
+}
+
+// addExecution adds the --size estimate for each side
+func (r *bookRecord) addExecution(book *websocket.OrderBook, size decimal.Decimal, unit string, adjuster *common.PriceAdjuster) {
+	for _, side := range []string{"BUY", "SELL"} {
+		execution := bookRecordExecution{Side: side, Size: size.String(), Unit: unit}
+		estimate, err := book.EstimateExecution(side, unit, size, adjuster)
+		if err != nil {
+			execution.Error = err.Error()
+		} else {
+			execution.Vwap = estimate.Walk.Vwap.String()
+			execution.AllInPrice = estimate.AllInPrice.String()
+			execution.AllInTotal = estimate.AllInTotal.String()
+			execution.Filled = estimate.Walk.BaseQty.String()
+			execution.Complete = estimate.Walk.Complete
+		}
+		r.Execution = append(r.Execution, execution)
+	}
+}
+
+// streamWriter writes stream records in one structured format
+type streamWriter struct {
+	format    string
+	out       io.Writer
+	depth     int  // Levels per side in CSV rows
+	execution bool // Add --size estimate columns to CSV rows
+
+	csv     *csv.Writer
+	started bool // The CSV header or the opening JSON bracket has been written
+}
+
+// newStreamWriter creates a writer for a json, jsonl or csv format
+func newStreamWriter(format string, out io.Writer, depth int, execution bool) *streamWriter {
+	w := &streamWriter{format: format, out: out, depth: depth, execution: execution}
+	if format == streamOutputCsv {
+		w.csv = csv.NewWriter(out)
+	}
+	return w
+}
+
+// Write writes one record; CSV rows are flushed immediately so readers see every tick
+func (w *streamWriter) Write(record bookRecord) error {
+	switch w.format {
+	case streamOutputJson:
+		data, err := json.MarshalIndent(record, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		separator := ",\n"
+		if !w.started {
+			separator = "[\n"
+			w.started = true
+		}
+		_, err = fmt.Fprintf(w.out, "%s  %s", separator, data)
+		return err
+	case streamOutputJsonl:
+		return json.NewEncoder(w.out).Encode(record)
+	case streamOutputCsv:
+		if !w.started {
+			w.csv.Write(w.csvHeader())
+			w.started = true
+		}
+		w.csv.Write(w.csvRow(record))
+		w.csv.Flush()
+		return w.csv.Error()
+	default:
+		return fmt.Errorf("unsupported stream output: %s", w.format)
+	}
+}
+
+// Close ends the output; json needs its closing bracket to be a valid document
+// runStream defers it, so it also runs when the stream stops on a lost connection
+func (w *streamWriter) Close() error {
+	if w.format != streamOutputJson {
+		return nil
+	}
+	closing := "\n]\n"
+	if !w.started {
+		closing = "[]\n"
+	}
+	_, err := io.WriteString(w.out, closing)
+	return err
+}
+
+// clampDepth limits --depth to the levels the store keeps visible, warning when it has to
+// Snapshots never hold more than maxLevels per side, so deeper columns would always be empty
+func clampDepth(depth, maxLevels int, warn io.Writer) int {
+	if maxLevels <= 0 || depth <= maxLevels {
+		return depth
+	}
+	fmt.Fprintf(warn, "Warning: --depth %d exceeds MARKET_DATA_MAX_LEVELS (%d); writing %d levels per side\n", depth, maxLevels, maxLevels)
+	return maxLevels
+}
+
+// csvHeader names the fixed columns, then depth levels of bids and asks, then the execution columns
+func (w *streamWriter) csvHeader() []string {
+	header := []string{
+		"time", "product_id", "sequence", "book_time", "indicative", "mid",
+		"spread", "spread_bps", "adjusted_spread", "healthy", "problems",
+	}
+	for _, side := range []string{"bid", "ask"} {
+		for i := 1; i <= w.depth; i++ {
+			prefix := fmt.Sprintf("%s_%d_", side, i)
+			header = append(header, prefix+"price", prefix+"size", prefix+"adjusted_price", prefix+"all_in_price")
+		}
+	}
+	if w.execution {
+		for _, side := range []string{"buy", "sell"} {
+			header = append(header, side+"_vwap", side+"_all_in_price", side+"_all_in_total", side+"_complete")
+		}
+	}
+	return header
+}
+
+// csvRow flattens a record to the columns of csvHeader; missing levels and estimates are left empty
+func (w *streamWriter) csvRow(record bookRecord) []string {
+	row := []string{
+		record.Time.Format(time.RFC3339Nano), record.ProductId, strconv.FormatUint(record.Sequence, 10),
+		record.BookTime.Format(time.RFC3339Nano), strconv.FormatBool(record.Indicative), record.Mid,
+		record.Spread, record.SpreadBps, record.AdjustedSpread, strconv.FormatBool(record.Healthy),
+		strings.Join(record.Problems, "; "),
+	}
+	for _, levels := range [][]bookRecordLevel{record.Bids, record.Asks} {
+		for i := 0; i < w.depth; i++ {
+			if i < len(levels) {
+				row = append(row, levels[i].Price, levels[i].Size, levels[i].AdjustedPrice, levels[i].AllInPrice)
+			} else {
+				row = append(row, "", "", "", "")
+			}
+		}
+	}
+	if w.execution {
+		executions := make(map[string]bookRecordExecution, len(record.Execution))
+		for _, execution := range record.Execution {
+			executions[execution.Side] = execution
+		}
+		for _, side := range []string{"BUY", "SELL"} {
+			execution, ok := executions[side]
+			complete := ""
+			if ok {
+				complete = strconv.FormatBool(execution.Complete)
+			}
+			row = append(row, execution.Vwap, execution.AllInPrice, execution.AllInTotal, complete)
+		}
+	}
+	return row
+}
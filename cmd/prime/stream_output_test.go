@@ -0,0 +1,241 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
+	"github.com/shopspring/decimal"
+)
+
+func TestParseStreamOutput(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "text", want: streamOutputText},
+		{value: "JSON", want: streamOutputJson},
+		{value: " jsonl ", want: streamOutputJsonl},
+		{value: "csv", want: streamOutputCsv},
+		{value: "yaml", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseStreamOutput(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseStreamOutput(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseStreamOutput(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+// testBook returns a BTC-USD book with two bids and one ask
+func testBook() *websocket.OrderBook {
+	book := websocket.NewOrderBook("BTC-USD")
+	book.Update(
+		[]common.PriceLevel{
+			{Price: decimal.NewFromInt(100), Size: decimal.NewFromInt(2)},
+			{Price: decimal.NewFromInt(99), Size: decimal.NewFromInt(3)},
+		},
+		[]common.PriceLevel{{Price: decimal.NewFromInt(102), Size: decimal.NewFromInt(1)}},
+		7)
+	return book
+}
+
+func TestNewBookRecord(t *testing.T) {
+	book := testBook()
+	adjuster := common.NewPriceAdjuster(common.NewFeeStrategy(decimal.RequireFromString("0.01")))
+	now := time.Now()
+	health := websocket.CheckBookHealth("BTC-USD", book, websocket.HealthConfig{}, now)
+
+	record := newBookRecord(book.Snapshot(), adjuster, 1, health, now)
+	if record.ProductId != "BTC-USD" || record.Sequence != 7 || !record.Healthy {
+		t.Errorf("record = %+v, want healthy BTC-USD at sequence 7", record)
+	}
+	if record.Mid != "101" || record.Spread != "2" || record.SpreadBps != "198.0198" || record.AdjustedSpread != "4.02" {
+		t.Errorf("mid/spread = %s/%s/%s/%s, want 101/2/198.0198/4.02", record.Mid, record.Spread, record.SpreadBps, record.AdjustedSpread)
+	}
+	if len(record.Bids) != 1 || len(record.Asks) != 1 {
+		t.Fatalf("levels = %d bids, %d asks, want 1 each at depth 1", len(record.Bids), len(record.Asks))
+	}
+	wantBid := bookRecordLevel{Price: "100", Size: "2", AdjustedPrice: "99", AllInPrice: "99"}
+	if record.Bids[0] != wantBid {
+		t.Errorf("bid = %+v, want %+v", record.Bids[0], wantBid)
+	}
+	wantAsk := bookRecordLevel{Price: "102", Size: "1", AdjustedPrice: "103.02", AllInPrice: "103.02"}
+	if record.Asks[0] != wantAsk {
+		t.Errorf("ask = %+v, want %+v", record.Asks[0], wantAsk)
+	}
+
+	record.addExecution(book, decimal.NewFromInt(2), "base", adjuster)
+	if len(record.Execution) != 2 {
+		t.Fatalf("execution = %+v, want BUY and SELL", record.Execution)
+	}
+	if buy := record.Execution[0]; buy.Side != "BUY" || buy.Complete || buy.Filled != "1" {
+		t.Errorf("buy = %+v, want an incomplete fill of 1 for a size beyond the asks", buy)
+	}
+	if sell := record.Execution[1]; sell.Side != "SELL" || !sell.Complete || sell.Vwap != "100" {
+		t.Errorf("sell = %+v, want a complete fill at vwap 100", sell)
+	}
+}
+
+func TestStreamWriter(t *testing.T) {
+	book := testBook()
+	adjuster := common.NewPriceAdjuster(common.NewFeeStrategy(decimal.Zero))
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	record := newBookRecord(book.Snapshot(), adjuster, 10, websocket.BookHealth{Product: "BTC-USD", Stale: true}, now)
+
+	t.Run("jsonl", func(t *testing.T) {
+		var out bytes.Buffer
+		w := newStreamWriter(streamOutputJsonl, &out, 10, false)
+		for i := 0; i < 2; i++ {
+			if err := w.Write(record); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("wrote %d lines, want 2", len(lines))
+		}
+		var decoded bookRecord
+		if err := json.Unmarshal([]byte(lines[0]), &decoded); err != nil {
+			t.Fatalf("line is not JSON: %v", err)
+		}
+		if decoded.ProductId != "BTC-USD" || decoded.Healthy || len(decoded.Problems) != 1 || len(decoded.Bids) != 2 {
+			t.Errorf("decoded = %+v", decoded)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		w := newStreamWriter(streamOutputJson, &out, 10, false)
+		for i := 0; i < 2; i++ {
+			if err := w.Write(record); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+
+		var decoded []bookRecord
+		if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+			t.Fatalf("output is not a JSON array: %v\n%s", err, out.String())
+		}
+		if len(decoded) != 2 || !decoded[1].Time.Equal(now) {
+			t.Errorf("decoded = %+v", decoded)
+		}
+	})
+
+	t.Run("json without records", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := newStreamWriter(streamOutputJson, &out, 10, false).Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		var decoded []bookRecord
+		if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || len(decoded) != 0 {
+			t.Errorf("empty output = %q, want an empty JSON array", out.String())
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var out bytes.Buffer
+		w := newStreamWriter(streamOutputCsv, &out, 2, true)
+		withExecution := record
+		withExecution.addExecution(book, decimal.NewFromInt(1), "base", adjuster)
+		for _, r := range []bookRecord{record, withExecution} {
+			if err := w.Write(r); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+		}
+
+		rows, err := csv.NewReader(&out).ReadAll()
+		if err != nil {
+			t.Fatalf("output is not CSV: %v", err)
+		}
+		if len(rows) != 3 {
+			t.Fatalf("wrote %d rows, want a header and 2 records", len(rows))
+		}
+		header := rows[0]
+		if len(header) != 11+2*2*4+2*4 {
+			t.Fatalf("header has %d columns: %v", len(header), header)
+		}
+		column := func(row []string, name string) string {
+			for i, h := range header {
+				if h == name {
+					return row[i]
+				}
+			}
+			t.Fatalf("no %s column", name)
+			return ""
+		}
+		if got := column(rows[1], "bid_2_price"); got != "99" {
+			t.Errorf("bid_2_price = %q, want 99", got)
+		}
+		if got := column(rows[1], "ask_2_price"); got != "" {
+			t.Errorf("ask_2_price = %q, want empty", got)
+		}
+		if got := column(rows[1], "buy_all_in_price"); got != "" {
+			t.Errorf("buy_all_in_price without an estimate = %q, want empty", got)
+		}
+		if got := column(rows[2], "buy_vwap"); got != "102" {
+			t.Errorf("buy_vwap = %q, want 102", got)
+		}
+		if got := column(rows[2], "sell_complete"); got != "true" {
+			t.Errorf("sell_complete = %q, want true", got)
+		}
+		if got := column(rows[1], "problems"); !strings.HasPrefix(got, "stale") {
+			t.Errorf("problems = %q, want stale", got)
+		}
+	})
+}
+
+func TestClampDepth(t *testing.T) {
+	tests := []struct {
+		depth, maxLevels int
+		want             int
+		wantWarning      bool
+	}{
+		{depth: 5, maxLevels: 10, want: 5},
+		{depth: 10, maxLevels: 10, want: 10},
+		{depth: 25, maxLevels: 10, want: 10, wantWarning: true},
+		{depth: 25, maxLevels: 0, want: 25},
+	}
+
+	for _, tt := range tests {
+		var warn bytes.Buffer
+		if got := clampDepth(tt.depth, tt.maxLevels, &warn); got != tt.want {
+			t.Errorf("clampDepth(%d, %d) = %d, want %d", tt.depth, tt.maxLevels, got, tt.want)
+		}
+		if (warn.Len() > 0) != tt.wantWarning {
+			t.Errorf("clampDepth(%d, %d) warning = %q, want warning %v", tt.depth, tt.maxLevels, warn.String(), tt.wantWarning)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseProductCommand(t *testing.T) {
+	tests := []struct {
+		line         string
+		wantAction   string
+		wantProducts string
+		wantErr      bool
+	}{
+		{line: "add ETH-USD", wantAction: "add", wantProducts: "ETH-USD"},
+		{line: "  ADD eth-usd, sol-usd ", wantAction: "add", wantProducts: "ETH-USD,SOL-USD"},
+		{line: "remove BTC-USD ETH-USD", wantAction: "remove", wantProducts: "BTC-USD,ETH-USD"},
+		{line: "", wantAction: ""},
+		{line: "add", wantErr: true},
+		{line: "add ,", wantErr: true},
+		{line: "subscribe ETH-USD", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			action, products, err := parseProductCommand(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseProductCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if action != tt.wantAction {
+				t.Errorf("action = %q, want %q", action, tt.wantAction)
+			}
+			if got := strings.Join(products, ","); got != tt.wantProducts {
+				t.Errorf("products = %s, want %s", got, tt.wantProducts)
+			}
+		})
+	}
+}
+
+// recordingUpdater records product changes applied by watchProductCommands
+type recordingUpdater struct {
+	calls []string
+}
+
+func (u *recordingUpdater) AddProducts(products ...string) error {
+	u.calls = append(u.calls, "add "+strings.Join(products, ","))
+	return nil
+}
+
+func (u *recordingUpdater) RemoveProducts(products ...string) error {
+	u.calls = append(u.calls, "remove "+strings.Join(products, ","))
+	return fmt.Errorf("cannot remove every subscribed product")
+}
+
+func TestWatchProductCommands(t *testing.T) {
+	updater := &recordingUpdater{}
+	watchProductCommands(strings.NewReader("add ETH-USD\nbogus\n\nremove BTC-USD\nadd SOL-USD\n"), updater, io.Discard)
+
+	// Invalid lines and failed updates don't stop later commands
+	want := "add ETH-USD;remove BTC-USD;add SOL-USD"
+	if got := strings.Join(updater.calls, ";"); got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+}
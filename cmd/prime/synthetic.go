@@ -0,0 +1,71 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
+)
+
+// defaultCrossCurrency is the currency synthetic crosses are priced through unless --via says otherwise
+const defaultCrossCurrency = "USD"
+
+// registerSynthetics adds each comma-separated synthetic product to the store, crossed through via,
+// and returns the leg products that must be streamed for them
+func registerSynthetics(store *websocket.OrderBookStore, symbols, via string) ([]string, error) {
+	via = strings.ToUpper(strings.TrimSpace(via))
+
+	var legs []string
+	for _, symbol := range strings.Split(symbols, ",") {
+		product := strings.ToUpper(strings.TrimSpace(symbol))
+		if product == "" {
+			continue
+		}
+
+		baseLeg, quoteLeg, err := common.CrossLegs(product, via)
+		if err != nil {
+			return nil, err
+		}
+		if err := store.AddSynthetic(product, baseLeg, quoteLeg); err != nil {
+			return nil, err
+		}
+		legs = mergeProducts(legs, baseLeg, quoteLeg)
+	}
+	return legs, nil
+}
+
+// mergeProducts appends the products not already in list, keeping order
+func mergeProducts(list []string, products ...string) []string {
+	for _, product := range products {
+		if !slices.Contains(list, product) {
+			list = append(list, product)
+		}
+	}
+	return list
+}
+
+// syntheticLabel describes a synthetic book's legs for display, or "" for a streamed book
+func syntheticLabel(book *websocket.OrderBook) string {
+	if !book.Indicative() {
+		return ""
+	}
+	return fmt.Sprintf("SYNTHETIC %s, INDICATIVE ONLY", strings.Join(book.Legs, " / "))
+}
@@ -24,11 +24,10 @@ import (
 	"os"
 	"strings"
 
-	"github.com/coinbase-samples/prime-sdk-go/client"
-	"github.com/coinbase-samples/prime-sdk-go/credentials"
 	"github.com/coinbase-samples/prime-sdk-go/orders"
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/prime"
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/rfq"
 	"github.com/joho/godotenv"
 	"github.com/shopspring/decimal"
@@ -210,18 +209,11 @@ func loadConfigAndSetup() (*config.Config, *common.PriceAdjuster, orders.OrdersS
 	adjuster := common.NewPriceAdjuster(feeStrategy)
 
 	// Create Prime client
-	creds := &credentials.Credentials{
-		AccessKey:    cfg.Prime.AccessKey,
-		Passphrase:   cfg.Prime.Passphrase,
-		SigningKey:   cfg.Prime.SigningKey,
-		PortfolioId:  cfg.Prime.Portfolio,
-		SvcAccountId: cfg.Prime.ServiceAccountId,
+	primeClient, err := prime.NewOrdersService(cfg)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create Prime client: %w", err)
 	}
 
-	httpClient, _ := client.DefaultHttpClient()
-	restClient := client.NewRestClient(creds, httpClient)
-	primeClient := orders.NewOrdersService(restClient)
-
 	return cfg, adjuster, primeClient, nil
 }
 
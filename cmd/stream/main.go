@@ -17,6 +17,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -88,22 +89,10 @@ func run() error {
 	adjuster := common.NewPriceAdjuster(feeStrategy)
 
 	// Start market data feed
-	wsConfig := websocket.MarketDataConfig{
-		CommonConfig: websocket.CommonConfig{
-			Url:              cfg.MarketData.WebSocketUrl,
-			AccessKey:        cfg.Prime.AccessKey,
-			Passphrase:       cfg.Prime.Passphrase,
-			SigningKey:       cfg.Prime.SigningKey,
-			ServiceAccountId: cfg.Prime.ServiceAccountId,
-			Products:         products,
-			ReconnectDelay:   cfg.MarketData.ReconnectDelay,
-		},
-		Portfolio: cfg.Prime.Portfolio,
-		MaxLevels: cfg.MarketData.MaxLevels,
-	}
+	wsConfig := websocket.NewMarketDataConfig(cfg, products)
 	wsClient := websocket.NewMarketDataClient(wsConfig, store)
 
-	if err := wsClient.Start(); err != nil {
+	if err := wsClient.Start(context.Background()); err != nil {
 		return fmt.Errorf("failed to start market data: %w", err)
 	}
 	defer wsClient.Stop()
@@ -149,6 +138,13 @@ func run() error {
 		case <-sigChan:
 			fmt.Printf("\nShutting down...\n")
 			return nil
+
+		case <-wsClient.Done():
+			// The client gave up reconnecting or hit a fatal error such as rejected credentials
+			if err := wsClient.Err(); err != nil {
+				return fmt.Errorf("market data connection lost: %w", err)
+			}
+			return nil
 		}
 	}
 }
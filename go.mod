@@ -3,6 +3,7 @@ module github.com/coinbase-samples/prime-trading-fees-go
 go 1.25.4
 
 require (
+	github.com/coinbase-samples/core-go v0.2.1
 	github.com/coinbase-samples/prime-sdk-go v0.5.5
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/websocket v1.5.3
@@ -14,7 +15,6 @@ require (
 )
 
 require (
-	github.com/coinbase-samples/core-go v0.2.1 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
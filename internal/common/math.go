@@ -134,6 +134,190 @@ func CalculateNotional(qty, price decimal.Decimal) decimal.Decimal {
 	return qty.Mul(price)
 }
 
+// ============================================================================
+// Slippage Calculations
+// ============================================================================
+
+// CalculateMidPrice computes the midpoint between the best bid and best ask
+func CalculateMidPrice(bestBid, bestAsk decimal.Decimal) decimal.Decimal {
+	return bestBid.Add(bestAsk).Div(decimal.NewFromInt(2))
+}
+
+// CalculateDeviationBps computes the absolute deviation of price from reference in basis points
+// Example: price 100.25, reference 100 -> 25 bps
+func CalculateDeviationBps(price, reference decimal.Decimal) decimal.Decimal {
+	if reference.IsZero() {
+		return decimal.Zero
+	}
+	return price.Sub(reference).Abs().Div(reference).Mul(decimal.NewFromInt(10000))
+}
+
+// ============================================================================
+// Book Depth Calculations
+// ============================================================================
+
+// WalkBookByBase fills qty base against levels, best price first
+func WalkBookByBase(levels []PriceLevel, qty decimal.Decimal) BookWalk {
+	return walkBook(levels, qty, false)
+}
+
+// WalkBookByQuote spends amount of quote currency against levels, best price first
+func WalkBookByQuote(levels []PriceLevel, amount decimal.Decimal) BookWalk {
+	return walkBook(levels, amount, true)
+}
+
+// walkBook takes from each level until remaining (base, or quote when byQuote) is used up
+func walkBook(levels []PriceLevel, remaining decimal.Decimal, byQuote bool) BookWalk {
+	var walk BookWalk
+	for _, level := range levels {
+		if !remaining.IsPositive() {
+			break
+		}
+
+		take := level.Size
+		if byQuote {
+			if notional := level.Price.Mul(take); notional.GreaterThan(remaining) {
+				take = remaining.Div(level.Price)
+			}
+			remaining = remaining.Sub(level.Price.Mul(take))
+		} else {
+			if take.GreaterThan(remaining) {
+				take = remaining
+			}
+			remaining = remaining.Sub(take)
+		}
+
+		walk.BaseQty = walk.BaseQty.Add(take)
+		walk.QuoteValue = walk.QuoteValue.Add(level.Price.Mul(take))
+		walk.WorstPrice = level.Price
+		walk.Levels++
+	}
+
+	// Division rounding can leave dust when spending quote; treat it as filled
+	walk.Complete = !remaining.IsPositive() || (byQuote && remaining.LessThan(decimal.New(1, -8)))
+	if walk.BaseQty.IsPositive() {
+		walk.Vwap = walk.QuoteValue.Div(walk.BaseQty)
+	}
+	return walk
+}
+
+// EstimateExecution walks levels for a buy (asks) or sell (bids) and applies the markup and Prime commission
+// Quote sizes include both, as with quote-denominated orders: they are deducted before walking the book
+func EstimateExecution(levels []PriceLevel, side, unit string, size, feePercent, commissionRate decimal.Decimal) ExecutionEstimate {
+	estimate := ExecutionEstimate{
+		Side:      side,
+		Unit:      unit,
+		Requested: size,
+	}
+
+	if unit == "quote" {
+		estimate.FeeAmount = CalculateFeeFromNotional(size, feePercent)
+		budget := size.Sub(estimate.FeeAmount).Div(decimal.NewFromInt(1).Add(commissionRate))
+		estimate.Walk = WalkBookByQuote(levels, budget)
+	} else {
+		estimate.Walk = WalkBookByBase(levels, size)
+		estimate.FeeAmount = CalculateFeeFromNotional(estimate.Walk.QuoteValue, feePercent)
+	}
+	estimate.CommissionAmount = CalculateFeeFromNotional(estimate.Walk.QuoteValue, commissionRate)
+
+	markupTotal := estimate.Walk.QuoteValue.Add(estimate.FeeAmount)
+	estimate.AllInTotal = markupTotal.Add(estimate.CommissionAmount)
+	if side != "BUY" {
+		markupTotal = estimate.Walk.QuoteValue.Sub(estimate.FeeAmount)
+		estimate.AllInTotal = markupTotal.Sub(estimate.CommissionAmount)
+	}
+	if estimate.Walk.BaseQty.IsPositive() {
+		estimate.MarkupPrice = markupTotal.Div(estimate.Walk.BaseQty)
+		estimate.AllInPrice = estimate.AllInTotal.Div(estimate.Walk.BaseQty)
+		// Fees scale every level's price alike, so the last level carries the same all-in ratio as the total
+		estimate.AllInWorstPrice = estimate.Walk.WorstPrice.Mul(estimate.AllInTotal).Div(estimate.Walk.QuoteValue)
+	}
+	return estimate
+}
+
+// ============================================================================
+// Synthetic Cross Calculations
+// ============================================================================
+
+// ComposeCrossBids builds up to levels BASE-QUOTE bids from BASE-X bids and QUOTE-X asks; 0 composes full depth
+// Selling BASE for QUOTE means selling BASE into the BASE-X bids and buying QUOTE from the QUOTE-X asks
+func ComposeCrossBids(baseBids, quoteAsks []PriceLevel, levels int) []PriceLevel {
+	return composeCross(baseBids, quoteAsks, levels)
+}
+
+// ComposeCrossAsks builds up to levels BASE-QUOTE asks from BASE-X asks and QUOTE-X bids; 0 composes full depth
+// Buying BASE with QUOTE means selling QUOTE into the QUOTE-X bids and buying BASE from the BASE-X asks
+func ComposeCrossAsks(baseAsks, quoteBids []PriceLevel, levels int) []PriceLevel {
+	return composeCross(baseAsks, quoteBids, levels)
+}
+
+// crossDust is the smallest leg remainder worth composing into a synthetic level
+var crossDust = decimal.New(1, -12)
+
+// composeCross walks both legs together, best price first, so every synthetic level is backed by
+// liquidity on both legs at the prices it was composed from. The price of each level is baseLeg/quoteLeg
+// and its size is the BASE both legs can carry, so deeper levels only ever get worse.
+// The walk stops before starting level limit+1, so a limited result costs O(limit) rather than O(depth).
+func composeCross(baseLevels, quoteLevels []PriceLevel, limit int) []PriceLevel {
+	var result []PriceLevel
+	i, j := 0, 0
+	var baseLeft, quoteLeft decimal.Decimal
+	if len(baseLevels) > 0 {
+		baseLeft = baseLevels[0].Size
+	}
+	if len(quoteLevels) > 0 {
+		quoteLeft = quoteLevels[0].Size
+	}
+
+	for i < len(baseLevels) && j < len(quoteLevels) {
+		basePrice, quotePrice := baseLevels[i].Price, quoteLevels[j].Price
+		if !basePrice.IsPositive() || !quotePrice.IsPositive() {
+			break
+		}
+
+		// BASE the remaining QUOTE on this level can pay for (or absorb) at these prices
+		capacity := quoteLeft.Mul(quotePrice).Div(basePrice)
+		take := baseLeft
+		if capacity.LessThan(take) {
+			take = capacity
+		}
+
+		if take.IsPositive() {
+			price := basePrice.Div(quotePrice)
+			if n := len(result); n > 0 && result[n-1].Price.Equal(price) {
+				result[n-1].Size = result[n-1].Size.Add(take)
+			} else if limit > 0 && n == limit {
+				break
+			} else {
+				result = append(result, PriceLevel{Price: price, Size: take})
+			}
+		}
+
+		if capacity.LessThan(baseLeft) {
+			baseLeft = baseLeft.Sub(capacity)
+			j++
+			if j < len(quoteLevels) {
+				quoteLeft = quoteLevels[j].Size
+			}
+			continue
+		}
+
+		quoteLeft = quoteLeft.Sub(take.Mul(basePrice).Div(quotePrice))
+		i++
+		if i < len(baseLevels) {
+			baseLeft = baseLevels[i].Size
+		}
+		// Division rounding can leave dust on the quote leg; treat it as used up
+		if quoteLeft.LessThan(crossDust) {
+			j++
+			if j < len(quoteLevels) {
+				quoteLeft = quoteLevels[j].Size
+			}
+		}
+	}
+	return result
+}
+
 // ============================================================================
 // RFQ Calculations
 // ============================================================================
@@ -177,6 +361,10 @@ func FromPercentageDisplay(percentValue decimal.Decimal) decimal.Decimal {
 // PriceAdjuster applies fee strategy to market prices
 type PriceAdjuster struct {
 	FeeStrategy *FeeStrategy
+
+	// CommissionRate is Prime's estimated commission, added on top of the markup in all-in prices
+	// Zero leaves it out, so all-in prices include only our markup
+	CommissionRate decimal.Decimal
 }
 
 // NewPriceAdjuster creates a new price adjuster with a fee strategy
@@ -196,6 +384,21 @@ func (a *PriceAdjuster) AdjustAskPrice(price, qty decimal.Decimal) decimal.Decim
 	return AdjustAskPrice(price, qty, a.FeeStrategy.Percent)
 }
 
+// AllInBidPrice reduces bid price by the markup and Prime commission when user is selling
+func (a *PriceAdjuster) AllInBidPrice(price decimal.Decimal) decimal.Decimal {
+	return AdjustBidPrice(price, decimal.NewFromInt(1), a.FeeStrategy.Percent.Add(a.CommissionRate))
+}
+
+// AllInAskPrice increases ask price by the markup and Prime commission when user is buying
+func (a *PriceAdjuster) AllInAskPrice(price decimal.Decimal) decimal.Decimal {
+	return AdjustAskPrice(price, decimal.NewFromInt(1), a.FeeStrategy.Percent.Add(a.CommissionRate))
+}
+
+// EstimateExecution walks levels for a size and applies the strategy's markup and the commission rate
+func (a *PriceAdjuster) EstimateExecution(levels []PriceLevel, side, unit string, size decimal.Decimal) ExecutionEstimate {
+	return EstimateExecution(levels, side, unit, size, a.FeeStrategy.Percent, a.CommissionRate)
+}
+
 // ComputeFee calculates the fee for a given quantity and price
 func (a *PriceAdjuster) ComputeFee(qty, price decimal.Decimal) decimal.Decimal {
 	return a.FeeStrategy.Compute(qty, price)
@@ -0,0 +1,269 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// ============================================================================
+// Slippage Calculation Tests
+// ============================================================================
+
+func TestCalculateMidPrice(t *testing.T) {
+	mid := CalculateMidPrice(decimal.NewFromInt(99), decimal.NewFromInt(101))
+	if !mid.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("CalculateMidPrice(99, 101) = %s, want 100", mid)
+	}
+}
+
+func TestCalculateDeviationBps(t *testing.T) {
+	tests := []struct {
+		name      string
+		price     string
+		reference string
+		expected  string
+	}{
+		{"above reference", "100.25", "100", "25"},
+		{"below reference", "99.5", "100", "50"},
+		{"equal to reference", "100", "100", "0"},
+		{"zero reference", "100", "0", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CalculateDeviationBps(decimal.RequireFromString(tt.price), decimal.RequireFromString(tt.reference))
+			if !result.Equal(decimal.RequireFromString(tt.expected)) {
+				t.Errorf("CalculateDeviationBps(%s, %s) = %s, want %s", tt.price, tt.reference, result, tt.expected)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Book Depth Calculation Tests
+// ============================================================================
+
+func testLevels(pairs ...string) []PriceLevel {
+	levels := make([]PriceLevel, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		levels = append(levels, PriceLevel{Price: decimal.RequireFromString(pairs[i]), Size: decimal.RequireFromString(pairs[i+1])})
+	}
+	return levels
+}
+
+func TestWalkBook(t *testing.T) {
+	asks := testLevels("100", "1", "101", "2", "103", "1")
+
+	tests := []struct {
+		name         string
+		byQuote      bool
+		size         string
+		wantBase     string
+		wantQuote    string
+		wantVwap     string
+		wantWorst    string
+		wantLevels   int
+		wantComplete bool
+	}{
+		{name: "within the top level", size: "0.5", wantBase: "0.5", wantQuote: "50", wantVwap: "100", wantWorst: "100", wantLevels: 1, wantComplete: true},
+		{name: "across levels", size: "2", wantBase: "2", wantQuote: "201", wantVwap: "100.5", wantWorst: "101", wantLevels: 2, wantComplete: true},
+		{name: "exactly the full book", size: "4", wantBase: "4", wantQuote: "405", wantVwap: "101.25", wantWorst: "103", wantLevels: 3, wantComplete: true},
+		{name: "more than the book holds", size: "5", wantBase: "4", wantQuote: "405", wantVwap: "101.25", wantWorst: "103", wantLevels: 3},
+		{name: "quote within the top level", byQuote: true, size: "50", wantBase: "0.5", wantQuote: "50", wantVwap: "100", wantWorst: "100", wantLevels: 1, wantComplete: true},
+		{name: "quote across levels", byQuote: true, size: "302", wantBase: "3", wantQuote: "302", wantVwap: "100.6666666666666667", wantWorst: "101", wantLevels: 2, wantComplete: true},
+		{name: "quote beyond the book", byQuote: true, size: "1000", wantBase: "4", wantQuote: "405", wantVwap: "101.25", wantWorst: "103", wantLevels: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var walk BookWalk
+			if tt.byQuote {
+				walk = WalkBookByQuote(asks, decimal.RequireFromString(tt.size))
+			} else {
+				walk = WalkBookByBase(asks, decimal.RequireFromString(tt.size))
+			}
+
+			if !walk.BaseQty.Equal(decimal.RequireFromString(tt.wantBase)) {
+				t.Errorf("BaseQty = %s, want %s", walk.BaseQty, tt.wantBase)
+			}
+			if !walk.QuoteValue.Equal(decimal.RequireFromString(tt.wantQuote)) {
+				t.Errorf("QuoteValue = %s, want %s", walk.QuoteValue, tt.wantQuote)
+			}
+			if !walk.Vwap.Equal(decimal.RequireFromString(tt.wantVwap)) {
+				t.Errorf("Vwap = %s, want %s", walk.Vwap, tt.wantVwap)
+			}
+			if !walk.WorstPrice.Equal(decimal.RequireFromString(tt.wantWorst)) {
+				t.Errorf("WorstPrice = %s, want %s", walk.WorstPrice, tt.wantWorst)
+			}
+			if walk.Levels != tt.wantLevels {
+				t.Errorf("Levels = %d, want %d", walk.Levels, tt.wantLevels)
+			}
+			if walk.Complete != tt.wantComplete {
+				t.Errorf("Complete = %v, want %v", walk.Complete, tt.wantComplete)
+			}
+		})
+	}
+}
+
+func TestComposeCross(t *testing.T) {
+	tests := []struct {
+		name   string
+		bids   bool
+		base   []PriceLevel
+		quote  []PriceLevel
+		levels int
+		want   []PriceLevel // Prices and sizes compared at 10 decimals
+	}{
+		{
+			name:  "quote level exactly covers the base level",
+			bids:  true,
+			base:  testLevels("2000", "1", "1990", "2"),
+			quote: testLevels("40000", "0.05", "40100", "1"),
+			want:  testLevels("0.05", "1", "0.0496259352", "2"),
+		},
+		{
+			name:  "quote leg limits the size",
+			base:  testLevels("2010", "1"),
+			quote: testLevels("39900", "0.02"),
+			want:  testLevels("0.0503759398", "0.3970149254"),
+		},
+		{
+			name:  "one quote level spans base levels",
+			base:  testLevels("100", "1", "101", "1"),
+			quote: testLevels("1000", "1"),
+			want:  testLevels("0.1", "1", "0.101", "1"),
+		},
+		{
+			name:   "limited to the top level",
+			base:   testLevels("100", "1", "101", "1"),
+			quote:  testLevels("1000", "1"),
+			levels: 1,
+			want:   testLevels("0.1", "1"),
+		},
+		{
+			name:  "empty leg",
+			base:  testLevels("100", "1"),
+			quote: nil,
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []PriceLevel
+			if tt.bids {
+				got = ComposeCrossBids(tt.base, tt.quote, tt.levels)
+			} else {
+				got = ComposeCrossAsks(tt.base, tt.quote, tt.levels)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("composed %d levels %v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				if !got[i].Price.Round(10).Equal(tt.want[i].Price) || !got[i].Size.Round(10).Equal(tt.want[i].Size) {
+					t.Errorf("level %d = %s x %s, want %s x %s", i, got[i].Price, got[i].Size, tt.want[i].Price, tt.want[i].Size)
+				}
+			}
+		})
+	}
+}
+
+func TestEstimateExecution(t *testing.T) {
+	asks := testLevels("100", "1", "102", "1")
+	bids := testLevels("99", "1", "97", "1")
+	fee := decimal.RequireFromString("0.01")
+
+	tests := []struct {
+		name            string
+		levels          []PriceLevel
+		side            string
+		unit            string
+		size            string
+		commission      string
+		wantBase        string
+		wantFee         string
+		wantCommission  string
+		wantMarkupPrice string
+		wantAllInPrice  string
+		wantAllInTotal  string
+		wantAllInWorst  string
+	}{
+		// 2 BTC cost 202, plus 1% markup
+		{name: "buy base", levels: asks, side: "BUY", unit: "base", size: "2", commission: "0",
+			wantBase: "2", wantFee: "2.02", wantCommission: "0", wantMarkupPrice: "102.01", wantAllInPrice: "102.01", wantAllInTotal: "204.02", wantAllInWorst: "103.02"},
+		// 2 BTC sell for 196, less 1% markup
+		{name: "sell base", levels: bids, side: "SELL", unit: "base", size: "2", commission: "0",
+			wantBase: "2", wantFee: "1.96", wantCommission: "0", wantMarkupPrice: "97.02", wantAllInPrice: "97.02", wantAllInTotal: "194.04", wantAllInWorst: "96.03"},
+		// $101 includes $1.01 markup, leaving $99.99 for the book
+		{name: "buy quote", levels: asks, side: "BUY", unit: "quote", size: "101", commission: "0",
+			wantBase: "0.9999", wantFee: "1.01", wantCommission: "0", wantMarkupPrice: "101.0101010101010101", wantAllInPrice: "101.0101010101010101", wantAllInTotal: "101", wantAllInWorst: "101.0101010101010101"},
+		// Prime's 0.1% adds 0.202 on top of the markup
+		{name: "buy base with commission", levels: asks, side: "BUY", unit: "base", size: "2", commission: "0.001",
+			wantBase: "2", wantFee: "2.02", wantCommission: "0.202", wantMarkupPrice: "102.01", wantAllInPrice: "102.111", wantAllInTotal: "204.222", wantAllInWorst: "103.122"},
+		{name: "sell base with commission", levels: bids, side: "SELL", unit: "base", size: "2", commission: "0.001",
+			wantBase: "2", wantFee: "1.96", wantCommission: "0.196", wantMarkupPrice: "97.02", wantAllInPrice: "96.922", wantAllInTotal: "193.844", wantAllInWorst: "95.933"},
+		// $101 less $1.01 markup leaves $99.99 to cover the fill and 1% commission: $99 of BTC plus $0.99
+		{name: "buy quote with commission", levels: asks, side: "BUY", unit: "quote", size: "101", commission: "0.01",
+			wantBase: "0.99", wantFee: "1.01", wantCommission: "0.99", wantMarkupPrice: "101.0202020202020202", wantAllInPrice: "102.0202020202020202", wantAllInTotal: "101", wantAllInWorst: "102.0202020202020202"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimate := EstimateExecution(tt.levels, tt.side, tt.unit, decimal.RequireFromString(tt.size), fee, decimal.RequireFromString(tt.commission))
+
+			checks := []struct {
+				field string
+				got   decimal.Decimal
+				want  string
+			}{
+				{"Walk.BaseQty", estimate.Walk.BaseQty, tt.wantBase},
+				{"FeeAmount", estimate.FeeAmount, tt.wantFee},
+				{"CommissionAmount", estimate.CommissionAmount, tt.wantCommission},
+				{"MarkupPrice", estimate.MarkupPrice, tt.wantMarkupPrice},
+				{"AllInPrice", estimate.AllInPrice, tt.wantAllInPrice},
+				{"AllInTotal", estimate.AllInTotal, tt.wantAllInTotal},
+				{"AllInWorstPrice", estimate.AllInWorstPrice, tt.wantAllInWorst},
+			}
+			for _, c := range checks {
+				if !c.got.Equal(decimal.RequireFromString(c.want)) {
+					t.Errorf("%s = %s, want %s", c.field, c.got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPriceAdjuster_AllInPrices(t *testing.T) {
+	adjuster := NewPriceAdjuster(NewFeeStrategy(decimal.RequireFromString("0.005")))
+	price := decimal.NewFromInt(1000)
+
+	if got := adjuster.AllInAskPrice(price); !got.Equal(decimal.NewFromInt(1005)) {
+		t.Errorf("AllInAskPrice() without commission = %s, want 1005", got)
+	}
+
+	adjuster.CommissionRate = decimal.RequireFromString("0.001")
+	if got := adjuster.AllInAskPrice(price); !got.Equal(decimal.NewFromInt(1006)) {
+		t.Errorf("AllInAskPrice() = %s, want 1006", got)
+	}
+	if got := adjuster.AllInBidPrice(price); !got.Equal(decimal.NewFromInt(994)) {
+		t.Errorf("AllInBidPrice() = %s, want 994", got)
+	}
+}
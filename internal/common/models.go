@@ -107,6 +107,10 @@ type OrderPreviewResponse struct {
 	// Our overlay on top
 	CustomFeeOverlay *CustomFeeOverlay `json:"custom_fee_overlay,omitempty"`
 
+	// Locked fee terms (set when the preview is persisted for execution)
+	PreviewId string     `json:"preview_id,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
 	Timestamp time.Time `json:"timestamp"`
 }
 
@@ -116,6 +120,8 @@ type RawPrimePreview struct {
 	AverageFilledPrice string `json:"average_filled_price"` // Prime's execution price
 	TotalValue         string `json:"total_value"`          // What we sent to Prime
 	Commission         string `json:"commission"`           // Prime's fee
+	BestBid            string `json:"best_bid,omitempty"`   // Top of book bid at preview time
+	BestAsk            string `json:"best_ask,omitempty"`   // Top of book ask at preview time
 }
 
 // CustomFeeOverlay contains our custom fee calculations on top of Prime's execution
@@ -162,6 +168,12 @@ type NormalizedOrderRequest struct {
 // Market Data Models
 // ============================================================================
 
+// TradingGate decides whether trading on a product may proceed, e.g. based on market data health
+// CheckTrading returns a non-nil error describing why trading is refused
+type TradingGate interface {
+	CheckTrading(product string) error
+}
+
 // PriceLevel represents a single price level in the order book
 type PriceLevel struct {
 	Price decimal.Decimal
@@ -175,4 +187,43 @@ type OrderBookSnapshot struct {
 	Asks       []PriceLevel
 	UpdateTime time.Time
 	Sequence   uint64
+	Indicative bool // Synthetic cross book composed from two legs; prices are not directly executable
+}
+
+// Candle is an OHLC summary of one price over an interval
+type Candle struct {
+	Product  string
+	Source   string // Price tracked: "mid", or the fee-adjusted "bid" or "ask"
+	Interval time.Duration
+	Start    time.Time // Interval start, aligned to the interval
+	Open     decimal.Decimal
+	High     decimal.Decimal
+	Low      decimal.Decimal
+	Close    decimal.Decimal
+}
+
+// BookWalk is the result of filling a size against one side of the order book, before markup
+type BookWalk struct {
+	BaseQty    decimal.Decimal // Base quantity the book can fill, up to the requested size
+	QuoteValue decimal.Decimal // Notional of that fill at book prices
+	Vwap       decimal.Decimal // Volume-weighted average fill price
+	WorstPrice decimal.Decimal // Raw book price of the last level touched; see ExecutionEstimate.AllInWorstPrice
+	Levels     int             // Number of levels touched
+	Complete   bool            // False when the book ran out before the requested size was filled
+}
+
+// ExecutionEstimate is the fee-inclusive executable price for a size, derived from book depth
+type ExecutionEstimate struct {
+	Product          string
+	Side             string          // "BUY" or "SELL"
+	Unit             string          // "base" or "quote"
+	Requested        decimal.Decimal // Size as requested, in Unit
+	Walk             BookWalk        // Fill against the book for the size left after fees; Walk.Vwap is the raw price
+	FeeAmount        decimal.Decimal // Our markup, in quote currency
+	CommissionAmount decimal.Decimal // Estimated Prime commission; zero unless a commission rate is set
+	MarkupPrice      decimal.Decimal // Vwap including our markup only
+	AllInPrice       decimal.Decimal // Vwap including markup and Prime commission
+	AllInTotal       decimal.Decimal // Quote paid (buy) or received (sell) including markup and commission
+	AllInWorstPrice  decimal.Decimal // Walk.WorstPrice including markup and commission; the limit price that covers the whole fill all-in
+	Indicative       bool            // Priced from a synthetic cross book; not directly executable on Prime
 }
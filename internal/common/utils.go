@@ -41,6 +41,29 @@ func GetQuoteCurrency(productSymbol string) string {
 	return "" // Unknown format
 }
 
+// GetBaseCurrency extracts the base currency from a product symbol
+// Example: "BTC-USD" -> "BTC"
+func GetBaseCurrency(productSymbol string) string {
+	parts := strings.Split(productSymbol, "-")
+	if len(parts) == 2 {
+		return parts[0]
+	}
+	return "" // Unknown format
+}
+
+// CrossLegs returns the two products a synthetic cross is priced from
+// Example: CrossLegs("ETH-BTC", "USD") -> "ETH-USD", "BTC-USD"
+func CrossLegs(productSymbol, via string) (baseLeg, quoteLeg string, err error) {
+	base, quote := GetBaseCurrency(productSymbol), GetQuoteCurrency(productSymbol)
+	if base == "" || quote == "" || via == "" {
+		return "", "", fmt.Errorf("invalid synthetic product %q: must be BASE-QUOTE with a cross currency", productSymbol)
+	}
+	if base == via || quote == via {
+		return "", "", fmt.Errorf("synthetic product %s cannot be crossed through its own currency %s", productSymbol, via)
+	}
+	return base + "-" + via, quote + "-" + via, nil
+}
+
 // GetQuotePrecision returns the decimal precision for a given quote currency
 func GetQuotePrecision(quoteCurrency string) int32 {
 	switch quoteCurrency {
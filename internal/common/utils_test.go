@@ -608,6 +608,31 @@ func TestPrepareOrderRequest_BaseOrders(t *testing.T) {
 // Rounding Tests
 // ============================================================================
 
+func TestCrossLegs(t *testing.T) {
+	tests := []struct {
+		product, via        string
+		wantBase, wantQuote string
+		wantErr             bool
+	}{
+		{product: "ETH-BTC", via: "USD", wantBase: "ETH-USD", wantQuote: "BTC-USD"},
+		{product: "SOL-EUR", via: "USD", wantBase: "SOL-USD", wantQuote: "EUR-USD"},
+		{product: "ETH-USD", via: "USD", wantErr: true},
+		{product: "ETHBTC", via: "USD", wantErr: true},
+		{product: "ETH-BTC", via: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		baseLeg, quoteLeg, err := CrossLegs(tt.product, tt.via)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CrossLegs(%q, %q) error = %v, wantErr %v", tt.product, tt.via, err, tt.wantErr)
+			continue
+		}
+		if baseLeg != tt.wantBase || quoteLeg != tt.wantQuote {
+			t.Errorf("CrossLegs(%q, %q) = %s, %s, want %s, %s", tt.product, tt.via, baseLeg, quoteLeg, tt.wantBase, tt.wantQuote)
+		}
+	}
+}
+
 func TestRoundPrice(t *testing.T) {
 	tests := []struct {
 		name     string
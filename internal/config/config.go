@@ -33,6 +33,7 @@ type Config struct {
 	Prime      PrimeConfig
 	MarketData MarketDataConfig
 	Fees       FeesConfig
+	Order      OrderConfig
 	Server     ServerConfig
 	Database   DatabaseConfig
 }
@@ -44,6 +45,7 @@ type PrimeConfig struct {
 	SigningKey       string
 	Portfolio        string
 	ServiceAccountId string
+	RestUrl          string // Optional REST base URL override (e.g., a local stand-in); empty uses the SDK default
 }
 
 // String masks sensitive credentials when printing
@@ -62,9 +64,24 @@ type MarketDataConfig struct {
 	WebSocketUrl      string
 	Products          []string
 	MaxLevels         int
-	ReconnectDelay    time.Duration
+	ReconnectDelay    time.Duration // Delay before the first reconnect attempt; doubles (with jitter) on each further failure
+	ReconnectMaxDelay time.Duration // Cap on the reconnect delay
+	ReconnectAttempts int           // Consecutive failed reconnects before giving up; 0 retries forever
+	ReconnectStable   time.Duration // Connection uptime after which the reconnect delay resets
+	HeartbeatTimeout  time.Duration // Reconnect if no heartbeat arrives within this window; 0 disables
+	QueueSize         int           // Messages buffered per channel between the socket and its handler; 0 handles inline
+	QueueSpillDir     string        // Where the orders queue spills to disk when full; empty uses the OS temp directory
 	InitialWaitTime   time.Duration
 	DisplayUpdateRate time.Duration
+
+	StaleAfter          time.Duration            // A book with no update or heartbeat for this long is stale; 0 disables
+	StaleAfterByProduct map[string]time.Duration // Per-product overrides of StaleAfter
+	MaxSpreadBps        string                   // Spreads wider than this many bps of mid raise the wide-spread alarm; "0" disables
+
+	HistorySampleInterval   time.Duration // How often --history writes mid/spread samples
+	HistorySnapshotInterval time.Duration // How often --history writes top-of-book snapshots; 0 disables them
+	HistoryDepth            int           // Levels per side in history snapshots
+	HistoryRetention        time.Duration // History older than this is pruned; 0 keeps everything
 }
 
 // FeesConfig holds percentage-based fee configuration
@@ -72,6 +89,13 @@ type FeesConfig struct {
 	Percent string // e.g., "0.002" for 20 bps (0.2%)
 }
 
+// OrderConfig holds order preview and execution settings
+type OrderConfig struct {
+	PreviewTtl          time.Duration // How long a preview's locked fee terms remain executable
+	PreviewRetention    time.Duration // Previews expired for longer than this are deleted; 0 keeps everything
+	PaperCommissionRate string        // Simulated Prime commission for --paper orders, e.g., "0.001" for 10 bps
+}
+
 // ServerConfig holds server settings
 type ServerConfig struct {
 	LogLevel string
@@ -80,7 +104,8 @@ type ServerConfig struct {
 
 // DatabaseConfig holds database settings
 type DatabaseConfig struct {
-	Path string
+	Path      string
+	PaperPath string // Separate database for --paper orders, so simulated fills never mix with real ones
 }
 
 // LoadConfig loads configuration from environment variables
@@ -93,18 +118,37 @@ func LoadConfig() (*Config, error) {
 			Products:          []string{"BTC-USD"},
 			MaxLevels:         10,
 			ReconnectDelay:    5 * time.Second,
+			ReconnectMaxDelay: 2 * time.Minute,
+			ReconnectAttempts: 0,
+			ReconnectStable:   30 * time.Second,
+			HeartbeatTimeout:  10 * time.Second,
+			QueueSize:         1000,
 			InitialWaitTime:   2 * time.Second,
 			DisplayUpdateRate: 5 * time.Second,
+
+			StaleAfter:   15 * time.Second,
+			MaxSpreadBps: "100",
+
+			HistorySampleInterval:   time.Second,
+			HistorySnapshotInterval: 30 * time.Second,
+			HistoryDepth:            10,
+			HistoryRetention:        7 * 24 * time.Hour,
 		},
 		Fees: FeesConfig{
 			Percent: "0.002", // 0.2% (20 bps)
 		},
+		Order: OrderConfig{
+			PreviewTtl:          30 * time.Second,
+			PreviewRetention:    24 * time.Hour,
+			PaperCommissionRate: "0.001", // 0.1% (10 bps)
+		},
 		Server: ServerConfig{
 			LogLevel: "info",
 			LogJson:  false,
 		},
 		Database: DatabaseConfig{
-			Path: "orders.db",
+			Path:      "orders.db",
+			PaperPath: "paper_orders.db",
 		},
 	}
 
@@ -136,6 +180,9 @@ func loadFromEnv(cfg *Config) {
 	if v := os.Getenv("PRIME_SERVICE_ACCOUNT_ID"); v != "" {
 		cfg.Prime.ServiceAccountId = v
 	}
+	if v := os.Getenv("PRIME_REST_URL"); v != "" {
+		cfg.Prime.RestUrl = v
+	}
 
 	// Market data
 	if v := os.Getenv("MARKET_DATA_WEBSOCKET_URL"); v != "" {
@@ -154,6 +201,34 @@ func loadFromEnv(cfg *Config) {
 			cfg.MarketData.ReconnectDelay = d
 		}
 	}
+	if v := os.Getenv("MARKET_DATA_RECONNECT_MAX_DELAY"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.MarketData.ReconnectMaxDelay = d
+		}
+	}
+	if v := os.Getenv("MARKET_DATA_RECONNECT_MAX_ATTEMPTS"); v != "" {
+		if i, err := strconv.Atoi(v); err == nil {
+			cfg.MarketData.ReconnectAttempts = i
+		}
+	}
+	if v := os.Getenv("MARKET_DATA_RECONNECT_STABLE_AFTER"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.MarketData.ReconnectStable = d
+		}
+	}
+	if v := os.Getenv("MARKET_DATA_HEARTBEAT_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.MarketData.HeartbeatTimeout = d
+		}
+	}
+	if v := os.Getenv("MARKET_DATA_QUEUE_SIZE"); v != "" {
+		if i, err := strconv.Atoi(v); err == nil {
+			cfg.MarketData.QueueSize = i
+		}
+	}
+	if v := os.Getenv("MARKET_DATA_QUEUE_SPILL_DIR"); v != "" {
+		cfg.MarketData.QueueSpillDir = v
+	}
 	if v := os.Getenv("MARKET_DATA_INITIAL_WAIT_TIME"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.MarketData.InitialWaitTime = d
@@ -164,12 +239,58 @@ func loadFromEnv(cfg *Config) {
 			cfg.MarketData.DisplayUpdateRate = d
 		}
 	}
+	if v := os.Getenv("MARKET_DATA_STALE_AFTER"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.MarketData.StaleAfter = d
+		}
+	}
+	if v := os.Getenv("MARKET_DATA_STALE_AFTER_BY_PRODUCT"); v != "" {
+		cfg.MarketData.StaleAfterByProduct = parseDurationMap(v)
+	}
+	if v := os.Getenv("MARKET_DATA_MAX_SPREAD_BPS"); v != "" {
+		cfg.MarketData.MaxSpreadBps = v
+	}
+	if v := os.Getenv("MARKET_DATA_HISTORY_SAMPLE_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.MarketData.HistorySampleInterval = d
+		}
+	}
+	if v := os.Getenv("MARKET_DATA_HISTORY_SNAPSHOT_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.MarketData.HistorySnapshotInterval = d
+		}
+	}
+	if v := os.Getenv("MARKET_DATA_HISTORY_DEPTH"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MarketData.HistoryDepth = n
+		}
+	}
+	if v := os.Getenv("MARKET_DATA_HISTORY_RETENTION"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.MarketData.HistoryRetention = d
+		}
+	}
 
 	// Fees (percentage only)
 	if v := os.Getenv("FEE_PERCENT"); v != "" {
 		cfg.Fees.Percent = v
 	}
 
+	// Order
+	if v := os.Getenv("ORDER_PREVIEW_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Order.PreviewTtl = d
+		}
+	}
+	if v := os.Getenv("ORDER_PREVIEW_RETENTION"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Order.PreviewRetention = d
+		}
+	}
+	if v := os.Getenv("PAPER_COMMISSION_RATE"); v != "" {
+		cfg.Order.PaperCommissionRate = v
+	}
+
 	// Server
 	if v := os.Getenv("LOG_LEVEL"); v != "" {
 		cfg.Server.LogLevel = v
@@ -182,6 +303,24 @@ func loadFromEnv(cfg *Config) {
 	if v := os.Getenv("DATABASE_PATH"); v != "" {
 		cfg.Database.Path = v
 	}
+	if v := os.Getenv("PAPER_DATABASE_PATH"); v != "" {
+		cfg.Database.PaperPath = v
+	}
+}
+
+// parseDurationMap parses "BTC-USD=10s,ETH-USD=30s"; malformed entries are skipped
+func parseDurationMap(v string) map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	for _, entry := range strings.Split(v, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		if d, err := time.ParseDuration(strings.TrimSpace(value)); err == nil {
+			result[strings.ToUpper(strings.TrimSpace(key))] = d
+		}
+	}
+	return result
 }
 
 // Validate checks if the configuration is valid
@@ -207,6 +346,15 @@ func (c *Config) Validate() error {
 	if len(c.MarketData.Products) == 0 {
 		return fmt.Errorf("at least one product is required")
 	}
+	if c.MarketData.MaxSpreadBps != "" {
+		spread, err := decimal.NewFromString(c.MarketData.MaxSpreadBps)
+		if err != nil {
+			return fmt.Errorf("invalid MARKET_DATA_MAX_SPREAD_BPS: %w", err)
+		}
+		if spread.IsNegative() {
+			return fmt.Errorf("MARKET_DATA_MAX_SPREAD_BPS cannot be negative")
+		}
+	}
 
 	// Validate fee config
 	if err := c.Fees.Validate(); err != nil {
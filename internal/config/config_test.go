@@ -19,6 +19,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestFeesConfig_Validate(t *testing.T) {
@@ -284,6 +285,12 @@ func TestLoadConfig_Defaults(t *testing.T) {
 	if cfg.Database.Path != "orders.db" {
 		t.Errorf("Database.Path = %q, want orders.db", cfg.Database.Path)
 	}
+	if cfg.Database.PaperPath != "paper_orders.db" {
+		t.Errorf("Database.PaperPath = %q, want paper_orders.db", cfg.Database.PaperPath)
+	}
+	if cfg.Order.PreviewRetention != 24*time.Hour {
+		t.Errorf("Order.PreviewRetention = %s, want 24h", cfg.Order.PreviewRetention)
+	}
 
 	if cfg.Server.LogLevel != "info" {
 		t.Errorf("Logging.Level = %q, want info", cfg.Server.LogLevel)
@@ -339,6 +346,20 @@ func TestLoadFromEnv(t *testing.T) {
 	}
 }
 
+func TestParseDurationMap(t *testing.T) {
+	got := parseDurationMap(" eth-usd=30s, SOL-USD = 1m ,BAD,XRP-USD=soon")
+
+	want := map[string]time.Duration{"ETH-USD": 30 * time.Second, "SOL-USD": time.Minute}
+	if len(got) != len(want) {
+		t.Fatalf("parseDurationMap() = %v, want %v", got, want)
+	}
+	for product, d := range want {
+		if got[product] != d {
+			t.Errorf("parseDurationMap()[%s] = %s, want %s", product, got[product], d)
+		}
+	}
+}
+
 func TestLoadFromEnv_EmptyValues(t *testing.T) {
 	// Clear all env vars
 	vars := []string{
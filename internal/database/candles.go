@@ -0,0 +1,135 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// CandleRecord is one stored OHLC candle
+type CandleRecord struct {
+	ProductId string
+	Source    string // Price the candle tracks, e.g. "mid"
+	Interval  time.Duration
+	StartTime time.Time
+	Open      string
+	High      string
+	Low       string
+	Close     string
+}
+
+// UpsertCandles stores candles, merging each with any stored candle for the same product, source, interval and start
+// The stored open is kept, high and low are widened and the close is replaced, so a candle that is written
+// while still forming, or again after a restart, never loses the prices already recorded
+func (db *OrdersDb) UpsertCandles(candles []CandleRecord) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin candle transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	for _, candle := range candles {
+		intervalSeconds := int64(candle.Interval / time.Second)
+		startTime := candle.StartTime.UTC()
+
+		var stored CandleRecord
+		err := tx.QueryRow(`
+			SELECT open, high, low FROM candles
+			WHERE product_id = ? AND source = ? AND interval_seconds = ? AND start_time = ?`,
+			candle.ProductId, candle.Source, intervalSeconds, startTime,
+		).Scan(&stored.Open, &stored.High, &stored.Low)
+		switch {
+		case err == sql.ErrNoRows:
+		case err != nil:
+			return fmt.Errorf("failed to read candle: %w", err)
+		default:
+			if candle, err = mergeCandle(stored, candle); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(`
+			INSERT INTO candles (product_id, source, interval_seconds, start_time, open, high, low, close, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (product_id, source, interval_seconds, start_time) DO UPDATE SET
+				open = excluded.open, high = excluded.high, low = excluded.low,
+				close = excluded.close, updated_at = excluded.updated_at`,
+			candle.ProductId, candle.Source, intervalSeconds, startTime,
+			candle.Open, candle.High, candle.Low, candle.Close, now,
+		); err != nil {
+			return fmt.Errorf("failed to upsert candle: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit candles: %w", err)
+	}
+	return nil
+}
+
+// mergeCandle folds candle into the stored open, high and low
+func mergeCandle(stored, candle CandleRecord) (CandleRecord, error) {
+	prices := make([]decimal.Decimal, 4)
+	for i, value := range []string{stored.High, stored.Low, candle.High, candle.Low} {
+		price, err := decimal.NewFromString(value)
+		if err != nil {
+			return candle, fmt.Errorf("invalid candle price %q: %w", value, err)
+		}
+		prices[i] = price
+	}
+
+	candle.Open = stored.Open
+	candle.High = decimal.Max(prices[0], prices[2]).String()
+	candle.Low = decimal.Min(prices[1], prices[3]).String()
+	return candle, nil
+}
+
+// ListCandles returns a product's candles for one source and interval starting in [from, to], oldest first
+func (db *OrdersDb) ListCandles(productId, source string, interval time.Duration, from, to time.Time) ([]CandleRecord, error) {
+	rows, err := db.db.Query(`
+		SELECT product_id, source, start_time, open, high, low, close
+		FROM candles
+		WHERE product_id = ? AND source = ? AND interval_seconds = ? AND start_time >= ? AND start_time <= ?
+		ORDER BY start_time`,
+		productId, source, int64(interval/time.Second), from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list candles: %w", err)
+	}
+	defer rows.Close()
+
+	var candles []CandleRecord
+	for rows.Next() {
+		candle := CandleRecord{Interval: interval}
+		if err := rows.Scan(
+			&candle.ProductId, &candle.Source, &candle.StartTime,
+			&candle.Open, &candle.High, &candle.Low, &candle.Close,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan candle: %w", err)
+		}
+		candles = append(candles, candle)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list candles: %w", err)
+	}
+
+	return candles, nil
+}
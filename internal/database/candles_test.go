@@ -0,0 +1,86 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCandles(t *testing.T) {
+	dbPath := "test_candles.db"
+	defer os.Remove(dbPath)
+	defer os.Remove(dbPath + "-wal")
+	defer os.Remove(dbPath + "-shm")
+
+	db, err := NewOrdersDb(dbPath)
+	if err != nil {
+		t.Fatalf("NewOrdersDb() error = %v", err)
+	}
+	defer db.Close()
+
+	base := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	candle := func(start time.Time, open, high, low, close string) CandleRecord {
+		return CandleRecord{
+			ProductId: "BTC-USD", Source: "mid", Interval: time.Minute, StartTime: start,
+			Open: open, High: high, Low: low, Close: close,
+		}
+	}
+
+	if err := db.UpsertCandles([]CandleRecord{
+		candle(base, "100", "105", "99", "104"),
+		candle(base.Add(time.Minute), "104", "106", "103", "105"),
+		candle(base.Add(2*time.Minute), "105", "105", "101", "102"),
+	}); err != nil {
+		t.Fatalf("UpsertCandles() error = %v", err)
+	}
+
+	// A restarted stream rewrites the first minute from a later open; stored open and range are kept
+	if err := db.UpsertCandles([]CandleRecord{candle(base, "103", "108", "102", "107")}); err != nil {
+		t.Fatalf("UpsertCandles() merge error = %v", err)
+	}
+	// Another interval for the same start is stored separately
+	hourly := candle(base, "1", "1", "1", "1")
+	hourly.Interval = time.Hour
+	if err := db.UpsertCandles([]CandleRecord{hourly}); err != nil {
+		t.Fatalf("UpsertCandles() hourly error = %v", err)
+	}
+
+	candles, err := db.ListCandles("BTC-USD", "mid", time.Minute, base, base.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("ListCandles() error = %v", err)
+	}
+	if len(candles) != 2 {
+		t.Fatalf("ListCandles() returned %d candles, want 2", len(candles))
+	}
+	want := CandleRecord{
+		ProductId: "BTC-USD", Source: "mid", Interval: time.Minute, StartTime: base,
+		Open: "100", High: "108", Low: "99", Close: "107",
+	}
+	if got := candles[0]; got.Open != want.Open || got.High != want.High || got.Low != want.Low ||
+		got.Close != want.Close || !got.StartTime.Equal(base) || got.Interval != time.Minute {
+		t.Errorf("merged candle = %+v, want %+v", got, want)
+	}
+	if !candles[1].StartTime.Equal(base.Add(time.Minute)) {
+		t.Errorf("second candle start = %v, want %v", candles[1].StartTime, base.Add(time.Minute))
+	}
+
+	if candles, err := db.ListCandles("BTC-USD", "ask", time.Minute, base, base.Add(time.Hour)); err != nil || len(candles) != 0 {
+		t.Errorf("ListCandles(ask) = %v, %v, want none", candles, err)
+	}
+}
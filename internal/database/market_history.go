@@ -0,0 +1,173 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// MarketSample is a product's top of book at one point in time
+type MarketSample struct {
+	ProductId  string
+	BestBid    string
+	BestAsk    string
+	Mid        string
+	SpreadBps  string    // Spread relative to mid, in basis points
+	BookTime   time.Time // Last update to the book when sampled
+	RecordedAt time.Time
+}
+
+// MarketSnapshot is a product's top levels at one point in time
+type MarketSnapshot struct {
+	ProductId  string
+	Sequence   int64
+	Bids       [][2]string // [price, size], best first
+	Asks       [][2]string
+	BookTime   time.Time
+	RecordedAt time.Time
+}
+
+// InsertMarketHistory stores one recording tick's samples and snapshots in a single transaction
+func (db *OrdersDb) InsertMarketHistory(samples []MarketSample, snapshots []MarketSnapshot) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin market history transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, sample := range samples {
+		if _, err := tx.Exec(`
+			INSERT INTO market_samples (product_id, best_bid, best_ask, mid, spread_bps, book_time, recorded_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			sample.ProductId, sample.BestBid, sample.BestAsk, sample.Mid, sample.SpreadBps,
+			sample.BookTime.UTC(), sample.RecordedAt.UTC(),
+		); err != nil {
+			return fmt.Errorf("failed to insert market sample: %w", err)
+		}
+	}
+
+	for _, snapshot := range snapshots {
+		bids, err := json.Marshal(snapshot.Bids)
+		if err != nil {
+			return fmt.Errorf("failed to encode bids: %w", err)
+		}
+		asks, err := json.Marshal(snapshot.Asks)
+		if err != nil {
+			return fmt.Errorf("failed to encode asks: %w", err)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO market_snapshots (product_id, sequence_num, bids, asks, book_time, recorded_at)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			snapshot.ProductId, snapshot.Sequence, string(bids), string(asks),
+			snapshot.BookTime.UTC(), snapshot.RecordedAt.UTC(),
+		); err != nil {
+			return fmt.Errorf("failed to insert market snapshot: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit market history: %w", err)
+	}
+	return nil
+}
+
+// ListMarketSamples returns a product's samples recorded in [from, to], oldest first
+func (db *OrdersDb) ListMarketSamples(productId string, from, to time.Time) ([]MarketSample, error) {
+	rows, err := db.db.Query(`
+		SELECT product_id, best_bid, best_ask, mid, spread_bps, book_time, recorded_at
+		FROM market_samples
+		WHERE product_id = ? AND recorded_at >= ? AND recorded_at <= ?
+		ORDER BY recorded_at, id`,
+		productId, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list market samples: %w", err)
+	}
+	defer rows.Close()
+
+	var samples []MarketSample
+	for rows.Next() {
+		var sample MarketSample
+		if err := rows.Scan(
+			&sample.ProductId, &sample.BestBid, &sample.BestAsk, &sample.Mid, &sample.SpreadBps,
+			&sample.BookTime, &sample.RecordedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan market sample: %w", err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list market samples: %w", err)
+	}
+
+	return samples, nil
+}
+
+// ListMarketSnapshots returns a product's snapshots recorded in [from, to], oldest first
+func (db *OrdersDb) ListMarketSnapshots(productId string, from, to time.Time) ([]MarketSnapshot, error) {
+	rows, err := db.db.Query(`
+		SELECT product_id, sequence_num, bids, asks, book_time, recorded_at
+		FROM market_snapshots
+		WHERE product_id = ? AND recorded_at >= ? AND recorded_at <= ?
+		ORDER BY recorded_at, id`,
+		productId, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list market snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []MarketSnapshot
+	for rows.Next() {
+		var snapshot MarketSnapshot
+		var bids, asks string
+		if err := rows.Scan(
+			&snapshot.ProductId, &snapshot.Sequence, &bids, &asks, &snapshot.BookTime, &snapshot.RecordedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan market snapshot: %w", err)
+		}
+		if err := json.Unmarshal([]byte(bids), &snapshot.Bids); err != nil {
+			return nil, fmt.Errorf("failed to decode snapshot bids: %w", err)
+		}
+		if err := json.Unmarshal([]byte(asks), &snapshot.Asks); err != nil {
+			return nil, fmt.Errorf("failed to decode snapshot asks: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list market snapshots: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// PruneMarketHistory deletes samples and snapshots recorded before cutoff and returns how many rows were removed
+func (db *OrdersDb) PruneMarketHistory(cutoff time.Time) (int64, error) {
+	var removed int64
+	for _, table := range []string{"market_samples", "market_snapshots"} {
+		result, err := db.db.Exec(`DELETE FROM `+table+` WHERE recorded_at < ?`, cutoff.UTC())
+		if err != nil {
+			return removed, fmt.Errorf("failed to prune %s: %w", table, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return removed, fmt.Errorf("failed to prune %s: %w", table, err)
+		}
+		removed += n
+	}
+	return removed, nil
+}
@@ -0,0 +1,94 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMarketHistory(t *testing.T) {
+	dbPath := "test_market_history.db"
+	defer os.Remove(dbPath)
+	defer os.Remove(dbPath + "-wal")
+	defer os.Remove(dbPath + "-shm")
+
+	db, err := NewOrdersDb(dbPath)
+	if err != nil {
+		t.Fatalf("NewOrdersDb() error = %v", err)
+	}
+	defer db.Close()
+
+	// Fractional and whole seconds, in a non-UTC zone, must still sort and filter by instant
+	base := time.Date(2026, 10, 18, 13, 0, 0, 0, time.FixedZone("EST", -5*3600))
+	times := []time.Time{base, base.Add(500 * time.Millisecond), base.Add(time.Second), base.Add(2 * time.Hour)}
+	for i, recordedAt := range times {
+		samples := []MarketSample{
+			{ProductId: "BTC-USD", BestBid: "100", BestAsk: "101", Mid: "100.5", SpreadBps: "99.5025", BookTime: recordedAt, RecordedAt: recordedAt},
+			{ProductId: "ETH-USD", BestBid: "10", BestAsk: "11", Mid: "10.5", SpreadBps: "952.3810", BookTime: recordedAt, RecordedAt: recordedAt},
+		}
+		var snapshots []MarketSnapshot
+		if i == 0 {
+			snapshots = []MarketSnapshot{{
+				ProductId:  "BTC-USD",
+				Sequence:   42,
+				Bids:       [][2]string{{"100", "1.5"}, {"99", "2"}},
+				Asks:       [][2]string{{"101", "0.5"}},
+				BookTime:   recordedAt,
+				RecordedAt: recordedAt,
+			}}
+		}
+		if err := db.InsertMarketHistory(samples, snapshots); err != nil {
+			t.Fatalf("InsertMarketHistory() error = %v", err)
+		}
+	}
+
+	samples, err := db.ListMarketSamples("BTC-USD", base.Add(100*time.Millisecond), base.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("ListMarketSamples() error = %v", err)
+	}
+	if len(samples) != 2 || !samples[0].RecordedAt.Equal(times[1]) || !samples[1].RecordedAt.Equal(times[2]) {
+		t.Fatalf("ListMarketSamples() = %+v, want the samples at +500ms and +1s", samples)
+	}
+	if samples[0].ProductId != "BTC-USD" || samples[0].Mid != "100.5" {
+		t.Errorf("sample = %+v, want BTC-USD mid 100.5", samples[0])
+	}
+
+	snapshots, err := db.ListMarketSnapshots("BTC-USD", base, base)
+	if err != nil {
+		t.Fatalf("ListMarketSnapshots() error = %v", err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Sequence != 42 || len(snapshots[0].Bids) != 2 || snapshots[0].Asks[0] != [2]string{"101", "0.5"} {
+		t.Fatalf("ListMarketSnapshots() = %+v, want the stored snapshot", snapshots)
+	}
+
+	removed, err := db.PruneMarketHistory(base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("PruneMarketHistory() error = %v", err)
+	}
+	if removed != 7 {
+		t.Errorf("PruneMarketHistory() removed %d rows, want 6 samples and 1 snapshot", removed)
+	}
+	remaining, err := db.ListMarketSamples("BTC-USD", base, base.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("ListMarketSamples() error = %v", err)
+	}
+	if len(remaining) != 1 || !remaining[0].RecordedAt.Equal(times[3]) {
+		t.Errorf("after pruning, samples = %+v, want only the +2h sample", remaining)
+	}
+}
@@ -176,6 +176,72 @@ func (db *OrdersDb) createTables() error {
 		received_at TIMESTAMP NOT NULL
 	);`
 
+	// Previews table - locked fee terms that can be executed until expiry
+	previewsTable := `
+	CREATE TABLE IF NOT EXISTS previews (
+		preview_id TEXT PRIMARY KEY,
+		product_id TEXT NOT NULL,
+		side TEXT NOT NULL,
+		order_type TEXT NOT NULL,
+		unit TEXT NOT NULL,
+
+		-- User's original request
+		base_qty TEXT NOT NULL DEFAULT '0',
+		quote_value TEXT NOT NULL DEFAULT '0',
+		limit_price TEXT NOT NULL DEFAULT '0',
+
+		-- Locked fee terms
+		fee_percent TEXT NOT NULL,
+		markup_amount TEXT NOT NULL DEFAULT '0',
+		prime_order_quote_amount TEXT NOT NULL DEFAULT '0',
+
+		-- Lifecycle
+		status TEXT NOT NULL,
+		order_id TEXT NOT NULL DEFAULT '',
+		client_order_id TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	);`
+
+	// Market data history - periodic mid/spread samples and top-of-book snapshots
+	marketSamplesTable := `
+	CREATE TABLE IF NOT EXISTS market_samples (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id TEXT NOT NULL,
+		best_bid TEXT NOT NULL,
+		best_ask TEXT NOT NULL,
+		mid TEXT NOT NULL,
+		spread_bps TEXT NOT NULL,
+		book_time TIMESTAMP NOT NULL,
+		recorded_at TIMESTAMP NOT NULL
+	);`
+
+	marketSnapshotsTable := `
+	CREATE TABLE IF NOT EXISTS market_snapshots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id TEXT NOT NULL,
+		sequence_num INTEGER NOT NULL,
+		bids TEXT NOT NULL, -- JSON [[price, size], ...], best first
+		asks TEXT NOT NULL,
+		book_time TIMESTAMP NOT NULL,
+		recorded_at TIMESTAMP NOT NULL
+	);`
+
+	// Candles - OHLC aggregated from streamed books, one row per product, price source, interval and start
+	candlesTable := `
+	CREATE TABLE IF NOT EXISTS candles (
+		product_id TEXT NOT NULL,
+		source TEXT NOT NULL,
+		interval_seconds INTEGER NOT NULL,
+		start_time TIMESTAMP NOT NULL,
+		open TEXT NOT NULL,
+		high TEXT NOT NULL,
+		low TEXT NOT NULL,
+		close TEXT NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (product_id, source, interval_seconds, start_time)
+	);`
+
 	// Create indexes separately
 	indexes := []string{
 		`CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status);`,
@@ -184,6 +250,12 @@ func (db *OrdersDb) createTables() error {
 		`CREATE INDEX IF NOT EXISTS idx_events_order ON order_events(order_id);`,
 		`CREATE INDEX IF NOT EXISTS idx_events_seq ON order_events(order_id, sequence_num);`,
 		`CREATE INDEX IF NOT EXISTS idx_events_received ON order_events(received_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_previews_status ON previews(status);`,
+		`CREATE INDEX IF NOT EXISTS idx_previews_expires_at ON previews(expires_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_market_samples_product ON market_samples(product_id, recorded_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_market_samples_recorded ON market_samples(recorded_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_market_snapshots_product ON market_snapshots(product_id, recorded_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_market_snapshots_recorded ON market_snapshots(recorded_at);`,
 	}
 
 	if _, err := db.db.Exec(ordersTable); err != nil {
@@ -194,6 +266,22 @@ func (db *OrdersDb) createTables() error {
 		return fmt.Errorf("failed to create order_events table: %w", err)
 	}
 
+	if _, err := db.db.Exec(previewsTable); err != nil {
+		return fmt.Errorf("failed to create previews table: %w", err)
+	}
+
+	if _, err := db.db.Exec(marketSamplesTable); err != nil {
+		return fmt.Errorf("failed to create market_samples table: %w", err)
+	}
+
+	if _, err := db.db.Exec(marketSnapshotsTable); err != nil {
+		return fmt.Errorf("failed to create market_snapshots table: %w", err)
+	}
+
+	if _, err := db.db.Exec(candlesTable); err != nil {
+		return fmt.Errorf("failed to create candles table: %w", err)
+	}
+
 	for _, idx := range indexes {
 		if _, err := db.db.Exec(idx); err != nil {
 			return fmt.Errorf("failed to create index: %w", err)
@@ -342,6 +430,49 @@ func (db *OrdersDb) GetOrder(orderId string) (*OrderRecord, error) {
 	return &order, nil
 }
 
+// ListUnsettledOrders returns orders that have not reached a terminal state (fee not yet settled)
+func (db *OrdersDb) ListUnsettledOrders() ([]*OrderRecord, error) {
+	query := `
+	SELECT
+		order_id, client_order_id, product_id, side, order_type, status,
+		cum_qty, leaves_qty, avg_px, net_avg_px, fees,
+		commission, venue_fee, ces_commission,
+		user_requested_amount, markup_amount, prime_order_quote_amount,
+		actual_filled_value, actual_earned_fee, rebate_amount, fee_settled,
+		first_seen_at, last_updated_at
+	FROM orders
+	WHERE fee_settled = 0
+	ORDER BY first_seen_at
+	`
+
+	rows, err := db.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unsettled orders: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*OrderRecord
+	for rows.Next() {
+		var order OrderRecord
+		if err := rows.Scan(
+			&order.OrderId, &order.ClientOrderId, &order.ProductId, &order.Side, &order.OrderType, &order.Status,
+			&order.CumQty, &order.LeavesQty, &order.AvgPx, &order.NetAvgPx, &order.Fees,
+			&order.Commission, &order.VenueFee, &order.CesCommission,
+			&order.UserRequestedAmount, &order.MarkupAmount, &order.PrimeOrderQuoteAmount,
+			&order.ActualFilledValue, &order.ActualEarnedFee, &order.RebateAmount, &order.FeeSettled,
+			&order.FirstSeenAt, &order.LastUpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		records = append(records, &order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list unsettled orders: %w", err)
+	}
+
+	return records, nil
+}
+
 // ComputeCustomFees calculates custom fees for an order
 func ComputeCustomFees(side string, cumQty, avgPx decimal.Decimal, feePercent decimal.Decimal) (customFee, adjustedPrice, totalCost decimal.Decimal) {
 	if cumQty.IsZero() || avgPx.IsZero() {
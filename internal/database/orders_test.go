@@ -466,3 +466,40 @@ func TestUpsertOrder_MetadataPreservation(t *testing.T) {
 		t.Errorf("PrimeOrderQuoteAmount = %q, want 49.75 (should be preserved)", retrieved.PrimeOrderQuoteAmount)
 	}
 }
+
+func TestListUnsettledOrders(t *testing.T) {
+	dbPath := "test_list_unsettled.db"
+	defer os.Remove(dbPath)
+	defer os.Remove(dbPath + "-wal")
+	defer os.Remove(dbPath + "-shm")
+
+	db, err := NewOrdersDb(dbPath)
+	if err != nil {
+		t.Fatalf("NewOrdersDb() error = %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	orders := []*OrderRecord{
+		{OrderId: "open-order", Status: "OPEN", FeeSettled: false, FirstSeenAt: now, LastUpdatedAt: now},
+		{OrderId: "filled-order", Status: "FILLED", FeeSettled: true, FirstSeenAt: now, LastUpdatedAt: now},
+		{OrderId: "pending-order", Status: "PENDING", FeeSettled: false, FirstSeenAt: now.Add(time.Second), LastUpdatedAt: now},
+	}
+	for _, order := range orders {
+		if err := db.UpsertOrder(order); err != nil {
+			t.Fatalf("UpsertOrder() error = %v", err)
+		}
+	}
+
+	unsettled, err := db.ListUnsettledOrders()
+	if err != nil {
+		t.Fatalf("ListUnsettledOrders() error = %v", err)
+	}
+
+	if len(unsettled) != 2 {
+		t.Fatalf("len(unsettled) = %d, want 2", len(unsettled))
+	}
+	if unsettled[0].OrderId != "open-order" || unsettled[1].OrderId != "pending-order" {
+		t.Errorf("unsettled = [%s %s], want [open-order pending-order]", unsettled[0].OrderId, unsettled[1].OrderId)
+	}
+}
@@ -0,0 +1,189 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Preview lifecycle statuses
+const (
+	PreviewStatusPending  = "PENDING"
+	PreviewStatusExecuted = "EXECUTED"
+	PreviewStatusUnknown  = "UNKNOWN" // Sent to Prime but the outcome was never confirmed
+)
+
+// PreviewRecord captures a preview's request and the fee terms locked for execution
+type PreviewRecord struct {
+	PreviewId string
+	ProductId string
+	Side      string
+	OrderType string
+	Unit      string // "base" or "quote"
+
+	// User's original request
+	BaseQty    string
+	QuoteValue string
+	LimitPrice string
+
+	// Locked fee terms
+	FeePercent            string // Fee rate in effect when the preview was generated (e.g., 0.005)
+	MarkupAmount          string // Our markup shown to the user (enforced for quote orders, an estimate for base orders)
+	PrimeOrderQuoteAmount string // What will be sent to Prime for quote orders (enforced at execution)
+
+	// Lifecycle
+	Status        string
+	OrderId       string // Prime order Id once executed
+	ClientOrderId string // Client order Id sent to Prime, kept when the outcome is unknown
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+}
+
+// IsExpired reports whether the preview can no longer be executed at the given time
+func (p *PreviewRecord) IsExpired(now time.Time) bool {
+	return !now.Before(p.ExpiresAt)
+}
+
+// InsertPreview stores a new preview record
+func (db *OrdersDb) InsertPreview(preview *PreviewRecord) error {
+	query := `
+	INSERT INTO previews (
+		preview_id, product_id, side, order_type, unit,
+		base_qty, quote_value, limit_price,
+		fee_percent, markup_amount, prime_order_quote_amount,
+		status, order_id, client_order_id, created_at, expires_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := db.db.Exec(query,
+		preview.PreviewId, preview.ProductId, preview.Side, preview.OrderType, preview.Unit,
+		preview.BaseQty, preview.QuoteValue, preview.LimitPrice,
+		preview.FeePercent, preview.MarkupAmount, preview.PrimeOrderQuoteAmount,
+		preview.Status, preview.OrderId, preview.ClientOrderId, preview.CreatedAt, preview.ExpiresAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to insert preview: %w", err)
+	}
+
+	return nil
+}
+
+// GetPreview retrieves a preview record, returning nil if it does not exist
+func (db *OrdersDb) GetPreview(previewId string) (*PreviewRecord, error) {
+	query := `
+	SELECT
+		preview_id, product_id, side, order_type, unit,
+		base_qty, quote_value, limit_price,
+		fee_percent, markup_amount, prime_order_quote_amount,
+		status, order_id, client_order_id, created_at, expires_at
+	FROM previews
+	WHERE preview_id = ?
+	`
+
+	var preview PreviewRecord
+	err := db.db.QueryRow(query, previewId).Scan(
+		&preview.PreviewId, &preview.ProductId, &preview.Side, &preview.OrderType, &preview.Unit,
+		&preview.BaseQty, &preview.QuoteValue, &preview.LimitPrice,
+		&preview.FeePercent, &preview.MarkupAmount, &preview.PrimeOrderQuoteAmount,
+		&preview.Status, &preview.OrderId, &preview.ClientOrderId, &preview.CreatedAt, &preview.ExpiresAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preview: %w", err)
+	}
+
+	return &preview, nil
+}
+
+// ClaimPreview atomically marks a pending preview as executed so it cannot be used twice
+// Returns false if the preview was already claimed
+func (db *OrdersDb) ClaimPreview(previewId string) (bool, error) {
+	result, err := db.db.Exec(
+		`UPDATE previews SET status = ? WHERE preview_id = ? AND status = ?`,
+		PreviewStatusExecuted, previewId, PreviewStatusPending,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim preview: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim preview: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+// ReleasePreview returns a claimed preview to pending (used when Prime definitely rejected the order)
+func (db *OrdersDb) ReleasePreview(previewId string) error {
+	_, err := db.db.Exec(
+		`UPDATE previews SET status = ? WHERE preview_id = ? AND status = ? AND order_id = ''`,
+		PreviewStatusPending, previewId, PreviewStatusExecuted,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release preview: %w", err)
+	}
+	return nil
+}
+
+// MarkPreviewUnknown parks a claimed preview whose order may or may not have reached Prime
+// The preview cannot be executed again; the client order Id lets the order be looked up
+func (db *OrdersDb) MarkPreviewUnknown(previewId, clientOrderId string) error {
+	_, err := db.db.Exec(
+		`UPDATE previews SET status = ?, client_order_id = ? WHERE preview_id = ? AND status = ? AND order_id = ''`,
+		PreviewStatusUnknown, clientOrderId, previewId, PreviewStatusExecuted,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark preview unknown: %w", err)
+	}
+	return nil
+}
+
+// PrunePreviews deletes previews that expired before cutoff and returns how many were removed
+// Previews whose outcome is unknown are kept so their client order Id can still be looked up
+func (db *OrdersDb) PrunePreviews(cutoff time.Time) (int64, error) {
+	result, err := db.db.Exec(
+		`DELETE FROM previews WHERE expires_at < ? AND status != ?`,
+		cutoff, PreviewStatusUnknown,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune previews: %w", err)
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune previews: %w", err)
+	}
+	return removed, nil
+}
+
+// CompletePreview records the Prime order Id placed from a claimed preview
+func (db *OrdersDb) CompletePreview(previewId, orderId string) error {
+	_, err := db.db.Exec(
+		`UPDATE previews SET order_id = ? WHERE preview_id = ?`,
+		orderId, previewId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete preview: %w", err)
+	}
+	return nil
+}
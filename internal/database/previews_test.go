@@ -0,0 +1,234 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package database
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestPreviewRecord(previewId string, expiresAt time.Time) *PreviewRecord {
+	return &PreviewRecord{
+		PreviewId:             previewId,
+		ProductId:             "BTC-USD",
+		Side:                  "BUY",
+		OrderType:             "MARKET",
+		Unit:                  "quote",
+		BaseQty:               "0",
+		QuoteValue:            "100",
+		LimitPrice:            "0",
+		FeePercent:            "0.005",
+		MarkupAmount:          "0.5",
+		PrimeOrderQuoteAmount: "99.5",
+		Status:                PreviewStatusPending,
+		CreatedAt:             time.Now(),
+		ExpiresAt:             expiresAt,
+	}
+}
+
+func TestInsertAndGetPreview(t *testing.T) {
+	dbPath := "test_previews.db"
+	defer os.Remove(dbPath)
+	defer os.Remove(dbPath + "-wal")
+	defer os.Remove(dbPath + "-shm")
+
+	db, err := NewOrdersDb(dbPath)
+	if err != nil {
+		t.Fatalf("NewOrdersDb() error = %v", err)
+	}
+	defer db.Close()
+
+	preview := newTestPreviewRecord("preview-1", time.Now().Add(time.Minute))
+	if err := db.InsertPreview(preview); err != nil {
+		t.Fatalf("InsertPreview() error = %v", err)
+	}
+
+	retrieved, err := db.GetPreview("preview-1")
+	if err != nil {
+		t.Fatalf("GetPreview() error = %v", err)
+	}
+	if retrieved == nil {
+		t.Fatal("GetPreview() returned nil")
+	}
+
+	if retrieved.FeePercent != "0.005" {
+		t.Errorf("FeePercent = %q, want 0.005", retrieved.FeePercent)
+	}
+	if retrieved.MarkupAmount != "0.5" {
+		t.Errorf("MarkupAmount = %q, want 0.5", retrieved.MarkupAmount)
+	}
+	if retrieved.Status != PreviewStatusPending {
+		t.Errorf("Status = %q, want %q", retrieved.Status, PreviewStatusPending)
+	}
+	if retrieved.IsExpired(time.Now()) {
+		t.Error("IsExpired() = true, want false before expiry")
+	}
+	if !retrieved.IsExpired(time.Now().Add(2 * time.Minute)) {
+		t.Error("IsExpired() = false, want true after expiry")
+	}
+
+	missing, err := db.GetPreview("missing")
+	if err != nil {
+		t.Fatalf("GetPreview() error = %v", err)
+	}
+	if missing != nil {
+		t.Error("GetPreview() should return nil for missing preview")
+	}
+}
+
+func TestClaimPreview(t *testing.T) {
+	dbPath := "test_previews_claim.db"
+	defer os.Remove(dbPath)
+	defer os.Remove(dbPath + "-wal")
+	defer os.Remove(dbPath + "-shm")
+
+	db, err := NewOrdersDb(dbPath)
+	if err != nil {
+		t.Fatalf("NewOrdersDb() error = %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InsertPreview(newTestPreviewRecord("preview-1", time.Now().Add(time.Minute))); err != nil {
+		t.Fatalf("InsertPreview() error = %v", err)
+	}
+
+	// First claim succeeds, second is rejected
+	claimed, err := db.ClaimPreview("preview-1")
+	if err != nil || !claimed {
+		t.Fatalf("ClaimPreview() = %v, %v; want true, nil", claimed, err)
+	}
+	claimed, err = db.ClaimPreview("preview-1")
+	if err != nil || claimed {
+		t.Fatalf("second ClaimPreview() = %v, %v; want false, nil", claimed, err)
+	}
+
+	// Releasing a claim without an order makes it executable again
+	if err := db.ReleasePreview("preview-1"); err != nil {
+		t.Fatalf("ReleasePreview() error = %v", err)
+	}
+	claimed, err = db.ClaimPreview("preview-1")
+	if err != nil || !claimed {
+		t.Fatalf("ClaimPreview() after release = %v, %v; want true, nil", claimed, err)
+	}
+
+	// Completed previews record the order and cannot be released
+	if err := db.CompletePreview("preview-1", "order-1"); err != nil {
+		t.Fatalf("CompletePreview() error = %v", err)
+	}
+	if err := db.ReleasePreview("preview-1"); err != nil {
+		t.Fatalf("ReleasePreview() error = %v", err)
+	}
+
+	retrieved, err := db.GetPreview("preview-1")
+	if err != nil {
+		t.Fatalf("GetPreview() error = %v", err)
+	}
+	if retrieved.Status != PreviewStatusExecuted {
+		t.Errorf("Status = %q, want %q", retrieved.Status, PreviewStatusExecuted)
+	}
+	if retrieved.OrderId != "order-1" {
+		t.Errorf("OrderId = %q, want order-1", retrieved.OrderId)
+	}
+}
+
+func TestMarkPreviewUnknown(t *testing.T) {
+	dbPath := "test_previews_unknown.db"
+	defer os.Remove(dbPath)
+	defer os.Remove(dbPath + "-wal")
+	defer os.Remove(dbPath + "-shm")
+
+	db, err := NewOrdersDb(dbPath)
+	if err != nil {
+		t.Fatalf("NewOrdersDb() error = %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InsertPreview(newTestPreviewRecord("preview-1", time.Now().Add(time.Minute))); err != nil {
+		t.Fatalf("InsertPreview() error = %v", err)
+	}
+	if claimed, err := db.ClaimPreview("preview-1"); err != nil || !claimed {
+		t.Fatalf("ClaimPreview() = %v, %v; want true, nil", claimed, err)
+	}
+	if err := db.MarkPreviewUnknown("preview-1", "client-1"); err != nil {
+		t.Fatalf("MarkPreviewUnknown() error = %v", err)
+	}
+
+	// Unknown previews can be neither released nor claimed again
+	if err := db.ReleasePreview("preview-1"); err != nil {
+		t.Fatalf("ReleasePreview() error = %v", err)
+	}
+	if claimed, err := db.ClaimPreview("preview-1"); err != nil || claimed {
+		t.Fatalf("ClaimPreview() on unknown preview = %v, %v; want false, nil", claimed, err)
+	}
+
+	retrieved, err := db.GetPreview("preview-1")
+	if err != nil {
+		t.Fatalf("GetPreview() error = %v", err)
+	}
+	if retrieved.Status != PreviewStatusUnknown {
+		t.Errorf("Status = %q, want %q", retrieved.Status, PreviewStatusUnknown)
+	}
+	if retrieved.ClientOrderId != "client-1" {
+		t.Errorf("ClientOrderId = %q, want client-1", retrieved.ClientOrderId)
+	}
+}
+
+func TestPrunePreviews(t *testing.T) {
+	dbPath := "test_previews_prune.db"
+	defer os.Remove(dbPath)
+	defer os.Remove(dbPath + "-wal")
+	defer os.Remove(dbPath + "-shm")
+
+	db, err := NewOrdersDb(dbPath)
+	if err != nil {
+		t.Fatalf("NewOrdersDb() error = %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	for id, expiresAt := range map[string]time.Time{
+		"old":     now.Add(-48 * time.Hour),
+		"unknown": now.Add(-48 * time.Hour),
+		"recent":  now.Add(-time.Minute),
+		"live":    now.Add(time.Minute),
+	} {
+		if err := db.InsertPreview(newTestPreviewRecord(id, expiresAt)); err != nil {
+			t.Fatalf("InsertPreview() error = %v", err)
+		}
+	}
+	db.ClaimPreview("unknown")
+	db.MarkPreviewUnknown("unknown", "client-1")
+
+	removed, err := db.PrunePreviews(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("PrunePreviews() error = %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("PrunePreviews() removed %d, want 1", removed)
+	}
+
+	for id, wantKept := range map[string]bool{"old": false, "unknown": true, "recent": true, "live": true} {
+		preview, err := db.GetPreview(id)
+		if err != nil {
+			t.Fatalf("GetPreview(%s) error = %v", id, err)
+		}
+		if (preview != nil) != wantKept {
+			t.Errorf("preview %s kept = %v, want %v", id, preview != nil, wantKept)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/shopspring/decimal"
+)
+
+// Customer is a downstream client with its own markup, identified by API key
+type Customer struct {
+	Id     string          `json:"id"`
+	ApiKey string          `json:"api_key"`
+	Markup decimal.Decimal `json:"markup"` // e.g., 0.003 for 30 bps
+}
+
+// LoadCustomers reads a JSON array of customers
+func LoadCustomers(path string) ([]Customer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read customers: %w", err)
+	}
+	return ParseCustomers(data)
+}
+
+// ParseCustomers decodes and validates a JSON array of customers
+func ParseCustomers(data []byte) ([]Customer, error) {
+	var customers []Customer
+	if err := json.Unmarshal(data, &customers); err != nil {
+		return nil, fmt.Errorf("failed to parse customers: %w", err)
+	}
+
+	ids := make(map[string]bool, len(customers))
+	keys := make(map[string]bool, len(customers))
+	for i, c := range customers {
+		if c.Id == "" {
+			return nil, fmt.Errorf("customer %d: id is required", i)
+		}
+		if c.ApiKey == "" {
+			return nil, fmt.Errorf("customer %s: api_key is required", c.Id)
+		}
+		if c.Markup.IsNegative() {
+			return nil, fmt.Errorf("customer %s: markup cannot be negative", c.Id)
+		}
+		if ids[c.Id] {
+			return nil, fmt.Errorf("customer %s is listed twice", c.Id)
+		}
+		if keys[c.ApiKey] {
+			return nil, fmt.Errorf("customer %s: api_key is already used by another customer", c.Id)
+		}
+		ids[c.Id] = true
+		keys[c.ApiKey] = true
+	}
+	return customers, nil
+}
@@ -0,0 +1,45 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gateway
+
+import "testing"
+
+func TestParseCustomers(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "valid", data: `[{"id":"acme","api_key":"k1","markup":"0.003"},{"id":"beta","api_key":"k2","markup":"0"}]`},
+		{name: "empty list", data: `[]`},
+		{name: "missing id", data: `[{"api_key":"k1","markup":"0.003"}]`, wantErr: true},
+		{name: "missing api key", data: `[{"id":"acme","markup":"0.003"}]`, wantErr: true},
+		{name: "negative markup", data: `[{"id":"acme","api_key":"k1","markup":"-0.001"}]`, wantErr: true},
+		{name: "duplicate id", data: `[{"id":"acme","api_key":"k1"},{"id":"acme","api_key":"k2"}]`, wantErr: true},
+		{name: "shared api key", data: `[{"id":"acme","api_key":"k1"},{"id":"beta","api_key":"k1"}]`, wantErr: true},
+		{name: "malformed", data: `{"id":"acme"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCustomers([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCustomers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,547 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gateway re-broadcasts fee-adjusted order books to local WebSocket and
+// Server-Sent Events clients. Each client subscribes to products, chooses how
+// often it wants updates and sees prices with its own markup. Raw Prime prices
+// are only sent when Config.ExposeRaw is set.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
+	gorilla "github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultDepth       = 10
+	DefaultInterval    = time.Second
+	DefaultMinInterval = 100 * time.Millisecond
+
+	writeTimeout = 5 * time.Second
+)
+
+// Errors returned when identifying a client
+var (
+	ErrUnknownApiKey = errors.New("unknown api key")
+	ErrQueryApiKey   = errors.New("api key must be sent in the Authorization header")
+)
+
+// Config controls what the gateway serves and to whom
+type Config struct {
+	Products    []string        // Products clients may subscribe to
+	Markup      decimal.Decimal // Markup for clients that don't present an API key
+	Customers   []Customer      // Customers with their own markup, identified by API key
+	Depth       int             // Levels per side sent to clients; 0 uses DefaultDepth
+	Interval    time.Duration   // Update interval for clients that don't ask for one; 0 uses DefaultInterval
+	MinInterval time.Duration   // Fastest update interval a client may ask for; 0 uses DefaultMinInterval
+	ExposeRaw   bool            // Also send raw Prime prices; off by default so only fee-adjusted prices leave the gateway
+
+	// AllowedOrigins lists the browser origins (e.g. https://app.example.com) that may connect, besides the
+	// gateway's own origin; "*" allows any. Requests without an Origin header come from non-browser clients and are allowed.
+	AllowedOrigins []string
+	// AllowQueryKey also accepts ?api_key=; off by default because query strings end up in proxy and access logs
+	AllowQueryKey bool
+}
+
+// Server serves fee-adjusted books from an OrderBookStore on /ws and /sse
+type Server struct {
+	cfg       Config
+	books     *websocket.OrderBookStore
+	products  map[string]bool
+	customers map[string]Customer // API key -> customer
+	upgrader  gorilla.Upgrader
+	mux       *http.ServeMux
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewServer creates a gateway over books; call Close to end client sessions before shutting down the HTTP server
+func NewServer(cfg Config, books *websocket.OrderBookStore) *Server {
+	if cfg.Depth <= 0 {
+		cfg.Depth = DefaultDepth
+	}
+	if cfg.MinInterval <= 0 {
+		cfg.MinInterval = DefaultMinInterval
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	cfg.Interval = max(cfg.Interval, cfg.MinInterval)
+
+	s := &Server{
+		cfg:       cfg,
+		books:     books,
+		products:  make(map[string]bool, len(cfg.Products)),
+		customers: make(map[string]Customer, len(cfg.Customers)),
+		mux:       http.NewServeMux(),
+	}
+	s.upgrader = gorilla.Upgrader{CheckOrigin: s.checkOrigin}
+	for _, product := range cfg.Products {
+		s.products[product] = true
+	}
+	for _, c := range cfg.Customers {
+		s.customers[c.ApiKey] = c
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	s.mux.HandleFunc("GET /ws", s.serveWebSocket)
+	s.mux.HandleFunc("GET /sse", s.serveEvents)
+	return s
+}
+
+// checkOrigin allows requests without an Origin header, from the gateway's own host or from Config.AllowedOrigins
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	origin = strings.TrimSuffix(origin, "/")
+	for _, allowed := range s.cfg.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP routes /ws and /sse requests
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close ends every client session; streams would otherwise keep http.Server.Shutdown waiting
+func (s *Server) Close() {
+	s.cancel()
+}
+
+// ============================================================================
+// Messages
+// ============================================================================
+
+// Level is a price level as sent to clients
+type Level struct {
+	Price string `json:"price"`
+	Size  string `json:"size"`
+}
+
+// BookUpdate is the fee-adjusted top of book and depth for one product
+type BookUpdate struct {
+	Type      string    `json:"type"` // "book"
+	ProductId string    `json:"product_id"`
+	Sequence  uint64    `json:"sequence"`
+	Time      time.Time `json:"time"`
+	BestBid   Level     `json:"best_bid"`
+	BestAsk   Level     `json:"best_ask"`
+	Bids      []Level   `json:"bids"`
+	Asks      []Level   `json:"asks"`
+	Raw       *RawBook  `json:"raw,omitempty"` // Only with Config.ExposeRaw
+}
+
+// RawBook is the unadjusted Prime book
+type RawBook struct {
+	Bids []Level `json:"bids"`
+	Asks []Level `json:"asks"`
+}
+
+// Request is a WebSocket client's subscribe or unsubscribe message
+type Request struct {
+	Type       string   `json:"type"` // "subscribe" or "unsubscribe"
+	ProductIds []string `json:"product_ids"`
+	Interval   string   `json:"interval,omitempty"` // Update interval, e.g. "250ms"; kept when empty
+}
+
+// Subscriptions confirms a client's current products, update interval and markup
+type Subscriptions struct {
+	Type       string   `json:"type"` // "subscriptions"
+	ProductIds []string `json:"product_ids"`
+	Interval   string   `json:"interval"`
+	Markup     string   `json:"markup"`
+}
+
+// errorMessage reports a rejected request
+type errorMessage struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// ============================================================================
+// Subscribers
+// ============================================================================
+
+// bookVersion identifies the book state last sent for a product
+type bookVersion struct {
+	sequence   uint64
+	updateTime time.Time
+}
+
+// subscriber is one client's products, markup and update interval
+type subscriber struct {
+	adjuster *common.PriceAdjuster
+
+	mu       sync.Mutex
+	interval time.Duration
+	sent     map[string]bookVersion // Subscribed products and the version last sent; zero before the first send
+}
+
+// newSubscriber identifies the client by API key (Authorization: Bearer, or ?api_key= with Config.AllowQueryKey) and picks its markup
+func (s *Server) newSubscriber(r *http.Request) (*subscriber, error) {
+	markup := s.cfg.Markup
+
+	key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if queryKey := r.URL.Query().Get("api_key"); key == "" && queryKey != "" {
+		if !s.cfg.AllowQueryKey {
+			return nil, ErrQueryApiKey
+		}
+		key = queryKey
+	}
+	if key != "" {
+		customer, ok := s.customers[key]
+		if !ok {
+			return nil, ErrUnknownApiKey
+		}
+		markup = customer.Markup
+	}
+
+	return &subscriber{
+		adjuster: common.NewPriceAdjuster(common.NewFeeStrategy(markup)),
+		interval: s.cfg.Interval,
+		sent:     make(map[string]bookVersion),
+	}, nil
+}
+
+// apply subscribes or unsubscribes products and changes the update interval
+func (s *Server) apply(sub *subscriber, req Request) error {
+	if req.Type != "subscribe" && req.Type != "unsubscribe" {
+		return fmt.Errorf("unsupported message type: %q", req.Type)
+	}
+
+	var interval time.Duration
+	if req.Interval != "" {
+		d, err := time.ParseDuration(req.Interval)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid interval: %q", req.Interval)
+		}
+		interval = max(d, s.cfg.MinInterval)
+	}
+
+	products := make([]string, 0, len(req.ProductIds))
+	for _, product := range req.ProductIds {
+		product = strings.ToUpper(strings.TrimSpace(product))
+		if product == "" {
+			continue
+		}
+		if req.Type == "subscribe" && !s.products[product] {
+			return fmt.Errorf("product not available: %s", product)
+		}
+		products = append(products, product)
+	}
+
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	for _, product := range products {
+		if req.Type == "subscribe" {
+			if _, ok := sub.sent[product]; !ok {
+				sub.sent[product] = bookVersion{}
+			}
+		} else {
+			delete(sub.sent, product)
+		}
+	}
+	if interval > 0 {
+		sub.interval = interval
+	}
+	return nil
+}
+
+// subscriptions describes the subscriber's current state
+func (sub *subscriber) subscriptions() Subscriptions {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	products := make([]string, 0, len(sub.sent))
+	for product := range sub.sent {
+		products = append(products, product)
+	}
+	slices.Sort(products)
+
+	return Subscriptions{
+		Type:       "subscriptions",
+		ProductIds: products,
+		Interval:   sub.interval.String(),
+		Markup:     sub.adjuster.FeeStrategy.Percent.String(),
+	}
+}
+
+// currentInterval returns how often the subscriber wants updates
+func (sub *subscriber) currentInterval() time.Duration {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	return sub.interval
+}
+
+// pending returns updates for subscribed books that changed since they were last sent
+// Updates between two calls are coalesced, which is what throttles fast books to the client's interval
+func (s *Server) pending(sub *subscriber) []BookUpdate {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	products := make([]string, 0, len(sub.sent))
+	for product := range sub.sent {
+		products = append(products, product)
+	}
+	slices.Sort(products)
+
+	var updates []BookUpdate
+	for _, product := range products {
+		book, ok := s.books.Get(product)
+		if !ok {
+			continue
+		}
+		snapshot := book.Snapshot()
+		if len(snapshot.Bids) == 0 || len(snapshot.Asks) == 0 {
+			continue
+		}
+
+		version := bookVersion{sequence: snapshot.Sequence, updateTime: snapshot.UpdateTime}
+		if sub.sent[product] == version {
+			continue
+		}
+		sub.sent[product] = version
+		updates = append(updates, s.bookUpdate(snapshot, sub.adjuster))
+	}
+	return updates
+}
+
+// bookUpdate adjusts a snapshot with the subscriber's markup: bids down, asks up
+func (s *Server) bookUpdate(snapshot common.OrderBookSnapshot, adjuster *common.PriceAdjuster) BookUpdate {
+	bids := snapshot.Bids[:min(len(snapshot.Bids), s.cfg.Depth)]
+	asks := snapshot.Asks[:min(len(snapshot.Asks), s.cfg.Depth)]
+	one := decimal.NewFromInt(1)
+
+	update := BookUpdate{
+		Type:      "book",
+		ProductId: snapshot.Product,
+		Sequence:  snapshot.Sequence,
+		Time:      snapshot.UpdateTime,
+		Bids:      make([]Level, len(bids)),
+		Asks:      make([]Level, len(asks)),
+	}
+	for i, bid := range bids {
+		update.Bids[i] = Level{Price: adjuster.AdjustBidPrice(bid.Price, one).String(), Size: bid.Size.String()}
+	}
+	for i, ask := range asks {
+		update.Asks[i] = Level{Price: adjuster.AdjustAskPrice(ask.Price, one).String(), Size: ask.Size.String()}
+	}
+	update.BestBid, update.BestAsk = update.Bids[0], update.Asks[0]
+
+	if s.cfg.ExposeRaw {
+		update.Raw = &RawBook{Bids: rawLevels(bids), Asks: rawLevels(asks)}
+	}
+	return update
+}
+
+// rawLevels converts book levels without adjusting them
+func rawLevels(levels []common.PriceLevel) []Level {
+	result := make([]Level, len(levels))
+	for i, level := range levels {
+		result[i] = Level{Price: level.Price.String(), Size: level.Size.String()}
+	}
+	return result
+}
+
+// ============================================================================
+// WebSocket
+// ============================================================================
+
+// serveWebSocket runs a WebSocket session: subscribe/unsubscribe requests in, book updates out
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	if !s.checkOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	sub, err := s.newSubscriber(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		zap.L().Warn("Gateway websocket upgrade failed", zap.Error(err))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+
+	session := &wsSession{conn: conn, cancel: cancel}
+	go session.publish(ctx, s, sub)
+
+	for {
+		var req Request
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		if err := s.apply(sub, req); err != nil {
+			session.write(errorMessage{Type: "error", Message: err.Error()})
+			continue
+		}
+		session.write(sub.subscriptions())
+	}
+}
+
+// wsSession serializes writes from the request reader and the publisher
+type wsSession struct {
+	conn   *gorilla.Conn
+	cancel context.CancelFunc
+
+	writeMu sync.Mutex
+}
+
+// publish sends changed books every interval until the session or gateway ends
+func (ws *wsSession) publish(ctx context.Context, s *Server, sub *subscriber) {
+	// Closing the connection also ends the read loop in serveWebSocket
+	defer ws.conn.Close()
+
+	interval := sub.currentInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			ws.writeMu.Lock()
+			ws.conn.WriteControl(gorilla.CloseMessage, gorilla.FormatCloseMessage(gorilla.CloseGoingAway, ""), time.Now().Add(writeTimeout))
+			ws.writeMu.Unlock()
+			return
+		case <-ticker.C:
+			for _, update := range s.pending(sub) {
+				ws.write(update)
+			}
+			if next := sub.currentInterval(); next != interval {
+				interval = next
+				ticker.Reset(interval)
+			}
+		}
+	}
+}
+
+// write sends a JSON message; a failed write ends the session
+func (ws *wsSession) write(v interface{}) {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+
+	ws.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := ws.conn.WriteJSON(v); err != nil {
+		ws.cancel()
+	}
+}
+
+// ============================================================================
+// Server-Sent Events
+// ============================================================================
+
+// serveEvents streams book updates for ?product_ids=BTC-USD,ETH-USD as Server-Sent Events
+// An optional ?interval=500ms sets the update rate
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	if !s.checkOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	sub, err := s.newSubscriber(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	req := Request{
+		Type:       "subscribe",
+		ProductIds: strings.Split(query.Get("product_ids"), ","),
+		Interval:   query.Get("interval"),
+	}
+	if err := s.apply(sub, req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	subscriptions := sub.subscriptions()
+	if len(subscriptions.ProductIds) == 0 {
+		http.Error(w, "product_ids is required", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	if err := writeEvent(w, "subscriptions", subscriptions); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(sub.currentInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			for _, update := range s.pending(sub) {
+				if err := writeEvent(w, "book", update); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes one named Server-Sent Event with a JSON payload
+func writeEvent(w http.ResponseWriter, event string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
@@ -0,0 +1,366 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gateway
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
+	gorilla "github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+)
+
+// testStore returns a store with a one-level BTC-USD book at 100/101
+func testStore() *websocket.OrderBookStore {
+	store := websocket.NewOrderBookStore()
+	setBook(store, "100", "101", 1)
+	return store
+}
+
+func setBook(store *websocket.OrderBookStore, bid, ask string, sequence uint64) {
+	store.GetOrCreate("BTC-USD").Update(
+		[]common.PriceLevel{{Price: decimal.RequireFromString(bid), Size: decimal.NewFromInt(2)}},
+		[]common.PriceLevel{{Price: decimal.RequireFromString(ask), Size: decimal.NewFromInt(3)}},
+		sequence)
+}
+
+func testConfig() Config {
+	return Config{
+		Products:    []string{"BTC-USD"},
+		Markup:      decimal.RequireFromString("0.01"),
+		Customers:   []Customer{{Id: "acme", ApiKey: "acme-key", Markup: decimal.RequireFromString("0.002")}},
+		Interval:    20 * time.Millisecond,
+		MinInterval: 10 * time.Millisecond,
+	}
+}
+
+// dial opens a gateway WebSocket, optionally with an API key
+func dial(t *testing.T, server *httptest.Server, apiKey string) (*gorilla.Conn, *http.Response, error) {
+	t.Helper()
+	header := http.Header{}
+	if apiKey != "" {
+		header.Set("Authorization", "Bearer "+apiKey)
+	}
+	return gorilla.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", header)
+}
+
+// readType reads messages until one of the given type arrives
+func readType(t *testing.T, conn *gorilla.Conn, msgType string, v interface{}) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("waiting for %s: %v", msgType, err)
+		}
+		var envelope struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal(data, &envelope)
+		if envelope.Type == msgType {
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatalf("decoding %s: %v", msgType, err)
+			}
+			return
+		}
+	}
+}
+
+func TestServer_WebSocketAppliesCustomerMarkup(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiKey     string
+		exposeRaw  bool
+		wantMarkup string
+		wantBid    string
+		wantAsk    string
+	}{
+		{name: "default markup without a key", wantMarkup: "0.01", wantBid: "99", wantAsk: "102.01"},
+		{name: "customer markup", apiKey: "acme-key", wantMarkup: "0.002", wantBid: "99.8", wantAsk: "101.202"},
+		{name: "raw prices when enabled", apiKey: "acme-key", exposeRaw: true, wantMarkup: "0.002", wantBid: "99.8", wantAsk: "101.202"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.ExposeRaw = tt.exposeRaw
+			gateway := NewServer(cfg, testStore())
+			defer gateway.Close()
+			server := httptest.NewServer(gateway)
+			defer server.Close()
+
+			conn, _, err := dial(t, server, tt.apiKey)
+			if err != nil {
+				t.Fatalf("Dial() error = %v", err)
+			}
+			defer conn.Close()
+
+			conn.WriteJSON(Request{Type: "subscribe", ProductIds: []string{"btc-usd"}})
+
+			var subs Subscriptions
+			readType(t, conn, "subscriptions", &subs)
+			if subs.Markup != tt.wantMarkup || strings.Join(subs.ProductIds, ",") != "BTC-USD" {
+				t.Errorf("subscriptions = %+v, want BTC-USD with markup %s", subs, tt.wantMarkup)
+			}
+
+			var update BookUpdate
+			readType(t, conn, "book", &update)
+			if update.BestBid.Price != tt.wantBid || update.BestAsk.Price != tt.wantAsk {
+				t.Errorf("best bid/ask = %s/%s, want %s/%s", update.BestBid.Price, update.BestAsk.Price, tt.wantBid, tt.wantAsk)
+			}
+			if update.BestBid.Size != "2" || update.Sequence != 1 {
+				t.Errorf("update = %+v, want size 2 at sequence 1", update)
+			}
+
+			if tt.exposeRaw {
+				if update.Raw == nil || update.Raw.Bids[0].Price != "100" || update.Raw.Asks[0].Price != "101" {
+					t.Errorf("Raw = %+v, want 100/101", update.Raw)
+				}
+			} else if update.Raw != nil {
+				t.Errorf("Raw = %+v, want raw prices hidden", update.Raw)
+			}
+		})
+	}
+}
+
+func TestServer_RejectsUnknownKeysAndProducts(t *testing.T) {
+	gateway := NewServer(testConfig(), testStore())
+	defer gateway.Close()
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	if _, resp, err := dial(t, server, "stolen-key"); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Dial() with an unknown key = %v, want 401", err)
+	}
+
+	conn, _, err := dial(t, server, "")
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	conn.WriteJSON(Request{Type: "subscribe", ProductIds: []string{"DOGE-USD"}})
+	var msg errorMessage
+	readType(t, conn, "error", &msg)
+	if !strings.Contains(msg.Message, "DOGE-USD") {
+		t.Errorf("error = %q, want it to name DOGE-USD", msg.Message)
+	}
+
+	resp, err := http.Get(server.URL + "/sse?product_ids=BTC-USD&api_key=stolen-key")
+	if err != nil {
+		t.Fatalf("GET /sse error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /sse with an unknown key = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestServer_QueryApiKeyNeedsOptIn(t *testing.T) {
+	tests := []struct {
+		name          string
+		allowQueryKey bool
+		wantStatus    int
+	}{
+		{name: "rejected by default", wantStatus: http.StatusUnauthorized},
+		{name: "accepted when allowed", allowQueryKey: true, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.AllowQueryKey = tt.allowQueryKey
+			gateway := NewServer(cfg, testStore())
+			defer gateway.Close()
+
+			sub, err := gateway.newSubscriber(httptest.NewRequest(http.MethodGet, "/sse?api_key=acme-key", nil))
+			if tt.wantStatus == http.StatusOK {
+				if err != nil || !sub.adjuster.FeeStrategy.Percent.Equal(decimal.RequireFromString("0.002")) {
+					t.Errorf("newSubscriber() = %v, want the customer's markup", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrQueryApiKey) {
+				t.Errorf("newSubscriber() error = %v, want ErrQueryApiKey", err)
+			}
+
+			server := httptest.NewServer(gateway)
+			defer server.Close()
+			resp, err := http.Get(server.URL + "/sse?product_ids=BTC-USD&api_key=acme-key")
+			if err != nil {
+				t.Fatalf("GET /sse error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("GET /sse with a query key = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestServer_CheckOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  string
+		allowed []string
+		want    bool
+	}{
+		{name: "no origin header", want: true},
+		{name: "same origin", origin: "http://gateway.local:8780", want: true},
+		{name: "foreign origin", origin: "https://evil.example", want: false},
+		{name: "allow-listed origin", origin: "https://app.example.com", allowed: []string{"https://app.example.com/"}, want: true},
+		{name: "origin not on the list", origin: "https://evil.example", allowed: []string{"https://app.example.com"}, want: false},
+		{name: "wildcard", origin: "https://evil.example", allowed: []string{"*"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.AllowedOrigins = tt.allowed
+			gateway := NewServer(cfg, testStore())
+			defer gateway.Close()
+
+			r := httptest.NewRequest(http.MethodGet, "http://gateway.local:8780/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := gateway.checkOrigin(r); got != tt.want {
+				t.Errorf("checkOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+
+	// A foreign page cannot open the WebSocket
+	gateway := NewServer(testConfig(), testStore())
+	defer gateway.Close()
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+	_, resp, err := gorilla.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", http.Header{"Origin": {"https://evil.example"}})
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Dial() from a foreign origin = %v, want 403", err)
+	}
+}
+
+func TestServer_PendingCoalescesUpdates(t *testing.T) {
+	store := testStore()
+	gateway := NewServer(testConfig(), store)
+	defer gateway.Close()
+
+	sub, err := gateway.newSubscriber(httptest.NewRequest(http.MethodGet, "/ws", nil))
+	if err != nil {
+		t.Fatalf("newSubscriber() error = %v", err)
+	}
+	if err := gateway.apply(sub, Request{Type: "subscribe", ProductIds: []string{"BTC-USD"}, Interval: "1ms"}); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+
+	// Intervals below the minimum are raised to it
+	if got := sub.currentInterval(); got != 10*time.Millisecond {
+		t.Errorf("interval = %s, want the 10ms minimum", got)
+	}
+
+	if updates := gateway.pending(sub); len(updates) != 1 {
+		t.Fatalf("first pending() = %d updates, want 1", len(updates))
+	}
+	if updates := gateway.pending(sub); len(updates) != 0 {
+		t.Errorf("pending() without changes = %d updates, want 0", len(updates))
+	}
+
+	// Many book changes between two sends arrive as one update with the latest book
+	for seq := uint64(2); seq <= 20; seq++ {
+		setBook(store, "100", decimal.NewFromInt(int64(100+seq)).String(), seq)
+	}
+	updates := gateway.pending(sub)
+	if len(updates) != 1 || updates[0].Sequence != 20 || updates[0].Raw != nil {
+		t.Fatalf("pending() after 19 changes = %+v, want one update at sequence 20", updates)
+	}
+
+	if err := gateway.apply(sub, Request{Type: "unsubscribe", ProductIds: []string{"BTC-USD"}}); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+	setBook(store, "100", "130", 21)
+	if updates := gateway.pending(sub); len(updates) != 0 {
+		t.Errorf("pending() after unsubscribing = %d updates, want 0", len(updates))
+	}
+}
+
+func TestServer_ServerSentEvents(t *testing.T) {
+	gateway := NewServer(testConfig(), testStore())
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/sse?product_ids=BTC-USD&interval=10ms", nil)
+	req.Header.Set("Authorization", "Bearer acme-key")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /sse error = %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	var events []string
+	var update BookUpdate
+	scanner := bufio.NewScanner(resp.Body)
+	for update.Type == "" && scanner.Scan() {
+		line := scanner.Text()
+		if event, ok := strings.CutPrefix(line, "event: "); ok {
+			events = append(events, event)
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok && len(events) == 2 {
+			json.Unmarshal([]byte(data), &update)
+		}
+	}
+	if strings.Join(events, ",") != "subscriptions,book" {
+		t.Fatalf("events = %v, want subscriptions then book", events)
+	}
+	if update.BestAsk.Price != "101.202" {
+		t.Errorf("best ask = %s, want 101.202", update.BestAsk.Price)
+	}
+
+	// Close ends open streams so the HTTP server can shut down
+	gateway.Close()
+	done := make(chan struct{})
+	go func() {
+		for scanner.Scan() {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream still open after Close()")
+	}
+
+	badResp, err := http.Get(server.URL + "/sse")
+	if err != nil {
+		t.Fatalf("GET /sse error = %v", err)
+	}
+	badResp.Body.Close()
+	if badResp.StatusCode != http.StatusBadRequest {
+		t.Errorf("GET /sse without products = %d, want 400", badResp.StatusCode)
+	}
+}
@@ -0,0 +1,272 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mockws
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+//go:embed default_scenario.json
+var defaultScenario []byte
+
+// Scenario drives the emulator's simulated markets and order lifecycles
+type Scenario struct {
+	Seed              int64             `json:"seed"`
+	TickInterval      Duration          `json:"tick_interval"`
+	HeartbeatInterval Duration          `json:"heartbeat_interval"`
+	Products          []ProductScenario `json:"products"`
+	Orders            []OrderScenario   `json:"orders"`
+}
+
+// ProductScenario describes a simulated order book
+type ProductScenario struct {
+	ProductId     string          `json:"product_id"`
+	Mid           decimal.Decimal `json:"mid"`
+	SpreadBps     decimal.Decimal `json:"spread_bps"`
+	TickSize      decimal.Decimal `json:"tick_size"`
+	Depth         int             `json:"depth"`
+	LevelSize     decimal.Decimal `json:"level_size"`     // Average size per level; actual sizes are jittered
+	VolatilityBps decimal.Decimal `json:"volatility_bps"` // Standard deviation of the mid's move per tick
+}
+
+// OrderScenario describes an order lifecycle replayed to orders channel subscribers
+type OrderScenario struct {
+	OrderId        string          `json:"order_id"`        // Optional; generated when empty
+	ClientOrderId  string          `json:"client_order_id"` // Optional; generated when empty
+	ProductId      string          `json:"product_id"`
+	Side           string          `json:"side"`
+	OrderType      string          `json:"order_type"`
+	BaseQuantity   decimal.Decimal `json:"base_quantity"`
+	CommissionRate decimal.Decimal `json:"commission_rate"`
+	At             Duration        `json:"at"` // Delay after subscribing before the order opens
+	Fills          []FillScenario  `json:"fills"`
+	CancelAfter    *Duration       `json:"cancel_after,omitempty"` // Cancel the unfilled remainder after the last fill
+}
+
+// FillScenario is a single fill within an order lifecycle
+type FillScenario struct {
+	After    Duration        `json:"after"`    // Delay after the previous step
+	Fraction decimal.Decimal `json:"fraction"` // Portion of the order's base quantity filled
+}
+
+// Duration is a time.Duration that unmarshals from strings such as "500ms"
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON parses a Go duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// MarshalJSON formats the duration as a Go duration string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// DefaultScenario returns the built-in demo scenario
+func DefaultScenario() *Scenario {
+	scenario, err := ParseScenario(defaultScenario)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded scenario: %v", err))
+	}
+	return scenario
+}
+
+// LoadScenario reads and validates a scenario file
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+	return ParseScenario(data)
+}
+
+// ParseScenario decodes and validates a JSON scenario
+func ParseScenario(data []byte) (*Scenario, error) {
+	var scenario Scenario
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+	if err := scenario.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario: %w", err)
+	}
+	return &scenario, nil
+}
+
+// Validate checks the scenario is internally consistent
+func (s *Scenario) Validate() error {
+	if s.TickInterval.Duration <= 0 {
+		return fmt.Errorf("tick_interval must be positive")
+	}
+	if s.HeartbeatInterval.Duration <= 0 {
+		return fmt.Errorf("heartbeat_interval must be positive")
+	}
+
+	products := make(map[string]bool)
+	for _, p := range s.Products {
+		if p.ProductId == "" {
+			return fmt.Errorf("product_id is required")
+		}
+		if !p.Mid.IsPositive() || !p.TickSize.IsPositive() || !p.LevelSize.IsPositive() {
+			return fmt.Errorf("%s: mid, tick_size and level_size must be positive", p.ProductId)
+		}
+		if p.Depth <= 0 {
+			return fmt.Errorf("%s: depth must be positive", p.ProductId)
+		}
+		products[p.ProductId] = true
+	}
+
+	for i, o := range s.Orders {
+		if !products[o.ProductId] {
+			return fmt.Errorf("order %d: unknown product %s", i, o.ProductId)
+		}
+		if o.Side != "BUY" && o.Side != "SELL" {
+			return fmt.Errorf("order %d: side must be BUY or SELL", i)
+		}
+		if !o.BaseQuantity.IsPositive() {
+			return fmt.Errorf("order %d: base_quantity must be positive", i)
+		}
+		total := decimal.Zero
+		for _, f := range o.Fills {
+			total = total.Add(f.Fraction)
+		}
+		if total.GreaterThan(decimal.NewFromInt(1)) {
+			return fmt.Errorf("order %d: fill fractions exceed 1", i)
+		}
+	}
+
+	return nil
+}
+
+// product returns the scenario for a product Id
+func (s *Scenario) product(productId string) (ProductScenario, bool) {
+	for _, p := range s.Products {
+		if p.ProductId == productId {
+			return p, true
+		}
+	}
+	return ProductScenario{}, false
+}
+
+// ============================================================================
+// Simulated Order Books
+// ============================================================================
+
+// level is a single simulated price level
+type level struct {
+	price decimal.Decimal
+	size  decimal.Decimal
+}
+
+// market evolves one simulated order book with a random walk of the mid price
+type market struct {
+	scenario ProductScenario
+	mid      decimal.Decimal
+	bids     []level // Descending
+	asks     []level // Ascending
+}
+
+func newMarket(scenario ProductScenario, rng *rand.Rand) *market {
+	m := &market{scenario: scenario, mid: scenario.Mid}
+	m.bids, m.asks = m.ladder(rng)
+	return m
+}
+
+// tick moves the mid and rebuilds the ladder, returning the level changes
+// Removed levels are reported with zero size, as Prime does
+func (m *market) tick(rng *rand.Rand) (bidChanges, askChanges []level) {
+	move := decimal.NewFromFloat(rng.NormFloat64()).Mul(m.scenario.VolatilityBps).Div(decimal.NewFromInt(10000))
+	m.mid = m.mid.Mul(decimal.NewFromInt(1).Add(move))
+
+	bids, asks := m.ladder(rng)
+	bidChanges = diffLevels(m.bids, bids)
+	askChanges = diffLevels(m.asks, asks)
+	m.bids, m.asks = bids, asks
+	return bidChanges, askChanges
+}
+
+// ladder builds depth levels around the current mid, snapped to the tick size
+func (m *market) ladder(rng *rand.Rand) ([]level, []level) {
+	halfSpread := m.mid.Mul(m.scenario.SpreadBps).Div(decimal.NewFromInt(20000))
+	tick := m.scenario.TickSize
+
+	bestBid := m.mid.Sub(halfSpread).Div(tick).Floor().Mul(tick)
+	bestAsk := m.mid.Add(halfSpread).Div(tick).Ceil().Mul(tick)
+	if !bestAsk.GreaterThan(bestBid) {
+		bestAsk = bestBid.Add(tick)
+	}
+
+	bids := make([]level, m.scenario.Depth)
+	asks := make([]level, m.scenario.Depth)
+	for i := 0; i < m.scenario.Depth; i++ {
+		offset := tick.Mul(decimal.NewFromInt(int64(i)))
+		bids[i] = level{price: bestBid.Sub(offset), size: m.jitteredSize(rng)}
+		asks[i] = level{price: bestAsk.Add(offset), size: m.jitteredSize(rng)}
+	}
+	return bids, asks
+}
+
+// jitteredSize returns between 0.5x and 1.5x the scenario's level size
+func (m *market) jitteredSize(rng *rand.Rand) decimal.Decimal {
+	return m.scenario.LevelSize.Mul(decimal.NewFromFloat(0.5 + rng.Float64())).Round(4)
+}
+
+// diffLevels returns levels that changed between two ladders, with zero size for removals
+func diffLevels(previous, current []level) []level {
+	prevSizes := make(map[string]decimal.Decimal, len(previous))
+	for _, l := range previous {
+		prevSizes[l.price.String()] = l.size
+	}
+
+	var changes []level
+	seen := make(map[string]bool, len(current))
+	for _, l := range current {
+		key := l.price.String()
+		seen[key] = true
+		if size, ok := prevSizes[key]; !ok || !size.Equal(l.size) {
+			changes = append(changes, l)
+		}
+	}
+	for _, l := range previous {
+		if !seen[l.price.String()] {
+			changes = append(changes, level{price: l.price, size: decimal.Zero})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].price.LessThan(changes[j].price)
+	})
+	return changes
+}
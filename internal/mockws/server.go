@@ -0,0 +1,565 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package mockws emulates the Prime WebSocket feed for demos and integration tests.
+// It serves the l2_data, orders and heartbeats channels, verifies subscription
+// signatures the way Prime does, and generates book updates and order
+// lifecycles from a Scenario.
+package mockws
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	ChannelL2Data     = "l2_data"
+	ChannelOrders     = "orders"
+	ChannelHeartbeats = "heartbeats"
+
+	writeTimeout = 5 * time.Second
+)
+
+// Credentials are the API credentials subscriptions must be signed with
+type Credentials struct {
+	AccessKey        string
+	Passphrase       string
+	SigningKey       string
+	ServiceAccountId string
+	PortfolioId      string
+}
+
+// Server is an http.Handler that upgrades connections and emulates the Prime feed
+type Server struct {
+	creds    Credentials
+	scenario *Scenario
+	upgrader websocket.Upgrader
+}
+
+// NewServer creates an emulator that accepts subscriptions signed with creds
+func NewServer(creds Credentials, scenario *Scenario) *Server {
+	return &Server{
+		creds:    creds,
+		scenario: scenario,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// ServeHTTP upgrades the request and runs a session until the client disconnects
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		zap.L().Warn("Mock websocket upgrade failed", zap.Error(err))
+		return
+	}
+
+	sess := newSession(s, conn)
+	sess.run()
+}
+
+// subscribeMessage is a subscribe or unsubscribe request from a client
+type subscribeMessage struct {
+	Type        string   `json:"type"`
+	Channel     string   `json:"channel"`
+	AccessKey   string   `json:"access_key"`
+	ApiKeyId    string   `json:"api_key_id"`
+	Timestamp   string   `json:"timestamp"`
+	Passphrase  string   `json:"passphrase"`
+	Signature   string   `json:"signature"`
+	ProductIds  []string `json:"product_ids"`
+	PortfolioId string   `json:"portfolio_id,omitempty"`
+}
+
+// channelMessage is the envelope for every message sent to clients
+type channelMessage struct {
+	Channel     string        `json:"channel"`
+	Type        string        `json:"type,omitempty"`
+	Timestamp   string        `json:"timestamp"`
+	SequenceNum int64         `json:"sequence_num"`
+	Events      []interface{} `json:"events"`
+}
+
+// errorMessage mirrors Prime's error payload
+type errorMessage struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// session is a single client connection and its subscriptions
+type session struct {
+	server *Server
+	conn   *websocket.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// writeMu serializes writes; sequences is stamped under it so numbers reach the client in order
+	writeMu   sync.Mutex
+	sequences map[string]int64
+
+	mu            sync.Mutex
+	rng           *rand.Rand
+	markets       map[string]*market
+	subscriptions map[string]map[string]bool // channel -> product Ids
+	startedOrders map[int]bool
+}
+
+func newSession(server *Server, conn *websocket.Conn) *session {
+	ctx, cancel := context.WithCancel(context.Background())
+	rng := rand.New(rand.NewSource(server.scenario.Seed))
+
+	markets := make(map[string]*market)
+	for _, p := range server.scenario.Products {
+		markets[p.ProductId] = newMarket(p, rng)
+	}
+
+	return &session{
+		server:        server,
+		conn:          conn,
+		ctx:           ctx,
+		cancel:        cancel,
+		rng:           rng,
+		markets:       markets,
+		subscriptions: make(map[string]map[string]bool),
+		sequences:     make(map[string]int64),
+		startedOrders: make(map[int]bool),
+	}
+}
+
+func (s *session) run() {
+	defer s.conn.Close()
+	defer s.cancel()
+
+	go s.tickMarkets()
+	go s.sendHeartbeats()
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg subscribeMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.sendError("malformed message")
+			continue
+		}
+
+		if err := s.handleSubscription(msg); err != nil {
+			zap.L().Warn("Mock websocket rejected subscription",
+				zap.String("channel", msg.Channel),
+				zap.Error(err))
+			s.sendError(err.Error())
+		}
+	}
+}
+
+// handleSubscription authenticates and applies a subscribe or unsubscribe request
+func (s *session) handleSubscription(msg subscribeMessage) error {
+	if msg.Type != "subscribe" && msg.Type != "unsubscribe" {
+		return fmt.Errorf("unsupported message type: %s", msg.Type)
+	}
+	if msg.Channel != ChannelL2Data && msg.Channel != ChannelOrders && msg.Channel != ChannelHeartbeats {
+		return fmt.Errorf("unknown channel: %s", msg.Channel)
+	}
+	if err := s.server.authenticate(msg); err != nil {
+		return err
+	}
+	if msg.Channel != ChannelHeartbeats {
+		for _, productId := range msg.ProductIds {
+			if _, ok := s.server.scenario.product(productId); !ok {
+				return fmt.Errorf("unknown product_id: %s", productId)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	products, ok := s.subscriptions[msg.Channel]
+	if !ok {
+		products = make(map[string]bool)
+		s.subscriptions[msg.Channel] = products
+	}
+	for _, productId := range msg.ProductIds {
+		if msg.Type == "subscribe" {
+			products[productId] = true
+		} else {
+			delete(products, productId)
+		}
+	}
+	current := sortedKeys(products)
+	s.mu.Unlock()
+
+	s.send(channelMessage{
+		Channel: "subscriptions",
+		Type:    "subscriptions",
+		Events: []interface{}{map[string]interface{}{
+			"subscriptions": map[string][]string{msg.Channel: current},
+		}},
+	})
+
+	if msg.Type == "subscribe" {
+		switch msg.Channel {
+		case ChannelL2Data:
+			s.sendSnapshots(msg.ProductIds)
+		case ChannelOrders:
+			s.send(channelMessage{
+				Channel: ChannelOrders,
+				Events:  []interface{}{map[string]interface{}{"type": "snapshot", "orders": []interface{}{}}},
+			})
+			s.startOrders(msg.ProductIds)
+		}
+	}
+
+	return nil
+}
+
+// authenticate verifies credentials and the subscription signature
+func (s *Server) authenticate(msg subscribeMessage) error {
+	if msg.AccessKey != s.creds.AccessKey || msg.ApiKeyId != s.creds.ServiceAccountId || msg.Passphrase != s.creds.Passphrase {
+		return fmt.Errorf("authentication failure: invalid credentials")
+	}
+	if _, err := strconv.ParseInt(msg.Timestamp, 10, 64); err != nil {
+		return fmt.Errorf("authentication failure: invalid timestamp")
+	}
+	if msg.Channel == ChannelOrders && msg.PortfolioId != s.creds.PortfolioId {
+		return fmt.Errorf("authentication failure: invalid portfolio_id")
+	}
+	if !hmac.Equal([]byte(msg.Signature), []byte(s.signature(msg))) {
+		return fmt.Errorf("authentication failure: invalid signature")
+	}
+	return nil
+}
+
+// signature computes the expected subscription signature
+// Format: channel + access_key + api_key_id + timestamp + [portfolio_id for orders] + joined product_ids
+func (s *Server) signature(msg subscribeMessage) string {
+	message := msg.Channel + msg.AccessKey + msg.ApiKeyId + msg.Timestamp
+	if msg.Channel == ChannelOrders {
+		message += msg.PortfolioId
+	}
+	message += strings.Join(msg.ProductIds, "")
+
+	h := hmac.New(sha256.New, []byte(s.creds.SigningKey))
+	h.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// ============================================================================
+// Channel Publishers
+// ============================================================================
+
+// tickMarkets advances every simulated book and publishes changes to l2_data subscribers
+func (s *session) tickMarkets() {
+	ticker := time.NewTicker(s.server.scenario.TickInterval.Duration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		var events []interface{}
+		for _, p := range s.server.scenario.Products {
+			bids, asks := s.markets[p.ProductId].tick(s.rng)
+			if s.subscriptions[ChannelL2Data][p.ProductId] && len(bids)+len(asks) > 0 {
+				events = append(events, l2Event("update", p.ProductId, bids, asks))
+			}
+		}
+		s.mu.Unlock()
+
+		if len(events) > 0 {
+			s.send(channelMessage{Channel: ChannelL2Data, Events: events})
+		}
+	}
+}
+
+// sendSnapshots publishes the full book for each product
+func (s *session) sendSnapshots(productIds []string) {
+	s.mu.Lock()
+	events := make([]interface{}, 0, len(productIds))
+	for _, productId := range productIds {
+		m := s.markets[productId]
+		events = append(events, l2Event("snapshot", productId, m.bids, m.asks))
+	}
+	s.mu.Unlock()
+
+	s.send(channelMessage{Channel: ChannelL2Data, Events: events})
+}
+
+// l2Event builds an l2_data event; Prime reports asks with side "offer"
+func l2Event(eventType, productId string, bids, asks []level) map[string]interface{} {
+	eventTime := time.Now().UTC().Format(time.RFC3339Nano)
+	updates := make([]map[string]string, 0, len(bids)+len(asks))
+	for _, l := range bids {
+		updates = append(updates, map[string]string{"side": "bid", "event_time": eventTime, "px": l.price.String(), "qty": l.size.String()})
+	}
+	for _, l := range asks {
+		updates = append(updates, map[string]string{"side": "offer", "event_time": eventTime, "px": l.price.String(), "qty": l.size.String()})
+	}
+	return map[string]interface{}{
+		"type":       eventType,
+		"product_id": productId,
+		"updates":    updates,
+	}
+}
+
+// sendHeartbeats publishes heartbeats while the heartbeats channel is subscribed
+func (s *session) sendHeartbeats() {
+	ticker := time.NewTicker(s.server.scenario.HeartbeatInterval.Duration)
+	defer ticker.Stop()
+
+	var counter int64
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		_, subscribed := s.subscriptions[ChannelHeartbeats]
+		s.mu.Unlock()
+		if !subscribed {
+			continue
+		}
+
+		counter++
+		s.send(channelMessage{
+			Channel: ChannelHeartbeats,
+			Events: []interface{}{map[string]string{
+				"current_time":      time.Now().UTC().Format(time.RFC3339Nano),
+				"heartbeat_counter": strconv.FormatInt(counter, 10),
+			}},
+		})
+	}
+}
+
+// startOrders launches the lifecycle of each scenario order for the given products
+func (s *session) startOrders(productIds []string) {
+	wanted := make(map[string]bool, len(productIds))
+	for _, productId := range productIds {
+		wanted[productId] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, o := range s.server.scenario.Orders {
+		if wanted[o.ProductId] && !s.startedOrders[i] {
+			s.startedOrders[i] = true
+			go s.runOrder(o)
+		}
+	}
+}
+
+// runOrder replays an order lifecycle, filling at the simulated touch price
+func (s *session) runOrder(o OrderScenario) {
+	orderId := o.OrderId
+	if orderId == "" {
+		orderId = uuid.New().String()
+	}
+	clientOrderId := o.ClientOrderId
+	if clientOrderId == "" {
+		clientOrderId = uuid.New().String()
+	}
+
+	progress := &orderProgress{
+		OrderId:       orderId,
+		ClientOrderId: clientOrderId,
+		ProductId:     o.ProductId,
+		Side:          o.Side,
+		OrderType:     o.OrderType,
+		quantity:      o.BaseQuantity,
+		commission:    o.CommissionRate,
+	}
+
+	if !s.sleep(o.At.Duration) {
+		return
+	}
+	s.sendOrder(progress.state("OPEN"))
+
+	for _, f := range o.Fills {
+		if !s.sleep(f.After.Duration) {
+			return
+		}
+
+		s.mu.Lock()
+		m := s.markets[o.ProductId]
+		price := m.asks[0].price
+		if o.Side == "SELL" {
+			price = m.bids[0].price
+		}
+		s.mu.Unlock()
+
+		progress.fill(f.Fraction.Mul(o.BaseQuantity), price)
+		status := "OPEN"
+		if progress.filled.GreaterThanOrEqual(o.BaseQuantity) {
+			status = "FILLED"
+		}
+		s.sendOrder(progress.state(status))
+	}
+
+	if o.CancelAfter != nil && progress.filled.LessThan(o.BaseQuantity) {
+		if !s.sleep(o.CancelAfter.Duration) {
+			return
+		}
+		s.sendOrder(progress.state("CANCELLED"))
+	}
+}
+
+// sendOrder publishes an order update if the product is still subscribed
+func (s *session) sendOrder(state map[string]string) {
+	s.mu.Lock()
+	subscribed := s.subscriptions[ChannelOrders][state["product_id"]]
+	s.mu.Unlock()
+	if !subscribed {
+		return
+	}
+
+	s.send(channelMessage{
+		Channel: ChannelOrders,
+		Events:  []interface{}{map[string]interface{}{"type": "update", "orders": []interface{}{state}}},
+	})
+}
+
+// orderProgress tracks cumulative fills for a scenario order
+type orderProgress struct {
+	OrderId       string
+	ClientOrderId string
+	ProductId     string
+	Side          string
+	OrderType     string
+	quantity      decimal.Decimal
+	commission    decimal.Decimal
+	filled        decimal.Decimal
+	filledValue   decimal.Decimal
+}
+
+func (p *orderProgress) fill(qty, price decimal.Decimal) {
+	p.filled = p.filled.Add(qty)
+	p.filledValue = p.filledValue.Add(qty.Mul(price))
+}
+
+// state renders the order as a Prime orders channel entry
+func (p *orderProgress) state(status string) map[string]string {
+	commission := p.filledValue.Mul(p.commission).Round(2)
+	avgPx, netAvgPx := decimal.Zero, decimal.Zero
+	if p.filled.IsPositive() {
+		avgPx = p.filledValue.DivRound(p.filled, 8)
+		net := p.filledValue.Add(commission)
+		if p.Side == "SELL" {
+			net = p.filledValue.Sub(commission)
+		}
+		netAvgPx = net.DivRound(p.filled, 8)
+	}
+
+	leaves := p.quantity.Sub(p.filled)
+	if status != "OPEN" {
+		leaves = decimal.Zero
+	}
+
+	return map[string]string{
+		"order_id":        p.OrderId,
+		"client_order_id": p.ClientOrderId,
+		"product_id":      p.ProductId,
+		"side":            p.Side,
+		"order_type":      p.OrderType,
+		"status":          status,
+		"cum_qty":         p.filled.String(),
+		"leaves_qty":      leaves.String(),
+		"avg_px":          avgPx.String(),
+		"net_avg_px":      netAvgPx.String(),
+		"filled_value":    p.filledValue.String(),
+		"fees":            commission.String(),
+		"commission":      commission.String(),
+		"venue_fee":       "0",
+		"ces_commission":  "0",
+	}
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// send stamps the per-channel sequence number and writes the message under one lock,
+// so concurrent senders can't deliver sequence numbers out of order
+func (s *session) send(msg channelMessage) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.sequences[msg.Channel]++
+	msg.SequenceNum = s.sequences[msg.Channel]
+	msg.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+	s.writeLocked(msg)
+}
+
+func (s *session) sendError(message string) {
+	s.write(errorMessage{Type: "error", Message: message})
+}
+
+func (s *session) write(v interface{}) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.writeLocked(v)
+}
+
+// writeLocked writes v; the caller holds writeMu
+func (s *session) writeLocked(v interface{}) {
+	s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := s.conn.WriteJSON(v); err != nil {
+		s.cancel()
+	}
+}
+
+// sleep waits for d, returning false if the session ended first
+func (s *session) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-s.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,382 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mockws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gorilla "github.com/gorilla/websocket"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
+)
+
+var testCreds = Credentials{
+	AccessKey:        "test-access-key",
+	Passphrase:       "test-passphrase",
+	SigningKey:       "test-signing-key",
+	ServiceAccountId: "test-service-account",
+	PortfolioId:      "test-portfolio",
+}
+
+const fastScenario = `{
+  "seed": 7,
+  "tick_interval": "10ms",
+  "heartbeat_interval": "10ms",
+  "products": [
+    {"product_id": "BTC-USD", "mid": "100000", "spread_bps": "1", "tick_size": "0.01", "depth": 5, "level_size": "0.5", "volatility_bps": "2"},
+    {"product_id": "ETH-USD", "mid": "3000", "spread_bps": "2", "tick_size": "0.01", "depth": 5, "level_size": "5", "volatility_bps": "3"}
+  ],
+  "orders": [
+    {"client_order_id": "client-filled", "product_id": "BTC-USD", "side": "BUY", "order_type": "MARKET",
+     "base_quantity": "0.01", "commission_rate": "0.001", "at": "10ms",
+     "fills": [{"after": "10ms", "fraction": "0.4"}, {"after": "10ms", "fraction": "0.6"}]},
+    {"client_order_id": "client-cancelled", "product_id": "ETH-USD", "side": "SELL", "order_type": "LIMIT",
+     "base_quantity": "1", "commission_rate": "0.001", "at": "10ms",
+     "fills": [{"after": "10ms", "fraction": "0.25"}], "cancel_after": "10ms"}
+  ]
+}`
+
+// recordingOrderHandler captures the order states delivered by the orders channel
+type recordingOrderHandler struct {
+	mu       sync.Mutex
+	statuses map[string][]string // client_order_id -> statuses in arrival order
+	orders   map[string]websocket.OrderUpdate
+}
+
+func newRecordingOrderHandler() *recordingOrderHandler {
+	return &recordingOrderHandler{
+		statuses: make(map[string][]string),
+		orders:   make(map[string]websocket.OrderUpdate),
+	}
+}
+
+func (h *recordingOrderHandler) HandleOrderUpdate(update *websocket.Message) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, event := range update.OrderEvents {
+		for _, order := range event.Orders {
+			h.statuses[order.ClientOrderId] = append(h.statuses[order.ClientOrderId], order.Status)
+			h.orders[order.ClientOrderId] = order
+		}
+	}
+	return nil
+}
+
+func (h *recordingOrderHandler) snapshot(clientOrderId string) ([]string, websocket.OrderUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.statuses[clientOrderId]...), h.orders[clientOrderId]
+}
+
+func newTestServer(t *testing.T) string {
+	t.Helper()
+
+	scenario, err := ParseScenario([]byte(fastScenario))
+	if err != nil {
+		t.Fatalf("ParseScenario() error = %v", err)
+	}
+
+	srv := httptest.NewServer(NewServer(testCreds, scenario))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func commonConfig(url string, products []string) websocket.CommonConfig {
+	return websocket.CommonConfig{
+		Url:              url,
+		AccessKey:        testCreds.AccessKey,
+		Passphrase:       testCreds.Passphrase,
+		SigningKey:       testCreds.SigningKey,
+		ServiceAccountId: testCreds.ServiceAccountId,
+		Products:         products,
+		ReconnectDelay:   50 * time.Millisecond,
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestServer_MarketData(t *testing.T) {
+	url := newTestServer(t)
+
+	store := websocket.NewOrderBookStore()
+	client := websocket.NewMarketDataClient(websocket.MarketDataConfig{
+		CommonConfig: commonConfig(url, []string{"BTC-USD", "ETH-USD"}),
+		MaxLevels:    5,
+	}, store)
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer client.Stop()
+
+	for _, product := range []string{"BTC-USD", "ETH-USD"} {
+		waitFor(t, product+" book", func() bool {
+			book, ok := store.Get(product)
+			if !ok {
+				return false
+			}
+			bids, asks := book.GetTopLevels(5)
+			return len(bids) == 5 && len(asks) == 5
+		})
+
+		book, _ := store.Get(product)
+		bid, _ := book.GetBestBid()
+		ask, _ := book.GetBestAsk()
+		if !bid.Price.LessThan(ask.Price) {
+			t.Errorf("%s: best bid %s should be below best ask %s", product, bid.Price, ask.Price)
+		}
+	}
+
+	// Updates must keep flowing after the snapshot
+	book, _ := store.Get("BTC-USD")
+	first := book.Snapshot().UpdateTime
+	waitFor(t, "book update", func() bool {
+		return book.Snapshot().UpdateTime.After(first)
+	})
+}
+
+func TestServer_OrderLifecycles(t *testing.T) {
+	url := newTestServer(t)
+
+	handler := newRecordingOrderHandler()
+	client := websocket.NewOrdersClient(websocket.OrdersConfig{
+		CommonConfig: commonConfig(url, []string{"BTC-USD", "ETH-USD"}),
+		PortfolioId:  testCreds.PortfolioId,
+	}, handler)
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer client.Stop()
+
+	tests := []struct {
+		clientOrderId string
+		wantStatuses  []string
+		wantCumQty    string
+	}{
+		{"client-filled", []string{"OPEN", "OPEN", "FILLED"}, "0.01"},
+		{"client-cancelled", []string{"OPEN", "OPEN", "CANCELLED"}, "0.25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.clientOrderId, func(t *testing.T) {
+			waitFor(t, tt.clientOrderId, func() bool {
+				statuses, _ := handler.snapshot(tt.clientOrderId)
+				return len(statuses) >= len(tt.wantStatuses)
+			})
+
+			statuses, order := handler.snapshot(tt.clientOrderId)
+			if strings.Join(statuses, ",") != strings.Join(tt.wantStatuses, ",") {
+				t.Errorf("statuses = %v, want %v", statuses, tt.wantStatuses)
+			}
+			if order.CumQty != tt.wantCumQty {
+				t.Errorf("cum_qty = %v, want %s", order.CumQty, tt.wantCumQty)
+			}
+			if order.Commission == "0" {
+				t.Error("commission should be charged on filled quantity")
+			}
+			if order.LeavesQty != "0" {
+				t.Errorf("leaves_qty = %v, want 0 for a terminal order", order.LeavesQty)
+			}
+		})
+	}
+}
+
+func TestSession_SendOrder(t *testing.T) {
+	const senders, perSender = 8, 200
+
+	// Stand-ins for tickMarkets, sendSnapshots and runOrder sending on one channel at once
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&gorilla.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		s := &session{conn: conn, ctx: ctx, cancel: cancel, sequences: make(map[string]int64)}
+
+		var wg sync.WaitGroup
+		for i := 0; i < senders; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < perSender; j++ {
+					s.send(channelMessage{Channel: ChannelL2Data, Type: "update"})
+				}
+			}()
+		}
+		wg.Wait()
+	}))
+	defer srv.Close()
+
+	conn, _, err := gorilla.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for want := int64(1); want <= senders*perSender; want++ {
+		var msg channelMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("ReadJSON() error = %v", err)
+		}
+		if msg.SequenceNum != want {
+			t.Fatalf("sequence_num = %d, want %d", msg.SequenceNum, want)
+		}
+	}
+}
+
+func TestServer_RejectsInvalidSubscriptions(t *testing.T) {
+	url := newTestServer(t)
+
+	server := NewServer(testCreds, DefaultScenario())
+	valid := func(channel string, products ...string) subscribeMessage {
+		msg := subscribeMessage{
+			Type:       "subscribe",
+			Channel:    channel,
+			AccessKey:  testCreds.AccessKey,
+			ApiKeyId:   testCreds.ServiceAccountId,
+			Timestamp:  "1700000000",
+			Passphrase: testCreds.Passphrase,
+			ProductIds: products,
+		}
+		if channel == ChannelOrders {
+			msg.PortfolioId = testCreds.PortfolioId
+		}
+		msg.Signature = server.signature(msg)
+		return msg
+	}
+
+	tests := []struct {
+		name    string
+		msg     func() subscribeMessage
+		wantErr string
+	}{
+		{
+			name: "bad signature",
+			msg: func() subscribeMessage {
+				msg := valid(ChannelL2Data, "BTC-USD")
+				msg.Signature = "bm90LWEtc2lnbmF0dXJl"
+				return msg
+			},
+			wantErr: "invalid signature",
+		},
+		{
+			name: "signature over different products",
+			msg: func() subscribeMessage {
+				msg := valid(ChannelL2Data, "BTC-USD")
+				msg.ProductIds = []string{"ETH-USD"}
+				return msg
+			},
+			wantErr: "invalid signature",
+		},
+		{
+			name: "wrong passphrase",
+			msg: func() subscribeMessage {
+				msg := valid(ChannelL2Data, "BTC-USD")
+				msg.Passphrase = "wrong"
+				return msg
+			},
+			wantErr: "invalid credentials",
+		},
+		{
+			name: "wrong portfolio",
+			msg: func() subscribeMessage {
+				msg := valid(ChannelOrders, "BTC-USD")
+				msg.PortfolioId = "other-portfolio"
+				msg.Signature = server.signature(msg)
+				return msg
+			},
+			wantErr: "invalid portfolio_id",
+		},
+		{
+			name:    "unknown product",
+			msg:     func() subscribeMessage { return valid(ChannelL2Data, "DOGE-USD") },
+			wantErr: "unknown product_id: DOGE-USD",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, _, err := gorilla.DefaultDialer.Dial(url, nil)
+			if err != nil {
+				t.Fatalf("Dial() error = %v", err)
+			}
+			defer conn.Close()
+
+			if err := conn.WriteJSON(tt.msg()); err != nil {
+				t.Fatalf("WriteJSON() error = %v", err)
+			}
+
+			var reply errorMessage
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			if err := conn.ReadJSON(&reply); err != nil {
+				t.Fatalf("ReadJSON() error = %v", err)
+			}
+			if reply.Type != "error" || !strings.Contains(reply.Message, tt.wantErr) {
+				t.Errorf("reply = %+v, want error containing %q", reply, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseScenario_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"default scenario", string(defaultScenario), ""},
+		{"missing tick interval", `{"heartbeat_interval": "1s"}`, "tick_interval"},
+		{"bad duration", `{"tick_interval": "soon"}`, "invalid duration"},
+		{
+			name:    "order for unknown product",
+			data:    `{"tick_interval": "1s", "heartbeat_interval": "1s", "orders": [{"product_id": "BTC-USD", "side": "BUY", "base_quantity": "1"}]}`,
+			wantErr: "unknown product",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseScenario([]byte(tt.data))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ParseScenario() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseScenario() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coinbase-samples/core-go"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/database"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// DefaultPreviewTtl is used when no preview TTL is configured
+const DefaultPreviewTtl = 30 * time.Second
+
+// Errors returned when executing a persisted preview
+var (
+	ErrPreviewNotFound        = errors.New("preview not found")
+	ErrPreviewExpired         = errors.New("preview has expired")
+	ErrPreviewAlreadyExecuted = errors.New("preview has already been executed")
+	ErrPreviewOutcomeUnknown  = errors.New("previewed order outcome is unknown")
+	ErrPreviewTermsChanged    = errors.New("locked preview amounts no longer match")
+)
+
+// PreviewStore persists previews so execution can honour the fee terms shown to the user
+type PreviewStore interface {
+	InsertPreview(preview *database.PreviewRecord) error
+	GetPreview(previewId string) (*database.PreviewRecord, error)
+	ClaimPreview(previewId string) (bool, error)
+	ReleasePreview(previewId string) error
+	MarkPreviewUnknown(previewId, clientOrderId string) error
+	CompletePreview(previewId, orderId string) error
+	PrunePreviews(cutoff time.Time) (int64, error)
+}
+
+// LockedOrder is the result of executing a persisted preview
+type LockedOrder struct {
+	PreviewId   string
+	Request     common.OrderRequest // Request reconstructed from the preview
+	FeeStrategy *common.FeeStrategy // Fee terms locked at preview time
+	Response    *common.OrderResponse
+}
+
+// savePreview stores the request and fee terms behind a preview and stamps its Id and expiry
+func (s *OrderService) savePreview(req common.OrderRequest, prepared *common.PreparedOrder, customFee decimal.Decimal, response *common.OrderPreviewResponse) error {
+	ttl := s.previewTtl
+	if ttl <= 0 {
+		ttl = DefaultPreviewTtl
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	// Quote orders lock the upfront hold; base orders lock the estimated add-on fee
+	markupAmount := customFee
+	primeOrderQuoteAmount := decimal.Zero
+	if prepared.Metadata != nil {
+		markupAmount = prepared.Metadata.MarkupAmount
+		primeOrderQuoteAmount = prepared.Metadata.PrimeOrderQuoteAmount
+	}
+
+	record := &database.PreviewRecord{
+		PreviewId:             uuid.New().String(),
+		ProductId:             req.Product,
+		Side:                  prepared.NormalizedReq.Side,
+		OrderType:             prepared.NormalizedReq.Type,
+		Unit:                  req.Unit,
+		BaseQty:               req.BaseQty.String(),
+		QuoteValue:            req.QuoteValue.String(),
+		LimitPrice:            req.Price.String(),
+		FeePercent:            s.priceAdjuster.FeeStrategy.Percent.String(),
+		MarkupAmount:          markupAmount.String(),
+		PrimeOrderQuoteAmount: primeOrderQuoteAmount.String(),
+		Status:                database.PreviewStatusPending,
+		CreatedAt:             now,
+		ExpiresAt:             expiresAt,
+	}
+
+	if err := s.previewStore.InsertPreview(record); err != nil {
+		return err
+	}
+
+	// Each new preview clears out long-expired ones so the table stays bounded
+	if s.previewRetention > 0 {
+		if removed, err := s.previewStore.PrunePreviews(now.Add(-s.previewRetention)); err != nil {
+			zap.L().Warn("Failed to prune previews", zap.Error(err))
+		} else if removed > 0 {
+			zap.L().Debug("Pruned expired previews", zap.Int64("removed", removed))
+		}
+	}
+
+	response.PreviewId = record.PreviewId
+	response.ExpiresAt = &expiresAt
+
+	zap.L().Info("Preview persisted",
+		zap.String("preview_id", record.PreviewId),
+		zap.String("fee_percent", record.FeePercent),
+		zap.String("markup_amount", record.MarkupAmount),
+		zap.Time("expires_at", expiresAt))
+
+	return nil
+}
+
+// PlacePreviewedOrder places exactly the order captured by a preview using its locked fee terms
+// Expired or already executed previews are rejected. The preview is only released for reuse when
+// the order definitely was not created; timeouts and transport failures park it as unknown
+func (s *OrderService) PlacePreviewedOrder(ctx context.Context, previewId string) (*LockedOrder, error) {
+	if s.previewStore == nil {
+		return nil, fmt.Errorf("preview store not configured")
+	}
+
+	record, err := s.previewStore.GetPreview(previewId)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPreviewNotFound, previewId)
+	}
+	if record.Status == database.PreviewStatusUnknown {
+		return nil, fmt.Errorf("%w: %s (client order %s); check Prime orders before placing it again",
+			ErrPreviewOutcomeUnknown, previewId, record.ClientOrderId)
+	}
+	if record.Status != database.PreviewStatusPending {
+		return nil, fmt.Errorf("%w: %s (order %s)", ErrPreviewAlreadyExecuted, previewId, record.OrderId)
+	}
+	if record.IsExpired(time.Now()) {
+		return nil, fmt.Errorf("%w: %s expired at %s", ErrPreviewExpired, previewId, record.ExpiresAt.Format(time.RFC3339))
+	}
+
+	req, feeStrategy, err := lockedTermsFromPreview(record)
+	if err != nil {
+		return nil, err
+	}
+
+	// Claim before placing so concurrent executions of the same preview cannot both succeed
+	claimed, err := s.previewStore.ClaimPreview(previewId)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, fmt.Errorf("%w: %s", ErrPreviewAlreadyExecuted, previewId)
+	}
+
+	// Nothing has been sent to Prime yet, so any failure here can release the claim
+	prepared, err := s.prepareOrder(req, common.NewPriceAdjuster(feeStrategy))
+	if err == nil {
+		err = checkLockedAmounts(record, prepared)
+	}
+	if err != nil {
+		s.releasePreview(previewId)
+		return nil, err
+	}
+
+	response, err := s.submitOrder(ctx, req, prepared)
+	if err != nil {
+		if isDefiniteRejection(err) {
+			s.releasePreview(previewId)
+			return nil, err
+		}
+
+		clientOrderId := prepared.NormalizedReq.ClientOrderId
+		if markErr := s.previewStore.MarkPreviewUnknown(previewId, clientOrderId); markErr != nil {
+			zap.L().Warn("Failed to mark preview unknown", zap.String("preview_id", previewId), zap.Error(markErr))
+		}
+		return nil, fmt.Errorf("%w: %s (client order %s); check Prime orders before placing it again: %w",
+			ErrPreviewOutcomeUnknown, previewId, clientOrderId, err)
+	}
+
+	if err := s.previewStore.CompletePreview(previewId, response.OrderId); err != nil {
+		zap.L().Warn("Failed to record order on preview",
+			zap.String("preview_id", previewId),
+			zap.String("order_id", response.OrderId),
+			zap.Error(err))
+	}
+
+	return &LockedOrder{
+		PreviewId:   previewId,
+		Request:     req,
+		FeeStrategy: feeStrategy,
+		Response:    response,
+	}, nil
+}
+
+// releasePreview makes a claimed preview executable again after a definite rejection
+func (s *OrderService) releasePreview(previewId string) {
+	if err := s.previewStore.ReleasePreview(previewId); err != nil {
+		zap.L().Warn("Failed to release preview", zap.String("preview_id", previewId), zap.Error(err))
+	}
+}
+
+// isDefiniteRejection reports whether Prime answered with a client error, so no order was created
+// Timeouts, transport failures and server errors leave the outcome unknown
+func isDefiniteRejection(err error) bool {
+	var apiErr *core.ApiError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	code := apiErr.CodeReceived
+	return code >= 400 && code < 500 && code != http.StatusRequestTimeout
+}
+
+// checkLockedAmounts refuses a quote order whose markup or Prime amount differs from the preview
+// Base orders settle the fee on the fill, so only their fee rate is locked
+func checkLockedAmounts(record *database.PreviewRecord, prepared *common.PreparedOrder) error {
+	if prepared.Metadata == nil {
+		return nil
+	}
+
+	markupAmount, err := decimal.NewFromString(record.MarkupAmount)
+	if err != nil {
+		return fmt.Errorf("invalid locked markup amount: %w", err)
+	}
+	primeOrderQuoteAmount, err := decimal.NewFromString(record.PrimeOrderQuoteAmount)
+	if err != nil {
+		return fmt.Errorf("invalid locked Prime order amount: %w", err)
+	}
+
+	if !prepared.Metadata.MarkupAmount.Equal(markupAmount) || !prepared.Metadata.PrimeOrderQuoteAmount.Equal(primeOrderQuoteAmount) {
+		return fmt.Errorf("%w: preview locked markup %s and Prime amount %s, execution computed %s and %s",
+			ErrPreviewTermsChanged, markupAmount, primeOrderQuoteAmount,
+			prepared.Metadata.MarkupAmount, prepared.Metadata.PrimeOrderQuoteAmount)
+	}
+
+	return nil
+}
+
+// lockedTermsFromPreview rebuilds the order request and fee strategy captured by a preview
+func lockedTermsFromPreview(record *database.PreviewRecord) (common.OrderRequest, *common.FeeStrategy, error) {
+	feePercent, err := decimal.NewFromString(record.FeePercent)
+	if err != nil {
+		return common.OrderRequest{}, nil, fmt.Errorf("invalid locked fee percent: %w", err)
+	}
+	baseQty, err := decimal.NewFromString(record.BaseQty)
+	if err != nil {
+		return common.OrderRequest{}, nil, fmt.Errorf("invalid locked base quantity: %w", err)
+	}
+	quoteValue, err := decimal.NewFromString(record.QuoteValue)
+	if err != nil {
+		return common.OrderRequest{}, nil, fmt.Errorf("invalid locked quote value: %w", err)
+	}
+	limitPrice, err := decimal.NewFromString(record.LimitPrice)
+	if err != nil {
+		return common.OrderRequest{}, nil, fmt.Errorf("invalid locked limit price: %w", err)
+	}
+
+	req := common.OrderRequest{
+		Product:    record.ProductId,
+		Side:       record.Side,
+		Type:       record.OrderType,
+		BaseQty:    baseQty,
+		QuoteValue: quoteValue,
+		Price:      limitPrice,
+		Unit:       record.Unit,
+	}
+
+	return req, common.NewFeeStrategy(feePercent), nil
+}
@@ -0,0 +1,326 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package order
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/coinbase-samples/core-go"
+	"github.com/coinbase-samples/prime-sdk-go/orders"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/database"
+	"github.com/shopspring/decimal"
+)
+
+// memoryPreviewStore is an in-memory PreviewStore for tests
+type memoryPreviewStore struct {
+	previews map[string]*database.PreviewRecord
+}
+
+func newMemoryPreviewStore() *memoryPreviewStore {
+	return &memoryPreviewStore{previews: make(map[string]*database.PreviewRecord)}
+}
+
+func (m *memoryPreviewStore) InsertPreview(preview *database.PreviewRecord) error {
+	m.previews[preview.PreviewId] = preview
+	return nil
+}
+
+func (m *memoryPreviewStore) GetPreview(previewId string) (*database.PreviewRecord, error) {
+	return m.previews[previewId], nil
+}
+
+func (m *memoryPreviewStore) ClaimPreview(previewId string) (bool, error) {
+	preview, ok := m.previews[previewId]
+	if !ok || preview.Status != database.PreviewStatusPending {
+		return false, nil
+	}
+	preview.Status = database.PreviewStatusExecuted
+	return true, nil
+}
+
+func (m *memoryPreviewStore) ReleasePreview(previewId string) error {
+	if preview, ok := m.previews[previewId]; ok && preview.OrderId == "" {
+		preview.Status = database.PreviewStatusPending
+	}
+	return nil
+}
+
+func (m *memoryPreviewStore) MarkPreviewUnknown(previewId, clientOrderId string) error {
+	if preview, ok := m.previews[previewId]; ok && preview.OrderId == "" {
+		preview.Status = database.PreviewStatusUnknown
+		preview.ClientOrderId = clientOrderId
+	}
+	return nil
+}
+
+func (m *memoryPreviewStore) PrunePreviews(cutoff time.Time) (int64, error) {
+	var removed int64
+	for id, preview := range m.previews {
+		if preview.ExpiresAt.Before(cutoff) && preview.Status != database.PreviewStatusUnknown {
+			delete(m.previews, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (m *memoryPreviewStore) CompletePreview(previewId, orderId string) error {
+	if preview, ok := m.previews[previewId]; ok {
+		preview.OrderId = orderId
+	}
+	return nil
+}
+
+func TestPlacePreviewedOrder_Rejections(t *testing.T) {
+	store := newMemoryPreviewStore()
+	store.previews["expired"] = &database.PreviewRecord{
+		PreviewId: "expired",
+		Status:    database.PreviewStatusPending,
+		ExpiresAt: time.Now().Add(-time.Second),
+	}
+	store.previews["executed"] = &database.PreviewRecord{
+		PreviewId: "executed",
+		Status:    database.PreviewStatusExecuted,
+		OrderId:   "order-1",
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	service := &OrderService{previewStore: store}
+
+	tests := []struct {
+		name      string
+		previewId string
+		wantErr   error
+	}{
+		{"unknown preview", "missing", ErrPreviewNotFound},
+		{"expired preview", "expired", ErrPreviewExpired},
+		{"executed preview", "executed", ErrPreviewAlreadyExecuted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.PlacePreviewedOrder(context.Background(), tt.previewId)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("PlacePreviewedOrder() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Rejected previews must not be claimed
+	if store.previews["expired"].Status != database.PreviewStatusPending {
+		t.Error("expired preview should not be claimed")
+	}
+}
+
+// stubOrdersService answers CreateOrder with a fixed result and counts calls
+type stubOrdersService struct {
+	orders.OrdersService
+	err   error
+	calls int
+}
+
+func (s *stubOrdersService) CreateOrder(ctx context.Context, request *orders.CreateOrderRequest) (*orders.CreateOrderResponse, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &orders.CreateOrderResponse{OrderId: "order-1", Request: request}, nil
+}
+
+// newLockedQuotePreview returns a pending $100 market buy preview locked at 0.5%
+func newLockedQuotePreview() *database.PreviewRecord {
+	return &database.PreviewRecord{
+		PreviewId:             "preview-1",
+		ProductId:             "BTC-USD",
+		Side:                  "BUY",
+		OrderType:             "MARKET",
+		Unit:                  "quote",
+		BaseQty:               "0",
+		QuoteValue:            "100",
+		LimitPrice:            "0",
+		FeePercent:            "0.005",
+		MarkupAmount:          "0.5",
+		PrimeOrderQuoteAmount: "99.5",
+		Status:                database.PreviewStatusPending,
+		ExpiresAt:             time.Now().Add(time.Minute),
+	}
+}
+
+func TestPlacePreviewedOrder_PlacementOutcomes(t *testing.T) {
+	tests := []struct {
+		name       string
+		createErr  error
+		wantErr    error
+		wantStatus string
+	}{
+		{
+			name:       "accepted order completes the preview",
+			wantStatus: database.PreviewStatusExecuted,
+		},
+		{
+			name:       "client error releases the preview",
+			createErr:  &core.ApiError{Message: "insufficient funds", CodeReceived: http.StatusBadRequest},
+			wantStatus: database.PreviewStatusPending,
+		},
+		{
+			name:       "transport failure leaves the outcome unknown",
+			createErr:  &core.ApiError{Message: "context deadline exceeded", CodeReceived: 0},
+			wantErr:    ErrPreviewOutcomeUnknown,
+			wantStatus: database.PreviewStatusUnknown,
+		},
+		{
+			name:       "server error leaves the outcome unknown",
+			createErr:  &core.ApiError{Message: "bad gateway", CodeReceived: http.StatusBadGateway},
+			wantErr:    ErrPreviewOutcomeUnknown,
+			wantStatus: database.PreviewStatusUnknown,
+		},
+		{
+			name:       "request timeout leaves the outcome unknown",
+			createErr:  &core.ApiError{Message: "timeout", CodeReceived: http.StatusRequestTimeout},
+			wantErr:    ErrPreviewOutcomeUnknown,
+			wantStatus: database.PreviewStatusUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryPreviewStore()
+			store.previews["preview-1"] = newLockedQuotePreview()
+			ordersSvc := &stubOrdersService{err: tt.createErr}
+			service := &OrderService{ordersSvc: ordersSvc, portfolioId: "portfolio-1", previewStore: store}
+
+			locked, err := service.PlacePreviewedOrder(context.Background(), "preview-1")
+			switch {
+			case tt.createErr == nil && err != nil:
+				t.Fatalf("PlacePreviewedOrder() error = %v", err)
+			case tt.createErr != nil && err == nil:
+				t.Fatal("PlacePreviewedOrder() expected error")
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("PlacePreviewedOrder() error = %v, want %v", err, tt.wantErr)
+			}
+
+			record := store.previews["preview-1"]
+			if record.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", record.Status, tt.wantStatus)
+			}
+
+			switch tt.wantStatus {
+			case database.PreviewStatusExecuted:
+				if record.OrderId != locked.Response.OrderId {
+					t.Errorf("OrderId = %q, want %q", record.OrderId, locked.Response.OrderId)
+				}
+			case database.PreviewStatusUnknown:
+				if record.ClientOrderId == "" {
+					t.Error("unknown preview should keep the client order Id")
+				}
+				// An unconfirmed preview must not be placed a second time
+				if _, err := service.PlacePreviewedOrder(context.Background(), "preview-1"); !errors.Is(err, ErrPreviewOutcomeUnknown) {
+					t.Errorf("re-execution error = %v, want %v", err, ErrPreviewOutcomeUnknown)
+				}
+				if ordersSvc.calls != 1 {
+					t.Errorf("CreateOrder called %d times, want 1", ordersSvc.calls)
+				}
+			}
+		})
+	}
+}
+
+func TestPlacePreviewedOrder_EnforcesLockedAmounts(t *testing.T) {
+	store := newMemoryPreviewStore()
+	preview := newLockedQuotePreview()
+	preview.PrimeOrderQuoteAmount = "99.6"
+	store.previews["preview-1"] = preview
+	ordersSvc := &stubOrdersService{}
+	service := &OrderService{ordersSvc: ordersSvc, portfolioId: "portfolio-1", previewStore: store}
+
+	_, err := service.PlacePreviewedOrder(context.Background(), "preview-1")
+	if !errors.Is(err, ErrPreviewTermsChanged) {
+		t.Fatalf("PlacePreviewedOrder() error = %v, want %v", err, ErrPreviewTermsChanged)
+	}
+	if ordersSvc.calls != 0 {
+		t.Errorf("CreateOrder called %d times, want 0", ordersSvc.calls)
+	}
+	if preview.Status != database.PreviewStatusPending {
+		t.Errorf("Status = %q, want %q", preview.Status, database.PreviewStatusPending)
+	}
+}
+
+func TestCheckLockedAmounts(t *testing.T) {
+	prepared, err := common.PrepareOrderRequest(common.OrderRequest{
+		Product:    "BTC-USD",
+		Side:       "buy",
+		Type:       "market",
+		Unit:       "quote",
+		QuoteValue: decimal.NewFromInt(100),
+	}, "portfolio-1", common.NewPriceAdjuster(common.NewFeeStrategy(decimal.RequireFromString("0.005"))), false)
+	if err != nil {
+		t.Fatalf("PrepareOrderRequest() error = %v", err)
+	}
+
+	record := newLockedQuotePreview()
+	if err := checkLockedAmounts(record, prepared); err != nil {
+		t.Errorf("checkLockedAmounts() error = %v", err)
+	}
+
+	record.MarkupAmount = "0.4"
+	if err := checkLockedAmounts(record, prepared); !errors.Is(err, ErrPreviewTermsChanged) {
+		t.Errorf("checkLockedAmounts() error = %v, want %v", err, ErrPreviewTermsChanged)
+	}
+
+	// Base orders only lock the fee rate
+	if err := checkLockedAmounts(record, &common.PreparedOrder{}); err != nil {
+		t.Errorf("checkLockedAmounts() base order error = %v", err)
+	}
+}
+
+func TestLockedTermsFromPreview(t *testing.T) {
+	record := &database.PreviewRecord{
+		ProductId:  "BTC-USD",
+		Side:       "BUY",
+		OrderType:  "LIMIT",
+		Unit:       "quote",
+		BaseQty:    "0",
+		QuoteValue: "100",
+		LimitPrice: "50000",
+		FeePercent: "0.005",
+	}
+
+	req, feeStrategy, err := lockedTermsFromPreview(record)
+	if err != nil {
+		t.Fatalf("lockedTermsFromPreview() error = %v", err)
+	}
+
+	if !req.QuoteValue.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("QuoteValue = %s, want 100", req.QuoteValue)
+	}
+	if !req.Price.Equal(decimal.NewFromInt(50000)) {
+		t.Errorf("Price = %s, want 50000", req.Price)
+	}
+	if !feeStrategy.Percent.Equal(decimal.RequireFromString("0.005")) {
+		t.Errorf("FeeStrategy.Percent = %s, want 0.005", feeStrategy.Percent)
+	}
+
+	record.FeePercent = "invalid"
+	if _, _, err := lockedTermsFromPreview(record); err == nil {
+		t.Error("lockedTermsFromPreview() expected error for invalid fee percent")
+	}
+}
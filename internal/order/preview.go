@@ -21,11 +21,10 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/coinbase-samples/prime-sdk-go/client"
-	"github.com/coinbase-samples/prime-sdk-go/credentials"
 	"github.com/coinbase-samples/prime-sdk-go/orders"
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
 	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/prime"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
@@ -38,28 +37,38 @@ type OrderService struct {
 	metadataStore interface {
 		Set(orderId string, metadata interface{})
 	}
+	previewStore     PreviewStore
+	previewTtl       time.Duration
+	previewRetention time.Duration      // Expired previews older than this are pruned; 0 keeps them
+	tradingGate      common.TradingGate // Optional; consulted before any order is sent to Prime
 }
 
-// NewOrderServiceWithPrime creates a new order service using Prime REST API
-func NewOrderServiceWithPrime(cfg *config.Config, priceAdjuster *common.PriceAdjuster, metadataStore interface{ Set(string, interface{}) }) *OrderService {
-	creds := &credentials.Credentials{
-		AccessKey:    cfg.Prime.AccessKey,
-		Passphrase:   cfg.Prime.Passphrase,
-		SigningKey:   cfg.Prime.SigningKey,
-		PortfolioId:  cfg.Prime.Portfolio,
-		SvcAccountId: cfg.Prime.ServiceAccountId,
+// NewOrderService creates a new order service using the given Prime orders service
+// previewStore is optional; when set, previews are persisted so they can be executed with locked fee terms
+func NewOrderService(cfg *config.Config, priceAdjuster *common.PriceAdjuster, ordersSvc orders.OrdersService, metadataStore interface{ Set(string, interface{}) }, previewStore PreviewStore) *OrderService {
+	return &OrderService{
+		ordersSvc:        ordersSvc,
+		portfolioId:      cfg.Prime.Portfolio,
+		priceAdjuster:    priceAdjuster,
+		metadataStore:    metadataStore,
+		previewStore:     previewStore,
+		previewTtl:       cfg.Order.PreviewTtl,
+		previewRetention: cfg.Order.PreviewRetention,
 	}
+}
 
-	httpClient, _ := client.DefaultHttpClient()
-	restClient := client.NewRestClient(creds, httpClient)
-	ordersSvc := orders.NewOrdersService(restClient)
-
-	return &OrderService{
-		ordersSvc:     ordersSvc,
-		portfolioId:   cfg.Prime.Portfolio,
-		priceAdjuster: priceAdjuster,
-		metadataStore: metadataStore,
+// NewOrderServiceWithPrime creates a new order service using Prime REST API
+func NewOrderServiceWithPrime(cfg *config.Config, priceAdjuster *common.PriceAdjuster, metadataStore interface{ Set(string, interface{}) }, previewStore PreviewStore) (*OrderService, error) {
+	ordersSvc, err := prime.NewOrdersService(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Prime client: %w", err)
 	}
+	return NewOrderService(cfg, priceAdjuster, ordersSvc, metadataStore, previewStore), nil
+}
+
+// SetTradingGate makes order placement refuse products the gate rejects, e.g. on unhealthy market data
+func (s *OrderService) SetTradingGate(gate common.TradingGate) {
+	s.tradingGate = gate
 }
 
 // GeneratePreview creates a complete order preview using Prime REST API
@@ -119,6 +128,12 @@ func (s *OrderService) GeneratePreview(ctx context.Context, req common.OrderRequ
 		TotalValue:         common.RoundPrice(decimal.RequireFromString(order.Total)),
 		Commission:         common.RoundPrice(primeFee),
 	}
+	if bestBid, err := decimal.NewFromString(order.BestBid); err == nil {
+		rawPreview.BestBid = common.RoundPrice(bestBid)
+	}
+	if bestAsk, err := decimal.NewFromString(order.BestAsk); err == nil {
+		rawPreview.BestAsk = common.RoundPrice(bestAsk)
+	}
 
 	// Calculate custom fee (our markup) on top of Prime's execution
 	customFee := s.priceAdjuster.ComputeFee(baseQty, executionPrice)
@@ -160,6 +175,13 @@ func (s *OrderService) GeneratePreview(ctx context.Context, req common.OrderRequ
 		response.RequestedPrice = req.Price.String()
 	}
 
+	// Persist the preview so it can be executed with these exact fee terms
+	if s.previewStore != nil {
+		if err := s.savePreview(req, prepared, customFee, response); err != nil {
+			return nil, fmt.Errorf("failed to persist preview: %w", err)
+		}
+	}
+
 	return response, nil
 }
 
@@ -167,13 +189,34 @@ func (s *OrderService) GeneratePreview(ctx context.Context, req common.OrderRequ
 // Order updates should be tracked via the orders websocket
 // IMPORTANT: For quote-denominated orders, we deduct our markup BEFORE sending to Prime
 func (s *OrderService) PlaceOrder(ctx context.Context, req common.OrderRequest) (*common.OrderResponse, error) {
+	return s.placeOrder(ctx, req, s.priceAdjuster)
+}
+
+// placeOrder places an order using the given price adjuster for fee calculations
+func (s *OrderService) placeOrder(ctx context.Context, req common.OrderRequest, priceAdjuster *common.PriceAdjuster) (*common.OrderResponse, error) {
+	prepared, err := s.prepareOrder(req, priceAdjuster)
+	if err != nil {
+		return nil, err
+	}
+	return s.submitOrder(ctx, req, prepared)
+}
+
+// prepareOrder validates an order and applies fee calculations without contacting Prime
+func (s *OrderService) prepareOrder(req common.OrderRequest, priceAdjuster *common.PriceAdjuster) (*common.PreparedOrder, error) {
 	// Validate request
 	if err := common.ValidateOrderRequest(req); err != nil {
 		return nil, fmt.Errorf("invalid request: %w", err)
 	}
 
+	// Refuse trading while the product's market data is unhealthy
+	if s.tradingGate != nil {
+		if err := s.tradingGate.CheckTrading(req.Product); err != nil {
+			return nil, fmt.Errorf("trading halted: %w", err)
+		}
+	}
+
 	// Prepare order request with fee calculations (generate client order Id for actual orders)
-	prepared, err := common.PrepareOrderRequest(req, s.portfolioId, s.priceAdjuster, true)
+	prepared, err := common.PrepareOrderRequest(req, s.portfolioId, priceAdjuster, true)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare order: %w", err)
 	}
@@ -186,6 +229,11 @@ func (s *OrderService) PlaceOrder(ctx context.Context, req common.OrderRequest)
 			zap.String("prime_order_amount", prepared.Metadata.PrimeOrderQuoteAmount.String()))
 	}
 
+	return prepared, nil
+}
+
+// submitOrder sends a prepared order to Prime
+func (s *OrderService) submitOrder(ctx context.Context, req common.OrderRequest, prepared *common.PreparedOrder) (*common.OrderResponse, error) {
 	// Add timeout to API call (15 seconds for actual order placement)
 	apiCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
@@ -0,0 +1,195 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package order
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/prime/primetest"
+	"github.com/shopspring/decimal"
+)
+
+// recordingMetadataStore captures metadata stored by PlaceOrder
+type recordingMetadataStore struct {
+	metadata map[string]interface{}
+}
+
+func (m *recordingMetadataStore) Set(orderId string, metadata interface{}) {
+	m.metadata[orderId] = metadata
+}
+
+func newTestOrderService(t *testing.T, server *primetest.Server, feePercent string, metadataStore interface{ Set(string, interface{}) }, previewStore PreviewStore) *OrderService {
+	t.Helper()
+
+	cfg := &config.Config{}
+	server.Configure(cfg)
+
+	feeStrategy, err := common.CreateFeeStrategy(feePercent)
+	if err != nil {
+		t.Fatalf("CreateFeeStrategy() error = %v", err)
+	}
+
+	return NewOrderService(cfg, common.NewPriceAdjuster(feeStrategy), server.OrdersService(), metadataStore, previewStore)
+}
+
+func TestGeneratePreview(t *testing.T) {
+	server := primetest.NewServer()
+	defer server.Close()
+
+	service := newTestOrderService(t, server, "0.005", nil, nil)
+
+	preview, err := service.GeneratePreview(context.Background(), common.OrderRequest{
+		Product: "BTC-USD",
+		Side:    "BUY",
+		Type:    "MARKET",
+		BaseQty: decimal.RequireFromString("0.1"),
+		Unit:    "base",
+	})
+	if err != nil {
+		t.Fatalf("GeneratePreview() error = %v", err)
+	}
+
+	// 0.1 BTC at 100010 = 10001 notional, 10 bps Prime commission, 50 bps markup
+	if preview.RawPreview.AverageFilledPrice != "100010" {
+		t.Errorf("AverageFilledPrice = %s, want 100010", preview.RawPreview.AverageFilledPrice)
+	}
+	if preview.RawPreview.Commission != "10" {
+		t.Errorf("Commission = %s, want 10", preview.RawPreview.Commission)
+	}
+	if preview.CustomFeeOverlay == nil || preview.CustomFeeOverlay.FeeAmount != "50.01" {
+		t.Errorf("CustomFeeOverlay = %+v, want fee 50.01", preview.CustomFeeOverlay)
+	}
+	if preview.PreviewId != "" {
+		t.Errorf("PreviewId = %s, want empty without a preview store", preview.PreviewId)
+	}
+	if len(server.Orders()) != 0 {
+		t.Error("preview must not place an order")
+	}
+}
+
+func TestPlaceOrder_QuoteDeductsMarkup(t *testing.T) {
+	server := primetest.NewServer()
+	defer server.Close()
+
+	metadataStore := &recordingMetadataStore{metadata: make(map[string]interface{})}
+	service := newTestOrderService(t, server, "0.005", metadataStore, nil)
+
+	response, err := service.PlaceOrder(context.Background(), common.OrderRequest{
+		Product:    "BTC-USD",
+		Side:       "buy",
+		Type:       "market",
+		QuoteValue: decimal.NewFromInt(1000),
+		Unit:       "quote",
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+
+	order, ok := server.Order(response.OrderId)
+	if !ok {
+		t.Fatalf("order %s not found on server", response.OrderId)
+	}
+	if order.QuoteValue != "995" {
+		t.Errorf("Prime order quote_value = %s, want 995", order.QuoteValue)
+	}
+	if order.ClientOrderId != response.ClientOrderId {
+		t.Errorf("ClientOrderId = %s, want %s", order.ClientOrderId, response.ClientOrderId)
+	}
+
+	metadata, ok := metadataStore.metadata[response.OrderId].(map[string]decimal.Decimal)
+	if !ok {
+		t.Fatalf("metadata not stored for order %s", response.OrderId)
+	}
+	if !metadata["MarkupAmount"].Equal(decimal.NewFromInt(5)) {
+		t.Errorf("MarkupAmount = %s, want 5", metadata["MarkupAmount"])
+	}
+}
+
+func TestPlacePreviewedOrder_UsesLockedFee(t *testing.T) {
+	server := primetest.NewServer()
+	defer server.Close()
+
+	store := newMemoryPreviewStore()
+	previewService := newTestOrderService(t, server, "0.005", nil, store)
+
+	preview, err := previewService.GeneratePreview(context.Background(), common.OrderRequest{
+		Product:    "ETH-USD",
+		Side:       "BUY",
+		Type:       "MARKET",
+		QuoteValue: decimal.NewFromInt(200),
+		Unit:       "quote",
+	})
+	if err != nil {
+		t.Fatalf("GeneratePreview() error = %v", err)
+	}
+
+	// Fee configuration changes between preview and execution
+	executeService := newTestOrderService(t, server, "0.01", nil, store)
+	locked, err := executeService.PlacePreviewedOrder(context.Background(), preview.PreviewId)
+	if err != nil {
+		t.Fatalf("PlacePreviewedOrder() error = %v", err)
+	}
+
+	order, _ := server.Order(locked.Response.OrderId)
+	if order.QuoteValue != "199" {
+		t.Errorf("Prime order quote_value = %s, want 199 (locked 50 bps markup)", order.QuoteValue)
+	}
+}
+
+// haltedGate refuses trading on one product
+type haltedGate struct {
+	product string
+}
+
+func (g haltedGate) CheckTrading(product string) error {
+	if product == g.product {
+		return errors.New("market data unhealthy")
+	}
+	return nil
+}
+
+func TestPlaceOrder_TradingGate(t *testing.T) {
+	server := primetest.NewServer()
+	defer server.Close()
+
+	service := newTestOrderService(t, server, "0.005", nil, nil)
+	service.SetTradingGate(haltedGate{product: "ETH-USD"})
+
+	req := common.OrderRequest{
+		Product:    "ETH-USD",
+		Side:       "buy",
+		Type:       "market",
+		QuoteValue: decimal.NewFromInt(1000),
+		Unit:       "quote",
+	}
+	if _, err := service.PlaceOrder(context.Background(), req); err == nil || !strings.Contains(err.Error(), "trading halted") {
+		t.Fatalf("PlaceOrder() on a halted product error = %v, want trading halted", err)
+	}
+	if len(server.Orders()) != 0 {
+		t.Errorf("server received %d orders, want none", len(server.Orders()))
+	}
+
+	req.Product = "BTC-USD"
+	if _, err := service.PlaceOrder(context.Background(), req); err != nil {
+		t.Fatalf("PlaceOrder() on a healthy product error = %v", err)
+	}
+}
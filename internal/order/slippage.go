@@ -0,0 +1,149 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// ErrSlippageExceeded is returned when the previewed price breaches the slippage guard
+var ErrSlippageExceeded = errors.New("slippage tolerance exceeded")
+
+// SlippageGuard bounds how far the previewed all-in price may drift against the customer before execution
+// The compared price includes our markup, so the tolerance must leave room for it
+type SlippageGuard struct {
+	MaxSlippageBps decimal.Decimal // Tolerance in basis points (e.g., 25 = 0.25%)
+	ReferencePrice decimal.Decimal // Optional; falls back to the preview's book mid when zero
+}
+
+// SlippageCheck contains the outcome of a slippage guard evaluation
+type SlippageCheck struct {
+	Preview        *common.OrderPreviewResponse
+	EffectivePrice decimal.Decimal // All-in price including Prime commission and our markup
+	ReferencePrice decimal.Decimal // User-supplied reference or book mid
+	SlippageBps    decimal.Decimal // Adverse deviation from reference: above it for buys, below it for sells; negative when favourable
+}
+
+// CheckSlippage previews the order and verifies the all-in price is within the guard's tolerance
+// Returns ErrSlippageExceeded (wrapped) along with the check details when the order should be aborted
+func (s *OrderService) CheckSlippage(ctx context.Context, req common.OrderRequest, guard SlippageGuard) (*SlippageCheck, error) {
+	preview, err := s.GeneratePreview(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate preview: %w", err)
+	}
+
+	check, err := EvaluateSlippage(preview, guard)
+	if check != nil {
+		zap.L().Info("Slippage check",
+			zap.String("product", req.Product),
+			zap.String("effective_price", check.EffectivePrice.String()),
+			zap.String("reference_price", check.ReferencePrice.String()),
+			zap.String("slippage_bps", check.SlippageBps.StringFixed(2)),
+			zap.String("max_slippage_bps", guard.MaxSlippageBps.String()))
+	}
+
+	return check, err
+}
+
+// EvaluateSlippage compares a preview's effective all-in price (including markup) against the guard's reference price
+// Only adverse moves abort: a buy priced above the reference or a sell priced below it by more than the tolerance
+func EvaluateSlippage(preview *common.OrderPreviewResponse, guard SlippageGuard) (*SlippageCheck, error) {
+	if preview == nil || preview.RawPreview == nil {
+		return nil, fmt.Errorf("preview is missing Prime execution details")
+	}
+
+	effectivePrice, err := effectivePreviewPrice(preview)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use the caller's reference price, otherwise the book mid Prime reported with the preview
+	referencePrice := guard.ReferencePrice
+	if referencePrice.IsZero() {
+		bestBid, bidErr := decimal.NewFromString(preview.RawPreview.BestBid)
+		bestAsk, askErr := decimal.NewFromString(preview.RawPreview.BestAsk)
+		if bidErr != nil || askErr != nil {
+			return nil, fmt.Errorf("no reference price supplied and preview did not include best bid/ask")
+		}
+		referencePrice = common.CalculateMidPrice(bestBid, bestAsk)
+	}
+
+	check := &SlippageCheck{
+		Preview:        preview,
+		EffectivePrice: effectivePrice,
+		ReferencePrice: referencePrice,
+		SlippageBps:    adverseSlippageBps(preview.Side, effectivePrice, referencePrice),
+	}
+
+	if check.SlippageBps.GreaterThan(guard.MaxSlippageBps) {
+		return check, fmt.Errorf("%w: effective price %s deviates %s bps from reference %s (max %s bps)",
+			ErrSlippageExceeded,
+			common.RoundPrice(effectivePrice),
+			check.SlippageBps.StringFixed(2),
+			common.RoundPrice(referencePrice),
+			guard.MaxSlippageBps.String())
+	}
+
+	return check, nil
+}
+
+// adverseSlippageBps measures in basis points how much worse than reference the price is for the customer
+func adverseSlippageBps(side string, price, reference decimal.Decimal) decimal.Decimal {
+	if reference.IsZero() {
+		return decimal.Zero
+	}
+	diff := price.Sub(reference)
+	if !strings.EqualFold(side, "BUY") {
+		diff = diff.Neg()
+	}
+	return diff.Div(reference).Mul(decimal.NewFromInt(10000))
+}
+
+// effectivePreviewPrice returns the all-in price per unit from a preview
+// Uses our fee overlay when present, otherwise derives it from Prime's execution and commission
+func effectivePreviewPrice(preview *common.OrderPreviewResponse) (decimal.Decimal, error) {
+	if preview.CustomFeeOverlay != nil && preview.CustomFeeOverlay.EffectivePrice != "" {
+		effectivePrice, err := decimal.NewFromString(preview.CustomFeeOverlay.EffectivePrice)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("failed to parse effective price: %w", err)
+		}
+		return effectivePrice, nil
+	}
+
+	baseQty, err := decimal.NewFromString(preview.RawPreview.Quantity)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to parse preview quantity: %w", err)
+	}
+	executionPrice, err := decimal.NewFromString(preview.RawPreview.AverageFilledPrice)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to parse preview price: %w", err)
+	}
+	primeFee, err := decimal.NewFromString(preview.RawPreview.Commission)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to parse preview commission: %w", err)
+	}
+
+	totalCost := common.CalculateTotalCost(baseQty, executionPrice, primeFee, decimal.Zero)
+	return common.CalculateEffectivePrice(totalCost, baseQty), nil
+}
@@ -0,0 +1,139 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package order
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/shopspring/decimal"
+)
+
+func newTestPreview(effectivePrice string) *common.OrderPreviewResponse {
+	preview := &common.OrderPreviewResponse{
+		Product: "BTC-USD",
+		Side:    "BUY",
+		RawPreview: &common.RawPrimePreview{
+			Quantity:           "0.001",
+			AverageFilledPrice: "50000",
+			TotalValue:         "50",
+			Commission:         "0.05",
+			BestBid:            "49990",
+			BestAsk:            "50010",
+		},
+	}
+	if effectivePrice != "" {
+		preview.CustomFeeOverlay = &common.CustomFeeOverlay{
+			FeeAmount:      "0.25",
+			FeePercent:     "0.5",
+			EffectivePrice: effectivePrice,
+		}
+	}
+	return preview
+}
+
+func TestEvaluateSlippage_WithinTolerance(t *testing.T) {
+	// Effective 50300 vs mid 50000 = 60 bps
+	check, err := EvaluateSlippage(newTestPreview("50300"), SlippageGuard{MaxSlippageBps: decimal.NewFromInt(75)})
+	if err != nil {
+		t.Fatalf("EvaluateSlippage() unexpected error: %v", err)
+	}
+	if !check.ReferencePrice.Equal(decimal.NewFromInt(50000)) {
+		t.Errorf("ReferencePrice = %s, want book mid 50000", check.ReferencePrice)
+	}
+	if !check.SlippageBps.Equal(decimal.NewFromInt(60)) {
+		t.Errorf("SlippageBps = %s, want 60", check.SlippageBps)
+	}
+}
+
+func TestEvaluateSlippage_Exceeded(t *testing.T) {
+	check, err := EvaluateSlippage(newTestPreview("50300"), SlippageGuard{MaxSlippageBps: decimal.NewFromInt(50)})
+	if !errors.Is(err, ErrSlippageExceeded) {
+		t.Fatalf("EvaluateSlippage() error = %v, want ErrSlippageExceeded", err)
+	}
+	if check == nil {
+		t.Fatal("EvaluateSlippage() should return check details alongside the error")
+	}
+}
+
+func TestEvaluateSlippage_OnlyAdverseMovesAbort(t *testing.T) {
+	guard := SlippageGuard{MaxSlippageBps: decimal.NewFromInt(50)}
+	tests := []struct {
+		name           string
+		side           string
+		effectivePrice string
+		wantBps        string
+		wantErr        bool
+	}{
+		{name: "buy above reference", side: "BUY", effectivePrice: "50300", wantBps: "60", wantErr: true},
+		{name: "buy below reference", side: "BUY", effectivePrice: "49700", wantBps: "-60"},
+		{name: "sell below reference", side: "SELL", effectivePrice: "49700", wantBps: "60", wantErr: true},
+		{name: "sell above reference", side: "sell", effectivePrice: "50300", wantBps: "-60"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preview := newTestPreview(tt.effectivePrice)
+			preview.Side = tt.side
+
+			check, err := EvaluateSlippage(preview, guard)
+			if errors.Is(err, ErrSlippageExceeded) != tt.wantErr {
+				t.Errorf("EvaluateSlippage() error = %v, want exceeded %v", err, tt.wantErr)
+			}
+			if !check.SlippageBps.Equal(decimal.RequireFromString(tt.wantBps)) {
+				t.Errorf("SlippageBps = %s, want %s", check.SlippageBps, tt.wantBps)
+			}
+		})
+	}
+}
+
+func TestEvaluateSlippage_ReferencePrice(t *testing.T) {
+	// Effective 50300 vs reference 50250 ≈ 9.95 bps
+	guard := SlippageGuard{
+		MaxSlippageBps: decimal.NewFromInt(10),
+		ReferencePrice: decimal.NewFromInt(50250),
+	}
+	check, err := EvaluateSlippage(newTestPreview("50300"), guard)
+	if err != nil {
+		t.Fatalf("EvaluateSlippage() unexpected error: %v", err)
+	}
+	if !check.ReferencePrice.Equal(guard.ReferencePrice) {
+		t.Errorf("ReferencePrice = %s, want %s", check.ReferencePrice, guard.ReferencePrice)
+	}
+}
+
+func TestEvaluateSlippage_NoOverlay(t *testing.T) {
+	// No markup: effective = (0.001 * 50000 + 0.05) / 0.001 = 50050 -> 10 bps from mid
+	check, err := EvaluateSlippage(newTestPreview(""), SlippageGuard{MaxSlippageBps: decimal.NewFromInt(10)})
+	if err != nil {
+		t.Fatalf("EvaluateSlippage() unexpected error: %v", err)
+	}
+	if !check.EffectivePrice.Equal(decimal.NewFromInt(50050)) {
+		t.Errorf("EffectivePrice = %s, want 50050", check.EffectivePrice)
+	}
+}
+
+func TestEvaluateSlippage_MissingBook(t *testing.T) {
+	preview := newTestPreview("50300")
+	preview.RawPreview.BestBid = ""
+	preview.RawPreview.BestAsk = ""
+
+	if _, err := EvaluateSlippage(preview, SlippageGuard{MaxSlippageBps: decimal.NewFromInt(50)}); err == nil {
+		t.Error("EvaluateSlippage() expected error without reference price or book")
+	}
+}
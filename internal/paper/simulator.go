@@ -0,0 +1,398 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package paper simulates Prime order execution against the live order book.
+// The Simulator implements orders.OrdersService so it can be injected into
+// order.OrderService, and it emits the same order updates the Prime orders
+// websocket would, allowing fee settlement to run unchanged without real funds.
+package paper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coinbase-samples/prime-sdk-go/model"
+	"github.com/coinbase-samples/prime-sdk-go/orders"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// OrderIdPrefix marks simulated orders so they are never confused with real Prime orders
+const OrderIdPrefix = "paper-"
+
+var (
+	ErrNotSupported  = errors.New("not supported in paper trading mode")
+	ErrNoMarketData  = errors.New("no market data for product")
+	ErrOrderNotFound = errors.New("paper order not found")
+)
+
+// Simulator fills orders against OrderBookStore levels and queues synthetic order updates
+// Fills do not consume liquidity from the book; the next market data update replaces it anyway
+type Simulator struct {
+	books          *websocket.OrderBookStore
+	handler        websocket.OrderUpdateHandler
+	commissionRate decimal.Decimal
+
+	mu       sync.Mutex
+	sequence int64
+	orders   map[string]*model.Order
+	pending  []*websocket.Message
+}
+
+// NewSimulator creates a simulator that fills against books and reports updates to handler
+// commissionRate is the simulated Prime commission (e.g., 0.001 = 10 bps)
+func NewSimulator(books *websocket.OrderBookStore, handler websocket.OrderUpdateHandler, commissionRate decimal.Decimal) *Simulator {
+	return &Simulator{
+		books:          books,
+		handler:        handler,
+		commissionRate: commissionRate,
+		orders:         make(map[string]*model.Order),
+	}
+}
+
+// fill is the quantity taken from a single price level
+type fill struct {
+	price decimal.Decimal
+	qty   decimal.Decimal
+}
+
+// execution summarizes the result of walking the book for an order
+type execution struct {
+	fills    []fill
+	complete bool // false when the book (or the limit price) ran out before the order was filled
+	bestBid  decimal.Decimal
+	bestAsk  decimal.Decimal
+}
+
+// CreateOrder fills the order against the current book and queues its order updates
+// Whatever does not fill immediately is cancelled, for limit orders as well as market orders:
+// nothing re-matches resting orders against later book updates, so a limit order is treated as IOC
+func (s *Simulator) CreateOrder(ctx context.Context, request *orders.CreateOrderRequest) (*orders.CreateOrderResponse, error) {
+	if request.Order == nil {
+		return nil, errors.New("order not set on request")
+	}
+
+	exec, err := s.match(request.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order := *request.Order
+	order.Id = OrderIdPrefix + uuid.New().String()
+	order.Created = time.Now().UTC().Format(time.RFC3339)
+	order.Status = common.OrderStatusOpen
+	order.FilledQuantity = common.DefaultZeroString
+	order.FilledValue = common.DefaultZeroString
+	order.AverageFilledPrice = common.DefaultZeroString
+	order.NetAverageFilledPrice = common.DefaultZeroString
+	order.Commission = common.DefaultZeroString
+	order.Total = common.DefaultZeroString
+	s.orders[order.Id] = &order
+
+	// Report each level walked as a partial fill, as Prime would
+	var cumQty, filledValue decimal.Decimal
+	for i, f := range exec.fills {
+		cumQty = cumQty.Add(f.qty)
+		filledValue = filledValue.Add(f.qty.Mul(f.price))
+		if i == len(exec.fills)-1 && exec.complete {
+			order.Status = common.OrderStatusFilled
+		}
+		s.recordFill(&order, cumQty, filledValue)
+		s.queueUpdate(&order)
+	}
+
+	if !exec.complete {
+		if len(exec.fills) == 0 {
+			s.queueUpdate(&order)
+		}
+		order.Status = common.OrderStatusCancelled
+		s.queueUpdate(&order)
+	}
+
+	zap.L().Info("Paper order simulated",
+		zap.String("order_id", order.Id),
+		zap.String("product", order.ProductId),
+		zap.String("side", order.Side),
+		zap.String("status", order.Status),
+		zap.String("filled_qty", cumQty.String()),
+		zap.Int("levels", len(exec.fills)))
+
+	return &orders.CreateOrderResponse{OrderId: order.Id, Request: request}, nil
+}
+
+// CreateOrderPreview simulates the order against the current book without recording it
+func (s *Simulator) CreateOrderPreview(ctx context.Context, request *orders.CreateOrderRequest) (*orders.CreateOrderPreviewResponse, error) {
+	if request.Order == nil {
+		return nil, errors.New("order not set on request")
+	}
+
+	exec, err := s.match(request.Order)
+	if err != nil {
+		return nil, err
+	}
+	if len(exec.fills) == 0 {
+		return nil, fmt.Errorf("order would not fill against the current book")
+	}
+
+	preview := *request.Order
+	var cumQty, filledValue decimal.Decimal
+	for _, f := range exec.fills {
+		cumQty = cumQty.Add(f.qty)
+		filledValue = filledValue.Add(f.qty.Mul(f.price))
+	}
+	s.recordFill(&preview, cumQty, filledValue)
+	preview.BaseQuantity = cumQty.String()
+	preview.BestBid = exec.bestBid.String()
+	preview.BestAsk = exec.bestAsk.String()
+	preview.Status = ""
+
+	return &orders.CreateOrderPreviewResponse{Order: &preview, Request: request}, nil
+}
+
+// GetOrder returns the current state of a simulated order
+func (s *Simulator) GetOrder(ctx context.Context, request *orders.GetOrderRequest) (*orders.GetOrderResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[request.OrderId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, request.OrderId)
+	}
+
+	result := *order
+	return &orders.GetOrderResponse{Order: &result, Request: request}, nil
+}
+
+// CancelOrder cancels an open simulated order
+// CreateOrder never leaves an order open, so this only reports the order's terminal status
+func (s *Simulator) CancelOrder(ctx context.Context, request *orders.CancelOrderRequest) (*orders.CancelOrderResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[request.OrderId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, request.OrderId)
+	}
+	if order.Status != common.OrderStatusOpen {
+		return nil, fmt.Errorf("order %s is %s and cannot be cancelled", order.Id, order.Status)
+	}
+
+	order.Status = common.OrderStatusCancelled
+	s.queueUpdate(order)
+
+	return &orders.CancelOrderResponse{OrderId: order.Id, Request: request}, nil
+}
+
+// PublishUpdates delivers queued order updates to the handler in sequence order
+// Call this after order metadata has been stored so settlement sees the upfront markup
+func (s *Simulator) PublishUpdates() error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	for _, update := range pending {
+		if err := s.handler.HandleOrderUpdate(update); err != nil {
+			return fmt.Errorf("failed to handle paper order update: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListOpenOrders is not supported in paper trading mode
+func (s *Simulator) ListOpenOrders(ctx context.Context, request *orders.ListOpenOrdersRequest) (*orders.ListOpenOrdersResponse, error) {
+	return nil, ErrNotSupported
+}
+
+// ListOrders is not supported in paper trading mode
+func (s *Simulator) ListOrders(ctx context.Context, request *orders.ListOrdersRequest) (*orders.ListOrdersResponse, error) {
+	return nil, ErrNotSupported
+}
+
+// ListOrderFills is not supported in paper trading mode
+func (s *Simulator) ListOrderFills(ctx context.Context, request *orders.ListOrderFillsRequest) (*orders.ListOrderFillsResponse, error) {
+	return nil, ErrNotSupported
+}
+
+// ListPortfolioFills is not supported in paper trading mode
+func (s *Simulator) ListPortfolioFills(ctx context.Context, request *orders.ListPortfolioFillsRequest) (*orders.ListPortfolioFillsResponse, error) {
+	return nil, ErrNotSupported
+}
+
+// CreateQuoteRequest is not supported in paper trading mode
+func (s *Simulator) CreateQuoteRequest(ctx context.Context, request *orders.CreateQuoteRequest) (*orders.CreateQuoteResponse, error) {
+	return nil, ErrNotSupported
+}
+
+// AcceptQuote is not supported in paper trading mode
+func (s *Simulator) AcceptQuote(ctx context.Context, request *orders.AcceptQuoteRequest) (*orders.AcceptQuoteResponse, error) {
+	return nil, ErrNotSupported
+}
+
+// match walks the opposite side of the book until the order is filled, the book runs out,
+// or the next level is through the limit price
+// Quote-denominated orders reserve the commission so fills plus commission stay within quote_value
+func (s *Simulator) match(order *model.Order) (*execution, error) {
+	book, ok := s.books.Get(order.ProductId)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoMarketData, order.ProductId)
+	}
+	// A fill against a composed cross book would simulate an order Prime cannot accept
+	if book.Indicative() {
+		return nil, fmt.Errorf("%w: %s", websocket.ErrSyntheticNotTradable, order.ProductId)
+	}
+	// Walk the full depth, like OrderBook.EstimateExecution; MaxLevels only limits what is displayed
+	snapshot := book.FullSnapshot()
+	if len(snapshot.Bids) == 0 || len(snapshot.Asks) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoMarketData, order.ProductId)
+	}
+
+	isBuy := strings.EqualFold(order.Side, "BUY")
+	levels := snapshot.Bids
+	if isBuy {
+		levels = snapshot.Asks
+	}
+
+	var limitPrice decimal.Decimal
+	if order.LimitPrice != "" {
+		price, err := decimal.NewFromString(order.LimitPrice)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit price: %w", err)
+		}
+		limitPrice = price
+	}
+
+	exec := &execution{
+		bestBid: snapshot.Bids[0].Price,
+		bestAsk: snapshot.Asks[0].Price,
+	}
+
+	var remainingQty, remainingValue decimal.Decimal
+	byValue := order.BaseQuantity == ""
+	if byValue {
+		quoteValue, err := decimal.NewFromString(order.QuoteValue)
+		if err != nil || !quoteValue.IsPositive() {
+			return nil, fmt.Errorf("invalid quote value: %s", order.QuoteValue)
+		}
+		remainingValue = quoteValue.Div(decimal.NewFromInt(1).Add(s.commissionRate))
+	} else {
+		baseQty, err := decimal.NewFromString(order.BaseQuantity)
+		if err != nil || !baseQty.IsPositive() {
+			return nil, fmt.Errorf("invalid base quantity: %s", order.BaseQuantity)
+		}
+		remainingQty = baseQty
+	}
+
+	for _, level := range levels {
+		if !limitPrice.IsZero() {
+			if (isBuy && level.Price.GreaterThan(limitPrice)) || (!isBuy && level.Price.LessThan(limitPrice)) {
+				break
+			}
+		}
+
+		take := level.Size
+		if byValue {
+			if level.Price.Mul(take).GreaterThan(remainingValue) {
+				take = remainingValue.Div(level.Price).Truncate(8)
+			}
+			remainingValue = remainingValue.Sub(level.Price.Mul(take))
+		} else {
+			if take.GreaterThan(remainingQty) {
+				take = remainingQty
+			}
+			remainingQty = remainingQty.Sub(take)
+		}
+
+		if take.IsPositive() {
+			exec.fills = append(exec.fills, fill{price: level.Price, qty: take})
+		}
+
+		// A by-value order can use up a level exactly, leaving no value for the next one
+		if (byValue && (take.LessThan(level.Size) || !remainingValue.IsPositive())) || (!byValue && remainingQty.IsZero()) {
+			exec.complete = true
+			break
+		}
+	}
+
+	return exec, nil
+}
+
+// recordFill updates an order's execution fields from cumulative fill totals
+func (s *Simulator) recordFill(order *model.Order, cumQty, filledValue decimal.Decimal) {
+	precision := common.GetProductQuotePrecision(order.ProductId)
+	commission := filledValue.Mul(s.commissionRate).Round(precision)
+
+	total := filledValue.Add(commission)
+	if !strings.EqualFold(order.Side, "BUY") {
+		total = filledValue.Sub(commission)
+	}
+
+	order.FilledQuantity = cumQty.String()
+	order.FilledValue = filledValue.String()
+	order.AverageFilledPrice = filledValue.DivRound(cumQty, 8).String()
+	order.NetAverageFilledPrice = total.DivRound(cumQty, 8).String()
+	order.Commission = commission.String()
+	order.Total = total.String()
+}
+
+// queueUpdate snapshots the order into an orders-channel message
+// Caller must hold s.mu
+func (s *Simulator) queueUpdate(order *model.Order) {
+	s.sequence++
+
+	state := websocket.OrderUpdate{
+		OrderId:       order.Id,
+		ClientOrderId: order.ClientOrderId,
+		ProductId:     order.ProductId,
+		Side:          strings.ToUpper(order.Side),
+		OrderType:     strings.ToUpper(order.Type),
+		Status:        order.Status,
+		CumQty:        order.FilledQuantity,
+		AvgPx:         order.AverageFilledPrice,
+		NetAvgPx:      order.NetAverageFilledPrice,
+		FilledValue:   order.FilledValue,
+		Fees:          order.Commission,
+		Commission:    order.Commission,
+	}
+
+	// Leaves quantity is only known in base units for base-denominated orders
+	if order.BaseQuantity != "" && order.Status == common.OrderStatusOpen {
+		baseQty, _ := decimal.NewFromString(order.BaseQuantity)
+		cumQty, _ := decimal.NewFromString(order.FilledQuantity)
+		state.LeavesQty = baseQty.Sub(cumQty).String()
+	}
+
+	sequence := uint64(s.sequence)
+	s.pending = append(s.pending, &websocket.Message{
+		Channel:     websocket.ChannelOrders,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339Nano),
+		SequenceNum: &sequence,
+		OrderEvents: []websocket.OrdersEvent{{
+			Type:   "update",
+			Orders: []websocket.OrderUpdate{state},
+		}},
+	})
+}
@@ -0,0 +1,288 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package paper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/coinbase-samples/prime-sdk-go/model"
+	"github.com/coinbase-samples/prime-sdk-go/orders"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/websocket"
+	"github.com/shopspring/decimal"
+)
+
+// recordingHandler captures order updates published by the simulator
+type recordingHandler struct {
+	updates []*websocket.Message
+}
+
+func (h *recordingHandler) HandleOrderUpdate(update *websocket.Message) error {
+	h.updates = append(h.updates, update)
+	return nil
+}
+
+// statuses returns the order status carried by each recorded update
+func (h *recordingHandler) statuses() []string {
+	var result []string
+	for _, update := range h.updates {
+		result = append(result, update.OrderEvents[0].Orders[0].Status)
+	}
+	return result
+}
+
+func level(price, size string) common.PriceLevel {
+	return common.PriceLevel{Price: decimal.RequireFromString(price), Size: decimal.RequireFromString(size)}
+}
+
+func newTestSimulator() (*Simulator, *recordingHandler) {
+	store := websocket.NewOrderBookStore()
+	store.GetOrCreate("BTC-USD").Update(
+		[]common.PriceLevel{level("99990", "1"), level("99980", "2")},
+		[]common.PriceLevel{level("100000", "0.5"), level("100010", "1"), level("100020", "2")},
+		1,
+	)
+	handler := &recordingHandler{}
+	return NewSimulator(store, handler, decimal.RequireFromString("0.001")), handler
+}
+
+func TestSimulator_BaseOrderWalksDepth(t *testing.T) {
+	sim, handler := newTestSimulator()
+
+	resp, err := sim.CreateOrder(context.Background(), &orders.CreateOrderRequest{Order: &model.Order{
+		ClientOrderId: "client-order-1",
+		ProductId:     "BTC-USD",
+		Side:          "BUY",
+		Type:          "MARKET",
+		BaseQuantity:  "1",
+	}})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	got, err := sim.GetOrder(context.Background(), &orders.GetOrderRequest{OrderId: resp.OrderId})
+	if err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+
+	// 0.5 @ 100000 + 0.5 @ 100010 = 100005 average
+	if got.Order.Status != common.OrderStatusFilled {
+		t.Errorf("Status = %s, want FILLED", got.Order.Status)
+	}
+	if got.Order.AverageFilledPrice != "100005" {
+		t.Errorf("AverageFilledPrice = %s, want 100005", got.Order.AverageFilledPrice)
+	}
+	if got.Order.Commission != "100.01" {
+		t.Errorf("Commission = %s, want 100.01", got.Order.Commission)
+	}
+
+	// Nothing is delivered until published
+	if len(handler.updates) != 0 {
+		t.Fatalf("expected no updates before publish, got %d", len(handler.updates))
+	}
+	if err := sim.PublishUpdates(); err != nil {
+		t.Fatalf("PublishUpdates() error = %v", err)
+	}
+
+	statuses := handler.statuses()
+	if len(statuses) != 2 || statuses[0] != common.OrderStatusOpen || statuses[1] != common.OrderStatusFilled {
+		t.Errorf("statuses = %v, want [OPEN FILLED]", statuses)
+	}
+	if seq, _ := handler.updates[1].Sequence(); seq != 2 {
+		t.Errorf("sequence_num = %v, want 2", seq)
+	}
+}
+
+func TestSimulator_WalksBeyondMaxLevels(t *testing.T) {
+	sim, _ := newTestSimulator()
+	// Display depth must not limit fills, matching OrderBook.EstimateExecution
+	sim.books.SetMaxLevels(1)
+
+	resp, err := sim.CreateOrder(context.Background(), &orders.CreateOrderRequest{Order: &model.Order{
+		ClientOrderId: "client-order-1",
+		ProductId:     "BTC-USD",
+		Side:          "BUY",
+		Type:          "MARKET",
+		BaseQuantity:  "3",
+	}})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	got, _ := sim.GetOrder(context.Background(), &orders.GetOrderRequest{OrderId: resp.OrderId})
+	if got.Order.Status != common.OrderStatusFilled || got.Order.FilledQuantity != "3" {
+		t.Errorf("Status = %s, FilledQuantity = %s, want FILLED 3 across all ask levels", got.Order.Status, got.Order.FilledQuantity)
+	}
+}
+
+func TestSimulator_QuoteOrderReservesCommission(t *testing.T) {
+	sim, _ := newTestSimulator()
+
+	resp, err := sim.CreateOrder(context.Background(), &orders.CreateOrderRequest{Order: &model.Order{
+		ClientOrderId: "client-order-1",
+		ProductId:     "BTC-USD",
+		Side:          "SELL",
+		Type:          "MARKET",
+		QuoteValue:    "10010",
+	}})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	got, _ := sim.GetOrder(context.Background(), &orders.GetOrderRequest{OrderId: resp.OrderId})
+	filledValue := decimal.RequireFromString(got.Order.FilledValue)
+	commission := decimal.RequireFromString(got.Order.Commission)
+
+	// 10010 / 1.001 = 10000 of notional at the best bid
+	if !filledValue.Round(0).Equal(decimal.NewFromInt(10000)) {
+		t.Errorf("FilledValue = %s, want ~10000", filledValue)
+	}
+	if filledValue.Add(commission).GreaterThan(decimal.RequireFromString("10010")) {
+		t.Errorf("filled value %s plus commission %s exceeds quote value", filledValue, commission)
+	}
+	if got.Order.AverageFilledPrice != "99990" {
+		t.Errorf("AverageFilledPrice = %s, want 99990", got.Order.AverageFilledPrice)
+	}
+}
+
+func TestSimulator_PartialFills(t *testing.T) {
+	tests := []struct {
+		name         string
+		order        *model.Order
+		wantStatuses []string
+		wantFilled   string
+	}{
+		{
+			name:         "market order exhausts depth",
+			order:        &model.Order{ProductId: "BTC-USD", Side: "SELL", Type: "MARKET", BaseQuantity: "5"},
+			wantStatuses: []string{common.OrderStatusOpen, common.OrderStatusOpen, common.OrderStatusCancelled},
+			wantFilled:   "3",
+		},
+		{
+			// 1 @ 99990 + 2 @ 99980 = 299950 of notional, plus 10 bps commission
+			name:         "by-value order uses up the last level exactly",
+			order:        &model.Order{ProductId: "BTC-USD", Side: "SELL", Type: "MARKET", QuoteValue: "300249.95"},
+			wantStatuses: []string{common.OrderStatusOpen, common.OrderStatusFilled},
+			wantFilled:   "3",
+		},
+		{
+			name:         "limit order remainder is cancelled after crossing levels",
+			order:        &model.Order{ProductId: "BTC-USD", Side: "BUY", Type: "LIMIT", BaseQuantity: "2", LimitPrice: "100010"},
+			wantStatuses: []string{common.OrderStatusOpen, common.OrderStatusOpen, common.OrderStatusCancelled},
+			wantFilled:   "1.5",
+		},
+		{
+			name:         "limit order away from the market",
+			order:        &model.Order{ProductId: "BTC-USD", Side: "BUY", Type: "LIMIT", BaseQuantity: "1", LimitPrice: "90000"},
+			wantStatuses: []string{common.OrderStatusOpen, common.OrderStatusCancelled},
+			wantFilled:   "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim, handler := newTestSimulator()
+			tt.order.ClientOrderId = "client-order-1"
+
+			resp, err := sim.CreateOrder(context.Background(), &orders.CreateOrderRequest{Order: tt.order})
+			if err != nil {
+				t.Fatalf("CreateOrder() error = %v", err)
+			}
+			if err := sim.PublishUpdates(); err != nil {
+				t.Fatalf("PublishUpdates() error = %v", err)
+			}
+
+			statuses := handler.statuses()
+			if len(statuses) != len(tt.wantStatuses) {
+				t.Fatalf("statuses = %v, want %v", statuses, tt.wantStatuses)
+			}
+			for i := range statuses {
+				if statuses[i] != tt.wantStatuses[i] {
+					t.Errorf("statuses = %v, want %v", statuses, tt.wantStatuses)
+					break
+				}
+			}
+
+			got, _ := sim.GetOrder(context.Background(), &orders.GetOrderRequest{OrderId: resp.OrderId})
+			if got.Order.FilledQuantity != tt.wantFilled {
+				t.Errorf("FilledQuantity = %q, want %q", got.Order.FilledQuantity, tt.wantFilled)
+			}
+		})
+	}
+}
+
+func TestSimulator_UnfilledLimitOrderIsCancelled(t *testing.T) {
+	sim, handler := newTestSimulator()
+	ctx := context.Background()
+
+	resp, err := sim.CreateOrder(ctx, &orders.CreateOrderRequest{Order: &model.Order{
+		ClientOrderId: "client-order-1",
+		ProductId:     "BTC-USD",
+		Side:          "BUY",
+		Type:          "LIMIT",
+		BaseQuantity:  "1",
+		LimitPrice:    "90000",
+	}})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	if _, err := sim.CancelOrder(ctx, &orders.CancelOrderRequest{OrderId: resp.OrderId}); err == nil {
+		t.Error("expected error cancelling an order that is already cancelled")
+	}
+
+	if err := sim.PublishUpdates(); err != nil {
+		t.Fatalf("PublishUpdates() error = %v", err)
+	}
+	for _, message := range handler.updates {
+		update := message.OrderEvents[0].Orders[0]
+		if update.CumQty != "0" || update.AvgPx != "0" || update.FilledValue != "0" {
+			t.Errorf("update %s has cum_qty=%q avg_px=%q filled_value=%q, want zero values",
+				update.Status, update.CumQty, update.AvgPx, update.FilledValue)
+		}
+	}
+}
+
+func TestSimulator_Errors(t *testing.T) {
+	sim, _ := newTestSimulator()
+	ctx := context.Background()
+
+	_, err := sim.CreateOrder(ctx, &orders.CreateOrderRequest{Order: &model.Order{ProductId: "ETH-USD", Side: "BUY", BaseQuantity: "1"}})
+	if !errors.Is(err, ErrNoMarketData) {
+		t.Errorf("CreateOrder() error = %v, want ErrNoMarketData", err)
+	}
+
+	// Synthetic crosses only exist locally and cannot be filled
+	sim.books.GetOrCreate("ETH-USD").Update(
+		[]common.PriceLevel{level("2000", "10")},
+		[]common.PriceLevel{level("2010", "10")},
+		1,
+	)
+	if err := sim.books.AddSynthetic("ETH-BTC", "ETH-USD", "BTC-USD"); err != nil {
+		t.Fatalf("AddSynthetic() error = %v", err)
+	}
+	_, err = sim.CreateOrder(ctx, &orders.CreateOrderRequest{Order: &model.Order{ProductId: "ETH-BTC", Side: "BUY", BaseQuantity: "1"}})
+	if !errors.Is(err, websocket.ErrSyntheticNotTradable) {
+		t.Errorf("CreateOrder() on a synthetic error = %v, want ErrSyntheticNotTradable", err)
+	}
+
+	if _, err := sim.CreateQuoteRequest(ctx, &orders.CreateQuoteRequest{}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("CreateQuoteRequest() error = %v, want ErrNotSupported", err)
+	}
+}
@@ -0,0 +1,69 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prime
+
+import (
+	"fmt"
+
+	"github.com/coinbase-samples/prime-sdk-go/client"
+	"github.com/coinbase-samples/prime-sdk-go/commission"
+	"github.com/coinbase-samples/prime-sdk-go/credentials"
+	"github.com/coinbase-samples/prime-sdk-go/orders"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+)
+
+// NewRestClient creates an authenticated Prime REST client from configuration
+// Honors cfg.Prime.RestUrl so the client can be pointed at a local stand-in
+func NewRestClient(cfg *config.Config) (client.RestClient, error) {
+	creds := &credentials.Credentials{
+		AccessKey:    cfg.Prime.AccessKey,
+		Passphrase:   cfg.Prime.Passphrase,
+		SigningKey:   cfg.Prime.SigningKey,
+		PortfolioId:  cfg.Prime.Portfolio,
+		SvcAccountId: cfg.Prime.ServiceAccountId,
+	}
+
+	httpClient, err := client.DefaultHttpClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create http client: %w", err)
+	}
+
+	restClient := client.NewRestClient(creds, httpClient)
+	if cfg.Prime.RestUrl != "" {
+		restClient.SetBaseUrl(cfg.Prime.RestUrl)
+	}
+
+	return restClient, nil
+}
+
+// NewOrdersService creates a Prime orders service from configuration
+func NewOrdersService(cfg *config.Config) (orders.OrdersService, error) {
+	restClient, err := NewRestClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return orders.NewOrdersService(restClient), nil
+}
+
+// NewCommissionService creates a Prime commission service from configuration
+func NewCommissionService(cfg *config.Config) (commission.CommissionService, error) {
+	restClient, err := NewRestClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return commission.NewCommissionService(restClient), nil
+}
@@ -0,0 +1,72 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prime
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/coinbase-samples/prime-sdk-go/commission"
+	"github.com/shopspring/decimal"
+)
+
+// CommissionRate fetches a portfolio's Prime commission rate on first use and caches it
+// Failed fetches are not cached, so a later call retries
+type CommissionRate struct {
+	service     commission.CommissionService
+	portfolioId string
+
+	mu     sync.Mutex
+	rate   decimal.Decimal
+	cached bool
+}
+
+// NewCommissionRate creates a cached commission rate for a portfolio
+func NewCommissionRate(service commission.CommissionService, portfolioId string) *CommissionRate {
+	return &CommissionRate{service: service, portfolioId: portfolioId}
+}
+
+// Get returns the portfolio's commission rate (e.g., 0.001 for 10 bps)
+func (c *CommissionRate) Get(ctx context.Context) (decimal.Decimal, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached {
+		return c.rate, nil
+	}
+
+	response, err := c.service.GetPortfolioCommission(ctx, &commission.GetPortfolioCommissionRequest{PortfolioId: c.portfolioId})
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get portfolio commission: %w", err)
+	}
+	if response.Commission == nil {
+		return decimal.Zero, fmt.Errorf("portfolio commission response has no commission")
+	}
+
+	rate, err := response.Commission.RateNum()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if rate.IsNegative() {
+		return decimal.Zero, fmt.Errorf("portfolio commission rate cannot be negative: %s", rate)
+	}
+
+	c.rate = rate
+	c.cached = true
+	return rate, nil
+}
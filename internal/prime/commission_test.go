@@ -0,0 +1,64 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/prime/primetest"
+)
+
+func TestCommissionRate_FetchesOnce(t *testing.T) {
+	server := primetest.NewServer()
+	defer server.Close()
+	server.SetCommissionRate("0.0015")
+
+	rate := NewCommissionRate(server.CommissionService(), primetest.DefaultPortfolioId)
+	for i := 0; i < 3; i++ {
+		got, err := rate.Get(context.Background())
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if got.String() != "0.0015" {
+			t.Errorf("Get() = %s, want 0.0015", got)
+		}
+	}
+
+	if n := server.CommissionRequests(); n != 1 {
+		t.Errorf("commission requests = %d, want 1", n)
+	}
+}
+
+func TestCommissionRate_RetriesAfterError(t *testing.T) {
+	server := primetest.NewServer()
+	defer server.Close()
+
+	rate := NewCommissionRate(server.CommissionService(), "other-portfolio")
+	if _, err := rate.Get(context.Background()); err == nil {
+		t.Fatal("Get() for an unknown portfolio error = nil, want an error")
+	}
+
+	rate.portfolioId = primetest.DefaultPortfolioId
+	got, err := rate.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get() after a failure error = %v", err)
+	}
+	if got.String() != primetest.DefaultCommissionRate {
+		t.Errorf("Get() = %s, want %s", got, primetest.DefaultCommissionRate)
+	}
+}
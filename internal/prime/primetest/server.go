@@ -0,0 +1,558 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package primetest provides an in-process stand-in for the Prime REST API.
+// It implements the order, preview, RFQ, cancel and commission endpoints used by this
+// application so that services and CLI flows can be exercised offline.
+package primetest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coinbase-samples/prime-sdk-go/client"
+	"github.com/coinbase-samples/prime-sdk-go/commission"
+	"github.com/coinbase-samples/prime-sdk-go/credentials"
+	"github.com/coinbase-samples/prime-sdk-go/model"
+	"github.com/coinbase-samples/prime-sdk-go/orders"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DefaultPortfolioId    = "test-portfolio"
+	DefaultCommissionRate = "0.001" // 10 bps
+	DefaultQuoteTtl       = 10 * time.Second
+
+	apiPrefix = "/v1"
+)
+
+// Market is the top of book the stand-in fills against
+type Market struct {
+	Bid decimal.Decimal
+	Ask decimal.Decimal
+}
+
+// Server is a fake Prime REST API backed by httptest
+// Requests must carry valid Prime authentication headers for the server's credentials
+type Server struct {
+	*httptest.Server
+
+	creds *credentials.Credentials
+
+	mu             sync.Mutex
+	markets        map[string]Market
+	commissionRate decimal.Decimal
+	commissionGets int
+	quoteTtl       time.Duration
+	orders         map[string]*model.Order
+	quotes         map[string]*quote
+}
+
+// quote is an outstanding RFQ awaiting acceptance
+type quote struct {
+	portfolioId string
+	productId   string
+	side        string
+	execution   *execution
+	expiresAt   time.Time
+	accepted    bool
+}
+
+// execution describes how an order fills against the current market
+type execution struct {
+	market     Market
+	price      decimal.Decimal
+	baseQty    decimal.Decimal
+	filled     decimal.Decimal // Notional actually exchanged, excluding commission
+	commission decimal.Decimal
+	total      decimal.Decimal
+}
+
+// NewServer starts a stand-in seeded with BTC-USD and ETH-USD markets
+// Callers must Close the server when done
+func NewServer() *Server {
+	s := &Server{
+		creds: &credentials.Credentials{
+			AccessKey:    "test-access-key",
+			Passphrase:   "test-passphrase",
+			SigningKey:   "test-signing-key",
+			PortfolioId:  DefaultPortfolioId,
+			SvcAccountId: "test-service-account",
+		},
+		markets:        make(map[string]Market),
+		commissionRate: decimal.RequireFromString(DefaultCommissionRate),
+		quoteTtl:       DefaultQuoteTtl,
+		orders:         make(map[string]*model.Order),
+		quotes:         make(map[string]*quote),
+	}
+	s.SetMarket("BTC-USD", "100000", "100010")
+	s.SetMarket("ETH-USD", "3000", "3000.50")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+apiPrefix+"/portfolios/{portfolio}/order", s.authenticate(s.handleCreateOrder))
+	mux.HandleFunc("POST "+apiPrefix+"/portfolios/{portfolio}/order_preview", s.authenticate(s.handleOrderPreview))
+	mux.HandleFunc("POST "+apiPrefix+"/portfolios/{portfolio}/rfq", s.authenticate(s.handleCreateQuote))
+	mux.HandleFunc("POST "+apiPrefix+"/portfolios/{portfolio}/accept_quote", s.authenticate(s.handleAcceptQuote))
+	mux.HandleFunc("GET "+apiPrefix+"/portfolios/{portfolio}/open_orders", s.authenticate(s.handleListOpenOrders))
+	mux.HandleFunc("GET "+apiPrefix+"/portfolios/{portfolio}/orders/{order}", s.authenticate(s.handleGetOrder))
+	mux.HandleFunc("POST "+apiPrefix+"/portfolios/{portfolio}/orders/{order}/cancel", s.authenticate(s.handleCancelOrder))
+	mux.HandleFunc("GET "+apiPrefix+"/portfolios/{portfolio}/commission", s.authenticate(s.handleGetCommission))
+
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// BaseUrl returns the REST base URL to configure clients with
+func (s *Server) BaseUrl() string {
+	return s.URL + apiPrefix
+}
+
+// Credentials returns the credentials the server accepts
+func (s *Server) Credentials() *credentials.Credentials {
+	return s.creds
+}
+
+// Configure points a configuration at the server and fills in matching credentials
+func (s *Server) Configure(cfg *config.Config) {
+	cfg.Prime.AccessKey = s.creds.AccessKey
+	cfg.Prime.Passphrase = s.creds.Passphrase
+	cfg.Prime.SigningKey = s.creds.SigningKey
+	cfg.Prime.Portfolio = s.creds.PortfolioId
+	cfg.Prime.ServiceAccountId = s.creds.SvcAccountId
+	cfg.Prime.RestUrl = s.BaseUrl()
+}
+
+// OrdersService returns a Prime SDK orders service wired to the server
+func (s *Server) OrdersService() orders.OrdersService {
+	httpClient := *s.Client()
+	restClient := client.NewRestClient(s.creds, httpClient).SetBaseUrl(s.BaseUrl())
+	return orders.NewOrdersService(restClient)
+}
+
+// CommissionService returns a Prime SDK commission service wired to the server
+func (s *Server) CommissionService() commission.CommissionService {
+	httpClient := *s.Client()
+	restClient := client.NewRestClient(s.creds, httpClient).SetBaseUrl(s.BaseUrl())
+	return commission.NewCommissionService(restClient)
+}
+
+// SetMarket sets the bid and ask used to fill orders for a product
+func (s *Server) SetMarket(product, bid, ask string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.markets[product] = Market{
+		Bid: decimal.RequireFromString(bid),
+		Ask: decimal.RequireFromString(ask),
+	}
+}
+
+// SetCommissionRate sets the Prime commission rate charged on fills (e.g., "0.001" = 10 bps)
+func (s *Server) SetCommissionRate(rate string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commissionRate = decimal.RequireFromString(rate)
+}
+
+// CommissionRequests returns how many times the commission endpoint has been called
+func (s *Server) CommissionRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commissionGets
+}
+
+// SetQuoteTtl sets how long RFQ quotes remain acceptable
+func (s *Server) SetQuoteTtl(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quoteTtl = ttl
+}
+
+// Order returns a copy of a stored order
+func (s *Server) Order(orderId string) (model.Order, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.orders[orderId]
+	if !ok {
+		return model.Order{}, false
+	}
+	return *order, true
+}
+
+// Orders returns copies of all stored orders
+func (s *Server) Orders() []model.Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]model.Order, 0, len(s.orders))
+	for _, order := range s.orders {
+		result = append(result, *order)
+	}
+	return result
+}
+
+// authenticate rejects requests whose Prime headers do not match the server's credentials
+// and requests addressed to a different portfolio
+func (s *Server) authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "failed to read body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		if r.Header.Get("X-CB-ACCESS-KEY") != s.creds.AccessKey ||
+			r.Header.Get("X-CB-ACCESS-PASSPHRASE") != s.creds.Passphrase {
+			writeError(w, http.StatusUnauthorized, "invalid api key")
+			return
+		}
+
+		timestamp := r.Header.Get("X-CB-ACCESS-TIMESTAMP")
+		if r.Header.Get("X-CB-ACCESS-SIGNATURE") != sign(r.Method, r.URL.Path, timestamp, s.creds.SigningKey, string(body)) {
+			writeError(w, http.StatusUnauthorized, "invalid signature")
+			return
+		}
+
+		if r.PathValue("portfolio") != s.creds.PortfolioId {
+			writeError(w, http.StatusNotFound, "portfolio not found")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (s *Server) handleOrderPreview(w http.ResponseWriter, r *http.Request) {
+	var req model.Order
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid order")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exec, err := s.execute(req.ProductId, req.Side, req.BaseQuantity, req.QuoteValue)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	preview := req
+	fillOrder(&preview, exec)
+	preview.Status = ""
+	writeJson(w, preview)
+}
+
+func (s *Server) handleCreateOrder(w http.ResponseWriter, r *http.Request) {
+	var req model.Order
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid order")
+		return
+	}
+	if req.ClientOrderId == "" {
+		writeError(w, http.StatusBadRequest, "client_order_id is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exec, err := s.execute(req.ProductId, req.Side, req.BaseQuantity, req.QuoteValue)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	order := req
+	order.Id = uuid.New().String()
+	order.PortfolioId = r.PathValue("portfolio")
+	order.Created = time.Now().UTC().Format(time.RFC3339)
+
+	// Limit orders that are not marketable rest on the book until cancelled
+	if req.Type == "LIMIT" && !isMarketable(req.Side, req.LimitPrice, exec.market) {
+		order.Status = "OPEN"
+		order.FilledQuantity = "0"
+		order.FilledValue = "0"
+	} else {
+		fillOrder(&order, exec)
+	}
+
+	s.orders[order.Id] = &order
+	writeJson(w, orders.CreateOrderResponse{OrderId: order.Id})
+}
+
+func (s *Server) handleCreateQuote(w http.ResponseWriter, r *http.Request) {
+	var req orders.CreateQuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid quote request")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	side := string(req.Side)
+	exec, err := s.execute(req.ProductId, side, req.BaseQuantity, req.QuoteValue)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !isMarketable(side, req.LimitPrice, exec.market) {
+		writeError(w, http.StatusBadRequest, "limit price is not marketable")
+		return
+	}
+
+	quoteId := uuid.New().String()
+	expiresAt := time.Now().Add(s.quoteTtl).UTC()
+	s.quotes[quoteId] = &quote{
+		portfolioId: req.PortfolioId,
+		productId:   req.ProductId,
+		side:        side,
+		execution:   exec,
+		expiresAt:   expiresAt,
+	}
+
+	writeJson(w, orders.CreateQuoteResponse{
+		QuoteId:              quoteId,
+		ExpirationTime:       expiresAt.Format(time.RFC3339),
+		BestPrice:            exec.price.String(),
+		OrderTotal:           exec.total.String(),
+		PriceInclusiveOfFees: exec.total.DivRound(exec.baseQty, 2).String(),
+	})
+}
+
+func (s *Server) handleAcceptQuote(w http.ResponseWriter, r *http.Request) {
+	var req orders.AcceptQuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid accept request")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, ok := s.quotes[req.QuoteId]
+	switch {
+	case !ok:
+		writeError(w, http.StatusNotFound, "quote not found")
+		return
+	case q.accepted:
+		writeError(w, http.StatusBadRequest, "quote already accepted")
+		return
+	case time.Now().After(q.expiresAt):
+		writeError(w, http.StatusBadRequest, "quote expired")
+		return
+	case q.productId != req.ProductId || !strings.EqualFold(q.side, req.Side):
+		writeError(w, http.StatusBadRequest, "quote does not match product or side")
+		return
+	}
+	q.accepted = true
+
+	order := model.Order{
+		Id:            uuid.New().String(),
+		PortfolioId:   q.portfolioId,
+		ClientOrderId: req.ClientOrderId,
+		ProductId:     q.productId,
+		Side:          strings.ToUpper(q.side),
+		Type:          "RFQ",
+		Created:       time.Now().UTC().Format(time.RFC3339),
+	}
+	fillOrder(&order, q.execution)
+
+	s.orders[order.Id] = &order
+	writeJson(w, orders.AcceptQuoteResponse{OrderId: order.Id})
+}
+
+func (s *Server) handleGetOrder(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[r.PathValue("order")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "order not found")
+		return
+	}
+
+	result := *order
+	writeJson(w, orders.GetOrderResponse{Order: &result})
+}
+
+func (s *Server) handleListOpenOrders(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	products := r.URL.Query()["product_ids"]
+	result := make([]*model.Order, 0)
+	for _, order := range s.orders {
+		if order.Status != "OPEN" {
+			continue
+		}
+		if len(products) > 0 && !slices.Contains(products, order.ProductId) {
+			continue
+		}
+		copied := *order
+		result = append(result, &copied)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Created != result[j].Created {
+			return result[i].Created < result[j].Created
+		}
+		return result[i].Id < result[j].Id
+	})
+
+	writeJson(w, orders.ListOpenOrdersResponse{Orders: result})
+}
+
+func (s *Server) handleCancelOrder(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[r.PathValue("order")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "order not found")
+		return
+	}
+	if order.Status != "OPEN" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("order is %s and cannot be cancelled", order.Status))
+		return
+	}
+	order.Status = "CANCELLED"
+
+	writeJson(w, orders.CancelOrderResponse{OrderId: order.Id})
+}
+
+func (s *Server) handleGetCommission(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.commissionGets++
+	writeJson(w, commission.GetPortfolioCommissionResponse{Commission: &model.Commission{
+		Type:          "ALL_IN",
+		Rate:          s.commissionRate.String(),
+		TradingVolume: "0",
+	}})
+}
+
+// execute prices an order against the current market
+// Commission is carved out of quote-denominated orders and added on top of base-denominated ones
+// Caller must hold s.mu
+func (s *Server) execute(productId, side, baseQuantity, quoteValue string) (*execution, error) {
+	market, ok := s.markets[productId]
+	if !ok {
+		return nil, fmt.Errorf("unknown product: %s", productId)
+	}
+
+	exec := &execution{market: market}
+	switch strings.ToUpper(side) {
+	case "BUY":
+		exec.price = market.Ask
+	case "SELL":
+		exec.price = market.Bid
+	default:
+		return nil, fmt.Errorf("invalid side: %s", side)
+	}
+
+	switch {
+	case baseQuantity != "" && quoteValue != "":
+		return nil, fmt.Errorf("only one of base_quantity or quote_value may be set")
+	case baseQuantity != "":
+		qty, err := decimal.NewFromString(baseQuantity)
+		if err != nil || !qty.IsPositive() {
+			return nil, fmt.Errorf("invalid base_quantity: %s", baseQuantity)
+		}
+		exec.baseQty = qty
+		exec.filled = qty.Mul(exec.price)
+		exec.commission = exec.filled.Mul(s.commissionRate).Round(2)
+		if strings.EqualFold(side, "BUY") {
+			exec.total = exec.filled.Add(exec.commission)
+		} else {
+			exec.total = exec.filled.Sub(exec.commission)
+		}
+	case quoteValue != "":
+		value, err := decimal.NewFromString(quoteValue)
+		if err != nil || !value.IsPositive() {
+			return nil, fmt.Errorf("invalid quote_value: %s", quoteValue)
+		}
+		exec.commission = value.Mul(s.commissionRate).Round(2)
+		exec.filled = value.Sub(exec.commission)
+		exec.baseQty = exec.filled.DivRound(exec.price, 8)
+		exec.total = value
+	default:
+		return nil, fmt.Errorf("one of base_quantity or quote_value is required")
+	}
+
+	return exec, nil
+}
+
+// fillOrder records a complete fill on an order
+func fillOrder(order *model.Order, exec *execution) {
+	order.Status = "FILLED"
+	order.BaseQuantity = exec.baseQty.String()
+	order.FilledQuantity = exec.baseQty.String()
+	order.FilledValue = exec.filled.String()
+	order.AverageFilledPrice = exec.price.String()
+	order.Commission = exec.commission.String()
+	order.Total = exec.total.String()
+	order.BestBid = exec.market.Bid.String()
+	order.BestAsk = exec.market.Ask.String()
+}
+
+// isMarketable reports whether a limit price would cross the market (an empty limit always does)
+func isMarketable(side, limitPrice string, market Market) bool {
+	if limitPrice == "" {
+		return true
+	}
+	limit, err := decimal.NewFromString(limitPrice)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(side, "BUY") {
+		return limit.GreaterThanOrEqual(market.Ask)
+	}
+	return limit.LessThanOrEqual(market.Bid)
+}
+
+// sign reproduces Prime's REST request signature
+func sign(method, path, timestamp, signingKey, body string) string {
+	h := hmac.New(sha256.New, []byte(signingKey))
+	h.Write([]byte(timestamp + method + path + body))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func writeJson(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
@@ -0,0 +1,209 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package primetest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/coinbase-samples/prime-sdk-go/client"
+	"github.com/coinbase-samples/prime-sdk-go/model"
+	"github.com/coinbase-samples/prime-sdk-go/orders"
+)
+
+func TestServer_OrderLifecycle(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	svc := server.OrdersService()
+
+	preview, err := svc.CreateOrderPreview(ctx, &orders.CreateOrderRequest{Order: &model.Order{
+		PortfolioId: DefaultPortfolioId,
+		ProductId:   "BTC-USD",
+		Side:        "BUY",
+		Type:        "MARKET",
+		QuoteValue:  "1000",
+	}})
+	if err != nil {
+		t.Fatalf("CreateOrderPreview() error = %v", err)
+	}
+	if preview.Order.AverageFilledPrice != "100010" {
+		t.Errorf("AverageFilledPrice = %s, want 100010", preview.Order.AverageFilledPrice)
+	}
+	if preview.Order.Commission != "1" {
+		t.Errorf("Commission = %s, want 1", preview.Order.Commission)
+	}
+	if preview.Order.BestBid != "100000" || preview.Order.BestAsk != "100010" {
+		t.Errorf("best bid/ask = %s/%s, want 100000/100010", preview.Order.BestBid, preview.Order.BestAsk)
+	}
+
+	// A non-marketable limit order rests until cancelled
+	created, err := svc.CreateOrder(ctx, &orders.CreateOrderRequest{Order: &model.Order{
+		PortfolioId:   DefaultPortfolioId,
+		ClientOrderId: "client-1",
+		ProductId:     "BTC-USD",
+		Side:          "BUY",
+		Type:          "LIMIT",
+		BaseQuantity:  "0.5",
+		LimitPrice:    "90000",
+	}})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	got, err := svc.GetOrder(ctx, &orders.GetOrderRequest{PortfolioId: DefaultPortfolioId, OrderId: created.OrderId})
+	if err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+	if got.Order.Status != "OPEN" {
+		t.Errorf("Status = %s, want OPEN", got.Order.Status)
+	}
+
+	cancelled, err := svc.CancelOrder(ctx, &orders.CancelOrderRequest{PortfolioId: DefaultPortfolioId, OrderId: created.OrderId})
+	if err != nil {
+		t.Fatalf("CancelOrder() error = %v", err)
+	}
+	if cancelled.OrderId != created.OrderId {
+		t.Errorf("cancelled order id = %s, want %s", cancelled.OrderId, created.OrderId)
+	}
+	if order, _ := server.Order(created.OrderId); order.Status != "CANCELLED" {
+		t.Errorf("Status after cancel = %s, want CANCELLED", order.Status)
+	}
+
+	// Cancelling again is rejected
+	if _, err := svc.CancelOrder(ctx, &orders.CancelOrderRequest{PortfolioId: DefaultPortfolioId, OrderId: created.OrderId}); err == nil {
+		t.Error("expected error cancelling a cancelled order")
+	}
+}
+
+func TestServer_QuoteLifecycle(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	svc := server.OrdersService()
+
+	quote, err := svc.CreateQuoteRequest(ctx, &orders.CreateQuoteRequest{
+		PortfolioId:   DefaultPortfolioId,
+		ProductId:     "ETH-USD",
+		Side:          model.OrderSide("SELL"),
+		ClientQuoteId: "quote-1",
+		BaseQuantity:  "2",
+		LimitPrice:    "2900",
+	})
+	if err != nil {
+		t.Fatalf("CreateQuoteRequest() error = %v", err)
+	}
+	if quote.BestPrice != "3000" {
+		t.Errorf("BestPrice = %s, want 3000", quote.BestPrice)
+	}
+	if quote.OrderTotal != "5994" {
+		t.Errorf("OrderTotal = %s, want 5994", quote.OrderTotal)
+	}
+
+	accepted, err := svc.AcceptQuote(ctx, &orders.AcceptQuoteRequest{
+		PortfolioId:   DefaultPortfolioId,
+		ProductId:     "ETH-USD",
+		Side:          "SELL",
+		ClientOrderId: "client-1",
+		QuoteId:       quote.QuoteId,
+	})
+	if err != nil {
+		t.Fatalf("AcceptQuote() error = %v", err)
+	}
+	if order, ok := server.Order(accepted.OrderId); !ok || order.Status != "FILLED" {
+		t.Errorf("accepted order = %+v, want FILLED", order)
+	}
+
+	// Quotes can only be accepted once
+	if _, err := svc.AcceptQuote(ctx, &orders.AcceptQuoteRequest{
+		PortfolioId: DefaultPortfolioId,
+		ProductId:   "ETH-USD",
+		Side:        "SELL",
+		QuoteId:     quote.QuoteId,
+	}); err == nil {
+		t.Error("expected error accepting a quote twice")
+	}
+
+	// Limit prices through the market are rejected
+	_, err = svc.CreateQuoteRequest(ctx, &orders.CreateQuoteRequest{
+		PortfolioId:  DefaultPortfolioId,
+		ProductId:    "ETH-USD",
+		Side:         model.OrderSide("SELL"),
+		BaseQuantity: "2",
+		LimitPrice:   "3100",
+	})
+	if err == nil || !strings.Contains(err.Error(), "not marketable") {
+		t.Errorf("expected not marketable error, got %v", err)
+	}
+}
+
+func TestServer_RejectsBadRequests(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	// Wrong signing key
+	creds := *server.Credentials()
+	creds.SigningKey = "wrong-key"
+	restClient := client.NewRestClient(&creds, *server.Client()).SetBaseUrl(server.BaseUrl())
+	_, err := orders.NewOrdersService(restClient).GetOrder(ctx, &orders.GetOrderRequest{PortfolioId: DefaultPortfolioId, OrderId: "missing"})
+	if err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Errorf("expected invalid signature error, got %v", err)
+	}
+
+	svc := server.OrdersService()
+	tests := []struct {
+		name        string
+		order       *model.Order
+		errContains string
+	}{
+		{
+			name:        "unknown product",
+			order:       &model.Order{PortfolioId: DefaultPortfolioId, ClientOrderId: "c", ProductId: "DOGE-USD", Side: "BUY", Type: "MARKET", QuoteValue: "10"},
+			errContains: "unknown product",
+		},
+		{
+			name:        "other portfolio",
+			order:       &model.Order{PortfolioId: "other", ClientOrderId: "c", ProductId: "BTC-USD", Side: "BUY", Type: "MARKET", QuoteValue: "10"},
+			errContains: "portfolio not found",
+		},
+		{
+			name:        "missing size",
+			order:       &model.Order{PortfolioId: DefaultPortfolioId, ClientOrderId: "c", ProductId: "BTC-USD", Side: "BUY", Type: "MARKET"},
+			errContains: "is required",
+		},
+		{
+			name:        "missing client order id",
+			order:       &model.Order{PortfolioId: DefaultPortfolioId, ProductId: "BTC-USD", Side: "BUY", Type: "MARKET", QuoteValue: "10"},
+			errContains: "client_order_id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateOrder(ctx, &orders.CreateOrderRequest{Order: tt.order})
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("CreateOrder() error = %v, want containing %q", err, tt.errContains)
+			}
+		})
+	}
+
+}
@@ -34,6 +34,7 @@ type RfqService struct {
 	primeClient   orders.OrdersService
 	portfolioId   string
 	priceAdjuster *common.PriceAdjuster
+	tradingGate   common.TradingGate // Optional; consulted before quoting or accepting
 }
 
 // NewRfqService creates a new RFQ service
@@ -45,12 +46,31 @@ func NewRfqService(cfg *config.Config, priceAdjuster *common.PriceAdjuster, prim
 	}
 }
 
+// SetTradingGate makes quoting and accepting refuse products the gate rejects, e.g. on unhealthy market data
+func (s *RfqService) SetTradingGate(gate common.TradingGate) {
+	s.tradingGate = gate
+}
+
+// checkTrading returns an error when the trading gate refuses the product
+func (s *RfqService) checkTrading(product string) error {
+	if s.tradingGate == nil {
+		return nil
+	}
+	if err := s.tradingGate.CheckTrading(product); err != nil {
+		return fmt.Errorf("trading halted: %w", err)
+	}
+	return nil
+}
+
 // CreateQuote creates an RFQ quote with fee markup applied
 func (s *RfqService) CreateQuote(ctx context.Context, req common.RfqRequest) (*common.RfqResponse, error) {
 	// Validate request
 	if err := common.ValidateRfqRequest(req); err != nil {
 		return nil, err
 	}
+	if err := s.checkTrading(req.Product); err != nil {
+		return nil, err
+	}
 
 	// Build Prime RFQ request with fee adjustments
 	primeReq, originalAmount, feeAmount := s.buildPrimeQuoteRequest(req)
@@ -76,6 +96,10 @@ func (s *RfqService) CreateQuote(ctx context.Context, req common.RfqRequest) (*c
 
 // AcceptQuote accepts an RFQ quote
 func (s *RfqService) AcceptQuote(ctx context.Context, req common.AcceptRfqRequest) (*common.AcceptRfqResponse, error) {
+	if err := s.checkTrading(req.Product); err != nil {
+		return nil, err
+	}
+
 	// Generate client order ID if not provided
 	clientOrderId := req.ClientOrderId
 	if clientOrderId == "" {
@@ -0,0 +1,161 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rfq
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/prime/primetest"
+	"github.com/shopspring/decimal"
+)
+
+func TestCreateAndAcceptQuote(t *testing.T) {
+	server := primetest.NewServer()
+	defer server.Close()
+
+	cfg := &config.Config{}
+	server.Configure(cfg)
+
+	feeStrategy, err := common.CreateFeeStrategy("0.005")
+	if err != nil {
+		t.Fatalf("CreateFeeStrategy() error = %v", err)
+	}
+	service := NewRfqService(cfg, common.NewPriceAdjuster(feeStrategy), server.OrdersService())
+
+	quote, err := service.CreateQuote(context.Background(), common.RfqRequest{
+		Product:    "BTC-USD",
+		Side:       "BUY",
+		QuoteValue: decimal.NewFromInt(1000),
+		LimitPrice: decimal.NewFromInt(101000),
+		Unit:       "quote",
+	})
+	if err != nil {
+		t.Fatalf("CreateQuote() error = %v", err)
+	}
+
+	// Markup is held upfront, so Prime quotes the remaining 995
+	if quote.CustomFeeOverlay.FeeAmount != "5" {
+		t.Errorf("FeeAmount = %s, want 5", quote.CustomFeeOverlay.FeeAmount)
+	}
+	if quote.RawPrimeQuote.OrderTotal != "995" {
+		t.Errorf("OrderTotal = %s, want 995", quote.RawPrimeQuote.OrderTotal)
+	}
+
+	accepted, err := service.AcceptQuote(context.Background(), common.AcceptRfqRequest{
+		QuoteId: quote.QuoteId,
+		Product: "BTC-USD",
+		Side:    "BUY",
+	})
+	if err != nil {
+		t.Fatalf("AcceptQuote() error = %v", err)
+	}
+	if accepted.ClientOrderId == "" {
+		t.Error("expected generated client order id")
+	}
+
+	order, ok := server.Order(accepted.OrderId)
+	if !ok || order.Status != "FILLED" {
+		t.Errorf("accepted order = %+v, want FILLED", order)
+	}
+}
+
+func TestCreateQuote_NonMarketableLimit(t *testing.T) {
+	server := primetest.NewServer()
+	defer server.Close()
+
+	cfg := &config.Config{}
+	server.Configure(cfg)
+
+	feeStrategy, _ := common.CreateFeeStrategy("0.005")
+	service := NewRfqService(cfg, common.NewPriceAdjuster(feeStrategy), server.OrdersService())
+
+	_, err := service.CreateQuote(context.Background(), common.RfqRequest{
+		Product:    "BTC-USD",
+		Side:       "BUY",
+		BaseQty:    decimal.RequireFromString("0.01"),
+		LimitPrice: decimal.NewFromInt(90000),
+		Unit:       "base",
+	})
+	if err == nil {
+		t.Error("CreateQuote() expected error for non-marketable limit price")
+	}
+}
+
+// haltedGate refuses trading on one product
+type haltedGate struct {
+	product string
+}
+
+func (g haltedGate) CheckTrading(product string) error {
+	if product == g.product {
+		return errors.New("market data unhealthy")
+	}
+	return nil
+}
+
+func TestTradingGate(t *testing.T) {
+	server := primetest.NewServer()
+	defer server.Close()
+
+	cfg := &config.Config{}
+	server.Configure(cfg)
+
+	feeStrategy, _ := common.CreateFeeStrategy("0.005")
+	service := NewRfqService(cfg, common.NewPriceAdjuster(feeStrategy), server.OrdersService())
+
+	quote, err := service.CreateQuote(context.Background(), common.RfqRequest{
+		Product:    "BTC-USD",
+		Side:       "BUY",
+		QuoteValue: decimal.NewFromInt(1000),
+		LimitPrice: decimal.NewFromInt(101000),
+		Unit:       "quote",
+	})
+	if err != nil {
+		t.Fatalf("CreateQuote() error = %v", err)
+	}
+
+	// Market data turns unhealthy between quoting and accepting
+	service.SetTradingGate(haltedGate{product: "BTC-USD"})
+
+	_, err = service.AcceptQuote(context.Background(), common.AcceptRfqRequest{
+		QuoteId: quote.QuoteId,
+		Product: "BTC-USD",
+		Side:    "BUY",
+	})
+	if err == nil || !strings.Contains(err.Error(), "trading halted") {
+		t.Fatalf("AcceptQuote() error = %v, want trading halted", err)
+	}
+	if len(server.Orders()) != 0 {
+		t.Errorf("server received %d orders, want none", len(server.Orders()))
+	}
+
+	_, err = service.CreateQuote(context.Background(), common.RfqRequest{
+		Product:    "BTC-USD",
+		Side:       "BUY",
+		QuoteValue: decimal.NewFromInt(1000),
+		LimitPrice: decimal.NewFromInt(101000),
+		Unit:       "quote",
+	})
+	if err == nil || !strings.Contains(err.Error(), "trading halted") {
+		t.Errorf("CreateQuote() error = %v, want trading halted", err)
+	}
+}
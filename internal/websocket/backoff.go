@@ -0,0 +1,156 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Default reconnect backoff settings, applied to zero-valued BackoffConfig fields
+const (
+	DefaultReconnectDelay    = 1 * time.Second
+	DefaultMaxReconnectDelay = 60 * time.Second
+	DefaultBackoffMultiplier = 2.0
+	DefaultBackoffJitter     = 0.2
+	DefaultStableAfter       = 30 * time.Second
+)
+
+// BackoffConfig controls how reconnect delays grow after consecutive failures
+// The first delay is the client's ReconnectDelay; each further failure multiplies it until MaxDelay
+type BackoffConfig struct {
+	MaxDelay    time.Duration // Upper bound on any single delay
+	Multiplier  float64       // Growth factor per consecutive failure (e.g., 2 doubles the delay)
+	Jitter      float64       // Fraction of each delay randomized away (0-1) so clients don't reconnect in lockstep
+	NoJitter    bool          // Use exact delays; Jitter is ignored (e.g., for deterministic tests)
+	MaxAttempts int           // Consecutive failures before giving up; 0 retries forever
+	StableAfter time.Duration // A connection that lasts this long resets the backoff
+}
+
+// withDefaults fills zero-valued fields with the package defaults
+func (b BackoffConfig) withDefaults() BackoffConfig {
+	if b.MaxDelay <= 0 {
+		b.MaxDelay = DefaultMaxReconnectDelay
+	}
+	if b.Multiplier < 1 {
+		b.Multiplier = DefaultBackoffMultiplier
+	}
+	switch {
+	case b.NoJitter:
+		b.Jitter = 0
+	case b.Jitter <= 0 || b.Jitter > 1:
+		b.Jitter = DefaultBackoffJitter
+	}
+	if b.StableAfter <= 0 {
+		b.StableAfter = DefaultStableAfter
+	}
+	return b
+}
+
+// backoff tracks consecutive reconnect failures and computes the next delay
+// It is only used from the client's run goroutine, so it needs no locking
+type backoff struct {
+	config   BackoffConfig
+	initial  time.Duration
+	attempts int
+	random   func() float64
+}
+
+func newBackoff(initial time.Duration, config BackoffConfig) *backoff {
+	if initial <= 0 {
+		initial = DefaultReconnectDelay
+	}
+	return &backoff{
+		config:  config.withDefaults(),
+		initial: initial,
+		random:  rand.Float64,
+	}
+}
+
+// Next records a failure and returns the delay before the next attempt
+// Returns false once MaxAttempts consecutive failures have been reached
+func (b *backoff) Next() (time.Duration, bool) {
+	b.attempts++
+	if b.config.MaxAttempts > 0 && b.attempts > b.config.MaxAttempts {
+		return 0, false
+	}
+
+	delay := float64(b.initial) * math.Pow(b.config.Multiplier, float64(b.attempts-1))
+	if delay > float64(b.config.MaxDelay) {
+		delay = float64(b.config.MaxDelay)
+	}
+
+	// Randomize away up to Jitter of the delay so the result never exceeds MaxDelay
+	delay -= delay * b.config.Jitter * b.random()
+	return time.Duration(delay), true
+}
+
+// Reset clears the failure count after a stable connection
+func (b *backoff) Reset() {
+	b.attempts = 0
+}
+
+// Attempts returns the number of consecutive failures recorded
+func (b *backoff) Attempts() int {
+	return b.attempts
+}
+
+// ============================================================================
+// Connection State
+// ============================================================================
+
+// ConnectionState describes where a websocket client is in its connect/reconnect cycle
+type ConnectionState int32
+
+const (
+	StateConnecting ConnectionState = iota
+	StateConnected
+	StateReconnecting
+	StateGaveUp
+	StateFailed // Stopped by a fatal error (e.g., rejected credentials); see StateChange.Err
+	StateStopped
+)
+
+// String returns a human-readable state name
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateGaveUp:
+		return "gave_up"
+	case StateFailed:
+		return "failed"
+	case StateStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// StateChange is delivered to OnStateChange callbacks on every state transition
+type StateChange struct {
+	Channel string
+	State   ConnectionState
+	Attempt int           // Consecutive failed attempts so far
+	Delay   time.Duration // Wait before the next attempt (StateReconnecting only)
+	Err     error         // Cause of the reconnect, or why the client gave up or failed
+}
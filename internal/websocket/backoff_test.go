@@ -0,0 +1,230 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoff_Next(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     BackoffConfig
+		random     float64
+		wantDelays []time.Duration
+		wantGiveUp bool
+	}{
+		{
+			name:       "doubles without jitter draw",
+			config:     BackoffConfig{MaxDelay: time.Minute},
+			random:     0,
+			wantDelays: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		{
+			name:       "capped at max delay",
+			config:     BackoffConfig{MaxDelay: 3 * time.Second},
+			random:     0,
+			wantDelays: []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second},
+		},
+		{
+			name:       "full jitter draw removes jitter fraction",
+			config:     BackoffConfig{MaxDelay: time.Minute, Jitter: 0.5},
+			random:     1,
+			wantDelays: []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second},
+		},
+		{
+			name:       "unset jitter uses the default",
+			config:     BackoffConfig{MaxDelay: time.Minute},
+			random:     1,
+			wantDelays: []time.Duration{800 * time.Millisecond, 1600 * time.Millisecond},
+		},
+		{
+			name:       "jitter can be turned off",
+			config:     BackoffConfig{MaxDelay: time.Minute, Jitter: 0.5, NoJitter: true},
+			random:     1,
+			wantDelays: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:       "custom multiplier",
+			config:     BackoffConfig{MaxDelay: time.Minute, Multiplier: 3},
+			random:     0,
+			wantDelays: []time.Duration{time.Second, 3 * time.Second, 9 * time.Second},
+		},
+		{
+			name:       "gives up after max attempts",
+			config:     BackoffConfig{MaxDelay: time.Minute, MaxAttempts: 2},
+			random:     0,
+			wantDelays: []time.Duration{time.Second, 2 * time.Second},
+			wantGiveUp: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBackoff(time.Second, tt.config)
+			b.random = func() float64 { return tt.random }
+
+			for i, want := range tt.wantDelays {
+				got, ok := b.Next()
+				if !ok {
+					t.Fatalf("attempt %d: Next() gave up early", i+1)
+				}
+				if got != want {
+					t.Errorf("attempt %d: delay = %v, want %v", i+1, got, want)
+				}
+			}
+
+			if _, ok := b.Next(); ok == tt.wantGiveUp {
+				t.Errorf("Next() after %d attempts ok = %v, want %v", len(tt.wantDelays), ok, !tt.wantGiveUp)
+			}
+		})
+	}
+}
+
+func TestBackoff_JitterStaysWithinBounds(t *testing.T) {
+	b := newBackoff(time.Second, BackoffConfig{MaxDelay: 10 * time.Second, Jitter: 0.3})
+
+	for i := 0; i < 100; i++ {
+		delay, _ := b.Next()
+		if delay > 10*time.Second {
+			t.Fatalf("delay %v exceeds max delay", delay)
+		}
+		if b.Attempts() >= 5 && delay < 7*time.Second {
+			t.Fatalf("capped delay %v jittered below 70%% of max", delay)
+		}
+	}
+}
+
+func TestBackoff_Reset(t *testing.T) {
+	b := newBackoff(time.Second, BackoffConfig{MaxDelay: time.Minute, MaxAttempts: 2})
+	b.random = func() float64 { return 0 }
+
+	b.Next()
+	b.Next()
+	b.Reset()
+
+	delay, ok := b.Next()
+	if !ok || delay != time.Second {
+		t.Errorf("Next() after Reset = (%v, %v), want (1s, true)", delay, ok)
+	}
+}
+
+// stateRecorder collects state changes delivered to OnStateChange
+type stateRecorder struct {
+	mu      sync.Mutex
+	changes []StateChange
+}
+
+func (r *stateRecorder) record(change StateChange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = append(r.changes, change)
+}
+
+func (r *stateRecorder) states() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	states := make([]string, 0, len(r.changes))
+	for _, c := range r.changes {
+		states = append(states, c.State.String())
+	}
+	return states
+}
+
+func (r *stateRecorder) last() StateChange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.changes[len(r.changes)-1]
+}
+
+func TestBaseWebSocketClient_GivesUpAfterMaxAttempts(t *testing.T) {
+	// A server that refuses every websocket upgrade
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	recorder := &stateRecorder{}
+	client := NewMarketDataClient(MarketDataConfig{
+		CommonConfig: CommonConfig{
+			Url:            "ws" + strings.TrimPrefix(srv.URL, "http"),
+			Products:       []string{"BTC-USD"},
+			ReconnectDelay: time.Millisecond,
+			Backoff:        BackoffConfig{MaxDelay: 5 * time.Millisecond, MaxAttempts: 3},
+			OnStateChange:  recorder.record,
+		},
+	}, NewOrderBookStore())
+
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer client.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for client.State() != StateGaveUp && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	want := "connecting,reconnecting,connecting,reconnecting,connecting,reconnecting,connecting,gave_up"
+	if got := strings.Join(recorder.states(), ","); got != want {
+		t.Errorf("states = %s, want %s", got, want)
+	}
+
+	last := recorder.last()
+	if last.Err == nil || !strings.Contains(last.Err.Error(), "gave up after 3") {
+		t.Errorf("give-up error = %v, want it to mention the attempt limit", last.Err)
+	}
+	if last.Channel != "l2_data" {
+		t.Errorf("Channel = %q, want l2_data", last.Channel)
+	}
+}
+
+func TestBaseWebSocketClient_StopWhileWaiting(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := NewMarketDataClient(MarketDataConfig{
+		CommonConfig: CommonConfig{
+			Url:            "ws" + strings.TrimPrefix(srv.URL, "http"),
+			Products:       []string{"BTC-USD"},
+			ReconnectDelay: time.Hour,
+		},
+	}, NewOrderBookStore())
+
+	client.Start(context.Background())
+
+	deadline := time.Now().Add(5 * time.Second)
+	for client.State() != StateReconnecting && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	client.Stop()
+
+	for client.State() != StateStopped && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if client.State() != StateStopped {
+		t.Errorf("State() = %s, want stopped", client.State())
+	}
+}
@@ -0,0 +1,260 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/database"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// Candle price sources
+const (
+	CandleSourceMid = "mid" // Raw Prime mid price
+	CandleSourceBid = "bid" // Best bid with the markup (and commission, when set) deducted
+	CandleSourceAsk = "ask" // Best ask with the markup (and commission, when set) added
+)
+
+// CandleSources lists every source the aggregator builds
+var CandleSources = []string{CandleSourceMid, CandleSourceBid, CandleSourceAsk}
+
+// DefaultCandleIntervals are the intervals built when CandleConfig.Intervals is empty
+var DefaultCandleIntervals = []time.Duration{time.Minute, 5 * time.Minute, time.Hour}
+
+// DefaultCandleRetention is how many candles per series are kept in memory when CandleConfig.Retain is zero
+const DefaultCandleRetention = 1440
+
+// CandleConfig sets which candles are built
+type CandleConfig struct {
+	Intervals []time.Duration       // Candle lengths; defaults to DefaultCandleIntervals
+	Adjuster  *common.PriceAdjuster // Fee-adjusts the bid and ask sources
+	Retain    int                   // Candles kept in memory per product, source and interval
+}
+
+// CandleWriter persists candles; database.OrdersDb implements it
+type CandleWriter interface {
+	UpsertCandles(candles []database.CandleRecord) error
+}
+
+// candleKey identifies one candle series
+type candleKey struct {
+	product  string
+	source   string
+	interval time.Duration
+}
+
+// candleSeries holds a series' finished candles and the one forming
+type candleSeries struct {
+	closed  []common.Candle
+	current *common.Candle
+	dirty   bool // current changed, or candles closed, since the last Persist
+	pending []common.Candle
+}
+
+// CandleAggregator builds OHLC candles incrementally from order book updates
+// Register it with OrderBookStore.AddUpdateListener(aggregator.OnBookUpdate); no extra Prime requests are made
+type CandleAggregator struct {
+	cfg CandleConfig
+
+	mu     sync.Mutex
+	series map[candleKey]*candleSeries
+}
+
+// NewCandleAggregator creates an aggregator with defaults filled in
+func NewCandleAggregator(cfg CandleConfig) *CandleAggregator {
+	if len(cfg.Intervals) == 0 {
+		cfg.Intervals = DefaultCandleIntervals
+	}
+	if cfg.Retain <= 0 {
+		cfg.Retain = DefaultCandleRetention
+	}
+	return &CandleAggregator{cfg: cfg, series: make(map[candleKey]*candleSeries)}
+}
+
+// OnBookUpdate folds the book's current top of book into every candle series for its product
+// Updates with an empty side are ignored
+func (a *CandleAggregator) OnBookUpdate(book *OrderBook) {
+	state := book.state.Load()
+	if len(state.bids) == 0 || len(state.asks) == 0 {
+		return
+	}
+	a.Observe(book.Product, state.bids[0].Price, state.asks[0].Price, state.updateTime)
+}
+
+// Observe records a best bid and ask for product at time at
+func (a *CandleAggregator) Observe(product string, bid, ask decimal.Decimal, at time.Time) {
+	prices := map[string]decimal.Decimal{CandleSourceMid: common.CalculateMidPrice(bid, ask)}
+	if a.cfg.Adjuster != nil {
+		prices[CandleSourceBid] = a.cfg.Adjuster.AllInBidPrice(bid)
+		prices[CandleSourceAsk] = a.cfg.Adjuster.AllInAskPrice(ask)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for source, price := range prices {
+		for _, interval := range a.cfg.Intervals {
+			key := candleKey{product: product, source: source, interval: interval}
+			series, ok := a.series[key]
+			if !ok {
+				series = &candleSeries{}
+				a.series[key] = series
+			}
+			a.observe(key, series, price, at)
+		}
+	}
+}
+
+// observe updates one series, closing its current candle when at falls in a later interval
+func (a *CandleAggregator) observe(key candleKey, series *candleSeries, price decimal.Decimal, at time.Time) {
+	start := at.Truncate(key.interval)
+	current := series.current
+
+	// An update from before the current candle (clock step back) is folded into the current candle
+	if current != nil && start.After(current.Start) {
+		series.closed = appendRetained(series.closed, *current, a.cfg.Retain)
+		series.pending = appendRetained(series.pending, *current, a.cfg.Retain)
+		current = nil
+	}
+
+	if current == nil {
+		series.current = &common.Candle{
+			Product:  key.product,
+			Source:   key.source,
+			Interval: key.interval,
+			Start:    start,
+			Open:     price,
+			High:     price,
+			Low:      price,
+			Close:    price,
+		}
+		series.dirty = true
+		return
+	}
+
+	if price.GreaterThan(current.High) {
+		current.High = price
+	}
+	if price.LessThan(current.Low) {
+		current.Low = price
+	}
+	current.Close = price
+	series.dirty = true
+}
+
+// Candles returns a product's candles for one source and interval, oldest first, including the one still forming
+func (a *CandleAggregator) Candles(product, source string, interval time.Duration) []common.Candle {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	series, ok := a.series[candleKey{product: product, source: source, interval: interval}]
+	if !ok {
+		return nil
+	}
+	candles := append([]common.Candle(nil), series.closed...)
+	if series.current != nil {
+		candles = append(candles, *series.current)
+	}
+	return candles
+}
+
+// Persist writes candles closed since the last call, and every candle still forming that changed, to writer
+// On failure the candles are kept and retried on the next call
+func (a *CandleAggregator) Persist(writer CandleWriter) error {
+	a.mu.Lock()
+	var records []database.CandleRecord
+	taken := make(map[candleKey][]common.Candle)
+	for key, series := range a.series {
+		if !series.dirty {
+			continue
+		}
+		for _, candle := range series.pending {
+			records = append(records, candleRecord(candle))
+		}
+		if series.current != nil {
+			records = append(records, candleRecord(*series.current))
+		}
+		taken[key] = series.pending
+		series.pending = nil
+		series.dirty = false
+	}
+	a.mu.Unlock()
+
+	if len(records) == 0 {
+		return nil
+	}
+	if err := writer.UpsertCandles(records); err != nil {
+		a.mu.Lock()
+		for key, pending := range taken {
+			series := a.series[key]
+			series.pending = append(pending, series.pending...)
+			series.dirty = true
+		}
+		a.mu.Unlock()
+		return fmt.Errorf("failed to persist candles: %w", err)
+	}
+	return nil
+}
+
+// Run persists candles to writer every interval until ctx is cancelled, then once more
+func (a *CandleAggregator) Run(ctx context.Context, writer CandleWriter, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := a.Persist(writer); err != nil {
+				zap.L().Warn("Failed to persist candles", zap.Error(err))
+			}
+			return
+		case <-ticker.C:
+			if err := a.Persist(writer); err != nil {
+				zap.L().Warn("Failed to persist candles", zap.Error(err))
+			}
+		}
+	}
+}
+
+// appendRetained appends candle and drops the oldest candles beyond retain
+func appendRetained(candles []common.Candle, candle common.Candle, retain int) []common.Candle {
+	candles = append(candles, candle)
+	if len(candles) > retain {
+		candles = append(candles[:0:0], candles[len(candles)-retain:]...)
+	}
+	return candles
+}
+
+// candleRecord converts a candle for storage
+func candleRecord(candle common.Candle) database.CandleRecord {
+	return database.CandleRecord{
+		ProductId: candle.Product,
+		Source:    candle.Source,
+		Interval:  candle.Interval,
+		StartTime: candle.Start,
+		Open:      candle.Open.String(),
+		High:      candle.High.String(),
+		Low:       candle.Low.String(),
+		Close:     candle.Close.String(),
+	}
+}
@@ -0,0 +1,194 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/database"
+	"github.com/shopspring/decimal"
+)
+
+// memoryCandles collects what the aggregator persists
+type memoryCandles struct {
+	records []database.CandleRecord
+	err     error
+}
+
+func (m *memoryCandles) UpsertCandles(candles []database.CandleRecord) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.records = append(m.records, candles...)
+	return nil
+}
+
+func TestCandleAggregator_Observe(t *testing.T) {
+	aggregator := NewCandleAggregator(CandleConfig{Intervals: []time.Duration{time.Minute, 5 * time.Minute}})
+	base := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	// Mids: 100, 103, 99, 101 in the first minute, then 104 and 102 in the second
+	quotes := []struct {
+		offset   time.Duration
+		bid, ask int64
+	}{
+		{0, 99, 101},
+		{10 * time.Second, 102, 104},
+		{20 * time.Second, 98, 100},
+		{59 * time.Second, 100, 102},
+		{61 * time.Second, 103, 105},
+		{90 * time.Second, 101, 103},
+		{30 * time.Second, 96, 98}, // late update folds into the current candle
+	}
+	for _, q := range quotes {
+		aggregator.Observe("BTC-USD", decimal.NewFromInt(q.bid), decimal.NewFromInt(q.ask), base.Add(q.offset))
+	}
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		want     [][4]int64 // open, high, low, close
+	}{
+		{"1m", time.Minute, [][4]int64{{100, 103, 99, 101}, {104, 104, 97, 97}}},
+		{"5m", 5 * time.Minute, [][4]int64{{100, 104, 97, 97}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candles := aggregator.Candles("BTC-USD", CandleSourceMid, tt.interval)
+			if len(candles) != len(tt.want) {
+				t.Fatalf("Candles() returned %d candles, want %d", len(candles), len(tt.want))
+			}
+			for i, want := range tt.want {
+				candle := candles[i]
+				if !candle.Start.Equal(base.Add(time.Duration(i) * tt.interval)) {
+					t.Errorf("candle %d start = %v", i, candle.Start)
+				}
+				got := [4]int64{candle.Open.IntPart(), candle.High.IntPart(), candle.Low.IntPart(), candle.Close.IntPart()}
+				if got != want {
+					t.Errorf("candle %d OHLC = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+
+	if candles := aggregator.Candles("BTC-USD", CandleSourceBid, time.Minute); candles != nil {
+		t.Errorf("bid candles without an adjuster = %v, want none", candles)
+	}
+}
+
+func TestCandleAggregator_AdjustedSources(t *testing.T) {
+	adjuster := common.NewPriceAdjuster(common.NewFeeStrategy(decimal.RequireFromString("0.01")))
+	aggregator := NewCandleAggregator(CandleConfig{Adjuster: adjuster})
+	aggregator.Observe("BTC-USD", decimal.NewFromInt(100), decimal.NewFromInt(200), time.Now())
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{CandleSourceMid, "150"},
+		{CandleSourceBid, "99"},
+		{CandleSourceAsk, "202"},
+	}
+	for _, tt := range tests {
+		for _, interval := range DefaultCandleIntervals {
+			candles := aggregator.Candles("BTC-USD", tt.source, interval)
+			if len(candles) != 1 || !candles[0].Close.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("%s %s candles = %v, want close %s", tt.source, interval, candles, tt.want)
+			}
+		}
+	}
+}
+
+func TestCandleAggregator_Retain(t *testing.T) {
+	aggregator := NewCandleAggregator(CandleConfig{Intervals: []time.Duration{time.Minute}, Retain: 2})
+	base := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		aggregator.Observe("BTC-USD", decimal.NewFromInt(int64(i)), decimal.NewFromInt(int64(i)), base.Add(time.Duration(i)*time.Minute))
+	}
+
+	candles := aggregator.Candles("BTC-USD", CandleSourceMid, time.Minute)
+	if len(candles) != 3 || !candles[0].Start.Equal(base.Add(2*time.Minute)) {
+		t.Errorf("Candles() = %v, want the last 2 closed candles and the current one", candles)
+	}
+}
+
+func TestCandleAggregator_Persist(t *testing.T) {
+	aggregator := NewCandleAggregator(CandleConfig{Intervals: []time.Duration{time.Minute}})
+	base := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	writer := &memoryCandles{}
+
+	aggregator.Observe("BTC-USD", decimal.NewFromInt(100), decimal.NewFromInt(100), base)
+	if err := aggregator.Persist(writer); err != nil {
+		t.Fatalf("Persist() error = %v", err)
+	}
+	if len(writer.records) != 1 || writer.records[0].Close != "100" || writer.records[0].Source != CandleSourceMid {
+		t.Fatalf("first Persist() wrote %+v, want the forming candle", writer.records)
+	}
+
+	// Nothing changed, nothing written
+	writer.records = nil
+	if err := aggregator.Persist(writer); err != nil || len(writer.records) != 0 {
+		t.Fatalf("idle Persist() wrote %+v, err = %v", writer.records, err)
+	}
+
+	// A failed write keeps the closed candle for the next attempt
+	aggregator.Observe("BTC-USD", decimal.NewFromInt(110), decimal.NewFromInt(110), base.Add(30*time.Second))
+	aggregator.Observe("BTC-USD", decimal.NewFromInt(120), decimal.NewFromInt(120), base.Add(time.Minute))
+	writer.err = errors.New("disk full")
+	if err := aggregator.Persist(writer); err == nil {
+		t.Fatal("Persist() error = nil, want the write error")
+	}
+
+	writer.err = nil
+	if err := aggregator.Persist(writer); err != nil {
+		t.Fatalf("retry Persist() error = %v", err)
+	}
+	if len(writer.records) != 2 {
+		t.Fatalf("retry Persist() wrote %d candles, want the closed and the forming candle", len(writer.records))
+	}
+	if closed := writer.records[0]; !closed.StartTime.Equal(base) || closed.High != "110" || closed.Close != "110" {
+		t.Errorf("closed candle = %+v, want start %v high/close 110", closed, base)
+	}
+	if current := writer.records[1]; !current.StartTime.Equal(base.Add(time.Minute)) || current.Open != "120" {
+		t.Errorf("forming candle = %+v, want open 120", current)
+	}
+}
+
+func TestCandleAggregator_StoreListener(t *testing.T) {
+	store := NewOrderBookStore()
+	aggregator := NewCandleAggregator(CandleConfig{Intervals: []time.Duration{time.Hour}})
+	store.AddUpdateListener(aggregator.OnBookUpdate)
+
+	book := store.GetOrCreate("BTC-USD")
+	book.Update(nil, []common.PriceLevel{{Price: decimal.NewFromInt(101), Size: decimal.NewFromInt(1)}}, 1) // one-sided, ignored
+	book.Update(
+		[]common.PriceLevel{{Price: decimal.NewFromInt(99), Size: decimal.NewFromInt(1)}},
+		[]common.PriceLevel{{Price: decimal.NewFromInt(101), Size: decimal.NewFromInt(1)}},
+		2)
+	book.ApplyUpdates([]common.PriceLevel{{Price: decimal.NewFromInt(100), Size: decimal.NewFromInt(1)}}, nil, 3)
+
+	candles := aggregator.Candles("BTC-USD", CandleSourceMid, time.Hour)
+	if len(candles) != 1 {
+		t.Fatalf("Candles() = %v, want one candle", candles)
+	}
+	if !candles[0].Open.Equal(decimal.NewFromInt(100)) || !candles[0].Close.Equal(decimal.RequireFromString("100.5")) {
+		t.Errorf("candle = %+v, want open 100 close 100.5", candles[0])
+	}
+}
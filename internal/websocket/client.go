@@ -21,9 +21,13 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -37,13 +41,17 @@ type BaseConfig struct {
 	Passphrase       string
 	SigningKey       string
 	ServiceAccountId string
-	ReconnectDelay   time.Duration
+	ReconnectDelay   time.Duration     // Delay before the first reconnect attempt
+	Backoff          BackoffConfig     // Growth, jitter and give-up policy for later attempts
+	OnStateChange    func(StateChange) // Optional; called from the client goroutine on each state transition
+	HeartbeatTimeout time.Duration     // Reconnect if no heartbeat arrives within this window; 0 disables heartbeats
+	Recorder         *FrameRecorder    // Optional; records every raw frame received for later replay
 }
 
 // ChannelHandler processes messages for a specific channel
 type ChannelHandler interface {
 	// HandleMessage processes a message for this channel
-	HandleMessage(message map[string]interface{}) error
+	HandleMessage(message *Message) error
 
 	// GetChannelName returns the channel name (e.g., "l2_data", "orders")
 	GetChannelName() string
@@ -58,173 +66,679 @@ type ChannelHandler interface {
 }
 
 // BaseWebSocketClient manages a WebSocket connection with common functionality
+// Several channel handlers can share one connection; messages are routed by their channel field
 type BaseWebSocketClient struct {
-	config    BaseConfig
-	handler   ChannelHandler
-	conn      *websocket.Conn
-	ctx       context.Context
+	config   BaseConfig
+	handlers []ChannelHandler          // Subscribed in registration order
+	channels map[string]ChannelHandler // Routing table keyed by channel name
+	name     string                    // Channel names joined with "+", for logs and state changes
+	conn     *websocket.Conn
+	writeMu  sync.Mutex // Serializes writes and guards conn for goroutines other than run
+	backoff  *backoff
+	state    atomic.Int32
+
+	lifecycle sync.Mutex      // Serializes Start and Stop
+	started   atomic.Bool     // Set by the first Start or Stop; handlers can't be added afterwards
+	parent    context.Context // The caller's context passed to Start
+	ctx       context.Context // Cancelled by Stop or the caller; set before run starts
 	cancel    context.CancelFunc
-	reconnect bool
-}
+	workers   sync.WaitGroup // Queue workers still handling messages
+	done      chan struct{}  // Closed once the client has stopped and drained its queues
+	err       error          // Why the client stopped; written before done is closed
+
+	heartbeats   *heartbeatsHandler // Set when HeartbeatTimeout > 0
+	resubscribes int                // Consecutive resubscribes after transient errors; reset on confirmation
+	sequences    *sequenceTracker
+	gaps         map[string]*atomic.Uint64 // Per channel; populated before Start so reads need no lock
 
-// NewBaseWebSocketClient creates a new base WebSocket client
-func NewBaseWebSocketClient(config BaseConfig, handler ChannelHandler) *BaseWebSocketClient {
-	ctx, cancel := context.WithCancel(context.Background())
+	queues        map[string]*channelQueue // Channels whose handlers run on their own worker goroutine
+	resyncPending map[string]bool          // Channels that dropped messages on overflow; read goroutine only
+}
 
-	return &BaseWebSocketClient{
+// NewBaseWebSocketClient creates a new base WebSocket client for the given channel handlers
+func NewBaseWebSocketClient(config BaseConfig, handlers ...ChannelHandler) *BaseWebSocketClient {
+	client := &BaseWebSocketClient{
 		config:    config,
-		handler:   handler,
-		ctx:       ctx,
-		cancel:    cancel,
-		reconnect: true,
+		channels:  make(map[string]ChannelHandler),
+		done:      make(chan struct{}),
+		backoff:   newBackoff(config.ReconnectDelay, config.Backoff),
+		sequences: newSequenceTracker(),
+		gaps:      make(map[string]*atomic.Uint64),
+
+		queues:        make(map[string]*channelQueue),
+		resyncPending: make(map[string]bool),
+	}
+	for _, handler := range handlers {
+		if err := client.addHandler(handler); err != nil {
+			zap.L().Error("Ignoring channel handler", zap.Error(err))
+		}
+	}
+	return client
+}
+
+// addHandler registers a handler on the connection; only allowed before Start
+func (c *BaseWebSocketClient) addHandler(handler ChannelHandler) error {
+	channel := handler.GetChannelName()
+	if c.started.Load() {
+		return fmt.Errorf("cannot add %s handler after the connection has started", channel)
+	}
+	if _, exists := c.channels[channel]; exists || channel == ChannelHeartbeats {
+		return fmt.Errorf("channel %s is already handled on this connection", channel)
+	}
+
+	c.handlers = append(c.handlers, handler)
+	c.channels[channel] = handler
+	c.gaps[channel] = &atomic.Uint64{}
+	if queued, ok := handler.(queuedHandler); ok && queued.queueConfig().Size > 0 {
+		c.queues[channel] = newChannelQueue(channel, queued.queueConfig())
+	}
+
+	names := make([]string, len(c.handlers))
+	for i, h := range c.handlers {
+		names[i] = h.GetChannelName()
+	}
+	c.name = strings.Join(names, "+")
+	return nil
+}
+
+// enableHeartbeats subscribes to the heartbeats channel for the given products on every connection
+// Has no effect unless HeartbeatTimeout is set
+func (c *BaseWebSocketClient) enableHeartbeats(products []string) {
+	if c.config.HeartbeatTimeout > 0 {
+		c.heartbeats = newHeartbeatsHandler(products)
+	}
+}
+
+// addHeartbeatListener calls fn on the read goroutine for each heartbeat; only allowed before Start
+// Has no effect when heartbeats are disabled
+func (c *BaseWebSocketClient) addHeartbeatListener(fn func(time.Time)) {
+	if c.heartbeats == nil || c.started.Load() {
+		return
+	}
+	c.heartbeats.listeners = append(c.heartbeats.listeners, fn)
+}
+
+// LastHeartbeat returns when the last heartbeat was received, or the zero time if heartbeats are disabled
+func (c *BaseWebSocketClient) LastHeartbeat() time.Time {
+	if c.heartbeats == nil {
+		return time.Time{}
+	}
+	return c.heartbeats.LastHeartbeat()
+}
+
+// SequenceGaps returns how many sequence gaps have been detected across all channels since the client was created
+func (c *BaseWebSocketClient) SequenceGaps() uint64 {
+	var total uint64
+	for _, gaps := range c.gaps {
+		total += gaps.Load()
+	}
+	return total
+}
+
+// channelSequenceGaps returns how many sequence gaps have been detected on a single channel
+func (c *BaseWebSocketClient) channelSequenceGaps(channel string) uint64 {
+	if gaps, ok := c.gaps[channel]; ok {
+		return gaps.Load()
+	}
+	return 0
+}
+
+// queueStats returns metrics for a channel's work queue, if it has one
+func (c *BaseWebSocketClient) queueStats(channel string) (QueueStats, bool) {
+	queue, ok := c.queues[channel]
+	if !ok {
+		return QueueStats{}, false
 	}
+	return queue.Stats(), true
 }
 
-// Start begins the WebSocket connection and message processing
-func (c *BaseWebSocketClient) Start() error {
+// Start connects in the background and keeps the connection up until ctx is cancelled or Stop is called
+// Calling Start again has no effect, so clients sharing a connection may each start it; the first ctx wins
+func (c *BaseWebSocketClient) Start(ctx context.Context) error {
+	c.lifecycle.Lock()
+	defer c.lifecycle.Unlock()
+
+	if !c.started.CompareAndSwap(false, true) {
+		return nil
+	}
+	c.parent = ctx
+	c.ctx, c.cancel = context.WithCancel(ctx)
+
+	// Closing the connection unblocks the read loop; run never touches conn concurrently with this
+	context.AfterFunc(c.ctx, c.closeConn)
+
+	for channel, queue := range c.queues {
+		c.workers.Add(1)
+		go func(handler ChannelHandler, queue *channelQueue) {
+			defer c.workers.Done()
+			c.processQueue(handler, queue)
+		}(c.channels[channel], queue)
+	}
 	go c.run()
 	return nil
 }
 
-// Stop gracefully stops the WebSocket client
+// Stop shuts the client down and waits until messages already queued have been handled
+// Calling Stop again, whether or not the client was started, just waits for the first call to finish.
+// It must not be called from a handler or OnStateChange callback, which Stop would wait on
 func (c *BaseWebSocketClient) Stop() {
-	c.reconnect = false
-	c.cancel()
+	c.lifecycle.Lock()
+	if c.started.CompareAndSwap(false, true) {
+		// Never started: nothing is running or queued
+		c.lifecycle.Unlock()
+		close(c.done)
+		return
+	}
+	// cancel is nil when an earlier Stop ran before Start; done is already closed then
+	cancel := c.cancel
+	c.lifecycle.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	<-c.done
+}
+
+// Done returns a channel that's closed once the client has stopped and its queues have drained
+// The client stops when Stop is called, Start's ctx is cancelled, a fatal error occurs or reconnects give up
+func (c *BaseWebSocketClient) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns nil until Done is closed, then why the client stopped:
+// the fatal or give-up error, the ctx error if Start's ctx was cancelled, or nil after Stop
+func (c *BaseWebSocketClient) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// closeConn closes the current connection, if any
+func (c *BaseWebSocketClient) closeConn() {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
 	if c.conn != nil {
 		c.conn.Close()
 	}
 }
 
+// State returns the client's current connection state
+func (c *BaseWebSocketClient) State() ConnectionState {
+	return ConnectionState(c.state.Load())
+}
+
+// run maintains the connection, then drains the queues and marks the client done
 func (c *BaseWebSocketClient) run() {
-	for c.reconnect {
+	err := c.maintainConnection()
+
+	// Stop reading first so no new work arrives, then let the workers finish what's queued
+	c.cancel()
+	for _, queue := range c.queues {
+		queue.close()
+	}
+	c.workers.Wait()
+
+	if err == nil {
+		err = c.parent.Err() // nil when stopped with Stop
+		c.setState(StateChange{State: StateStopped, Attempt: c.backoff.Attempts()})
+	}
+	c.err = err
+	close(c.done)
+}
+
+// maintainConnection connects, subscribes and reads until the client is stopped, reconnecting as needed
+// Returns nil when stopped, or the error that ended the client (fatal or giving up)
+func (c *BaseWebSocketClient) maintainConnection() error {
+	for c.ctx.Err() == nil {
+		c.setState(StateChange{State: StateConnecting, Attempt: c.backoff.Attempts()})
+
 		if err := c.connect(); err != nil {
+			if c.ctx.Err() != nil {
+				return nil
+			}
 			zap.L().Error("Failed to connect",
-				zap.String("channel", c.handler.GetChannelName()),
+				zap.String("channel", c.name),
 				zap.Error(err))
-			time.Sleep(c.config.ReconnectDelay)
+			if err := c.waitToReconnect(err); err != nil {
+				return err
+			}
 			continue
 		}
 
 		if err := c.subscribe(); err != nil {
+			c.conn.Close()
+			if c.ctx.Err() != nil {
+				return nil
+			}
 			zap.L().Error("Failed to subscribe",
-				zap.String("channel", c.handler.GetChannelName()),
+				zap.String("channel", c.name),
 				zap.Error(err))
-			c.conn.Close()
-			time.Sleep(c.config.ReconnectDelay)
+			if err := c.waitToReconnect(err); err != nil {
+				return err
+			}
 			continue
 		}
 
-		c.readMessages()
+		connectedAt := time.Now()
+		c.setState(StateChange{State: StateConnected, Attempt: c.backoff.Attempts()})
 
-		// Connection closed, reconnect if enabled
-		if c.reconnect {
-			zap.L().Info("Reconnecting",
-				zap.String("channel", c.handler.GetChannelName()),
-				zap.Duration("delay", c.config.ReconnectDelay))
-			time.Sleep(c.config.ReconnectDelay)
+		err := c.readMessages()
+		if c.ctx.Err() != nil {
+			return nil
+		}
+
+		// Bad credentials or products won't fix themselves; stop instead of reconnecting
+		if IsFatal(err) {
+			c.conn.Close()
+			zap.L().Error("Fatal websocket error, stopping client",
+				zap.String("channel", c.name),
+				zap.Error(err))
+			c.setState(StateChange{State: StateFailed, Attempt: c.backoff.Attempts(), Err: err})
+			return err
+		}
+
+		// Only a connection that stayed up long enough clears the failure count,
+		// so a server that accepts and immediately drops us still backs off
+		if time.Since(connectedAt) >= c.backoff.config.StableAfter {
+			c.backoff.Reset()
+		}
+
+		// Connection closed, reconnect
+		if err := c.waitToReconnect(err); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// waitToReconnect sleeps for the next backoff delay
+// Returns an error if the client gave up; being stopped while waiting returns nil and leaves ctx done
+func (c *BaseWebSocketClient) waitToReconnect(cause error) error {
+	delay, ok := c.backoff.Next()
+	if !ok {
+		err := fmt.Errorf("gave up after %d consecutive failed attempts: %w", c.backoff.config.MaxAttempts, cause)
+		zap.L().Error("Giving up reconnecting",
+			zap.String("channel", c.name),
+			zap.Error(err))
+		c.setState(StateChange{State: StateGaveUp, Attempt: c.backoff.config.MaxAttempts, Err: err})
+		return err
+	}
+
+	zap.L().Info("Reconnecting",
+		zap.String("channel", c.name),
+		zap.Int("attempt", c.backoff.Attempts()),
+		zap.Duration("delay", delay))
+	c.setState(StateChange{State: StateReconnecting, Attempt: c.backoff.Attempts(), Delay: delay, Err: cause})
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-c.ctx.Done():
+	case <-timer.C:
+	}
+	return nil
+}
+
+// setState records the new state and notifies the configured callback
+func (c *BaseWebSocketClient) setState(change StateChange) {
+	c.state.Store(int32(change.State))
+	change.Channel = c.name
+	if c.config.OnStateChange != nil {
+		c.config.OnStateChange(change)
+	}
 }
 
 func (c *BaseWebSocketClient) connect() error {
 	zap.L().Info("Connecting to Prime WebSocket",
 		zap.String("url", c.config.Url),
-		zap.String("channel", c.handler.GetChannelName()))
+		zap.String("channel", c.name))
 
 	dialer := websocket.DefaultDialer
-	conn, _, err := dialer.Dial(c.config.Url, nil)
+	conn, _, err := dialer.DialContext(c.ctx, c.config.Url, nil)
 	if err != nil {
 		return fmt.Errorf("dial failed: %w", err)
 	}
 
+	// Checked under the lock closeConn takes, so a Stop racing the dial can't leave this connection open
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.ctx.Err(); err != nil {
+		conn.Close()
+		return err
+	}
 	c.conn = conn
 	zap.L().Info("Connected to Prime WebSocket",
-		zap.String("channel", c.handler.GetChannelName()))
+		zap.String("channel", c.name))
 	return nil
 }
 
+// subscribe subscribes every handler on the connection, followed by heartbeats
 func (c *BaseWebSocketClient) subscribe() error {
+	for _, handler := range c.handlers {
+		if err := c.subscribeChannel(handler); err != nil {
+			return err
+		}
+	}
+
+	if c.heartbeats != nil {
+		// The heartbeat window starts now, not at the last heartbeat of a previous connection
+		c.heartbeats.touch(time.Now())
+		if err := c.subscribeChannel(c.heartbeats); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subscribeChannel sends a signed subscription for a single channel
+func (c *BaseWebSocketClient) subscribeChannel(handler ChannelHandler) error {
+	return c.sendSubscription(handler, "subscribe")
+}
+
+// sendSubscription sends a signed subscribe or unsubscribe message for a single channel
+// Sequence tracking restarts for the channel since the server may start a new stream
+func (c *BaseWebSocketClient) sendSubscription(handler ChannelHandler, msgType string) error {
 	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
 
 	// Build signature message using channel-specific logic
-	signatureMessage := c.handler.BuildSignatureMessage(c.config, timestamp)
+	signatureMessage := handler.BuildSignatureMessage(c.config, timestamp)
 	signature := c.sign(signatureMessage)
 
 	// Build subscription message using channel-specific logic
-	sub := c.handler.BuildSubscriptionMessage(c.config, timestamp, signature)
+	sub := handler.BuildSubscriptionMessage(c.config, timestamp, signature)
+	sub["type"] = msgType
 
-	if err := c.conn.WriteJSON(sub); err != nil {
-		return fmt.Errorf("failed to send subscription: %w", err)
+	c.writeMu.Lock()
+	err := c.conn.WriteJSON(sub)
+	c.writeMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to send %s: %w", msgType, err)
 	}
 
+	c.sequences.reset(handler.GetChannelName())
 	zap.L().Info("Sent subscription request",
-		zap.String("channel", c.handler.GetChannelName()))
+		zap.String("channel", handler.GetChannelName()),
+		zap.String("type", msgType))
 	return nil
 }
 
+// updateProducts subscribes or unsubscribes some of a channel's products on the live connection
+// When not connected it does nothing; the next subscribe picks up the channel's current products
+func (c *BaseWebSocketClient) updateProducts(handler productChannel, msgType string, products []string) error {
+	if len(products) == 0 || c.State() != StateConnected {
+		return nil
+	}
+	return c.sendSubscription(productSubscription{productChannel: handler, products: products}, msgType)
+}
+
+// resync resubscribes the handler's channel so the server sends a fresh snapshot
+func (c *BaseWebSocketClient) resync(handler ChannelHandler) error {
+	if err := c.sendSubscription(handler, "unsubscribe"); err != nil {
+		return err
+	}
+	return c.sendSubscription(handler, "subscribe")
+}
+
 func (c *BaseWebSocketClient) sign(message string) string {
 	h := hmac.New(sha256.New, []byte(c.config.SigningKey))
 	h.Write([]byte(message))
 	return base64.StdEncoding.EncodeToString(h.Sum(nil))
 }
 
-func (c *BaseWebSocketClient) readMessages() {
+// readMessages processes messages until the connection fails or the client stops
+// Returns the read error that ended the connection, or nil on shutdown
+func (c *BaseWebSocketClient) readMessages() error {
 	for {
 		select {
 		case <-c.ctx.Done():
-			return
+			return nil
 		default:
 		}
 
+		// Only heartbeats extend the deadline, so a connection that still delivers
+		// data but has lost its heartbeat subscription is also treated as stale
+		if c.heartbeats != nil {
+			c.conn.SetReadDeadline(c.heartbeats.LastHeartbeat().Add(c.config.HeartbeatTimeout))
+		}
+
 		_, message, err := c.conn.ReadMessage()
+		if err == nil && c.config.Recorder != nil {
+			if recErr := c.config.Recorder.Record(time.Now(), message); recErr != nil {
+				zap.L().Warn("Failed to record frame",
+					zap.String("channel", c.name),
+					zap.Error(recErr))
+			}
+		}
 		if err != nil {
+			var netErr net.Error
+			if c.heartbeats != nil && errors.As(err, &netErr) && netErr.Timeout() {
+				err = fmt.Errorf("%w (%s)", ErrHeartbeatTimeout, c.config.HeartbeatTimeout)
+			}
+
 			// Don't log error if we're shutting down
 			select {
 			case <-c.ctx.Done():
-				return
+				return nil
 			default:
 				zap.L().Error("Error reading message",
-					zap.String("channel", c.handler.GetChannelName()),
+					zap.String("channel", c.name),
 					zap.Error(err))
-				return
+				return err
 			}
 		}
 
 		if err := c.handleMessage(message); err != nil {
-			zap.L().Error("Error handling message",
-				zap.String("channel", c.handler.GetChannelName()),
+			var subErr *SubscriptionError
+			if !errors.As(err, &subErr) {
+				zap.L().Error("Error handling message",
+					zap.String("channel", c.name),
+					zap.Error(err))
+				continue
+			}
+
+			if subErr.Fatal() {
+				return err
+			}
+			if c.resubscribes >= maxResubscribeAttempts {
+				return fmt.Errorf("still failing after %d resubscribes: %w", c.resubscribes, err)
+			}
+
+			c.resubscribes++
+			zap.L().Warn("Transient websocket error, resubscribing",
+				zap.String("channel", c.name),
+				zap.Int("attempt", c.resubscribes),
 				zap.Error(err))
+			if err := c.subscribe(); err != nil {
+				return err
+			}
 		}
 	}
 }
 
-func (c *BaseWebSocketClient) handleMessage(message []byte) error {
-	var baseMsg map[string]interface{}
-	if err := json.Unmarshal(message, &baseMsg); err != nil {
+func (c *BaseWebSocketClient) handleMessage(frame []byte) error {
+	msg, err := decodeMessage(frame)
+	if err != nil {
 		return fmt.Errorf("failed to unmarshal message: %w", err)
 	}
 
-	// Check if this is a subscription confirmation (has "type" at root level)
-	if msgType, ok := baseMsg["type"].(string); ok {
-		if msgType == "subscriptions" {
-			zap.L().Info("Subscription confirmed",
-				zap.String("channel", c.handler.GetChannelName()))
-			return nil
+	// Subscription confirmations and errors carry a root-level type
+	switch msg.Type {
+	case MessageTypeSubscriptions:
+		c.resubscribes = 0
+		zap.L().Info("Subscription confirmed",
+			zap.String("channel", c.name))
+		return nil
+	case MessageTypeError:
+		// Errors don't always say which channel they belong to
+		channel := msg.Channel
+		if channel == "" {
+			channel = c.name
 		}
-		if msgType == "error" {
-			errMsg, _ := baseMsg["message"].(string)
-			return fmt.Errorf("WebSocket error: %s", errMsg)
+		return newSubscriptionError(channel, msg.Error)
+	}
+
+	// Route by channel
+	if handler, ok := c.channels[msg.Channel]; ok {
+		if seq, ok := msg.Sequence(); ok {
+			return c.handleSequenced(handler, msg, seq)
 		}
+
+		// Delegate to channel-specific handler
+		return c.dispatch(handler, queueItem{Message: msg, ReceivedAt: time.Now()})
+	}
+	if msg.Channel == ChannelHeartbeats && c.heartbeats != nil {
+		return c.heartbeats.HandleMessage(msg)
 	}
+	return nil
+}
 
-	// Check for channel match
-	channel, ok := baseMsg["channel"].(string)
-	if !ok || channel != c.handler.GetChannelName() {
+// handleSequenced dispatches a message after checking its sequence_num
+// Stale messages are dropped; a gap lets the handler recover and resubscribes the channel
+func (c *BaseWebSocketClient) handleSequenced(handler ChannelHandler, message *Message, seq uint64) error {
+	channel := handler.GetChannelName()
+	item := queueItem{Message: message, ReceivedAt: time.Now()}
+
+	result, expected := c.sequences.check(channel, seq)
+	switch result {
+	case sequenceOk:
+		return c.dispatch(handler, item)
+	case sequenceStale:
+		zap.L().Debug("Dropping stale message",
+			zap.String("channel", channel),
+			zap.Uint64("sequence_num", seq),
+			zap.Uint64("expected", expected))
 		return nil
 	}
 
-	// Delegate to channel-specific handler
-	return c.handler.HandleMessage(baseMsg)
+	gaps := c.gaps[channel].Add(1)
+	zap.L().Warn("Sequence gap detected, resyncing",
+		zap.String("channel", channel),
+		zap.Uint64("expected", expected),
+		zap.Uint64("received", seq),
+		zap.Uint64("total_gaps", gaps))
+
+	item.Gap, item.Expected, item.Received = true, expected, seq
+	err := c.dispatch(handler, item)
+
+	if resyncErr := c.resync(handler); resyncErr != nil {
+		return fmt.Errorf("failed to resync after sequence gap: %w", resyncErr)
+	}
+	return err
+}
+
+// dispatch hands a message to the channel's queue, or processes it inline when the channel has none
+func (c *BaseWebSocketClient) dispatch(handler ChannelHandler, item queueItem) error {
+	channel := handler.GetChannelName()
+	queue, ok := c.queues[channel]
+	if !ok {
+		return c.process(handler, item)
+	}
+
+	// After dropping on overflow, keep dropping until there's room again, then resubscribe;
+	// the fresh snapshot replaces everything that was lost
+	if c.resyncPending[channel] {
+		if queue.Stats().Depth >= queue.config.Size {
+			queue.drop()
+			return nil
+		}
+		queue.drop()
+		delete(c.resyncPending, channel)
+		zap.L().Warn("Queue drained after overflow, resyncing", zap.String("channel", channel))
+		if err := c.resync(handler); err != nil {
+			return fmt.Errorf("failed to resync after queue overflow: %w", err)
+		}
+		return nil
+	}
+
+	accepted, err := queue.push(item)
+	if err != nil {
+		return err
+	}
+	if !accepted && queue.config.Overflow == OverflowDropResync {
+		c.resyncPending[channel] = true
+		zap.L().Warn("Queue full, dropping messages until it drains",
+			zap.String("channel", channel),
+			zap.Int("size", queue.config.Size))
+	}
+	return nil
+}
+
+// process runs the handler for one message
+// For a gap, the revealing message is handled (unless the handler discards it) before the handler recovers,
+// so recovered state can't be overwritten by the older update
+func (c *BaseWebSocketClient) process(handler ChannelHandler, item queueItem) error {
+	if !item.Gap {
+		return handler.HandleMessage(item.Message)
+	}
+
+	gapHandler, _ := handler.(SequenceGapHandler)
+
+	var err error
+	if gapHandler == nil || !gapHandler.DiscardOnSequenceGap() {
+		err = handler.HandleMessage(item.Message)
+	}
+	if gapHandler != nil {
+		gapHandler.HandleSequenceGap(item.Expected, item.Received)
+	}
+	return err
+}
+
+// processQueue runs a channel's handler for queued messages until the queue is closed and drained
+// Spilled messages that can't be read back are recovered once the spilled backlog has drained,
+// so the recovered state isn't overwritten by older spilled updates
+func (c *BaseWebSocketClient) processQueue(handler ChannelHandler, queue *channelQueue) {
+	lost := 0
+	for {
+		item, ok, err := queue.pop()
+		if !ok {
+			if lost > 0 {
+				c.recoverLost(handler, lost)
+			}
+			return
+		}
+
+		if err != nil {
+			lost++
+			zap.L().Error("Lost spilled message",
+				zap.String("channel", handler.GetChannelName()),
+				zap.Error(err))
+		} else if err := c.process(handler, item); err != nil {
+			zap.L().Error("Error handling message",
+				zap.String("channel", handler.GetChannelName()),
+				zap.Error(err))
+		}
+
+		if lost > 0 && queue.Stats().Spilled == 0 {
+			c.recoverLost(handler, lost)
+			lost = 0
+		}
+	}
+}
+
+// recoverLost runs the sequence gap recovery for messages the queue accepted but couldn't deliver:
+// the handler's gap recovery (an orders backfill) and a resubscribe for a fresh snapshot
+func (c *BaseWebSocketClient) recoverLost(handler ChannelHandler, lost int) {
+	channel := handler.GetChannelName()
+	zap.L().Warn("Recovering messages lost from the queue",
+		zap.String("channel", channel),
+		zap.Int("lost", lost))
+
+	if gapHandler, ok := handler.(SequenceGapHandler); ok {
+		gapHandler.HandleSequenceGap(0, 0)
+	}
+
+	// When disconnected, the reconnect resubscribes anyway
+	if c.State() != StateConnected {
+		return
+	}
+	if err := c.resync(handler); err != nil {
+		zap.L().Error("Failed to resync after losing queued messages",
+			zap.String("channel", channel),
+			zap.Error(err))
+	}
 }
 
 // ============================================================================
@@ -240,6 +754,11 @@ type CommonConfig struct {
 	ServiceAccountId string
 	Products         []string
 	ReconnectDelay   time.Duration
+	Backoff          BackoffConfig
+	OnStateChange    func(StateChange)
+	HeartbeatTimeout time.Duration
+	Recorder         *FrameRecorder
+	Queue            QueueConfig // Work queue between the read loop and this channel's handler; zero handles inline
 }
 
 // joinProductIds concatenates product IDs for signature generation
@@ -276,5 +795,9 @@ func baseConfigFromCommon(common CommonConfig) BaseConfig {
 		SigningKey:       common.SigningKey,
 		ServiceAccountId: common.ServiceAccountId,
 		ReconnectDelay:   common.ReconnectDelay,
+		Backoff:          common.Backoff,
+		OnStateChange:    common.OnStateChange,
+		HeartbeatTimeout: common.HeartbeatTimeout,
+		Recorder:         common.Recorder,
 	}
 }
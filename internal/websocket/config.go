@@ -0,0 +1,66 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+)
+
+// NewCommonConfig builds the connection, reconnect and queue settings shared by every client from configuration
+func NewCommonConfig(cfg *config.Config, products []string) CommonConfig {
+	return CommonConfig{
+		Url:              cfg.MarketData.WebSocketUrl,
+		AccessKey:        cfg.Prime.AccessKey,
+		Passphrase:       cfg.Prime.Passphrase,
+		SigningKey:       cfg.Prime.SigningKey,
+		ServiceAccountId: cfg.Prime.ServiceAccountId,
+		Products:         products,
+		ReconnectDelay:   cfg.MarketData.ReconnectDelay,
+		Backoff: BackoffConfig{
+			MaxDelay:    cfg.MarketData.ReconnectMaxDelay,
+			MaxAttempts: cfg.MarketData.ReconnectAttempts,
+			StableAfter: cfg.MarketData.ReconnectStable,
+		},
+		HeartbeatTimeout: cfg.MarketData.HeartbeatTimeout,
+		Queue: QueueConfig{
+			Size:     cfg.MarketData.QueueSize,
+			Overflow: OverflowDropResync,
+		},
+	}
+}
+
+// NewMarketDataConfig builds the market data client configuration for products from configuration
+func NewMarketDataConfig(cfg *config.Config, products []string) MarketDataConfig {
+	return MarketDataConfig{
+		CommonConfig: NewCommonConfig(cfg, products),
+		Portfolio:    cfg.Prime.Portfolio,
+		MaxLevels:    cfg.MarketData.MaxLevels,
+	}
+}
+
+// NewOrdersConfig builds the orders client configuration for products from configuration
+// Order updates cannot be rebuilt from a snapshot, so overflow spills to disk instead of dropping
+func NewOrdersConfig(cfg *config.Config, products []string) OrdersConfig {
+	common := NewCommonConfig(cfg, products)
+	common.Queue.Overflow = OverflowSpill
+	common.Queue.SpillDir = cfg.MarketData.QueueSpillDir
+
+	return OrdersConfig{
+		CommonConfig: common,
+		PortfolioId:  cfg.Prime.Portfolio,
+	}
+}
@@ -0,0 +1,56 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/config"
+)
+
+func TestNewConfigs(t *testing.T) {
+	cfg := &config.Config{
+		Prime: config.PrimeConfig{Portfolio: "portfolio-1"},
+		MarketData: config.MarketDataConfig{
+			WebSocketUrl:      "wss://example.test",
+			ReconnectMaxDelay: time.Minute,
+			QueueSize:         512,
+			QueueSpillDir:     "/tmp/spill",
+			MaxLevels:         25,
+		},
+	}
+
+	md := NewMarketDataConfig(cfg, []string{"BTC-USD"})
+	if md.Queue.Size != 512 || md.Queue.Overflow != OverflowDropResync {
+		t.Errorf("market data Queue = %+v, want size 512 with drop-resync", md.Queue)
+	}
+	if md.Backoff.MaxDelay != time.Minute {
+		t.Errorf("market data Backoff.MaxDelay = %s, want 1m", md.Backoff.MaxDelay)
+	}
+	if md.MaxLevels != 25 || md.Portfolio != "portfolio-1" {
+		t.Errorf("market data MaxLevels/Portfolio = %d/%q, want 25/portfolio-1", md.MaxLevels, md.Portfolio)
+	}
+
+	orders := NewOrdersConfig(cfg, []string{"BTC-USD"})
+	if orders.Queue.Size != 512 || orders.Queue.Overflow != OverflowSpill || orders.Queue.SpillDir != "/tmp/spill" {
+		t.Errorf("orders Queue = %+v, want size 512 spilling to /tmp/spill", orders.Queue)
+	}
+	if orders.PortfolioId != "portfolio-1" {
+		t.Errorf("orders PortfolioId = %q, want portfolio-1", orders.PortfolioId)
+	}
+}
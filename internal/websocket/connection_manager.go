@@ -0,0 +1,92 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"time"
+)
+
+// sharedConnectionUser is implemented by clients that can run on a ConnectionManager's connection
+type sharedConnectionUser interface {
+	useConnection(baseClient *BaseWebSocketClient)
+}
+
+// ConnectionManager multiplexes several channel handlers over a single authenticated connection
+// Messages are routed by their channel field, and every handler is resubscribed together on reconnect.
+// The manager's CommonConfig supplies the URL, credentials and reconnect policy for all handlers;
+// its Products are used for the heartbeats subscription.
+type ConnectionManager struct {
+	baseClient *BaseWebSocketClient
+}
+
+// NewConnectionManager creates a connection manager with no handlers
+func NewConnectionManager(config CommonConfig) *ConnectionManager {
+	manager := &ConnectionManager{
+		baseClient: NewBaseWebSocketClient(baseConfigFromCommon(config)),
+	}
+	manager.baseClient.enableHeartbeats(config.Products)
+	return manager
+}
+
+// Register adds a channel handler to the shared connection; must be called before Start
+// A MarketDataClient or OrdersClient registered here reports the shared connection's state,
+// and starting or stopping it starts or stops the shared connection
+func (m *ConnectionManager) Register(handler ChannelHandler) error {
+	if err := m.baseClient.addHandler(handler); err != nil {
+		return err
+	}
+	if user, ok := handler.(sharedConnectionUser); ok {
+		user.useConnection(m.baseClient)
+	}
+	return nil
+}
+
+// Start connects and subscribes every registered handler; cancelling ctx stops the shared connection
+func (m *ConnectionManager) Start(ctx context.Context) error {
+	return m.baseClient.Start(ctx)
+}
+
+// Stop closes the shared connection and waits for every handler's queued messages to be handled
+func (m *ConnectionManager) Stop() {
+	m.baseClient.Stop()
+}
+
+// Done is closed once the shared connection has stopped and every handler has drained
+func (m *ConnectionManager) Done() <-chan struct{} {
+	return m.baseClient.Done()
+}
+
+// Err reports why the shared connection stopped; nil until Done is closed
+func (m *ConnectionManager) Err() error {
+	return m.baseClient.Err()
+}
+
+// State returns the shared connection's state
+func (m *ConnectionManager) State() ConnectionState {
+	return m.baseClient.State()
+}
+
+// LastHeartbeat returns when the last heartbeat was received (zero if heartbeats are disabled)
+func (m *ConnectionManager) LastHeartbeat() time.Time {
+	return m.baseClient.LastHeartbeat()
+}
+
+// SequenceGaps returns how many sequence gaps have triggered a resync across all channels
+func (m *ConnectionManager) SequenceGaps() uint64 {
+	return m.baseClient.SequenceGaps()
+}
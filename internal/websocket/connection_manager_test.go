@@ -0,0 +1,204 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/mockws"
+)
+
+func TestConnectionManager_MultiplexesChannels(t *testing.T) {
+	scenario, err := mockws.ParseScenario([]byte(`{
+		"seed": 1, "tick_interval": "10ms", "heartbeat_interval": "10ms",
+		"products": [{"product_id": "BTC-USD", "mid": "100", "spread_bps": "10", "tick_size": "0.01", "depth": 3, "level_size": "1", "volatility_bps": "1"}],
+		"orders": [{"client_order_id": "mux-order", "product_id": "BTC-USD", "side": "BUY", "order_type": "MARKET",
+			"base_quantity": "1", "commission_rate": "0.001", "at": "10ms", "fills": [{"after": "10ms", "fraction": "1"}]}]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseScenario() error = %v", err)
+	}
+	creds := mockws.Credentials{AccessKey: "access", Passphrase: "passphrase", SigningKey: "key", ServiceAccountId: "svc", PortfolioId: "portfolio"}
+
+	var mu sync.Mutex
+	connections := 0
+	mock := mockws.NewServer(creds, scenario)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		connections++
+		mu.Unlock()
+		mock.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	common := CommonConfig{
+		Url:              "ws" + strings.TrimPrefix(srv.URL, "http"),
+		AccessKey:        creds.AccessKey,
+		Passphrase:       creds.Passphrase,
+		SigningKey:       creds.SigningKey,
+		ServiceAccountId: creds.ServiceAccountId,
+		Products:         []string{"BTC-USD"},
+		ReconnectDelay:   time.Hour,
+		HeartbeatTimeout: time.Second,
+	}
+	manager := NewConnectionManager(common)
+
+	store := NewOrderBookStore()
+	marketData := NewMarketDataClient(MarketDataConfig{CommonConfig: common, MaxLevels: 10}, store)
+	orderHandler := &sequenceRecordingHandler{}
+	orders := NewOrdersClient(OrdersConfig{CommonConfig: common, PortfolioId: creds.PortfolioId}, orderHandler)
+
+	for _, handler := range []ChannelHandler{marketData, orders} {
+		if err := manager.Register(handler); err != nil {
+			t.Fatalf("Register(%s) error = %v", handler.GetChannelName(), err)
+		}
+	}
+	manager.Start(context.Background())
+	defer manager.Stop()
+
+	waitForState(t, marketData.State, StateConnected)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		_, hasBook := store.Get("BTC-USD")
+		if hasBook && len(orderHandler.received()) >= 3 && !manager.LastHeartbeat().IsZero() {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, ok := store.Get("BTC-USD"); !ok {
+		t.Error("no l2_data delivered to the market data client")
+	}
+	if got := len(orderHandler.received()); got < 3 {
+		t.Errorf("orders delivered = %d, want snapshot plus order updates", got)
+	}
+	if manager.LastHeartbeat().IsZero() {
+		t.Error("no heartbeats delivered")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if connections != 1 {
+		t.Errorf("connections = %d, want 1 shared connection", connections)
+	}
+}
+
+func TestConnectionManager_ResubscribesAllOnReconnect(t *testing.T) {
+	// Records subscribed channels per connection and drops the first connection once all are subscribed
+	var mu sync.Mutex
+	var subscriptions [][]string
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		mu.Lock()
+		subscriptions = append(subscriptions, nil)
+		index := len(subscriptions) - 1
+		mu.Unlock()
+
+		for {
+			var sub map[string]interface{}
+			if err := conn.ReadJSON(&sub); err != nil {
+				return
+			}
+			channel, _ := sub["channel"].(string)
+
+			mu.Lock()
+			subscriptions[index] = append(subscriptions[index], channel)
+			done := index == 0 && len(subscriptions[index]) == 3
+			mu.Unlock()
+			if done {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	common := CommonConfig{
+		Url:              "ws" + strings.TrimPrefix(srv.URL, "http"),
+		Products:         []string{"BTC-USD"},
+		ReconnectDelay:   time.Millisecond,
+		HeartbeatTimeout: time.Hour,
+	}
+	manager := NewConnectionManager(common)
+	marketData := NewMarketDataClient(MarketDataConfig{CommonConfig: common}, NewOrderBookStore())
+	orders := NewOrdersClient(OrdersConfig{CommonConfig: common}, nil)
+	manager.Register(marketData)
+	manager.Register(orders)
+	manager.Start(context.Background())
+	defer manager.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		reconnected := len(subscriptions) == 2 && len(subscriptions[1]) == 3
+		mu.Unlock()
+		if reconnected {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(subscriptions) != 2 {
+		t.Fatalf("connections = %d, want 2", len(subscriptions))
+	}
+	for i, channels := range subscriptions {
+		if got := strings.Join(channels, ","); got != "l2_data,orders,heartbeats" {
+			t.Errorf("connection %d subscribed %s, want l2_data,orders,heartbeats", i, got)
+		}
+	}
+}
+
+func TestConnectionManager_Register(t *testing.T) {
+	manager := NewConnectionManager(CommonConfig{Products: []string{"BTC-USD"}})
+	marketData := NewMarketDataClient(MarketDataConfig{CommonConfig: CommonConfig{Products: []string{"BTC-USD"}}}, NewOrderBookStore())
+
+	if err := manager.Register(marketData); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if marketData.baseClient != manager.baseClient {
+		t.Error("registered client should use the manager's connection")
+	}
+	if err := manager.Register(marketData); err == nil {
+		t.Error("registering a channel twice should fail")
+	}
+	if err := manager.Register(newHeartbeatsHandler(nil)); err == nil {
+		t.Error("registering heartbeats should fail; the manager subscribes them itself")
+	}
+
+	// No server is needed; dialing the empty URL just fails until Stop
+	manager.Start(context.Background())
+	defer manager.Stop()
+	if err := manager.Register(NewOrdersClient(OrdersConfig{}, nil)); err == nil {
+		t.Error("registering after Start should fail")
+	}
+}
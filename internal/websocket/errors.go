@@ -0,0 +1,104 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors for classifying Prime websocket error messages, usable with errors.Is
+var (
+	ErrAuthentication = errors.New("authentication failed")
+	ErrUnknownProduct = errors.New("unknown product")
+	ErrTransient      = errors.New("transient websocket error")
+)
+
+// maxResubscribeAttempts bounds consecutive resubscribes after transient errors before reconnecting
+const maxResubscribeAttempts = 3
+
+// SubscriptionError is an error message (`type: error`) sent by Prime on a websocket connection
+type SubscriptionError struct {
+	Channel string
+	Message string
+	kind    error
+}
+
+// newSubscriptionError classifies Prime's error message text
+func newSubscriptionError(channel, message string) *SubscriptionError {
+	return &SubscriptionError{
+		Channel: channel,
+		Message: message,
+		kind:    classifyErrorMessage(message),
+	}
+}
+
+// Error implements the error interface
+func (e *SubscriptionError) Error() string {
+	return fmt.Sprintf("%s websocket error: %s", e.Channel, e.Message)
+}
+
+// Unwrap returns the error class (ErrAuthentication, ErrUnknownProduct or ErrTransient)
+func (e *SubscriptionError) Unwrap() error {
+	return e.kind
+}
+
+// Fatal reports whether retrying cannot succeed without a configuration change
+func (e *SubscriptionError) Fatal() bool {
+	return e.kind != ErrTransient
+}
+
+// IsFatal reports whether err contains a fatal SubscriptionError
+func IsFatal(err error) bool {
+	var subErr *SubscriptionError
+	return errors.As(err, &subErr) && subErr.Fatal()
+}
+
+// fatalErrorPhrases are the error messages Prime sends when retrying cannot help
+// A message is fatal only if it starts with one of these phrases, so text that merely mentions
+// authentication or a portfolio (e.g. "portfolio rate limit exceeded") stays retryable
+var fatalErrorPhrases = []struct {
+	phrase string
+	kind   error
+}{
+	{"authentication failure", ErrAuthentication},
+	{"authentication failed", ErrAuthentication},
+	{"invalid signature", ErrAuthentication},
+	{"invalid passphrase", ErrAuthentication},
+	{"invalid api key", ErrAuthentication},
+	{"invalid access key", ErrAuthentication},
+	{"invalid portfolio_id", ErrAuthentication},
+	{"unauthorized", ErrAuthentication},
+	{"forbidden", ErrAuthentication},
+	{"unknown product", ErrUnknownProduct},
+	{"invalid product", ErrUnknownProduct},
+	{"product not found", ErrUnknownProduct},
+}
+
+// classifyErrorMessage maps Prime's error text to an error class
+// Unrecognized messages are treated as transient so the client keeps retrying
+func classifyErrorMessage(message string) error {
+	lower := strings.ToLower(strings.TrimSpace(message))
+
+	for _, fatal := range fatalErrorPhrases {
+		if strings.HasPrefix(lower, fatal.phrase) {
+			return fatal.kind
+		}
+	}
+	return ErrTransient
+}
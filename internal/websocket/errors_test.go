@@ -0,0 +1,208 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/mockws"
+)
+
+func TestClassifyErrorMessage(t *testing.T) {
+	tests := []struct {
+		message   string
+		wantKind  error
+		wantFatal bool
+	}{
+		{"authentication failure", ErrAuthentication, true},
+		{"Invalid signature", ErrAuthentication, true},
+		{"invalid passphrase", ErrAuthentication, true},
+		{"Unauthorized", ErrAuthentication, true},
+		{"unknown product_id: DOGE-USD", ErrUnknownProduct, true},
+		{"invalid product", ErrUnknownProduct, true},
+		{"authentication failure: invalid portfolio_id", ErrAuthentication, true},
+		{"Forbidden", ErrAuthentication, true},
+		{"rate limit exceeded", ErrTransient, false},
+		{"portfolio rate limit exceeded", ErrTransient, false},
+		{"authentication service unavailable, retry", ErrTransient, false},
+		{"api key cache refreshing", ErrTransient, false},
+		{"product_id list too long, retry in batches", ErrTransient, false},
+		{"internal server error", ErrTransient, false},
+		{"", ErrTransient, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			err := newSubscriptionError("l2_data", tt.message)
+			if !errors.Is(err, tt.wantKind) {
+				t.Errorf("errors.Is(%q, %v) = false", tt.message, tt.wantKind)
+			}
+			if err.Fatal() != tt.wantFatal {
+				t.Errorf("Fatal() = %v, want %v", err.Fatal(), tt.wantFatal)
+			}
+			if IsFatal(err) != tt.wantFatal {
+				t.Errorf("IsFatal() = %v, want %v", IsFatal(err), tt.wantFatal)
+			}
+		})
+	}
+}
+
+func TestBaseWebSocketClient_FatalErrorsStopClient(t *testing.T) {
+	creds := mockws.Credentials{
+		AccessKey:        "access",
+		Passphrase:       "passphrase",
+		SigningKey:       "signing-key",
+		ServiceAccountId: "service-account",
+	}
+	srv := httptest.NewServer(mockws.NewServer(creds, mockws.DefaultScenario()))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		signingKey string
+		products   []string
+		wantKind   error
+	}{
+		{"bad signature", "wrong-key", []string{"BTC-USD"}, ErrAuthentication},
+		{"unknown product", creds.SigningKey, []string{"DOGE-USD"}, ErrUnknownProduct},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &stateRecorder{}
+			client := NewMarketDataClient(MarketDataConfig{
+				CommonConfig: CommonConfig{
+					Url:              "ws" + strings.TrimPrefix(srv.URL, "http"),
+					AccessKey:        creds.AccessKey,
+					Passphrase:       creds.Passphrase,
+					SigningKey:       tt.signingKey,
+					ServiceAccountId: creds.ServiceAccountId,
+					Products:         tt.products,
+					ReconnectDelay:   time.Millisecond,
+					OnStateChange:    recorder.record,
+				},
+			}, NewOrderBookStore())
+			client.Start(context.Background())
+			defer client.Stop()
+
+			waitForState(t, client.State, StateFailed)
+
+			last := recorder.last()
+			if !errors.Is(last.Err, tt.wantKind) {
+				t.Errorf("Err = %v, want %v", last.Err, tt.wantKind)
+			}
+			if got := strings.Join(recorder.states(), ","); got != "connecting,connected,failed" {
+				t.Errorf("states = %s, want no reconnect after a fatal error", got)
+			}
+
+			// Supervisors see the same error once the client is done
+			waitForDone(t, client.Done())
+			if err := client.Err(); !errors.Is(err, tt.wantKind) {
+				t.Errorf("Err() = %v, want %v", err, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestBaseWebSocketClient_TransientErrorResubscribes(t *testing.T) {
+	var subscribes atomic.Int32
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			var sub map[string]interface{}
+			if err := conn.ReadJSON(&sub); err != nil {
+				return
+			}
+
+			// Reject the first subscription with a transient error, accept the retry
+			if subscribes.Add(1) == 1 {
+				conn.WriteJSON(map[string]string{"type": "error", "message": "rate limit exceeded"})
+				continue
+			}
+			conn.WriteJSON(map[string]interface{}{"channel": "subscriptions", "type": "subscriptions"})
+			conn.WriteJSON(map[string]interface{}{
+				"channel": "l2_data",
+				"events": []interface{}{map[string]interface{}{
+					"type":       "snapshot",
+					"product_id": "BTC-USD",
+					"updates": []interface{}{
+						map[string]string{"side": "bid", "px": "100", "qty": "1"},
+						map[string]string{"side": "offer", "px": "101", "qty": "1"},
+					},
+				}},
+			})
+		}
+	}))
+	defer srv.Close()
+
+	store := NewOrderBookStore()
+	recorder := &stateRecorder{}
+	client := NewMarketDataClient(MarketDataConfig{
+		CommonConfig: CommonConfig{
+			Url:            "ws" + strings.TrimPrefix(srv.URL, "http"),
+			Products:       []string{"BTC-USD"},
+			ReconnectDelay: time.Millisecond,
+			OnStateChange:  recorder.record,
+		},
+		MaxLevels: 10,
+	}, store)
+	client.Start(context.Background())
+	defer client.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if book, ok := store.Get("BTC-USD"); ok {
+			if _, ok := book.GetBestAsk(); ok {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got := subscribes.Load(); got != 2 {
+		t.Errorf("subscriptions sent = %d, want 2", got)
+	}
+	if got := strings.Join(recorder.states(), ","); got != "connecting,connected" {
+		t.Errorf("states = %s, want resubscribe on the same connection", got)
+	}
+}
+
+func waitForState(t *testing.T, state func() ConnectionState, want ConnectionState) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for state() != want && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := state(); got != want {
+		t.Fatalf("State() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,194 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// ErrMarketDataUnhealthy is returned by CircuitBreaker.CheckTrading while a product's breaker is open
+var ErrMarketDataUnhealthy = errors.New("market data unhealthy")
+
+// ErrSyntheticNotTradable is returned when an order targets a synthetic cross, which only exists locally
+var ErrSyntheticNotTradable = errors.New("synthetic cross products are indicative and cannot be traded on Prime")
+
+// HealthConfig sets when a book is considered unhealthy
+type HealthConfig struct {
+	StaleAfter          time.Duration            // No update or sign of a live feed for this long marks the book stale; 0 disables
+	StaleAfterByProduct map[string]time.Duration // Per-product overrides of StaleAfter, e.g. for thinly traded products
+	MaxSpreadBps        decimal.Decimal          // Spreads wider than this (relative to mid) raise the wide-spread alarm; 0 disables
+	RecoverAfter        time.Duration            // How long a book must stay healthy before a tripped breaker closes again
+}
+
+// staleAfter returns the staleness threshold for a product
+func (c HealthConfig) staleAfter(product string) time.Duration {
+	if d, ok := c.StaleAfterByProduct[product]; ok {
+		return d
+	}
+	return c.StaleAfter
+}
+
+// BookHealth describes a book's state at one point in time
+type BookHealth struct {
+	Product    string
+	Missing    bool            // No book, or one side is empty
+	Age        time.Duration   // Time since the last update, or since the feed last confirmed the book current
+	Stale      bool            // Age exceeded the product's threshold
+	Crossed    bool            // Best bid above best ask
+	Locked     bool            // Best bid equal to best ask
+	SpreadBps  decimal.Decimal // Spread relative to mid, in basis points
+	WideSpread bool            // SpreadBps exceeded MaxSpreadBps
+}
+
+// Healthy reports whether the book can be trusted for trading
+func (h BookHealth) Healthy() bool {
+	return !h.Missing && !h.Stale && !h.Crossed && !h.Locked && !h.WideSpread
+}
+
+// Problems lists what is wrong with the book, empty when healthy
+func (h BookHealth) Problems() []string {
+	var problems []string
+	if h.Missing {
+		problems = append(problems, "no book")
+	}
+	if h.Stale {
+		problems = append(problems, fmt.Sprintf("stale (no data for %s)", h.Age.Round(time.Second)))
+	}
+	if h.Crossed {
+		problems = append(problems, "crossed book")
+	}
+	if h.Locked {
+		problems = append(problems, "locked book")
+	}
+	if h.WideSpread {
+		problems = append(problems, fmt.Sprintf("wide spread (%s bps)", h.SpreadBps.StringFixed(1)))
+	}
+	return problems
+}
+
+// CheckBookHealth evaluates a book against cfg as of now; book may be nil
+func CheckBookHealth(product string, book *OrderBook, cfg HealthConfig, now time.Time) BookHealth {
+	health := BookHealth{Product: product}
+	if book == nil {
+		health.Missing = true
+		return health
+	}
+
+	state := book.state.Load()
+	health.Age = now.Sub(book.freshAt(state))
+	if threshold := cfg.staleAfter(product); threshold > 0 && health.Age > threshold {
+		health.Stale = true
+	}
+
+	if len(state.bids) == 0 || len(state.asks) == 0 {
+		health.Missing = true
+		return health
+	}
+
+	bid, ask := state.bids[0].Price, state.asks[0].Price
+	health.Crossed = bid.GreaterThan(ask)
+	health.Locked = bid.Equal(ask)
+	if mid := common.CalculateMidPrice(bid, ask); mid.IsPositive() {
+		health.SpreadBps = ask.Sub(bid).Div(mid).Mul(decimal.NewFromInt(10000))
+	}
+	if cfg.MaxSpreadBps.IsPositive() && health.SpreadBps.GreaterThan(cfg.MaxSpreadBps) {
+		health.WideSpread = true
+	}
+	return health
+}
+
+// CircuitBreaker refuses trading on products whose market data is unhealthy
+// A product's breaker trips as soon as its book is unhealthy and closes once it has stayed healthy for RecoverAfter
+type CircuitBreaker struct {
+	store *OrderBookStore
+	cfg   HealthConfig
+	now   func() time.Time
+
+	mu       sync.Mutex
+	breakers map[string]*breakerState
+}
+
+// breakerState tracks one product's breaker
+type breakerState struct {
+	open          bool
+	lastUnhealthy time.Time
+}
+
+// NewCircuitBreaker creates a breaker over the books in store
+func NewCircuitBreaker(store *OrderBookStore, cfg HealthConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		store:    store,
+		cfg:      cfg,
+		now:      time.Now,
+		breakers: make(map[string]*breakerState),
+	}
+}
+
+// Health returns the product's current book health
+func (b *CircuitBreaker) Health(product string) BookHealth {
+	book, _ := b.store.Get(product)
+	return CheckBookHealth(product, book, b.cfg, b.now())
+}
+
+// CheckTrading returns an error wrapping ErrMarketDataUnhealthy while the product's breaker is open
+// Synthetic products are always refused with ErrSyntheticNotTradable, however healthy their legs are
+func (b *CircuitBreaker) CheckTrading(product string) error {
+	if b.store.IsSynthetic(product) {
+		return fmt.Errorf("%w: %s", ErrSyntheticNotTradable, product)
+	}
+
+	health := b.Health(product)
+	now := b.now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, ok := b.breakers[product]
+	if !ok {
+		state = &breakerState{}
+		b.breakers[product] = state
+	}
+
+	if !health.Healthy() {
+		state.lastUnhealthy = now
+		if !state.open {
+			state.open = true
+			zap.L().Warn("Market data circuit breaker tripped",
+				zap.String("product", product),
+				zap.Strings("problems", health.Problems()))
+		}
+		return fmt.Errorf("%w for %s: %s", ErrMarketDataUnhealthy, product, strings.Join(health.Problems(), ", "))
+	}
+
+	if state.open {
+		if healthyFor := now.Sub(state.lastUnhealthy); healthyFor < b.cfg.RecoverAfter {
+			return fmt.Errorf("%w for %s: recovering (healthy for %s of %s)",
+				ErrMarketDataUnhealthy, product, healthyFor.Round(time.Millisecond), b.cfg.RecoverAfter)
+		}
+		state.open = false
+		zap.L().Info("Market data circuit breaker closed", zap.String("product", product))
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/shopspring/decimal"
+)
+
+// quotedBook returns a one-level book with the given best bid and ask
+func quotedBook(product, bid, ask string) *OrderBook {
+	book := NewOrderBook(product)
+	book.Update(
+		[]common.PriceLevel{{Price: decimal.RequireFromString(bid), Size: decimal.NewFromInt(1)}},
+		[]common.PriceLevel{{Price: decimal.RequireFromString(ask), Size: decimal.NewFromInt(1)}},
+		1)
+	return book
+}
+
+func TestCheckBookHealth(t *testing.T) {
+	cfg := HealthConfig{
+		StaleAfter:          10 * time.Second,
+		StaleAfterByProduct: map[string]time.Duration{"SOL-USD": time.Minute},
+		MaxSpreadBps:        decimal.NewFromInt(50),
+	}
+
+	tests := []struct {
+		name      string
+		product   string
+		book      *OrderBook
+		age       time.Duration
+		confirmed time.Duration // When the feed confirmed the unchanged book, after its last update
+		want      BookHealth
+	}{
+		{
+			name:    "healthy",
+			product: "BTC-USD",
+			book:    quotedBook("BTC-USD", "99.9", "100.1"),
+			age:     time.Second,
+			want:    BookHealth{},
+		},
+		{
+			name:    "stale",
+			product: "BTC-USD",
+			book:    quotedBook("BTC-USD", "99.9", "100.1"),
+			age:     11 * time.Second,
+			want:    BookHealth{Stale: true},
+		},
+		{
+			name:      "unchanged but confirmed by a live feed",
+			product:   "BTC-USD",
+			book:      quotedBook("BTC-USD", "99.9", "100.1"),
+			age:       30 * time.Second,
+			confirmed: 25 * time.Second,
+			want:      BookHealth{},
+		},
+		{
+			name:    "per-product threshold",
+			product: "SOL-USD",
+			book:    quotedBook("SOL-USD", "99.9", "100.1"),
+			age:     30 * time.Second,
+			want:    BookHealth{},
+		},
+		{
+			name:    "crossed",
+			product: "BTC-USD",
+			book:    quotedBook("BTC-USD", "100.1", "100"),
+			want:    BookHealth{Crossed: true},
+		},
+		{
+			name:    "locked",
+			product: "BTC-USD",
+			book:    quotedBook("BTC-USD", "100", "100"),
+			want:    BookHealth{Locked: true},
+		},
+		{
+			name:    "wide spread",
+			product: "BTC-USD",
+			book:    quotedBook("BTC-USD", "99", "101"),
+			want:    BookHealth{WideSpread: true},
+		},
+		{
+			name:    "no book",
+			product: "BTC-USD",
+			want:    BookHealth{Missing: true},
+		},
+		{
+			name:    "empty side",
+			product: "BTC-USD",
+			book:    NewOrderBook("BTC-USD"),
+			want:    BookHealth{Missing: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			if tt.book != nil {
+				updated := tt.book.Snapshot().UpdateTime
+				now = updated.Add(tt.age)
+				if tt.confirmed > 0 {
+					tt.book.confirm(updated.Add(tt.confirmed))
+				}
+			}
+
+			got := CheckBookHealth(tt.product, tt.book, cfg, now)
+			if got.Missing != tt.want.Missing || got.Stale != tt.want.Stale || got.Crossed != tt.want.Crossed ||
+				got.Locked != tt.want.Locked || got.WideSpread != tt.want.WideSpread {
+				t.Errorf("CheckBookHealth() = %+v, want flags %+v", got, tt.want)
+			}
+			wantHealthy := tt.want == BookHealth{}
+			if got.Healthy() != wantHealthy || (len(got.Problems()) == 0) != wantHealthy {
+				t.Errorf("Healthy() = %v, Problems() = %v, want healthy %v", got.Healthy(), got.Problems(), wantHealthy)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_RefusesSynthetics(t *testing.T) {
+	store := crossStore(t)
+	breaker := NewCircuitBreaker(store, HealthConfig{})
+
+	if err := breaker.CheckTrading("ETH-BTC"); !errors.Is(err, ErrSyntheticNotTradable) {
+		t.Errorf("CheckTrading(ETH-BTC) error = %v, want ErrSyntheticNotTradable", err)
+	}
+	if err := breaker.CheckTrading("ETH-USD"); err != nil {
+		t.Errorf("CheckTrading(ETH-USD) error = %v, want nil", err)
+	}
+}
+
+func TestCircuitBreaker_TripsAndRecovers(t *testing.T) {
+	store := NewOrderBookStore()
+	book := store.GetOrCreate("BTC-USD")
+	book.Update(
+		[]common.PriceLevel{{Price: decimal.NewFromInt(101), Size: decimal.NewFromInt(1)}},
+		[]common.PriceLevel{{Price: decimal.NewFromInt(100), Size: decimal.NewFromInt(1)}},
+		1)
+
+	breaker := NewCircuitBreaker(store, HealthConfig{RecoverAfter: 5 * time.Second})
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	if err := breaker.CheckTrading("BTC-USD"); !errors.Is(err, ErrMarketDataUnhealthy) {
+		t.Fatalf("CheckTrading() on a crossed book error = %v, want ErrMarketDataUnhealthy", err)
+	}
+
+	// Healthy again, but not for long enough to close the breaker
+	book.Update(
+		[]common.PriceLevel{{Price: decimal.NewFromInt(99), Size: decimal.NewFromInt(1)}},
+		[]common.PriceLevel{{Price: decimal.NewFromInt(100), Size: decimal.NewFromInt(1)}},
+		2)
+	now = now.Add(2 * time.Second)
+	if err := breaker.CheckTrading("BTC-USD"); !errors.Is(err, ErrMarketDataUnhealthy) {
+		t.Fatalf("CheckTrading() while recovering error = %v, want ErrMarketDataUnhealthy", err)
+	}
+
+	now = now.Add(4 * time.Second)
+	if err := breaker.CheckTrading("BTC-USD"); err != nil {
+		t.Fatalf("CheckTrading() after recovering error = %v, want nil", err)
+	}
+
+	// Other products are tracked independently
+	if err := breaker.CheckTrading("ETH-USD"); !errors.Is(err, ErrMarketDataUnhealthy) {
+		t.Errorf("CheckTrading() without a book error = %v, want ErrMarketDataUnhealthy", err)
+	}
+}
+
+func TestMarketDataClient_HeartbeatConfirmsQuietBooks(t *testing.T) {
+	store := NewOrderBookStore()
+	client := NewMarketDataClient(MarketDataConfig{CommonConfig: CommonConfig{
+		Products:         []string{"BTC-USD"},
+		HeartbeatTimeout: time.Second,
+	}}, store)
+
+	// The book last changed a minute ago
+	book := store.GetOrCreate("BTC-USD")
+	quiet := *quotedBook("BTC-USD", "99.9", "100.1").state.Load()
+	quiet.updateTime = time.Now().Add(-time.Minute)
+	book.state.Store(&quiet)
+
+	cfg := HealthConfig{StaleAfter: 10 * time.Second}
+	if health := CheckBookHealth("BTC-USD", book, cfg, time.Now()); !health.Stale {
+		t.Fatalf("book should be stale before a heartbeat, got %+v", health)
+	}
+
+	client.baseClient.heartbeats.HandleMessage(&Message{Channel: ChannelHeartbeats})
+	if health := CheckBookHealth("BTC-USD", book, cfg, time.Now()); !health.Healthy() {
+		t.Errorf("book should be fresh after a heartbeat, got problems %v", health.Problems())
+	}
+}
@@ -0,0 +1,89 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ChannelHeartbeats is Prime's keep-alive channel
+const ChannelHeartbeats = "heartbeats"
+
+// ErrHeartbeatTimeout is returned when no heartbeat arrives within the configured window
+var ErrHeartbeatTimeout = errors.New("no heartbeat received within timeout")
+
+// heartbeatsHandler subscribes to the heartbeats channel alongside a data channel
+// and records when the last heartbeat arrived so the read loop can detect a dead connection
+type heartbeatsHandler struct {
+	products      []string
+	lastHeartbeat atomic.Int64      // Unix nanoseconds
+	listeners     []func(time.Time) // Called on each heartbeat; only added before the connection starts
+}
+
+func newHeartbeatsHandler(products []string) *heartbeatsHandler {
+	return &heartbeatsHandler{products: products}
+}
+
+// GetChannelName returns the channel name for this handler
+func (h *heartbeatsHandler) GetChannelName() string {
+	return ChannelHeartbeats
+}
+
+// BuildSignatureMessage builds the message string to be signed
+func (h *heartbeatsHandler) BuildSignatureMessage(baseConfig BaseConfig, timestamp string) string {
+	// Format: channel + accessKey + serviceAccountId + timestamp + joinedProductIDs
+	return h.GetChannelName() + baseConfig.AccessKey + baseConfig.ServiceAccountId + timestamp + joinProductIds(h.products)
+}
+
+// BuildSubscriptionMessage builds the subscription payload
+func (h *heartbeatsHandler) BuildSubscriptionMessage(baseConfig BaseConfig, timestamp string, signature string) map[string]interface{} {
+	return buildBaseSubscriptionMessage(
+		h.GetChannelName(),
+		baseConfig.AccessKey,
+		baseConfig.ServiceAccountId,
+		timestamp,
+		baseConfig.Passphrase,
+		signature,
+		h.products,
+	)
+}
+
+// HandleMessage records the heartbeat's arrival time and tells the listeners
+func (h *heartbeatsHandler) HandleMessage(message *Message) error {
+	now := time.Now()
+	h.touch(now)
+	for _, fn := range h.listeners {
+		fn(now)
+	}
+	return nil
+}
+
+// touch marks t as the latest sign of life; also used to start the window on connect
+func (h *heartbeatsHandler) touch(t time.Time) {
+	h.lastHeartbeat.Store(t.UnixNano())
+}
+
+// LastHeartbeat returns when the last heartbeat (or connection) was seen
+func (h *heartbeatsHandler) LastHeartbeat() time.Time {
+	nanos := h.lastHeartbeat.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
@@ -0,0 +1,140 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/mockws"
+)
+
+func TestHeartbeats_KeepConnectionAlive(t *testing.T) {
+	scenario, err := mockws.ParseScenario([]byte(`{
+		"seed": 1, "tick_interval": "1h", "heartbeat_interval": "10ms",
+		"products": [{"product_id": "BTC-USD", "mid": "100", "spread_bps": "10", "tick_size": "0.01", "depth": 1, "level_size": "1", "volatility_bps": "1"}]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseScenario() error = %v", err)
+	}
+	creds := mockws.Credentials{AccessKey: "access", Passphrase: "passphrase", SigningKey: "key", ServiceAccountId: "svc"}
+	srv := httptest.NewServer(mockws.NewServer(creds, scenario))
+	defer srv.Close()
+
+	recorder := &stateRecorder{}
+	client := NewMarketDataClient(MarketDataConfig{
+		CommonConfig: CommonConfig{
+			Url:              "ws" + strings.TrimPrefix(srv.URL, "http"),
+			AccessKey:        creds.AccessKey,
+			Passphrase:       creds.Passphrase,
+			SigningKey:       creds.SigningKey,
+			ServiceAccountId: creds.ServiceAccountId,
+			Products:         []string{"BTC-USD"},
+			ReconnectDelay:   time.Millisecond,
+			HeartbeatTimeout: 200 * time.Millisecond,
+			OnStateChange:    recorder.record,
+		},
+		MaxLevels: 10,
+	}, NewOrderBookStore())
+	client.Start(context.Background())
+	defer client.Stop()
+
+	waitForState(t, client.State, StateConnected)
+	first := client.LastHeartbeat()
+
+	// Outlive the timeout several times over; steady heartbeats must keep the connection up
+	time.Sleep(600 * time.Millisecond)
+
+	if !client.LastHeartbeat().After(first) {
+		t.Error("LastHeartbeat() did not advance")
+	}
+	if got := strings.Join(recorder.states(), ","); got != "connecting,connected" {
+		t.Errorf("states = %s, want a single uninterrupted connection", got)
+	}
+}
+
+func TestHeartbeats_TimeoutTriggersReconnect(t *testing.T) {
+	// A server that accepts subscriptions but never sends heartbeats, like a half-open connection
+	var mu sync.Mutex
+	var channels []string
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var sub map[string]interface{}
+			if err := conn.ReadJSON(&sub); err != nil {
+				return
+			}
+			channel, _ := sub["channel"].(string)
+			mu.Lock()
+			channels = append(channels, channel)
+			mu.Unlock()
+		}
+	}))
+	defer srv.Close()
+
+	recorder := &stateRecorder{}
+	client := NewOrdersClient(OrdersConfig{
+		CommonConfig: CommonConfig{
+			Url:              "ws" + strings.TrimPrefix(srv.URL, "http"),
+			Products:         []string{"BTC-USD"},
+			ReconnectDelay:   time.Hour,
+			HeartbeatTimeout: 50 * time.Millisecond,
+			OnStateChange:    recorder.record,
+		},
+	}, nil)
+	client.Start(context.Background())
+	defer client.Stop()
+
+	waitForState(t, client.State, StateReconnecting)
+
+	last := recorder.last()
+	if !errors.Is(last.Err, ErrHeartbeatTimeout) {
+		t.Errorf("reconnect cause = %v, want ErrHeartbeatTimeout", last.Err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(channels, ","); got != "orders,heartbeats" {
+		t.Errorf("subscribed channels = %s, want orders,heartbeats", got)
+	}
+}
+
+func TestHeartbeats_DisabledByDefault(t *testing.T) {
+	client := NewMarketDataClient(MarketDataConfig{
+		CommonConfig: CommonConfig{Products: []string{"BTC-USD"}},
+	}, NewOrderBookStore())
+
+	if client.baseClient.heartbeats != nil {
+		t.Error("heartbeats should not be subscribed without HeartbeatTimeout")
+	}
+	if !client.LastHeartbeat().IsZero() {
+		t.Error("LastHeartbeat() should be zero when heartbeats are disabled")
+	}
+}
@@ -0,0 +1,156 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/database"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// HistoryConfig sets what the history recorder keeps and for how long
+type HistoryConfig struct {
+	SampleInterval   time.Duration // How often mid/spread samples are written
+	SnapshotInterval time.Duration // How often top-of-book snapshots are written; 0 disables them
+	Depth            int           // Levels per side in each snapshot
+	Retention        time.Duration // Rows older than this are pruned; 0 keeps everything
+}
+
+// HistoryWriter persists recorded market data; database.OrdersDb implements it
+type HistoryWriter interface {
+	InsertMarketHistory(samples []database.MarketSample, snapshots []database.MarketSnapshot) error
+	PruneMarketHistory(cutoff time.Time) (int64, error)
+}
+
+// historyPruneInterval bounds how often the recorder deletes expired rows
+const historyPruneInterval = time.Minute
+
+// HistoryRecorder periodically writes the store's books to a HistoryWriter
+type HistoryRecorder struct {
+	books  *OrderBookStore
+	writer HistoryWriter
+	cfg    HistoryConfig
+
+	lastSnapshot time.Time
+	lastPrune    time.Time
+}
+
+// NewHistoryRecorder creates a recorder over books
+func NewHistoryRecorder(books *OrderBookStore, writer HistoryWriter, cfg HistoryConfig) *HistoryRecorder {
+	return &HistoryRecorder{books: books, writer: writer, cfg: cfg}
+}
+
+// Run records the products returned by products every SampleInterval until ctx is cancelled
+// Write errors are logged and recording continues, so a busy database can't stop the stream
+func (r *HistoryRecorder) Run(ctx context.Context, products func() []string) {
+	ticker := time.NewTicker(r.cfg.SampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := r.Record(products(), now); err != nil {
+				zap.L().Warn("Failed to record market data history", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Record writes one sample per product, plus snapshots and pruning when they are due
+// Products without both sides of the book are skipped
+func (r *HistoryRecorder) Record(products []string, now time.Time) error {
+	takeSnapshots := r.cfg.SnapshotInterval > 0 && now.Sub(r.lastSnapshot) >= r.cfg.SnapshotInterval
+
+	var samples []database.MarketSample
+	var snapshots []database.MarketSnapshot
+	for _, product := range products {
+		book, ok := r.books.Get(product)
+		if !ok {
+			continue
+		}
+		state := book.state.Load()
+		if len(state.bids) == 0 || len(state.asks) == 0 {
+			continue
+		}
+
+		bid, ask := state.bids[0].Price, state.asks[0].Price
+		mid := common.CalculateMidPrice(bid, ask)
+		spreadBps := decimal.Zero
+		if mid.IsPositive() {
+			spreadBps = ask.Sub(bid).Div(mid).Mul(decimal.NewFromInt(10000))
+		}
+		samples = append(samples, database.MarketSample{
+			ProductId:  product,
+			BestBid:    bid.String(),
+			BestAsk:    ask.String(),
+			Mid:        mid.String(),
+			SpreadBps:  spreadBps.StringFixed(4),
+			BookTime:   state.updateTime,
+			RecordedAt: now,
+		})
+
+		if takeSnapshots {
+			snapshots = append(snapshots, database.MarketSnapshot{
+				ProductId:  product,
+				Sequence:   int64(state.sequence),
+				Bids:       historyLevels(state.bids, r.cfg.Depth),
+				Asks:       historyLevels(state.asks, r.cfg.Depth),
+				BookTime:   state.updateTime,
+				RecordedAt: now,
+			})
+		}
+	}
+
+	if len(samples) > 0 {
+		if err := r.writer.InsertMarketHistory(samples, snapshots); err != nil {
+			return err
+		}
+		if takeSnapshots {
+			r.lastSnapshot = now
+		}
+	}
+
+	if r.cfg.Retention > 0 && now.Sub(r.lastPrune) >= historyPruneInterval {
+		removed, err := r.writer.PruneMarketHistory(now.Add(-r.cfg.Retention))
+		if err != nil {
+			return err
+		}
+		r.lastPrune = now
+		if removed > 0 {
+			zap.L().Debug("Pruned market data history", zap.Int64("rows", removed))
+		}
+	}
+	return nil
+}
+
+// historyLevels converts up to depth levels into [price, size] pairs; depth 0 keeps them all
+func historyLevels(levels []common.PriceLevel, depth int) [][2]string {
+	if depth > 0 {
+		levels = topLevels(levels, depth)
+	}
+	result := make([][2]string, len(levels))
+	for i, level := range levels {
+		result[i] = [2]string{level.Price.String(), level.Size.String()}
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/database"
+	"github.com/shopspring/decimal"
+)
+
+// memoryHistory collects what the recorder writes
+type memoryHistory struct {
+	samples   []database.MarketSample
+	snapshots []database.MarketSnapshot
+	prunedTo  []time.Time
+}
+
+func (m *memoryHistory) InsertMarketHistory(samples []database.MarketSample, snapshots []database.MarketSnapshot) error {
+	m.samples = append(m.samples, samples...)
+	m.snapshots = append(m.snapshots, snapshots...)
+	return nil
+}
+
+func (m *memoryHistory) PruneMarketHistory(cutoff time.Time) (int64, error) {
+	m.prunedTo = append(m.prunedTo, cutoff)
+	return 0, nil
+}
+
+func TestHistoryRecorder_Record(t *testing.T) {
+	store := NewOrderBookStore()
+	store.GetOrCreate("BTC-USD").Update(
+		[]common.PriceLevel{
+			{Price: decimal.NewFromInt(99), Size: decimal.NewFromInt(1)},
+			{Price: decimal.NewFromInt(98), Size: decimal.NewFromInt(2)},
+		},
+		[]common.PriceLevel{{Price: decimal.NewFromInt(101), Size: decimal.NewFromInt(3)}},
+		7)
+	store.GetOrCreate("ETH-USD") // no levels yet
+
+	history := &memoryHistory{}
+	recorder := NewHistoryRecorder(store, history, HistoryConfig{
+		SampleInterval:   time.Second,
+		SnapshotInterval: 10 * time.Second,
+		Depth:            1,
+		Retention:        time.Hour,
+	})
+
+	start := time.Now()
+	for i := 0; i < 12; i++ {
+		if err := recorder.Record([]string{"BTC-USD", "ETH-USD", "SOL-USD"}, start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	// Books without both sides, and unknown products, are skipped
+	if len(history.samples) != 12 {
+		t.Fatalf("recorded %d samples, want 12 (BTC-USD only)", len(history.samples))
+	}
+	sample := history.samples[0]
+	if sample.ProductId != "BTC-USD" || sample.Mid != "100" || sample.SpreadBps != "200.0000" {
+		t.Errorf("sample = %+v, want BTC-USD mid 100 and 200 bps", sample)
+	}
+
+	// Snapshots at 0s and 10s, cut to Depth
+	if len(history.snapshots) != 2 {
+		t.Fatalf("recorded %d snapshots, want 2", len(history.snapshots))
+	}
+	snapshot := history.snapshots[0]
+	if snapshot.Sequence != 7 || len(snapshot.Bids) != 1 || snapshot.Bids[0] != [2]string{"99", "1"} || snapshot.Asks[0] != [2]string{"101", "3"} {
+		t.Errorf("snapshot = %+v, want sequence 7 with the top level per side", snapshot)
+	}
+
+	// Pruning runs once per historyPruneInterval, cutting at Retention
+	if len(history.prunedTo) != 1 || !history.prunedTo[0].Equal(start.Add(-time.Hour)) {
+		t.Errorf("pruned to %v, want once at start-1h", history.prunedTo)
+	}
+}
@@ -0,0 +1,208 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitForDone fails the test if done isn't closed within a few seconds
+func waitForDone(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not stop")
+	}
+}
+
+func newLifecycleClient(url string, recorder *stateRecorder) *MarketDataClient {
+	return NewMarketDataClient(MarketDataConfig{
+		CommonConfig: CommonConfig{
+			Url:            url,
+			Products:       []string{"BTC-USD"},
+			ReconnectDelay: time.Hour,
+			OnStateChange:  recorder.record,
+			Queue:          QueueConfig{Size: 16, Overflow: OverflowDropResync},
+		},
+	}, NewOrderBookStore())
+}
+
+func TestBaseWebSocketClient_StopWhileStarting(t *testing.T) {
+	srv := httptest.NewServer(&scriptedServer{})
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	// Stop races the dial, subscribe and first read; run with -race to check conn handling
+	for i := 0; i < 20; i++ {
+		recorder := &stateRecorder{}
+		client := newLifecycleClient(url, recorder)
+		client.Start(context.Background())
+
+		var wg sync.WaitGroup
+		for j := 0; j < 3; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				client.Stop()
+			}()
+		}
+		wg.Wait()
+
+		select {
+		case <-client.Done():
+		default:
+			t.Fatal("Done() not closed after Stop returned")
+		}
+		if err := client.Err(); err != nil {
+			t.Errorf("Err() = %v, want nil after Stop", err)
+		}
+		if got := recorder.last().State; got != StateStopped {
+			t.Errorf("last state = %s, want stopped", got)
+		}
+	}
+}
+
+func TestBaseWebSocketClient_ContextCancelStops(t *testing.T) {
+	srv := httptest.NewServer(&scriptedServer{})
+	defer srv.Close()
+
+	recorder := &stateRecorder{}
+	client := newLifecycleClient("ws"+strings.TrimPrefix(srv.URL, "http"), recorder)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client.Start(ctx)
+	waitForState(t, client.State, StateConnected)
+	if err := client.Err(); err != nil {
+		t.Errorf("Err() = %v while running, want nil", err)
+	}
+
+	cancel()
+	waitForDone(t, client.Done())
+
+	if err := client.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Err() = %v, want context.Canceled", err)
+	}
+	if got := strings.Join(recorder.states(), ","); got != "connecting,connected,stopped" {
+		t.Errorf("states = %s, want connecting,connected,stopped", got)
+	}
+
+	// Stop after the client already stopped returns immediately
+	client.Stop()
+}
+
+func TestBaseWebSocketClient_StopDrainsQueue(t *testing.T) {
+	var messages []map[string]interface{}
+	for seq := 1; seq <= 30; seq++ {
+		messages = append(messages, map[string]interface{}{
+			"channel":      "orders",
+			"sequence_num": seq,
+			"events":       []interface{}{},
+		})
+	}
+	server := &scriptedServer{batches: [][]map[string]interface{}{messages}}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	handler := &slowOrderHandler{delay: 2 * time.Millisecond}
+	client := NewOrdersClient(OrdersConfig{
+		CommonConfig: CommonConfig{
+			Url:            "ws" + strings.TrimPrefix(srv.URL, "http"),
+			Products:       []string{"BTC-USD"},
+			ReconnectDelay: time.Hour,
+			Queue:          QueueConfig{Size: 8, Overflow: OverflowSpill, SpillDir: t.TempDir()},
+		},
+	}, handler)
+	client.Start(context.Background())
+
+	// Stop once everything has been read but most of it is still waiting for the slow handler
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if stats, _ := client.QueueStats(); stats.Enqueued == 30 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if handled := len(handler.received()); handled == 30 {
+		t.Fatal("handler finished before Stop; nothing left to drain")
+	}
+	client.Stop()
+
+	if got := len(handler.received()); got != 30 {
+		t.Errorf("handled %d messages by the time Stop returned, want all 30", got)
+	}
+	if stats, _ := client.QueueStats(); stats.Depth != 0 || stats.Spilled != 0 || stats.Processed != 30 {
+		t.Errorf("stats = %+v, want an empty queue with 30 processed", stats)
+	}
+}
+
+func TestBaseWebSocketClient_ErrAfterGivingUp(t *testing.T) {
+	srv := httptest.NewServer(nil)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	srv.Close() // Every dial is refused
+
+	client := NewMarketDataClient(MarketDataConfig{
+		CommonConfig: CommonConfig{
+			Url:            url,
+			Products:       []string{"BTC-USD"},
+			ReconnectDelay: time.Millisecond,
+			Backoff:        BackoffConfig{MaxAttempts: 2},
+		},
+	}, NewOrderBookStore())
+	client.Start(context.Background())
+	waitForDone(t, client.Done())
+
+	if err := client.Err(); err == nil || !strings.Contains(err.Error(), "gave up") {
+		t.Errorf("Err() = %v, want the give-up error", err)
+	}
+	if client.State() != StateGaveUp {
+		t.Errorf("State() = %s, want gave_up", client.State())
+	}
+}
+
+func TestBaseWebSocketClient_StopBeforeStart(t *testing.T) {
+	client := NewMarketDataClient(MarketDataConfig{CommonConfig: CommonConfig{Products: []string{"BTC-USD"}}}, NewOrderBookStore())
+	client.Stop()
+
+	waitForDone(t, client.Done())
+	if err := client.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+
+	// A stopped client can't be restarted
+	client.Start(context.Background())
+	if client.baseClient.ctx != nil {
+		t.Error("Start on a stopped client should not run it")
+	}
+}
+
+func TestBaseWebSocketClient_StopTwiceBeforeStart(t *testing.T) {
+	client := NewMarketDataClient(MarketDataConfig{CommonConfig: CommonConfig{Products: []string{"BTC-USD"}}}, NewOrderBookStore())
+	client.Stop()
+	client.Stop()
+
+	waitForDone(t, client.Done())
+	if err := client.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+}
@@ -17,8 +17,10 @@
 package websocket
 
 import (
+	"context"
 	"fmt"
-	"sort"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -33,123 +35,266 @@ import (
 // ============================================================================
 
 // OrderBook maintains the current state of bids and asks for a product
+// Each side is a price-sorted slice updated in place with binary search. Updates publish a new immutable
+// state (copy-on-write), so readers never take a lock and snapshots share the published slices.
+// The full depth is kept; MaxLevels only limits what Snapshot and GetTopLevels return.
 type OrderBook struct {
-	mu           sync.Mutex // only writers use this
-	Product      string
-	Bids         []common.PriceLevel // Sorted descending by price
-	Asks         []common.PriceLevel // Sorted ascending by price
-	UpdateTime   time.Time
-	Sequence     uint64
-	bestBidValue atomic.Value // stores common.PriceLevel or nil
-	bestAskValue atomic.Value // stores common.PriceLevel or nil
+	Product string
+	Legs    []string // For synthetic cross books, the two products the book is composed from
+
+	mu        sync.Mutex // only writers use this
+	state     atomic.Pointer[bookState]
+	maxLevels atomic.Int64     // Levels per side returned to readers; 0 returns all
+	onUpdate  func(*OrderBook) // Called after each published update, under mu; set by the store
+	confirmed atomic.Int64     // Unix nanoseconds when the feed last showed the unchanged book is still current
+}
+
+// bookState is one published version of the book; its slices are never modified after publishing
+type bookState struct {
+	bids       []common.PriceLevel // Sorted descending by price
+	asks       []common.PriceLevel // Sorted ascending by price
+	updateTime time.Time
+	sequence   uint64
 }
 
 // NewOrderBook creates a new order book for a product
 func NewOrderBook(product string) *OrderBook {
-	return &OrderBook{
-		Product:    product,
-		Bids:       make([]common.PriceLevel, 0),
-		Asks:       make([]common.PriceLevel, 0),
-		UpdateTime: time.Now(),
-	}
+	ob := &OrderBook{Product: product}
+	ob.state.Store(&bookState{updateTime: time.Now()})
+	return ob
 }
 
 // Update replaces the order book with new levels
+// Bids must be sorted descending and asks ascending, without zero-size levels
 func (ob *OrderBook) Update(bids, asks []common.PriceLevel, sequence uint64) {
 	ob.mu.Lock()
 	defer ob.mu.Unlock()
 
-	ob.Bids = bids
-	ob.Asks = asks
-	ob.Sequence = sequence
-	ob.UpdateTime = time.Now()
+	ob.state.Store(&bookState{
+		bids:       copyLevels(bids, 0),
+		asks:       copyLevels(asks, 0),
+		updateTime: time.Now(),
+		sequence:   sequence,
+	})
+	ob.notify()
+}
 
-	// Update atomic best bid/ask
+// ApplyUpdates changes individual levels; a zero size removes the level
+// Each side touched is copied once per call, so a message's updates are published together
+func (ob *OrderBook) ApplyUpdates(bids, asks []common.PriceLevel, sequence uint64) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	current := ob.state.Load()
+	next := &bookState{
+		bids:       current.bids,
+		asks:       current.asks,
+		updateTime: time.Now(),
+		sequence:   sequence,
+	}
 	if len(bids) > 0 {
-		ob.bestBidValue.Store(bids[0])
-	} else {
-		ob.bestBidValue.Store(nil)
+		next.bids = applyLevels(current.bids, bids, compareBids)
 	}
-
 	if len(asks) > 0 {
-		ob.bestAskValue.Store(asks[0])
-	} else {
-		ob.bestAskValue.Store(nil)
+		next.asks = applyLevels(current.asks, asks, compareAsks)
+	}
+	ob.state.Store(next)
+	ob.notify()
+}
+
+// notify tells the store's update listeners about a newly published state
+func (ob *OrderBook) notify() {
+	if ob.onUpdate != nil {
+		ob.onUpdate(ob)
+	}
+}
+
+// applyLevels returns a copy of side with the changes applied, keeping it sorted by compare
+func applyLevels(side, changes []common.PriceLevel, compare func(common.PriceLevel, decimal.Decimal) int) []common.PriceLevel {
+	result := make([]common.PriceLevel, len(side), len(side)+len(changes))
+	copy(result, side)
+
+	for _, change := range changes {
+		i, found := slices.BinarySearchFunc(result, change.Price, compare)
+		switch {
+		case change.Size.IsZero():
+			if found {
+				result = slices.Delete(result, i, i+1)
+			}
+		case found:
+			result[i] = change
+		default:
+			result = slices.Insert(result, i, change)
+		}
+	}
+	return result
+}
+
+// compareBids orders bids highest price first
+func compareBids(level common.PriceLevel, price decimal.Decimal) int {
+	return price.Cmp(level.Price)
+}
+
+// compareAsks orders asks lowest price first
+func compareAsks(level common.PriceLevel, price decimal.Decimal) int {
+	return level.Price.Cmp(price)
+}
+
+// confirm records that the feed was live at t, so the book is current even if it hasn't changed
+func (ob *OrderBook) confirm(t time.Time) {
+	ob.confirmed.Store(t.UnixNano())
+}
+
+// freshAt returns when state was last known to be current: its update time or a later confirmation
+func (ob *OrderBook) freshAt(state *bookState) time.Time {
+	if nanos := ob.confirmed.Load(); nanos > state.updateTime.UnixNano() {
+		return time.Unix(0, nanos)
 	}
+	return state.updateTime
 }
 
 // GetBestBid returns the highest bid price and size
 func (ob *OrderBook) GetBestBid() (common.PriceLevel, bool) {
-	v := ob.bestBidValue.Load()
-	if v == nil {
+	bids := ob.state.Load().bids
+	if len(bids) == 0 {
 		return common.PriceLevel{}, false
 	}
-	return v.(common.PriceLevel), true
+	return bids[0], true
 }
 
 // GetBestAsk returns the lowest ask price and size
 func (ob *OrderBook) GetBestAsk() (common.PriceLevel, bool) {
-	v := ob.bestAskValue.Load()
-	if v == nil {
+	asks := ob.state.Load().asks
+	if len(asks) == 0 {
 		return common.PriceLevel{}, false
 	}
-	return v.(common.PriceLevel), true
+	return asks[0], true
 }
 
-// GetTopLevels returns the top N levels of bids and asks
-func (ob *OrderBook) GetTopLevels(n int) (bids []common.PriceLevel, asks []common.PriceLevel) {
-	ob.mu.Lock()
-	defer ob.mu.Unlock()
-
-	bidCount := n
-	if len(ob.Bids) < bidCount {
-		bidCount = len(ob.Bids)
-	}
-
-	askCount := n
-	if len(ob.Asks) < askCount {
-		askCount = len(ob.Asks)
-	}
+// SetMaxLevels limits how many levels per side readers see; 0 shows the full book
+func (ob *OrderBook) SetMaxLevels(n int) {
+	ob.maxLevels.Store(int64(n))
+}
 
-	bids = make([]common.PriceLevel, bidCount)
-	copy(bids, ob.Bids[:bidCount])
+// Indicative reports whether the book is a synthetic cross whose prices cannot be traded directly
+func (ob *OrderBook) Indicative() bool {
+	return len(ob.Legs) > 0
+}
 
-	asks = make([]common.PriceLevel, askCount)
-	copy(asks, ob.Asks[:askCount])
+// Depth returns how many bid and ask levels the book holds, regardless of MaxLevels
+func (ob *OrderBook) Depth() (bids, asks int) {
+	state := ob.state.Load()
+	return len(state.bids), len(state.asks)
+}
 
-	return bids, asks
+// GetTopLevels returns the top N levels of bids and asks, capped at MaxLevels when set
+// The slices are shared with the book and must not be modified
+func (ob *OrderBook) GetTopLevels(n int) (bids []common.PriceLevel, asks []common.PriceLevel) {
+	if maxLevels := int(ob.maxLevels.Load()); maxLevels > 0 && n > maxLevels {
+		n = maxLevels
+	}
+	if n < 0 {
+		n = 0
+	}
+	state := ob.state.Load()
+	return topLevels(state.bids, n), topLevels(state.asks, n)
 }
 
-// Snapshot returns a copy of the current order book state
+// Snapshot returns the current order book state, limited to MaxLevels per side when set
+// The slices are shared with the book and must not be modified
 func (ob *OrderBook) Snapshot() common.OrderBookSnapshot {
-	ob.mu.Lock()
-	defer ob.mu.Unlock()
+	return ob.snapshot(int(ob.maxLevels.Load()))
+}
 
-	bids := make([]common.PriceLevel, len(ob.Bids))
-	copy(bids, ob.Bids)
+// FullSnapshot returns the current order book state at full depth, ignoring MaxLevels
+// Use it to walk the book for executions; the slices are shared with the book and must not be modified
+func (ob *OrderBook) FullSnapshot() common.OrderBookSnapshot {
+	return ob.snapshot(0)
+}
 
-	asks := make([]common.PriceLevel, len(ob.Asks))
-	copy(asks, ob.Asks)
+// snapshot returns the current state limited to limit levels per side; 0 returns all
+func (ob *OrderBook) snapshot(limit int) common.OrderBookSnapshot {
+	state := ob.state.Load()
+	bids, asks := state.bids, state.asks
+	if limit > 0 {
+		bids, asks = topLevels(bids, limit), topLevels(asks, limit)
+	}
 
 	return common.OrderBookSnapshot{
 		Product:    ob.Product,
 		Bids:       bids,
 		Asks:       asks,
-		UpdateTime: ob.UpdateTime,
-		Sequence:   ob.Sequence,
+		UpdateTime: state.updateTime,
+		Sequence:   state.sequence,
+		Indicative: ob.Indicative(),
+	}
+}
+
+// EstimateExecution walks the full book, not just MaxLevels, for a BUY or SELL of size in unit ("base" or "quote")
+// and returns the VWAP, worst price and available liquidity with the adjuster's markup applied
+func (ob *OrderBook) EstimateExecution(side, unit string, size decimal.Decimal, adjuster *common.PriceAdjuster) (common.ExecutionEstimate, error) {
+	side = strings.ToUpper(side)
+	unit = strings.ToLower(unit)
+	if unit != "base" && unit != "quote" {
+		return common.ExecutionEstimate{}, fmt.Errorf("invalid unit %q: must be base or quote", unit)
+	}
+	if !size.IsPositive() {
+		return common.ExecutionEstimate{}, fmt.Errorf("size must be positive")
 	}
+
+	snapshot := ob.FullSnapshot()
+	var levels []common.PriceLevel
+	switch side {
+	case "BUY":
+		levels = snapshot.Asks
+	case "SELL":
+		levels = snapshot.Bids
+	default:
+		return common.ExecutionEstimate{}, fmt.Errorf("invalid side %q: must be BUY or SELL", side)
+	}
+	if len(levels) == 0 {
+		return common.ExecutionEstimate{}, fmt.Errorf("%s order book has no %s liquidity", ob.Product, strings.ToLower(side))
+	}
+
+	estimate := adjuster.EstimateExecution(levels, side, unit, size)
+	estimate.Product = ob.Product
+	estimate.Indicative = ob.Indicative()
+	return estimate, nil
+}
+
+// topLevels returns up to n levels without copying; the capacity is capped so appends can't reach the book
+func topLevels(levels []common.PriceLevel, n int) []common.PriceLevel {
+	if len(levels) > n {
+		levels = levels[:n]
+	}
+	return levels[:len(levels):len(levels)]
+}
+
+// copyLevels copies up to limit levels; 0 copies them all
+func copyLevels(levels []common.PriceLevel, limit int) []common.PriceLevel {
+	n := len(levels)
+	if limit > 0 && n > limit {
+		n = limit
+	}
+	result := make([]common.PriceLevel, n)
+	copy(result, levels[:n])
+	return result
 }
 
 // OrderBookStore manages multiple order books
 type OrderBookStore struct {
-	mu    sync.RWMutex
-	books map[string]*OrderBook
+	mu         sync.RWMutex
+	books      map[string]*OrderBook
+	synthetics map[string]*syntheticBook // Cross products composed from two books on read
+	maxLevels  int                       // Applied to every book
+	listeners  atomic.Pointer[[]func(*OrderBook)]
 }
 
 // NewOrderBookStore creates a new order book store
 func NewOrderBookStore() *OrderBookStore {
 	return &OrderBookStore{
-		books: make(map[string]*OrderBook),
+		books:      make(map[string]*OrderBook),
+		synthetics: make(map[string]*syntheticBook),
 	}
 }
 
@@ -163,17 +308,81 @@ func (s *OrderBookStore) GetOrCreate(product string) *OrderBook {
 	}
 
 	book := NewOrderBook(product)
+	book.SetMaxLevels(s.maxLevels)
+	book.onUpdate = s.notifyListeners
 	s.books[product] = book
 	return book
 }
 
+// AddUpdateListener calls fn after every update to any of the store's books
+// Whenever a leg updates, fn also gets its synthetic books, composed only to MaxLevels (just the top
+// of book when books are unlimited); use Get for a synthetic book's full depth.
+// fn runs on the writer's goroutine while the book's writer lock is held, so it must be quick and must not update books or change a client's products
+func (s *OrderBookStore) AddUpdateListener(fn func(*OrderBook)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var listeners []func(*OrderBook)
+	if current := s.listeners.Load(); current != nil {
+		listeners = append(listeners, *current...)
+	}
+	listeners = append(listeners, fn)
+	s.listeners.Store(&listeners)
+}
+
+// notifyListeners runs the update listeners for book and for any synthetic book it is a leg of
+func (s *OrderBookStore) notifyListeners(book *OrderBook) {
+	listeners := s.listeners.Load()
+	if listeners == nil {
+		return
+	}
+	for _, fn := range *listeners {
+		fn(book)
+	}
+
+	for _, synthetic := range s.syntheticsWithLeg(book.Product) {
+		composed, ok := s.composeSyntheticTop(synthetic)
+		if !ok {
+			continue
+		}
+		for _, fn := range *listeners {
+			fn(composed)
+		}
+	}
+}
+
+// SetMaxLevels limits how many levels per side every book's readers see; 0 shows full books
+func (s *OrderBookStore) SetMaxLevels(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxLevels = n
+	for _, book := range s.books {
+		book.SetMaxLevels(n)
+	}
+}
+
 // Get retrieves an order book for a product
+// Synthetic products are composed from their legs; the returned book is a read-only view as of the call
 func (s *OrderBookStore) Get(product string) (*OrderBook, bool) {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-
 	book, exists := s.books[product]
-	return book, exists
+	synthetic := s.synthetics[product]
+	s.mu.RUnlock()
+
+	if exists || synthetic == nil {
+		return book, exists
+	}
+	return s.composeSynthetic(synthetic)
+}
+
+// Remove evicts a product's order book or synthetic definition
+func (s *OrderBookStore) Remove(product string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.books, product)
+	delete(s.synthetics, product)
 }
 
 // ============================================================================
@@ -184,54 +393,140 @@ func (s *OrderBookStore) Get(product string) (*OrderBook, bool) {
 type MarketDataConfig struct {
 	CommonConfig
 	Portfolio string
-	MaxLevels int
+	MaxLevels int // Levels per side the store's books show readers; the full depth is always maintained
 }
 
 // MarketDataClient manages the connection to Coinbase Prime WebSocket
 type MarketDataClient struct {
 	config     MarketDataConfig
 	store      *OrderBookStore
+	products   *productSet
 	baseClient *BaseWebSocketClient
 }
 
 // NewMarketDataClient creates a new WebSocket client
 func NewMarketDataClient(config MarketDataConfig, store *OrderBookStore) *MarketDataClient {
 	client := &MarketDataClient{
-		config: config,
-		store:  store,
+		config:   config,
+		store:    store,
+		products: newProductSet(config.Products),
+	}
+	if config.MaxLevels > 0 {
+		store.SetMaxLevels(config.MaxLevels)
 	}
 
 	baseConfig := baseConfigFromCommon(config.CommonConfig)
 	client.baseClient = NewBaseWebSocketClient(baseConfig, client)
+	client.baseClient.enableHeartbeats(config.Products)
+	client.baseClient.addHeartbeatListener(client.handleHeartbeat)
 	return client
 }
 
-// Start begins the WebSocket connection and message processing
-func (c *MarketDataClient) Start() error {
-	return c.baseClient.Start()
+// Start connects in the background; cancelling ctx stops the client like Stop does
+func (c *MarketDataClient) Start(ctx context.Context) error {
+	return c.baseClient.Start(ctx)
 }
 
-// Stop gracefully stops the WebSocket client
+// Stop stops the client and waits until messages already received have been handled
 func (c *MarketDataClient) Stop() {
 	c.baseClient.Stop()
 }
 
+// Done is closed once the client has stopped and drained its queue
+func (c *MarketDataClient) Done() <-chan struct{} {
+	return c.baseClient.Done()
+}
+
+// Err reports why the client stopped; nil until Done is closed
+// It is the fatal or give-up error, the ctx error if Start's ctx was cancelled, or nil after Stop
+func (c *MarketDataClient) Err() error {
+	return c.baseClient.Err()
+}
+
+// State returns the current connection state
+// Use CommonConfig.OnStateChange to be notified of transitions instead of polling
+func (c *MarketDataClient) State() ConnectionState {
+	return c.baseClient.State()
+}
+
+// LastHeartbeat returns when the last heartbeat was received (zero if heartbeats are disabled)
+func (c *MarketDataClient) LastHeartbeat() time.Time {
+	return c.baseClient.LastHeartbeat()
+}
+
+// SequenceGaps returns how many l2_data sequence gaps have triggered a resync
+func (c *MarketDataClient) SequenceGaps() uint64 {
+	return c.baseClient.channelSequenceGaps(c.GetChannelName())
+}
+
+// Products returns the currently subscribed products
+func (c *MarketDataClient) Products() []string {
+	return c.products.list()
+}
+
+// AddProducts subscribes to more products without restarting the client
+func (c *MarketDataClient) AddProducts(products ...string) error {
+	added := c.products.add(products)
+	if err := c.baseClient.updateProducts(c, "subscribe", added); err != nil {
+		return fmt.Errorf("failed to subscribe to %v: %w", added, err)
+	}
+	return nil
+}
+
+// RemoveProducts unsubscribes from products and evicts their order books
+func (c *MarketDataClient) RemoveProducts(products ...string) error {
+	// Evicting under the set's lock waits out any l2 event being applied; later ones see the product gone
+	removed, err := c.products.remove(products, c.store.Remove)
+	if err != nil {
+		return err
+	}
+
+	if err := c.baseClient.updateProducts(c, "unsubscribe", removed); err != nil {
+		return fmt.Errorf("failed to unsubscribe from %v: %w", removed, err)
+	}
+	return nil
+}
+
+// QueueStats returns the work queue's depth and latency metrics; false when messages are handled inline
+func (c *MarketDataClient) QueueStats() (QueueStats, bool) {
+	return c.baseClient.queueStats(c.GetChannelName())
+}
+
+// queueConfig configures the work queue between the read loop and this client
+func (c *MarketDataClient) queueConfig() QueueConfig {
+	return c.config.Queue
+}
+
+// useConnection moves the client onto a connection shared through a ConnectionManager
+func (c *MarketDataClient) useConnection(baseClient *BaseWebSocketClient) {
+	c.baseClient = baseClient
+	c.baseClient.addHeartbeatListener(c.handleHeartbeat)
+}
+
 // ChannelHandler interface implementation
 
 // GetChannelName returns the channel name for this handler
 func (c *MarketDataClient) GetChannelName() string {
-	return "l2_data"
+	return ChannelL2Data
 }
 
 // BuildSignatureMessage builds the message string to be signed
 func (c *MarketDataClient) BuildSignatureMessage(baseConfig BaseConfig, timestamp string) string {
-	// Format: channel + accessKey + serviceAccountId + timestamp + joinedProductIDs
-	productIdsJoined := joinProductIds(c.config.Products)
-	return c.GetChannelName() + baseConfig.AccessKey + baseConfig.ServiceAccountId + timestamp + productIdsJoined
+	return c.signatureFor(baseConfig, timestamp, c.products.list())
 }
 
 // BuildSubscriptionMessage builds the subscription payload
 func (c *MarketDataClient) BuildSubscriptionMessage(baseConfig BaseConfig, timestamp string, signature string) map[string]interface{} {
+	return c.subscriptionFor(baseConfig, timestamp, signature, c.products.list())
+}
+
+func (c *MarketDataClient) signatureFor(baseConfig BaseConfig, timestamp string, products []string) string {
+	// Format: channel + accessKey + serviceAccountId + timestamp + joinedProductIDs
+	productIdsJoined := joinProductIds(products)
+	return c.GetChannelName() + baseConfig.AccessKey + baseConfig.ServiceAccountId + timestamp + productIdsJoined
+}
+
+func (c *MarketDataClient) subscriptionFor(baseConfig BaseConfig, timestamp, signature string, products []string) map[string]interface{} {
 	return buildBaseSubscriptionMessage(
 		c.GetChannelName(),
 		baseConfig.AccessKey,
@@ -239,95 +534,99 @@ func (c *MarketDataClient) BuildSubscriptionMessage(baseConfig BaseConfig, times
 		timestamp,
 		baseConfig.Passphrase,
 		signature,
-		c.config.Products,
+		products,
 	)
 }
 
 // HandleMessage processes messages for the l2_data channel
-func (c *MarketDataClient) HandleMessage(message map[string]interface{}) error {
-	// Get events array
-	eventsRaw, ok := message["events"].([]interface{})
-	if !ok || len(eventsRaw) == 0 {
+func (c *MarketDataClient) HandleMessage(message *Message) error {
+	if len(message.L2Events) == 0 {
 		return fmt.Errorf("message missing events array")
 	}
 
-	// Process each event
-	for _, eventRaw := range eventsRaw {
-		event, ok := eventRaw.(map[string]interface{})
-		if !ok {
-			continue
-		}
+	sequence, _ := message.Sequence()
 
-		if err := c.handleL2Event(event); err != nil {
+	// Process each event
+	for i := range message.L2Events {
+		if err := c.handleL2Event(&message.L2Events[i], sequence); err != nil {
 			zap.L().Error("Error handling L2 event", zap.Error(err))
 		}
 	}
 
+	// Sequence numbers span the whole channel, so an in-order message shows every subscribed book is current
+	c.confirmBooks(time.Now())
 	return nil
 }
 
-func (c *MarketDataClient) handleL2Event(event map[string]interface{}) error {
-	// Parse event metadata
-	eventType, productId, err := c.parseEventMetadata(event)
-	if err != nil {
-		return err
+// confirmBooks marks the subscribed products' books as current as of t
+func (c *MarketDataClient) confirmBooks(t time.Time) {
+	for _, product := range c.products.list() {
+		if book, ok := c.store.Get(product); ok {
+			book.confirm(t)
+		}
 	}
+}
 
-	// Parse price level updates
-	updates, err := c.parseUpdates(event)
-	if err != nil {
-		return err
+// handleHeartbeat counts a heartbeat on a live connection as confirming the books, so quiet products
+// don't look stale; not while l2 messages are still queued, since the books haven't caught up with them
+func (c *MarketDataClient) handleHeartbeat(t time.Time) {
+	if stats, ok := c.QueueStats(); ok && stats.Depth > 0 {
+		return
 	}
+	c.confirmBooks(t)
+}
 
-	// Get or create order book
-	book := c.store.GetOrCreate(productId)
-
-	// Apply updates based on event type
-	if eventType == "snapshot" {
-		return c.handleSnapshot(book, updates)
-	}
-	return c.handleUpdate(book, updates)
+// DiscardOnSequenceGap drops the update that revealed a gap rather than applying it to an incomplete book
+func (c *MarketDataClient) DiscardOnSequenceGap() bool {
+	return true
 }
 
-// parseEventMetadata extracts event type and product Id from event
-func (c *MarketDataClient) parseEventMetadata(event map[string]interface{}) (string, string, error) {
-	eventType, ok := event["type"].(string)
-	if !ok {
-		return "", "", fmt.Errorf("event missing type field")
-	}
+// HandleSequenceGap needs no extra recovery; the resubscribe delivers a fresh snapshot that replaces the book
+func (c *MarketDataClient) HandleSequenceGap(expected, received uint64) {}
 
-	productId, ok := event["product_id"].(string)
-	if !ok || productId == "" {
-		return "", "", fmt.Errorf("event missing product_id")
+func (c *MarketDataClient) handleL2Event(event *L2Event, sequence uint64) error {
+	if event.Type == "" {
+		return fmt.Errorf("event missing type field")
+	}
+	if event.ProductId == "" {
+		return fmt.Errorf("event missing product_id")
 	}
 
-	return eventType, productId, nil
-}
-
-// parseUpdates extracts and parses all price level updates from an event
-func (c *MarketDataClient) parseUpdates(event map[string]interface{}) (map[string]common.PriceLevel, error) {
-	updatesRaw, ok := event["updates"].([]interface{})
-	if !ok {
-		return nil, fmt.Errorf("event missing updates array")
+	// Parse price level updates
+	bids, asks, err := c.parseUpdates(event)
+	if err != nil {
+		return err
 	}
 
-	levels := make(map[string]common.PriceLevel)
+	// Drop events for products removed while they were in flight; holding the product set's lock
+	// while the book is created and updated keeps RemoveProducts from evicting it part way through
+	c.products.ifContains(event.ProductId, func() {
+		book := c.store.GetOrCreate(event.ProductId)
 
-	for _, updateRaw := range updatesRaw {
-		update, ok := updateRaw.(map[string]interface{})
-		if !ok {
-			continue
+		// Apply updates based on event type
+		if event.Type == "snapshot" {
+			err = c.handleSnapshot(book, bids, asks, sequence)
+			return
 		}
+		err = c.handleUpdate(book, bids, asks, sequence)
+	})
+	return err
+}
 
-		side, _ := update["side"].(string)
+// parseUpdates parses all price level updates from an event, split by side in message order
+func (c *MarketDataClient) parseUpdates(event *L2Event) (bids, asks []common.PriceLevel, err error) {
+	if event.Updates == nil {
+		return nil, nil, fmt.Errorf("event missing updates array")
+	}
 
+	for _, update := range event.Updates {
 		// Parse price and size using decimal (never float64 for financial data)
-		price, err := c.parseDecimalField(update, "px")
+		price, err := decimal.NewFromString(update.Px.String())
 		if err != nil {
 			continue
 		}
 
-		size, err := c.parseDecimalField(update, "qty")
+		size, err := decimal.NewFromString(update.Qty.String())
 		if err != nil {
 			continue
 		}
@@ -337,136 +636,26 @@ func (c *MarketDataClient) parseUpdates(event map[string]interface{}) (map[strin
 			Size:  size,
 		}
 
-		key := side + ":" + priceLevel.Price.String()
-		levels[key] = priceLevel
+		switch update.Side {
+		case "bid":
+			bids = append(bids, priceLevel)
+		case "ask", "offer": // Prime reports asks as "offer"
+			asks = append(asks, priceLevel)
+		}
 	}
 
-	return levels, nil
-}
-
-// parseDecimalField safely parses a field that may be string or float64 into decimal.Decimal
-// This handles JSON variability while ensuring we always use precise decimal types for financial data
-func (c *MarketDataClient) parseDecimalField(update map[string]interface{}, key string) (decimal.Decimal, error) {
-	val, ok := update[key]
-	if !ok {
-		return decimal.Zero, fmt.Errorf("missing field: %s", key)
-	}
-
-	switch v := val.(type) {
-	case string:
-		return decimal.NewFromString(v)
-	case float64:
-		// Convert float64 from JSON to decimal immediately
-		// We never keep financial data as float64
-		return decimal.NewFromFloat(v), nil
-	default:
-		return decimal.Zero, fmt.Errorf("invalid type for %s", key)
-	}
+	return bids, asks, nil
 }
 
 // handleSnapshot replaces the entire order book with snapshot data
-func (c *MarketDataClient) handleSnapshot(book *OrderBook, levels map[string]common.PriceLevel) error {
-	bids, asks := c.buildOrderBook(levels)
-	book.Update(bids, asks, 0)
+func (c *MarketDataClient) handleSnapshot(book *OrderBook, bids, asks []common.PriceLevel, sequence uint64) error {
+	// Sorting through applyLevels also drops zero sizes and keeps the last entry for a repeated price
+	book.Update(applyLevels(nil, bids, compareBids), applyLevels(nil, asks, compareAsks), sequence)
 	return nil
 }
 
 // handleUpdate applies incremental updates to existing order book
-func (c *MarketDataClient) handleUpdate(book *OrderBook, newLevels map[string]common.PriceLevel) error {
-	snapshot := book.Snapshot()
-
-	// Build maps of existing levels
-	bidMap := make(map[string]common.PriceLevel)
-	askMap := make(map[string]common.PriceLevel)
-
-	for _, bid := range snapshot.Bids {
-		bidMap[bid.Price.String()] = bid
-	}
-	for _, ask := range snapshot.Asks {
-		askMap[ask.Price.String()] = ask
-	}
-
-	// Apply updates (replace, add, or remove)
-	for key, level := range newLevels {
-		priceKey := level.Price.String()
-		if key[:3] == "bid" {
-			if level.Size.IsZero() {
-				delete(bidMap, priceKey)
-			} else {
-				bidMap[priceKey] = level
-			}
-		} else if key[:3] == "ask" {
-			if level.Size.IsZero() {
-				delete(askMap, priceKey)
-			} else {
-				askMap[priceKey] = level
-			}
-		}
-	}
-
-	// Convert maps back to slices and sort
-	bids := c.mapToSlice(bidMap)
-	asks := c.mapToSlice(askMap)
-
-	c.sortBids(bids)
-	c.sortAsks(asks)
-
-	bids = c.limitLevels(bids)
-	asks = c.limitLevels(asks)
-
-	book.Update(bids, asks, 0)
+func (c *MarketDataClient) handleUpdate(book *OrderBook, bids, asks []common.PriceLevel, sequence uint64) error {
+	book.ApplyUpdates(bids, asks, sequence)
 	return nil
 }
-
-// buildOrderBook converts a map of levels into sorted, limited bid/ask slices
-func (c *MarketDataClient) buildOrderBook(levels map[string]common.PriceLevel) ([]common.PriceLevel, []common.PriceLevel) {
-	bids := []common.PriceLevel{}
-	asks := []common.PriceLevel{}
-
-	for key, level := range levels {
-		if level.Size.IsZero() {
-			continue
-		}
-		if key[:3] == "bid" {
-			bids = append(bids, level)
-		} else if key[:3] == "ask" || key[:3] == "off" { // Prime might use "offer"
-			asks = append(asks, level)
-		}
-	}
-
-	c.sortBids(bids)
-	c.sortAsks(asks)
-
-	return c.limitLevels(bids), c.limitLevels(asks)
-}
-
-// mapToSlice converts a map of price levels to a slice
-func (c *MarketDataClient) mapToSlice(m map[string]common.PriceLevel) []common.PriceLevel {
-	result := make([]common.PriceLevel, 0, len(m))
-	for _, level := range m {
-		result = append(result, level)
-	}
-	return result
-}
-
-// limitLevels caps the number of levels if MaxLevels is configured
-func (c *MarketDataClient) limitLevels(levels []common.PriceLevel) []common.PriceLevel {
-	if c.config.MaxLevels > 0 && len(levels) > c.config.MaxLevels {
-		return levels[:c.config.MaxLevels]
-	}
-	return levels
-}
-
-// sortBids sorts bids in descending order (highest price first)
-func (c *MarketDataClient) sortBids(bids []common.PriceLevel) {
-	sort.Slice(bids, func(i, j int) bool {
-		return bids[i].Price.GreaterThan(bids[j].Price)
-	})
-}
-
-// sortAsks sorts asks in ascending order (lowest price first)
-func (c *MarketDataClient) sortAsks(asks []common.PriceLevel) {
-	sort.Slice(asks, func(i, j int) bool {
-		return asks[i].Price.LessThan(asks[j].Price)
-	})
-}
@@ -0,0 +1,414 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/shopspring/decimal"
+)
+
+// replayL2 replays l2_data messages into a MarketDataClient and returns the BTC-USD book
+func replayL2(t *testing.T, maxLevels int, messages ...map[string]interface{}) *OrderBook {
+	t.Helper()
+
+	frames := make([]string, len(messages))
+	offsets := make([]time.Duration, len(messages))
+	for i, msg := range messages {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		frames[i] = string(data)
+	}
+	source, err := NewReplaySource(writeRecording(t, offsets, frames))
+	if err != nil {
+		t.Fatalf("NewReplaySource() error = %v", err)
+	}
+
+	store := NewOrderBookStore()
+	client := NewMarketDataClient(MarketDataConfig{
+		CommonConfig: CommonConfig{Products: []string{"BTC-USD"}},
+		MaxLevels:    maxLevels,
+	}, store)
+	if _, err := source.Replay(context.Background(), 0, client); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+
+	book, ok := store.Get("BTC-USD")
+	if !ok {
+		t.Fatal("no BTC-USD book after replay")
+	}
+	return book
+}
+
+func prices(levels []common.PriceLevel) string {
+	result := make([]string, len(levels))
+	for i, l := range levels {
+		result[i] = l.Price.String() + "x" + l.Size.String()
+	}
+	return strings.Join(result, ",")
+}
+
+func TestMarketDataClient_FullDepthBook(t *testing.T) {
+	snapshot := l2Message(1, "snapshot",
+		level("bid", "100", "1"), level("bid", "99", "1"), level("bid", "98", "1"), level("bid", "97", "1"), level("bid", "96", "1"),
+		level("offer", "101", "1"), level("offer", "102", "1"), level("offer", "103", "1"), level("offer", "104", "1"), level("offer", "105", "1"))
+
+	tests := []struct {
+		name      string
+		maxLevels int
+		updates   []map[string]interface{}
+		wantBids  string
+		wantAsks  string
+		wantDepth [2]int
+	}{
+		{
+			name:      "snapshot keeps every level",
+			maxLevels: 3,
+			wantBids:  "100x1,99x1,98x1",
+			wantAsks:  "101x1,102x1,103x1",
+			wantDepth: [2]int{5, 5},
+		},
+		{
+			name:      "removing top levels reveals deeper ones",
+			maxLevels: 3,
+			updates: []map[string]interface{}{
+				l2Message(2, "update", level("bid", "100", "0")),
+				l2Message(3, "update", level("bid", "99", "0"), level("offer", "101", "0")),
+			},
+			wantBids:  "98x1,97x1,96x1",
+			wantAsks:  "102x1,103x1,104x1",
+			wantDepth: [2]int{3, 4},
+		},
+		{
+			name:      "levels beyond the display depth are still updated",
+			maxLevels: 2,
+			updates: []map[string]interface{}{
+				l2Message(2, "update", level("bid", "97", "7")),
+				l2Message(3, "update", level("bid", "100", "0"), level("bid", "99", "0"), level("bid", "98", "0")),
+			},
+			wantBids:  "97x7,96x1",
+			wantAsks:  "101x1,102x1",
+			wantDepth: [2]int{2, 5},
+		},
+		{
+			name:      "better prices push levels below the display depth without losing them",
+			maxLevels: 2,
+			updates: []map[string]interface{}{
+				l2Message(2, "update", level("bid", "100.5", "2"), level("offer", "100.75", "3")),
+				l2Message(3, "update", level("bid", "100.5", "0"), level("offer", "100.75", "0")),
+			},
+			wantBids:  "100x1,99x1",
+			wantAsks:  "101x1,102x1",
+			wantDepth: [2]int{5, 5},
+		},
+		{
+			name:      "a new snapshot replaces the full depth",
+			maxLevels: 3,
+			updates: []map[string]interface{}{
+				l2Message(2, "update", level("bid", "95", "1")),
+				l2Message(3, "snapshot", level("bid", "90", "1"), level("offer", "110", "1")),
+			},
+			wantBids:  "90x1",
+			wantAsks:  "110x1",
+			wantDepth: [2]int{1, 1},
+		},
+		{
+			name:      "no limit shows the full book",
+			updates:   []map[string]interface{}{l2Message(2, "update", level("bid", "100", "0"))},
+			wantBids:  "99x1,98x1,97x1,96x1",
+			wantAsks:  "101x1,102x1,103x1,104x1,105x1",
+			wantDepth: [2]int{4, 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := replayL2(t, tt.maxLevels, append([]map[string]interface{}{snapshot}, tt.updates...)...)
+
+			snap := book.Snapshot()
+			if got := prices(snap.Bids); got != tt.wantBids {
+				t.Errorf("Snapshot() bids = %s, want %s", got, tt.wantBids)
+			}
+			if got := prices(snap.Asks); got != tt.wantAsks {
+				t.Errorf("Snapshot() asks = %s, want %s", got, tt.wantAsks)
+			}
+			if bids, asks := book.Depth(); bids != tt.wantDepth[0] || asks != tt.wantDepth[1] {
+				t.Errorf("Depth() = %d, %d, want %d, %d", bids, asks, tt.wantDepth[0], tt.wantDepth[1])
+			}
+
+			// Best bid/ask come from the full book, which the display always starts with
+			if best, ok := book.GetBestBid(); !ok || !strings.HasPrefix(tt.wantBids, best.Price.String()+"x") {
+				t.Errorf("GetBestBid() = %v, want the first of %s", best.Price, tt.wantBids)
+			}
+		})
+	}
+}
+
+func TestOrderBook_GetTopLevelsRespectsMaxLevels(t *testing.T) {
+	book := replayL2(t, 3, l2Message(1, "snapshot",
+		level("bid", "100", "1"), level("bid", "99", "1"), level("bid", "98", "1"), level("bid", "97", "1"),
+		level("offer", "101", "1")))
+
+	tests := []struct {
+		n        int
+		wantBids string
+	}{
+		{n: 2, wantBids: "100x1,99x1"},
+		{n: 3, wantBids: "100x1,99x1,98x1"},
+		{n: 10, wantBids: "100x1,99x1,98x1"},
+	}
+	for _, tt := range tests {
+		bids, asks := book.GetTopLevels(tt.n)
+		if got := prices(bids); got != tt.wantBids {
+			t.Errorf("GetTopLevels(%d) bids = %s, want %s", tt.n, got, tt.wantBids)
+		}
+		if got := prices(asks); got != "101x1" {
+			t.Errorf("GetTopLevels(%d) asks = %s, want 101x1", tt.n, got)
+		}
+	}
+
+	// Raising the limit shows levels that were kept all along
+	book.SetMaxLevels(0)
+	if bids, _ := book.GetTopLevels(10); prices(bids) != "100x1,99x1,98x1,97x1" {
+		t.Errorf("GetTopLevels(10) without a limit = %s, want all four bids", prices(bids))
+	}
+}
+
+// legacyBook applies updates the way OrderBook did before it kept sorted slices:
+// rebuild price-keyed maps from the full book, apply the changes, then re-sort both sides.
+// It is kept as a reference for the equivalence test and the benchmarks.
+type legacyBook struct {
+	bids, asks []common.PriceLevel
+}
+
+func (b *legacyBook) apply(bids, asks []common.PriceLevel) {
+	bidMap := make(map[string]common.PriceLevel, len(b.bids))
+	askMap := make(map[string]common.PriceLevel, len(b.asks))
+	for _, l := range b.bids {
+		bidMap[l.Price.String()] = l
+	}
+	for _, l := range b.asks {
+		askMap[l.Price.String()] = l
+	}
+	for _, l := range bids {
+		if l.Size.IsZero() {
+			delete(bidMap, l.Price.String())
+		} else {
+			bidMap[l.Price.String()] = l
+		}
+	}
+	for _, l := range asks {
+		if l.Size.IsZero() {
+			delete(askMap, l.Price.String())
+		} else {
+			askMap[l.Price.String()] = l
+		}
+	}
+
+	b.bids = make([]common.PriceLevel, 0, len(bidMap))
+	for _, l := range bidMap {
+		b.bids = append(b.bids, l)
+	}
+	b.asks = make([]common.PriceLevel, 0, len(askMap))
+	for _, l := range askMap {
+		b.asks = append(b.asks, l)
+	}
+	sort.Slice(b.bids, func(i, j int) bool { return b.bids[i].Price.GreaterThan(b.bids[j].Price) })
+	sort.Slice(b.asks, func(i, j int) bool { return b.asks[i].Price.LessThan(b.asks[j].Price) })
+}
+
+// randomUpdate returns n changes within width ticks of mid, about a third of them removals
+func randomUpdate(rng *rand.Rand, mid int64, width, n int) (bids, asks []common.PriceLevel) {
+	for i := 0; i < n; i++ {
+		size := decimal.NewFromInt(rng.Int63n(3)) // 0 removes the level
+		offset := rng.Int63n(int64(width)) + 1
+		bids = append(bids, common.PriceLevel{Price: decimal.New(mid*100-offset, -2), Size: size})
+		asks = append(asks, common.PriceLevel{Price: decimal.New(mid*100+offset, -2), Size: size})
+	}
+	return bids, asks
+}
+
+// seededBook returns a book and its legacy equivalent holding depth levels per side
+func seededBook(depth int) (*OrderBook, *legacyBook) {
+	var bids, asks []common.PriceLevel
+	for i := 1; i <= depth; i++ {
+		bids = append(bids, common.PriceLevel{Price: decimal.New(10000*100-int64(i), -2), Size: decimal.NewFromInt(1)})
+		asks = append(asks, common.PriceLevel{Price: decimal.New(10000*100+int64(i), -2), Size: decimal.NewFromInt(1)})
+	}
+	book := NewOrderBook("BTC-USD")
+	book.Update(bids, asks, 1)
+	return book, &legacyBook{bids: bids, asks: asks}
+}
+
+func TestOrderBook_ApplyUpdatesMatchesRebuild(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	book, legacy := seededBook(200)
+
+	for i := 0; i < 500; i++ {
+		bids, asks := randomUpdate(rng, 10000, 300, 1+rng.Intn(20))
+		book.ApplyUpdates(bids, asks, uint64(i+2))
+		legacy.apply(bids, asks)
+
+		snap := book.Snapshot()
+		if prices(snap.Bids) != prices(legacy.bids) || prices(snap.Asks) != prices(legacy.asks) {
+			t.Fatalf("update %d: book diverged from rebuild\nbids: %s\nwant: %s\nasks: %s\nwant: %s",
+				i, prices(snap.Bids), prices(legacy.bids), prices(snap.Asks), prices(legacy.asks))
+		}
+		if snap.Sequence != uint64(i+2) {
+			t.Fatalf("update %d: Sequence = %d, want %d", i, snap.Sequence, i+2)
+		}
+	}
+}
+
+func TestOrderBook_SnapshotIsUnaffectedByLaterUpdates(t *testing.T) {
+	book := NewOrderBook("BTC-USD")
+	book.Update(
+		[]common.PriceLevel{{Price: decimal.NewFromInt(100), Size: decimal.NewFromInt(1)}, {Price: decimal.NewFromInt(99), Size: decimal.NewFromInt(1)}},
+		[]common.PriceLevel{{Price: decimal.NewFromInt(101), Size: decimal.NewFromInt(1)}},
+		1)
+
+	before := book.Snapshot()
+	top, _ := book.GetTopLevels(1)
+
+	book.ApplyUpdates(
+		[]common.PriceLevel{{Price: decimal.NewFromInt(100), Size: decimal.NewFromInt(5)}, {Price: decimal.NewFromInt(99), Size: decimal.Zero}},
+		[]common.PriceLevel{{Price: decimal.RequireFromString("100.5"), Size: decimal.NewFromInt(2)}},
+		2)
+
+	if got := prices(before.Bids); got != "100x1,99x1" {
+		t.Errorf("earlier snapshot bids = %s, want 100x1,99x1", got)
+	}
+	if got := prices(before.Asks); got != "101x1" {
+		t.Errorf("earlier snapshot asks = %s, want 101x1", got)
+	}
+	if got := prices(top); got != "100x1" {
+		t.Errorf("earlier GetTopLevels bids = %s, want 100x1", got)
+	}
+	if cap(top) != len(top) {
+		t.Errorf("GetTopLevels capacity = %d, want %d so appends can't reach the book", cap(top), len(top))
+	}
+	if got := prices(book.Snapshot().Bids); got != "100x5" {
+		t.Errorf("current bids = %s, want 100x5", got)
+	}
+}
+
+func BenchmarkOrderBook_Update(b *testing.B) {
+	for _, depth := range []int{50, 500} {
+		rng := rand.New(rand.NewSource(1))
+		updates := make([][2][]common.PriceLevel, 1024)
+		for i := range updates {
+			bids, asks := randomUpdate(rng, 10000, depth, 5)
+			updates[i] = [2][]common.PriceLevel{bids, asks}
+		}
+
+		b.Run(fmt.Sprintf("rebuild/depth=%d", depth), func(b *testing.B) {
+			_, legacy := seededBook(depth)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				u := updates[i%len(updates)]
+				legacy.apply(u[0], u[1])
+			}
+		})
+
+		b.Run(fmt.Sprintf("sorted/depth=%d", depth), func(b *testing.B) {
+			book, _ := seededBook(depth)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				u := updates[i%len(updates)]
+				book.ApplyUpdates(u[0], u[1], uint64(i))
+			}
+		})
+	}
+}
+
+func BenchmarkOrderBook_Snapshot(b *testing.B) {
+	book, _ := seededBook(500)
+	book.SetMaxLevels(10)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = book.Snapshot()
+	}
+}
+
+func BenchmarkOrderBook_BestBidDuringUpdates(b *testing.B) {
+	book, _ := seededBook(500)
+	rng := rand.New(rand.NewSource(1))
+	bids, asks := randomUpdate(rng, 10000, 500, 5)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for seq := uint64(0); ; seq++ {
+			select {
+			case <-stop:
+				return
+			default:
+				book.ApplyUpdates(bids, asks, seq)
+			}
+		}
+	}()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			book.GetBestBid()
+		}
+	})
+}
+
+func TestOrderBook_EstimateExecutionUsesFullDepth(t *testing.T) {
+	book := replayL2(t, 1, l2Message(1, "snapshot",
+		level("bid", "100", "1"), level("bid", "99", "1"),
+		level("offer", "101", "1"), level("offer", "102", "1")))
+	adjuster := common.NewPriceAdjuster(common.NewFeeStrategy(decimal.Zero))
+
+	// MaxLevels hides the second level from Snapshot but not from the walk
+	estimate, err := book.EstimateExecution("buy", "base", decimal.NewFromInt(2), adjuster)
+	if err != nil {
+		t.Fatalf("EstimateExecution() error = %v", err)
+	}
+	if !estimate.Walk.Complete || !estimate.Walk.WorstPrice.Equal(decimal.NewFromInt(102)) || estimate.Product != "BTC-USD" {
+		t.Errorf("EstimateExecution() = %+v, want a complete fill to 102 on BTC-USD", estimate)
+	}
+
+	errorCases := []struct {
+		side, unit, size string
+	}{
+		{side: "hold", unit: "base", size: "1"},
+		{side: "SELL", unit: "lots", size: "1"},
+		{side: "SELL", unit: "base", size: "0"},
+	}
+	for _, tc := range errorCases {
+		if _, err := book.EstimateExecution(tc.side, tc.unit, decimal.RequireFromString(tc.size), adjuster); err == nil {
+			t.Errorf("EstimateExecution(%s, %s, %s) error = nil, want an error", tc.side, tc.unit, tc.size)
+		}
+	}
+
+	if _, err := NewOrderBook("ETH-USD").EstimateExecution("BUY", "base", decimal.NewFromInt(1), adjuster); err == nil {
+		t.Error("EstimateExecution() on an empty book error = nil, want an error")
+	}
+}
@@ -0,0 +1,223 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Channel names carried in each message's channel field
+const (
+	ChannelL2Data        = "l2_data"
+	ChannelOrders        = "orders"
+	ChannelSubscriptions = "subscriptions"
+)
+
+// Root-level message types
+const (
+	MessageTypeSubscriptions = "subscriptions"
+	MessageTypeError         = "error"
+)
+
+// Message is a decoded Prime websocket frame
+// Events are decoded once, into the slice for the message's channel; the others stay nil
+type Message struct {
+	Channel     string
+	Type        string  // Set on subscription confirmations and errors
+	Error       string  // Error text when Type is "error"
+	Timestamp   string  // RFC3339Nano as sent by Prime
+	SequenceNum *uint64 // nil when the message carries no sequence_num
+
+	L2Events           []L2Event
+	OrderEvents        []OrdersEvent
+	HeartbeatEvents    []HeartbeatEvent
+	SubscriptionEvents []SubscriptionsEvent
+}
+
+// L2Event is a snapshot or update for one product's book
+type L2Event struct {
+	Type      string     `json:"type"`
+	ProductId string     `json:"product_id"`
+	Updates   []L2Update `json:"updates"`
+}
+
+// L2Update is a single price level change; a zero qty removes the level
+// Px and Qty accept JSON strings or numbers and keep the exact text for decimal parsing
+type L2Update struct {
+	Side      string      `json:"side"`
+	EventTime string      `json:"event_time"`
+	Px        json.Number `json:"px"`
+	Qty       json.Number `json:"qty"`
+}
+
+// OrdersEvent carries the current state of one or more orders
+type OrdersEvent struct {
+	Type   string        `json:"type"`
+	Orders []OrderUpdate `json:"orders"`
+}
+
+// OrderUpdate is an order's state on the orders channel
+// Numeric fields are kept as Prime's exact decimal strings
+type OrderUpdate struct {
+	OrderId       string `json:"order_id"`
+	ClientOrderId string `json:"client_order_id"`
+	ProductId     string `json:"product_id"`
+	Side          string `json:"side"`
+	OrderType     string `json:"order_type"`
+	Status        string `json:"status"`
+	CumQty        string `json:"cum_qty"`
+	LeavesQty     string `json:"leaves_qty"`
+	AvgPx         string `json:"avg_px"`
+	NetAvgPx      string `json:"net_avg_px"`
+	FilledValue   string `json:"filled_value"`
+	Fees          string `json:"fees"`
+	Commission    string `json:"commission"`
+	VenueFee      string `json:"venue_fee"`
+	CesCommission string `json:"ces_commission"`
+
+	raw json.RawMessage // The order as received, including fields not modelled above
+}
+
+// orderFields has OrderUpdate's layout without its JSON methods
+type orderFields OrderUpdate
+
+// UnmarshalJSON decodes the order and keeps the original JSON for the audit log
+func (o *OrderUpdate) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*orderFields)(o)); err != nil {
+		return err
+	}
+	o.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// MarshalJSON returns the order as received, or its modelled fields when it was built locally
+func (o OrderUpdate) MarshalJSON() ([]byte, error) {
+	if o.raw != nil {
+		return o.raw, nil
+	}
+	return json.Marshal(orderFields(o))
+}
+
+// HeartbeatEvent is a keep-alive tick
+type HeartbeatEvent struct {
+	CurrentTime      string      `json:"current_time"`
+	HeartbeatCounter json.Number `json:"heartbeat_counter"`
+}
+
+// SubscriptionsEvent lists the products subscribed on each channel
+type SubscriptionsEvent struct {
+	Subscriptions map[string][]string `json:"subscriptions"`
+}
+
+// Sequence returns the message's sequence_num, if it has one
+func (m *Message) Sequence() (uint64, bool) {
+	if m.SequenceNum == nil {
+		return 0, false
+	}
+	return *m.SequenceNum, true
+}
+
+// wireMessage is the envelope shared by every message; events are decoded once the channel is known
+type wireMessage struct {
+	Channel     string          `json:"channel"`
+	Type        string          `json:"type,omitempty"`
+	Message     string          `json:"message,omitempty"`
+	Timestamp   string          `json:"timestamp,omitempty"`
+	SequenceNum *uint64         `json:"sequence_num,omitempty"`
+	Events      json.RawMessage `json:"events,omitempty"`
+}
+
+// decodeMessage decodes a raw frame, using the channel to pick the event type
+func decodeMessage(frame []byte) (*Message, error) {
+	msg := &Message{}
+	if err := msg.decode(frame); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (m *Message) decode(data []byte) error {
+	var wire wireMessage
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+
+	*m = Message{
+		Channel:     wire.Channel,
+		Type:        wire.Type,
+		Error:       wire.Message,
+		Timestamp:   wire.Timestamp,
+		SequenceNum: wire.SequenceNum,
+	}
+	if len(wire.Events) == 0 || bytes.Equal(wire.Events, []byte("null")) {
+		return nil
+	}
+
+	var err error
+	switch {
+	case wire.Type == MessageTypeSubscriptions || wire.Channel == ChannelSubscriptions:
+		err = json.Unmarshal(wire.Events, &m.SubscriptionEvents)
+	case wire.Channel == ChannelL2Data:
+		err = json.Unmarshal(wire.Events, &m.L2Events)
+	case wire.Channel == ChannelOrders:
+		err = json.Unmarshal(wire.Events, &m.OrderEvents)
+	case wire.Channel == ChannelHeartbeats:
+		err = json.Unmarshal(wire.Events, &m.HeartbeatEvents)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode %s events: %w", wire.Channel, err)
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a Prime message, e.g., when read back from a spill file
+func (m *Message) UnmarshalJSON(data []byte) error {
+	return m.decode(data)
+}
+
+// MarshalJSON encodes the message in Prime's wire layout
+func (m *Message) MarshalJSON() ([]byte, error) {
+	wire := wireMessage{
+		Channel:     m.Channel,
+		Type:        m.Type,
+		Message:     m.Error,
+		Timestamp:   m.Timestamp,
+		SequenceNum: m.SequenceNum,
+	}
+
+	var events interface{}
+	switch {
+	case m.SubscriptionEvents != nil:
+		events = m.SubscriptionEvents
+	case m.L2Events != nil:
+		events = m.L2Events
+	case m.OrderEvents != nil:
+		events = m.OrderEvents
+	case m.HeartbeatEvents != nil:
+		events = m.HeartbeatEvents
+	}
+	if events != nil {
+		data, err := json.Marshal(events)
+		if err != nil {
+			return nil, err
+		}
+		wire.Events = data
+	}
+	return json.Marshal(wire)
+}
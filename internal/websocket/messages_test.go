@@ -0,0 +1,264 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/shopspring/decimal"
+)
+
+func TestDecodeMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		frame   string
+		check   func(t *testing.T, msg *Message)
+		wantErr bool
+	}{
+		{
+			name:  "l2 update",
+			frame: `{"channel":"l2_data","timestamp":"2025-01-01T00:00:00Z","sequence_num":7,"events":[{"type":"update","product_id":"BTC-USD","updates":[{"side":"bid","event_time":"2025-01-01T00:00:00Z","px":"100000.5","qty":"1.25"},{"side":"offer","px":100001,"qty":0}]}]}`,
+			check: func(t *testing.T, msg *Message) {
+				if seq, ok := msg.Sequence(); !ok || seq != 7 {
+					t.Errorf("Sequence() = %d, %v, want 7, true", seq, ok)
+				}
+				if len(msg.L2Events) != 1 || msg.OrderEvents != nil {
+					t.Fatalf("L2Events = %d, OrderEvents = %v, want 1 l2 event only", len(msg.L2Events), msg.OrderEvents)
+				}
+				event := msg.L2Events[0]
+				if event.Type != "update" || event.ProductId != "BTC-USD" || len(event.Updates) != 2 {
+					t.Fatalf("event = %+v", event)
+				}
+				if event.Updates[0].Px != "100000.5" || event.Updates[0].Qty != "1.25" {
+					t.Errorf("bid = %+v, want px 100000.5 qty 1.25", event.Updates[0])
+				}
+				// Numeric JSON is accepted and keeps its exact text
+				if event.Updates[1].Px != "100001" || event.Updates[1].Qty != "0" {
+					t.Errorf("offer = %+v, want px 100001 qty 0", event.Updates[1])
+				}
+			},
+		},
+		{
+			name:  "orders",
+			frame: `{"channel":"orders","timestamp":"2025-01-01T00:00:00Z","sequence_num":3,"events":[{"type":"update","orders":[{"order_id":"o-1","client_order_id":"c-1","product_id":"ETH-USD","status":"FILLED","cum_qty":"2","avg_px":"3000","expiry_time":"never"}]}]}`,
+			check: func(t *testing.T, msg *Message) {
+				if len(msg.OrderEvents) != 1 || len(msg.OrderEvents[0].Orders) != 1 {
+					t.Fatalf("OrderEvents = %+v, want one order", msg.OrderEvents)
+				}
+				order := msg.OrderEvents[0].Orders[0]
+				if order.OrderId != "o-1" || order.Status != "FILLED" || order.CumQty != "2" || order.AvgPx != "3000" {
+					t.Errorf("order = %+v", order)
+				}
+				// Unmodelled fields survive for the audit log
+				raw, err := json.Marshal(order)
+				if err != nil || !strings.Contains(string(raw), `"expiry_time":"never"`) {
+					t.Errorf("json.Marshal(order) = %s, %v, want the original fields", raw, err)
+				}
+			},
+		},
+		{
+			name:  "heartbeats",
+			frame: `{"channel":"heartbeats","timestamp":"2025-01-01T00:00:00Z","sequence_num":1,"events":[{"current_time":"2025-01-01T00:00:00Z","heartbeat_counter":"42"}]}`,
+			check: func(t *testing.T, msg *Message) {
+				if len(msg.HeartbeatEvents) != 1 || msg.HeartbeatEvents[0].HeartbeatCounter != "42" {
+					t.Errorf("HeartbeatEvents = %+v, want counter 42", msg.HeartbeatEvents)
+				}
+			},
+		},
+		{
+			name:  "subscriptions",
+			frame: `{"channel":"subscriptions","type":"subscriptions","events":[{"subscriptions":{"l2_data":["BTC-USD","ETH-USD"]}}]}`,
+			check: func(t *testing.T, msg *Message) {
+				if msg.Type != MessageTypeSubscriptions || len(msg.SubscriptionEvents) != 1 {
+					t.Fatalf("msg = %+v, want one subscriptions event", msg)
+				}
+				if got := msg.SubscriptionEvents[0].Subscriptions[ChannelL2Data]; len(got) != 2 {
+					t.Errorf("l2_data subscriptions = %v, want 2 products", got)
+				}
+			},
+		},
+		{
+			name:  "error",
+			frame: `{"type":"error","message":"authentication failure"}`,
+			check: func(t *testing.T, msg *Message) {
+				if msg.Type != MessageTypeError || msg.Error != "authentication failure" || msg.Channel != "" {
+					t.Errorf("msg = %+v, want error with message", msg)
+				}
+				if _, ok := msg.Sequence(); ok {
+					t.Error("Sequence() ok = true for a message without sequence_num")
+				}
+			},
+		},
+		{
+			name:  "unknown channel leaves events undecoded",
+			frame: `{"channel":"futures","events":[{"anything":1}]}`,
+			check: func(t *testing.T, msg *Message) {
+				if msg.L2Events != nil || msg.OrderEvents != nil || msg.HeartbeatEvents != nil || msg.SubscriptionEvents != nil {
+					t.Errorf("msg = %+v, want no events", msg)
+				}
+			},
+		},
+		{
+			name:    "invalid px",
+			frame:   `{"channel":"l2_data","events":[{"type":"update","product_id":"BTC-USD","updates":[{"side":"bid","px":"abc","qty":"1"}]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "events of the wrong shape",
+			frame:   `{"channel":"orders","events":{"type":"update"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "not json",
+			frame:   `{"channel":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := decodeMessage([]byte(tt.frame))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.check != nil {
+				tt.check(t, msg)
+			}
+		})
+	}
+}
+
+func TestMessage_JSONRoundTrip(t *testing.T) {
+	seq := uint64(9)
+	original := &Message{
+		Channel:     ChannelOrders,
+		Timestamp:   "2025-01-01T00:00:00Z",
+		SequenceNum: &seq,
+		OrderEvents: []OrdersEvent{{
+			Type:   "update",
+			Orders: []OrderUpdate{{OrderId: "o-1", Status: "OPEN", LeavesQty: "0.5"}},
+		}},
+	}
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if got, _ := decoded.Sequence(); got != 9 || decoded.Channel != ChannelOrders || decoded.Timestamp != original.Timestamp {
+		t.Errorf("decoded = %+v, want channel, timestamp and sequence preserved", decoded)
+	}
+	if len(decoded.OrderEvents) != 1 || len(decoded.OrderEvents[0].Orders) != 1 {
+		t.Fatalf("OrderEvents = %+v, want one order", decoded.OrderEvents)
+	}
+	if order := decoded.OrderEvents[0].Orders[0]; order.OrderId != "o-1" || order.Status != "OPEN" || order.LeavesQty != "0.5" {
+		t.Errorf("order = %+v, want the original fields", order)
+	}
+}
+
+// l2Frame builds an l2_data update with n levels per side, like a busy book update
+func l2Frame(n int) []byte {
+	updates := make([]string, 0, 2*n)
+	for i := 0; i < n; i++ {
+		updates = append(updates,
+			fmt.Sprintf(`{"side":"bid","event_time":"2025-01-01T00:00:00.123456Z","px":"%d.25","qty":"0.%04d"}`, 100000-i, i+1),
+			fmt.Sprintf(`{"side":"offer","event_time":"2025-01-01T00:00:00.123456Z","px":"%d.75","qty":"0.%04d"}`, 100000+i, i+1))
+	}
+	return []byte(`{"channel":"l2_data","timestamp":"2025-01-01T00:00:00.123456Z","sequence_num":42,"events":[{"type":"update","product_id":"BTC-USD","updates":[` +
+		strings.Join(updates, ",") + `]}]}`)
+}
+
+// decodeL2Generic is the generic map decoding the client used before typed messages, kept for comparison
+func decodeL2Generic(frame []byte) (map[string]common.PriceLevel, error) {
+	var msg map[string]interface{}
+	if err := json.Unmarshal(frame, &msg); err != nil {
+		return nil, err
+	}
+	levels := make(map[string]common.PriceLevel)
+	events, _ := msg["events"].([]interface{})
+	for _, e := range events {
+		event, _ := e.(map[string]interface{})
+		updates, _ := event["updates"].([]interface{})
+		for _, u := range updates {
+			update, _ := u.(map[string]interface{})
+			side, _ := update["side"].(string)
+			px, _ := update["px"].(string)
+			qty, _ := update["qty"].(string)
+			price, err := decimal.NewFromString(px)
+			if err != nil {
+				continue
+			}
+			size, err := decimal.NewFromString(qty)
+			if err != nil {
+				continue
+			}
+			levels[side+":"+price.String()] = common.PriceLevel{Price: price, Size: size}
+		}
+	}
+	return levels, nil
+}
+
+func BenchmarkDecodeL2Update(b *testing.B) {
+	frame := l2Frame(10)
+	client := NewMarketDataClient(MarketDataConfig{CommonConfig: CommonConfig{Products: []string{"BTC-USD"}}}, NewOrderBookStore())
+
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := decodeL2Generic(frame); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("typed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			msg, err := decodeMessage(frame)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, _, err := client.parseUpdates(&msg.L2Events[0]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkMarketDataClient_BookUpdate(b *testing.B) {
+	frame := l2Frame(10)
+	client := NewMarketDataClient(MarketDataConfig{CommonConfig: CommonConfig{Products: []string{"BTC-USD"}}}, NewOrderBookStore())
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		msg, err := decodeMessage(frame)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := client.HandleMessage(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coinbase-samples/prime-sdk-go/model"
+	"github.com/coinbase-samples/prime-sdk-go/orders"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/database"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// BackfillEventType marks order updates recovered over REST rather than received on the websocket
+const BackfillEventType = "backfill"
+
+// RestOrderBackfiller recovers order states from the Prime REST API after an orders sequence gap
+// It fetches open orders for the subscribed products plus every order the database still considers unsettled,
+// then replays them through the update handler as a single synthetic update
+type RestOrderBackfiller struct {
+	ordersSvc   orders.OrdersService
+	portfolioId string
+	db          *database.OrdersDb
+	handler     OrderUpdateHandler
+}
+
+// NewRestOrderBackfiller creates a backfiller that feeds recovered states to handler
+func NewRestOrderBackfiller(ordersSvc orders.OrdersService, portfolioId string, db *database.OrdersDb, handler OrderUpdateHandler) *RestOrderBackfiller {
+	return &RestOrderBackfiller{
+		ordersSvc:   ordersSvc,
+		portfolioId: portfolioId,
+		db:          db,
+		handler:     handler,
+	}
+}
+
+// Backfill fetches current order states and passes them to the update handler
+func (b *RestOrderBackfiller) Backfill(ctx context.Context, productIds []string) error {
+	subscribed := make(map[string]bool, len(productIds))
+	for _, productId := range productIds {
+		subscribed[productId] = true
+	}
+
+	// Orders opened during the gap are only discoverable through the open orders listing
+	openResp, err := b.ordersSvc.ListOpenOrders(ctx, &orders.ListOpenOrdersRequest{
+		PortfolioId: b.portfolioId,
+		ProductIds:  productIds,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list open orders: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	recovered := make([]OrderUpdate, 0, len(openResp.Orders))
+	for _, order := range openResp.Orders {
+		seen[order.Id] = true
+		recovered = append(recovered, orderUpdateFromModel(order))
+	}
+
+	// Orders we were tracking may have filled or been cancelled during the gap
+	unsettled, err := b.db.ListUnsettledOrders()
+	if err != nil {
+		return err
+	}
+	for _, record := range unsettled {
+		if seen[record.OrderId] || !subscribed[record.ProductId] {
+			continue
+		}
+
+		resp, err := b.ordersSvc.GetOrder(ctx, &orders.GetOrderRequest{
+			PortfolioId: b.portfolioId,
+			OrderId:     record.OrderId,
+		})
+		if err != nil {
+			zap.L().Warn("Failed to backfill order",
+				zap.String("order_id", record.OrderId),
+				zap.Error(err))
+			continue
+		}
+		recovered = append(recovered, orderUpdateFromModel(resp.Order))
+	}
+
+	zap.L().Info("Backfilled orders after sequence gap", zap.Int("orders", len(recovered)))
+	if len(recovered) == 0 {
+		return nil
+	}
+
+	var notSequenced uint64 // Not part of the websocket sequence
+	return b.handler.HandleOrderUpdate(&Message{
+		Channel:     ChannelOrders,
+		SequenceNum: &notSequenced,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339Nano),
+		OrderEvents: []OrdersEvent{{
+			Type:   BackfillEventType,
+			Orders: recovered,
+		}},
+	})
+}
+
+// orderUpdateFromModel converts a REST order into the orders channel's field layout
+func orderUpdateFromModel(order *model.Order) OrderUpdate {
+	leavesQty := "0"
+	if order.Status == "OPEN" || order.Status == "PENDING" {
+		baseQty, baseErr := decimal.NewFromString(order.BaseQuantity)
+		filledQty, filledErr := decimal.NewFromString(order.FilledQuantity)
+		if baseErr == nil && filledErr == nil {
+			leavesQty = baseQty.Sub(filledQty).String()
+		}
+	}
+
+	return OrderUpdate{
+		OrderId:       order.Id,
+		ClientOrderId: order.ClientOrderId,
+		ProductId:     order.ProductId,
+		Side:          order.Side,
+		OrderType:     order.Type,
+		Status:        order.Status,
+		CumQty:        order.FilledQuantity,
+		LeavesQty:     leavesQty,
+		AvgPx:         order.AverageFilledPrice,
+		NetAvgPx:      order.NetAverageFilledPrice,
+		FilledValue:   order.FilledValue,
+		Fees:          order.Commission,
+		Commission:    order.Commission,
+		VenueFee:      order.ExchangeFee,
+		CesCommission: "0",
+	}
+}
@@ -0,0 +1,113 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/coinbase-samples/prime-sdk-go/model"
+	"github.com/coinbase-samples/prime-sdk-go/orders"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/database"
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/prime/primetest"
+)
+
+// capturingHandler keeps the last order update it received
+type capturingHandler struct {
+	update *Message
+}
+
+func (h *capturingHandler) HandleOrderUpdate(update *Message) error {
+	h.update = update
+	return nil
+}
+
+func TestRestOrderBackfiller_Backfill(t *testing.T) {
+	ctx := context.Background()
+	server := primetest.NewServer()
+	svc := server.OrdersService()
+
+	createOrder := func(clientOrderId, productId, orderType, limitPrice string) string {
+		t.Helper()
+		resp, err := svc.CreateOrder(ctx, &orders.CreateOrderRequest{Order: &model.Order{
+			PortfolioId:   primetest.DefaultPortfolioId,
+			ClientOrderId: clientOrderId,
+			ProductId:     productId,
+			Side:          "BUY",
+			Type:          orderType,
+			BaseQuantity:  "0.5",
+			LimitPrice:    limitPrice,
+		}})
+		if err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+		return resp.OrderId
+	}
+
+	// Resting order opened during the gap, filled order we were tracking, and an order on another product
+	openId := createOrder("backfill-open", "BTC-USD", "LIMIT", "1000")
+	filledId := createOrder("backfill-filled", "BTC-USD", "MARKET", "")
+	otherId := createOrder("backfill-other", "ETH-USD", "MARKET", "")
+
+	db, err := database.NewOrdersDb(filepath.Join(t.TempDir(), "orders.db"))
+	if err != nil {
+		t.Fatalf("NewOrdersDb() error = %v", err)
+	}
+	defer db.Close()
+	if err := db.UpsertOrder(&database.OrderRecord{OrderId: filledId, ProductId: "BTC-USD", Status: "OPEN"}); err != nil {
+		t.Fatalf("UpsertOrder() error = %v", err)
+	}
+	// Only subscribed products are backfilled
+	if err := db.UpsertOrder(&database.OrderRecord{OrderId: otherId, ProductId: "ETH-USD", Status: "OPEN"}); err != nil {
+		t.Fatalf("UpsertOrder() error = %v", err)
+	}
+
+	handler := &capturingHandler{}
+	backfiller := NewRestOrderBackfiller(svc, primetest.DefaultPortfolioId, db, handler)
+	if err := backfiller.Backfill(ctx, []string{"BTC-USD"}); err != nil {
+		t.Fatalf("Backfill() error = %v", err)
+	}
+
+	if handler.update == nil {
+		t.Fatal("handler received no update")
+	}
+	event := handler.update.OrderEvents[0]
+	if event.Type != BackfillEventType {
+		t.Errorf("event type = %v, want %s", event.Type, BackfillEventType)
+	}
+
+	recovered := make(map[string]OrderUpdate)
+	for _, order := range event.Orders {
+		recovered[order.OrderId] = order
+	}
+	if len(recovered) != 2 {
+		t.Fatalf("recovered %d orders, want 2: %v", len(recovered), recovered)
+	}
+
+	open, ok := recovered[openId]
+	if !ok || open.Status != "OPEN" || open.LeavesQty != "0.5" {
+		t.Errorf("open order = %+v, want OPEN with leaves_qty 0.5", open)
+	}
+	filled, ok := recovered[filledId]
+	if !ok || filled.Status != "FILLED" || filled.LeavesQty != "0" {
+		t.Errorf("filled order = %+v, want FILLED with leaves_qty 0", filled)
+	}
+	if ok && filled.Commission == "" {
+		t.Error("filled order is missing commission")
+	}
+}
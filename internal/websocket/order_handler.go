@@ -80,60 +80,36 @@ func NewDbOrderHandler(db *database.OrdersDb, priceAdjuster *common.PriceAdjuste
 }
 
 // HandleOrderUpdate processes a websocket order update message
-func (h *DbOrderHandler) HandleOrderUpdate(update map[string]interface{}) error {
+func (h *DbOrderHandler) HandleOrderUpdate(update *Message) error {
 	// Extract sequence number and timestamp
-	sequenceNum, ok := update["sequence_num"].(float64)
+	sequenceNum, ok := update.Sequence()
 	if !ok {
 		zap.L().Warn("Missing or invalid sequence_num in order update")
-		sequenceNum = 0
 	}
 
-	timestampStr, ok := update["timestamp"].(string)
-	if !ok {
+	if update.Timestamp == "" {
 		zap.L().Warn("Missing or invalid timestamp in order update")
-		timestampStr = ""
 	}
 
-	timestamp, err := time.Parse(time.RFC3339Nano, timestampStr)
+	timestamp, err := time.Parse(time.RFC3339Nano, update.Timestamp)
 	if err != nil || timestamp.IsZero() {
 		timestamp = time.Now()
 	}
 
-	// Get events array
-	eventsRaw, ok := update["events"].([]interface{})
-	if !ok || len(eventsRaw) == 0 {
-		return nil
-	}
-
 	// Process each event
-	for _, eventRaw := range eventsRaw {
-		event, ok := eventRaw.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		eventType, ok := event["type"].(string)
-		if !ok {
+	for _, event := range update.OrderEvents {
+		eventType := event.Type
+		if eventType == "" {
 			zap.L().Warn("Missing or invalid event type")
 			eventType = common.UnknownEventType
 		}
 
-		// Get orders array from event
-		ordersRaw, ok := event["orders"].([]interface{})
-		if !ok || len(ordersRaw) == 0 {
-			continue
-		}
-
 		// Process each order in the event
-		for _, orderRaw := range ordersRaw {
-			orderData, ok := orderRaw.(map[string]interface{})
-			if !ok {
-				continue
-			}
-
-			if err := h.processOrderUpdate(orderData, eventType, int64(sequenceNum), timestamp); err != nil {
+		for i := range event.Orders {
+			order := &event.Orders[i]
+			if err := h.processOrderUpdate(order, eventType, int64(sequenceNum), timestamp); err != nil {
 				zap.L().Error("Failed to process order update",
-					zap.String("order_id", getString(orderData, "order_id")),
+					zap.String("order_id", order.OrderId),
 					zap.Error(err))
 			}
 		}
@@ -142,32 +118,32 @@ func (h *DbOrderHandler) HandleOrderUpdate(update map[string]interface{}) error
 	return nil
 }
 
-func (h *DbOrderHandler) processOrderUpdate(orderData map[string]interface{}, eventType string, sequenceNum int64, timestamp time.Time) error {
-	orderId := getString(orderData, "order_id")
+func (h *DbOrderHandler) processOrderUpdate(order *OrderUpdate, eventType string, sequenceNum int64, timestamp time.Time) error {
+	orderId := order.OrderId
 	if orderId == "" {
 		return fmt.Errorf("missing order_id")
 	}
 
 	// Parse order fields
-	clientOrderId := getString(orderData, "client_order_id")
-	productId := getString(orderData, "product_id")
-	side := getString(orderData, "side")
-	orderType := getString(orderData, "order_type")
-	status := getString(orderData, "status")
+	clientOrderId := order.ClientOrderId
+	productId := order.ProductId
+	side := order.Side
+	orderType := order.OrderType
+	status := order.Status
 
 	// Normalize numeric fields (empty string → "0" for storage)
-	cumQty := normalizeNumeric(getString(orderData, "cum_qty"))
-	leavesQty := normalizeNumeric(getString(orderData, "leaves_qty"))
-	avgPx := normalizeNumeric(getString(orderData, "avg_px"))
-	netAvgPx := normalizeNumeric(getString(orderData, "net_avg_px"))
-	filledValue := normalizeNumeric(getString(orderData, "filled_value"))
-	feesStr := normalizeNumeric(getString(orderData, "fees"))
-	commission := normalizeNumeric(getString(orderData, "commission"))
-	venueFee := normalizeNumeric(getString(orderData, "venue_fee"))
-	cesCommission := normalizeNumeric(getString(orderData, "ces_commission"))
+	cumQty := normalizeNumeric(order.CumQty)
+	leavesQty := normalizeNumeric(order.LeavesQty)
+	avgPx := normalizeNumeric(order.AvgPx)
+	netAvgPx := normalizeNumeric(order.NetAvgPx)
+	filledValue := normalizeNumeric(order.FilledValue)
+	feesStr := normalizeNumeric(order.Fees)
+	commission := normalizeNumeric(order.Commission)
+	venueFee := normalizeNumeric(order.VenueFee)
+	cesCommission := normalizeNumeric(order.CesCommission)
 
 	// Convert to JSON for event storage
-	rawJSON, err := json.Marshal(orderData)
+	rawJSON, err := json.Marshal(order)
 	if err != nil {
 		return fmt.Errorf("failed to marshal order data: %w", err)
 	}
@@ -317,16 +293,6 @@ func (h *DbOrderHandler) processOrderUpdate(orderData map[string]interface{}, ev
 	return nil
 }
 
-// Helper function to safely extract string from map
-func getString(m map[string]interface{}, key string) string {
-	if val, ok := m[key]; ok {
-		if str, ok := val.(string); ok {
-			return str
-		}
-	}
-	return ""
-}
-
 // Helper function to normalize numeric strings (convert empty to "0")
 func normalizeNumeric(s string) string {
 	if s == "" {
@@ -324,48 +324,5 @@ func TestMetadataStore(t *testing.T) {
 	store.Delete("non-existent")
 }
 
-func TestGetString(t *testing.T) {
-	tests := []struct {
-		name     string
-		m        map[string]interface{}
-		key      string
-		expected string
-	}{
-		{
-			name:     "existing string",
-			m:        map[string]interface{}{"key": "value"},
-			key:      "key",
-			expected: "value",
-		},
-		{
-			name:     "missing key",
-			m:        map[string]interface{}{"other": "value"},
-			key:      "key",
-			expected: "",
-		},
-		{
-			name:     "non-string value",
-			m:        map[string]interface{}{"key": 123},
-			key:      "key",
-			expected: "",
-		},
-		{
-			name:     "empty map",
-			m:        map[string]interface{}{},
-			key:      "key",
-			expected: "",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := getString(tt.m, tt.key)
-			if result != tt.expected {
-				t.Errorf("getString(%v, %q) = %q, want %q", tt.m, tt.key, result, tt.expected)
-			}
-		})
-	}
-}
-
 // Note: Rounding function tests removed - we store exact values from Prime
 // to support all asset precisions and quote currencies across all trading pairs.
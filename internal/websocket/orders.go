@@ -17,65 +17,182 @@
 package websocket
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"go.uber.org/zap"
 )
 
+// orderBackfillTimeout bounds the REST backfill run after an orders sequence gap
+const orderBackfillTimeout = 10 * time.Second
+
 // OrdersConfig holds configuration for the Prime Orders WebSocket connection
 type OrdersConfig struct {
 	CommonConfig
 	PortfolioId string
+	Backfiller  OrderBackfiller // Optional; recovers order states missed during a sequence gap
+}
+
+// OrderBackfiller fetches current order states out of band and feeds them to the update handler
+type OrderBackfiller interface {
+	Backfill(ctx context.Context, productIds []string) error
 }
 
 // OrderUpdateHandler processes order updates from the websocket
 type OrderUpdateHandler interface {
-	HandleOrderUpdate(update map[string]interface{}) error
+	HandleOrderUpdate(update *Message) error
 }
 
 // OrdersClient manages the connection to Coinbase Prime Orders WebSocket
 type OrdersClient struct {
 	config     OrdersConfig
 	handler    OrderUpdateHandler
+	products   *productSet
 	baseClient *BaseWebSocketClient
 }
 
 // NewOrdersClient creates a new Orders WebSocket client
 func NewOrdersClient(config OrdersConfig, handler OrderUpdateHandler) *OrdersClient {
 	client := &OrdersClient{
-		config:  config,
-		handler: handler,
+		config:   config,
+		handler:  handler,
+		products: newProductSet(config.Products),
 	}
 
 	baseConfig := baseConfigFromCommon(config.CommonConfig)
 	client.baseClient = NewBaseWebSocketClient(baseConfig, client)
+	client.baseClient.enableHeartbeats(config.Products)
 	return client
 }
 
-// Start begins the WebSocket connection and message processing
-func (c *OrdersClient) Start() error {
-	return c.baseClient.Start()
+// Start connects in the background; cancelling ctx stops the client like Stop does
+func (c *OrdersClient) Start(ctx context.Context) error {
+	return c.baseClient.Start(ctx)
 }
 
-// Stop gracefully stops the WebSocket client
+// Stop stops the client and waits until messages already received have been handled
 func (c *OrdersClient) Stop() {
 	c.baseClient.Stop()
 }
 
+// Done is closed once the client has stopped and drained its queue
+func (c *OrdersClient) Done() <-chan struct{} {
+	return c.baseClient.Done()
+}
+
+// Err reports why the client stopped; nil until Done is closed
+// It is the fatal or give-up error, the ctx error if Start's ctx was cancelled, or nil after Stop
+func (c *OrdersClient) Err() error {
+	return c.baseClient.Err()
+}
+
+// State returns the current connection state
+// Use CommonConfig.OnStateChange to be notified of transitions instead of polling
+func (c *OrdersClient) State() ConnectionState {
+	return c.baseClient.State()
+}
+
+// LastHeartbeat returns when the last heartbeat was received (zero if heartbeats are disabled)
+func (c *OrdersClient) LastHeartbeat() time.Time {
+	return c.baseClient.LastHeartbeat()
+}
+
+// SequenceGaps returns how many orders sequence gaps have triggered a resync
+func (c *OrdersClient) SequenceGaps() uint64 {
+	return c.baseClient.channelSequenceGaps(c.GetChannelName())
+}
+
+// Products returns the currently subscribed products
+func (c *OrdersClient) Products() []string {
+	return c.products.list()
+}
+
+// AddProducts subscribes to order updates for more products without restarting the client
+func (c *OrdersClient) AddProducts(products ...string) error {
+	added := c.products.add(products)
+	if err := c.baseClient.updateProducts(c, "subscribe", added); err != nil {
+		return fmt.Errorf("failed to subscribe to %v: %w", added, err)
+	}
+	return nil
+}
+
+// RemoveProducts stops order updates for products
+func (c *OrdersClient) RemoveProducts(products ...string) error {
+	removed, err := c.products.remove(products, nil)
+	if err != nil {
+		return err
+	}
+	if err := c.baseClient.updateProducts(c, "unsubscribe", removed); err != nil {
+		return fmt.Errorf("failed to unsubscribe from %v: %w", removed, err)
+	}
+	return nil
+}
+
+// QueueStats returns the work queue's depth and latency metrics; false when messages are handled inline
+func (c *OrdersClient) QueueStats() (QueueStats, bool) {
+	return c.baseClient.queueStats(c.GetChannelName())
+}
+
+// queueConfig configures the work queue between the read loop and this client
+func (c *OrdersClient) queueConfig() QueueConfig {
+	return c.config.Queue
+}
+
+// useConnection moves the client onto a connection shared through a ConnectionManager
+func (c *OrdersClient) useConnection(baseClient *BaseWebSocketClient) {
+	c.baseClient = baseClient
+}
+
+// DiscardOnSequenceGap keeps the message that revealed a gap; order updates carry full order state
+func (c *OrdersClient) DiscardOnSequenceGap() bool {
+	return false
+}
+
+// HandleSequenceGap backfills order states over REST so transitions sent during the gap aren't lost
+// The backfill runs in order with other updates so it can't be overwritten by older websocket updates
+func (c *OrdersClient) HandleSequenceGap(expected, received uint64) {
+	if c.config.Backfiller == nil {
+		return
+	}
+
+	// Bounded by its own timeout rather than the client's context, so a backfill queued before shutdown
+	// still completes while the queue drains
+	ctx, cancel := context.WithTimeout(context.Background(), orderBackfillTimeout)
+	defer cancel()
+
+	if err := c.config.Backfiller.Backfill(ctx, c.products.list()); err != nil {
+		zap.L().Error("Order backfill failed",
+			zap.Uint64("expected", expected),
+			zap.Uint64("received", received),
+			zap.Error(err))
+	}
+}
+
 // ChannelHandler interface implementation
 
 // GetChannelName returns the channel name for this handler
 func (c *OrdersClient) GetChannelName() string {
-	return "orders"
+	return ChannelOrders
 }
 
 // BuildSignatureMessage builds the message string to be signed
 func (c *OrdersClient) BuildSignatureMessage(baseConfig BaseConfig, timestamp string) string {
-	// Format: channel + accessKey + serviceAccountId + timestamp + portfolioId + joinedProductIDs
-	productIdsJoined := joinProductIds(c.config.Products)
-	return c.GetChannelName() + baseConfig.AccessKey + baseConfig.ServiceAccountId + timestamp + c.config.PortfolioId + productIdsJoined
+	return c.signatureFor(baseConfig, timestamp, c.products.list())
 }
 
 // BuildSubscriptionMessage builds the subscription payload
 func (c *OrdersClient) BuildSubscriptionMessage(baseConfig BaseConfig, timestamp string, signature string) map[string]interface{} {
+	return c.subscriptionFor(baseConfig, timestamp, signature, c.products.list())
+}
+
+func (c *OrdersClient) signatureFor(baseConfig BaseConfig, timestamp string, products []string) string {
+	// Format: channel + accessKey + serviceAccountId + timestamp + portfolioId + joinedProductIDs
+	productIdsJoined := joinProductIds(products)
+	return c.GetChannelName() + baseConfig.AccessKey + baseConfig.ServiceAccountId + timestamp + c.config.PortfolioId + productIdsJoined
+}
+
+func (c *OrdersClient) subscriptionFor(baseConfig BaseConfig, timestamp, signature string, products []string) map[string]interface{} {
 	// Start with base subscription message
 	msg := buildBaseSubscriptionMessage(
 		c.GetChannelName(),
@@ -84,7 +201,7 @@ func (c *OrdersClient) BuildSubscriptionMessage(baseConfig BaseConfig, timestamp
 		timestamp,
 		baseConfig.Passphrase,
 		signature,
-		c.config.Products,
+		products,
 	)
 
 	// Add orders-specific field
@@ -94,7 +211,7 @@ func (c *OrdersClient) BuildSubscriptionMessage(baseConfig BaseConfig, timestamp
 }
 
 // HandleMessage processes messages for the orders channel
-func (c *OrdersClient) HandleMessage(message map[string]interface{}) error {
+func (c *OrdersClient) HandleMessage(message *Message) error {
 	// Pass to handler without logging raw JSON
 	if c.handler != nil {
 		if err := c.handler.HandleOrderUpdate(message); err != nil {
@@ -0,0 +1,135 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"fmt"
+	"sync"
+)
+
+// productSet is a channel's subscribed products, safe to change while the client runs
+type productSet struct {
+	mu       sync.RWMutex
+	products []string
+	index    map[string]bool
+}
+
+func newProductSet(products []string) *productSet {
+	s := &productSet{index: make(map[string]bool)}
+	s.add(products)
+	return s
+}
+
+// list returns a copy of the products in subscription order
+func (s *productSet) list() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.products...)
+}
+
+// contains reports whether the product is subscribed
+func (s *productSet) contains(product string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.index[product]
+}
+
+// ifContains runs fn while holding the set's read lock if the product is subscribed
+// remove waits for fn to return, so fn can't act on a product remove has already dropped
+func (s *productSet) ifContains(product string, fn func()) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.index[product] {
+		return false
+	}
+	fn()
+	return true
+}
+
+// add appends products not already in the set and returns them
+func (s *productSet) add(products []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var added []string
+	for _, product := range products {
+		if product == "" || s.index[product] {
+			continue
+		}
+		s.index[product] = true
+		s.products = append(s.products, product)
+		added = append(added, product)
+	}
+	return added
+}
+
+// remove drops products from the set and returns the ones that were present
+// evict, if set, runs for each removed product before the lock is released
+// The last product can't be removed since Prime rejects subscriptions without products
+func (s *productSet) remove(products []string, evict func(product string)) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	drop := make(map[string]bool)
+	for _, product := range products {
+		if s.index[product] {
+			drop[product] = true
+		}
+	}
+	if len(drop) > 0 && len(drop) == len(s.products) {
+		return nil, fmt.Errorf("cannot remove every subscribed product")
+	}
+
+	var removed []string
+	kept := s.products[:0:0]
+	for _, product := range s.products {
+		if drop[product] {
+			removed = append(removed, product)
+			delete(s.index, product)
+			if evict != nil {
+				evict(product)
+			}
+			continue
+		}
+		kept = append(kept, product)
+	}
+	s.products = kept
+	return removed, nil
+}
+
+// productChannel is a channel whose subscription is signed over its product list
+type productChannel interface {
+	ChannelHandler
+	signatureFor(baseConfig BaseConfig, timestamp string, products []string) string
+	subscriptionFor(baseConfig BaseConfig, timestamp, signature string, products []string) map[string]interface{}
+}
+
+// productSubscription signs a subscribe or unsubscribe for a subset of a channel's products
+type productSubscription struct {
+	productChannel
+	products []string
+}
+
+// BuildSignatureMessage signs over the subset's products only
+func (p productSubscription) BuildSignatureMessage(baseConfig BaseConfig, timestamp string) string {
+	return p.signatureFor(baseConfig, timestamp, p.products)
+}
+
+// BuildSubscriptionMessage lists the subset's products only
+func (p productSubscription) BuildSubscriptionMessage(baseConfig BaseConfig, timestamp string, signature string) map[string]interface{} {
+	return p.subscriptionFor(baseConfig, timestamp, signature, p.products)
+}
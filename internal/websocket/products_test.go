@@ -0,0 +1,223 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/mockws"
+)
+
+func TestProductSet(t *testing.T) {
+	tests := []struct {
+		name        string
+		initial     []string
+		add         []string
+		remove      []string
+		wantAdded   string
+		wantRemoved string
+		wantList    string
+		wantErr     bool
+	}{
+		{
+			name:      "add new and skip duplicates",
+			initial:   []string{"BTC-USD"},
+			add:       []string{"ETH-USD", "BTC-USD", "ETH-USD", ""},
+			wantAdded: "ETH-USD",
+			wantList:  "BTC-USD,ETH-USD",
+		},
+		{
+			name:        "remove keeps order",
+			initial:     []string{"BTC-USD", "ETH-USD", "SOL-USD"},
+			remove:      []string{"ETH-USD", "DOGE-USD"},
+			wantRemoved: "ETH-USD",
+			wantList:    "BTC-USD,SOL-USD",
+		},
+		{
+			name:     "cannot remove every product",
+			initial:  []string{"BTC-USD", "ETH-USD"},
+			remove:   []string{"BTC-USD", "ETH-USD"},
+			wantList: "BTC-USD,ETH-USD",
+			wantErr:  true,
+		},
+		{
+			name:     "removing unknown products is a no-op",
+			initial:  []string{"BTC-USD"},
+			remove:   []string{"ETH-USD"},
+			wantList: "BTC-USD",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := newProductSet(tt.initial)
+
+			added := set.add(tt.add)
+			if got := strings.Join(added, ","); got != tt.wantAdded {
+				t.Errorf("add() = %s, want %s", got, tt.wantAdded)
+			}
+
+			removed, err := set.remove(tt.remove, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("remove() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := strings.Join(removed, ","); got != tt.wantRemoved {
+				t.Errorf("remove() = %s, want %s", got, tt.wantRemoved)
+			}
+
+			if got := strings.Join(set.list(), ","); got != tt.wantList {
+				t.Errorf("list() = %s, want %s", got, tt.wantList)
+			}
+			for _, product := range set.list() {
+				if !set.contains(product) {
+					t.Errorf("contains(%s) = false", product)
+				}
+			}
+		})
+	}
+}
+
+func TestMarketDataClient_AddRemoveProducts(t *testing.T) {
+	scenario, err := mockws.ParseScenario([]byte(`{
+		"seed": 1, "tick_interval": "5ms", "heartbeat_interval": "1s",
+		"products": [
+			{"product_id": "BTC-USD", "mid": "100", "spread_bps": "10", "tick_size": "0.01", "depth": 3, "level_size": "1", "volatility_bps": "1"},
+			{"product_id": "ETH-USD", "mid": "10", "spread_bps": "10", "tick_size": "0.01", "depth": 3, "level_size": "1", "volatility_bps": "1"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseScenario() error = %v", err)
+	}
+	creds := mockws.Credentials{AccessKey: "access", Passphrase: "passphrase", SigningKey: "key", ServiceAccountId: "svc"}
+	srv := httptest.NewServer(mockws.NewServer(creds, scenario))
+	defer srv.Close()
+
+	store := NewOrderBookStore()
+	recorder := &stateRecorder{}
+	client := NewMarketDataClient(MarketDataConfig{
+		CommonConfig: CommonConfig{
+			Url:              "ws" + strings.TrimPrefix(srv.URL, "http"),
+			AccessKey:        creds.AccessKey,
+			Passphrase:       creds.Passphrase,
+			SigningKey:       creds.SigningKey,
+			ServiceAccountId: creds.ServiceAccountId,
+			Products:         []string{"BTC-USD"},
+			ReconnectDelay:   time.Hour,
+			OnStateChange:    recorder.record,
+		},
+		MaxLevels: 10,
+	}, store)
+	client.Start(context.Background())
+	defer client.Stop()
+
+	waitForBook := func(product string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, ok := store.Get(product); ok {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("no order book for %s", product)
+	}
+
+	waitForBook("BTC-USD")
+
+	// The delta subscription is signed over ETH-USD alone; the mock rejects a bad signature as fatal
+	if err := client.AddProducts("ETH-USD"); err != nil {
+		t.Fatalf("AddProducts() error = %v", err)
+	}
+	waitForBook("ETH-USD")
+
+	if err := client.RemoveProducts("BTC-USD"); err != nil {
+		t.Fatalf("RemoveProducts() error = %v", err)
+	}
+
+	// Outlast several ticks; in-flight updates must not recreate the evicted book
+	time.Sleep(50 * time.Millisecond)
+	if _, ok := store.Get("BTC-USD"); ok {
+		t.Error("BTC-USD book should be evicted after RemoveProducts")
+	}
+	if got := strings.Join(client.Products(), ","); got != "ETH-USD" {
+		t.Errorf("Products() = %s, want ETH-USD", got)
+	}
+	if err := client.RemoveProducts("ETH-USD"); err == nil {
+		t.Error("removing the last product should fail")
+	}
+
+	if got := strings.Join(recorder.states(), ","); got != "connecting,connected" {
+		t.Errorf("states = %s, want a single uninterrupted connection", got)
+	}
+}
+
+func TestMarketDataClient_RemoveProductsDuringUpdate(t *testing.T) {
+	update := &Message{
+		Channel: ChannelL2Data,
+		L2Events: []L2Event{{
+			Type:      "update",
+			ProductId: "BTC-USD",
+			Updates:   []L2Update{{Side: "bid", Px: "100", Qty: "1"}},
+		}},
+	}
+
+	// Each round races queued updates against the removal; no update may land after RemoveProducts returns
+	for round := 0; round < 100; round++ {
+		store := NewOrderBookStore()
+		client := NewMarketDataClient(MarketDataConfig{
+			CommonConfig: CommonConfig{Products: []string{"BTC-USD", "ETH-USD"}},
+		}, store)
+
+		var wg sync.WaitGroup
+		stop := make(chan struct{})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					client.HandleMessage(update)
+				}
+			}
+		}()
+
+		for {
+			if _, ok := store.Get("BTC-USD"); ok {
+				break
+			}
+			time.Sleep(time.Microsecond)
+		}
+		if err := client.RemoveProducts("BTC-USD"); err != nil {
+			t.Fatalf("RemoveProducts() error = %v", err)
+		}
+		_, recreated := store.Get("BTC-USD")
+
+		close(stop)
+		wg.Wait()
+
+		if _, ok := store.Get("BTC-USD"); recreated || ok {
+			t.Fatalf("round %d: update recreated the BTC-USD book after RemoveProducts", round)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// OverflowPolicy decides what happens when a channel's queue is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock stops reading from the socket until the handler catches up
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropResync drops messages while the queue is full, then resubscribes for a fresh snapshot
+	// Suited to market data, where a new snapshot replaces everything that was dropped
+	OverflowDropResync
+	// OverflowSpill writes messages to a file on disk while the queue is full and replays them in order
+	// Suited to orders, where every update must eventually be handled
+	OverflowSpill
+)
+
+// String returns the policy name
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropResync:
+		return "drop_resync"
+	case OverflowSpill:
+		return "spill"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+}
+
+// QueueConfig configures the work queue between the socket read loop and a channel's handler
+type QueueConfig struct {
+	Size     int            // Messages buffered in memory; 0 handles messages inline on the read goroutine
+	Overflow OverflowPolicy // What to do when Size messages are already waiting
+	SpillDir string         // Directory for OverflowSpill files; defaults to the OS temp directory
+}
+
+// QueueStats reports a channel queue's depth and how long messages wait before their handler runs
+type QueueStats struct {
+	Depth     int           // Messages waiting in memory
+	Spilled   int           // Messages waiting on disk
+	HighWater int           // Largest in-memory depth seen
+	Enqueued  uint64        // Messages accepted, in memory or on disk
+	Processed uint64        // Messages handed to the handler
+	Dropped   uint64        // Messages dropped by OverflowDropResync
+	Lost      uint64        // Spilled messages that couldn't be read back; recovered by a resync and backfill
+	LastWait  time.Duration // Queue latency of the most recent message
+	MaxWait   time.Duration
+	AvgWait   time.Duration
+}
+
+// queuedHandler is implemented by channel handlers that want their messages queued
+type queuedHandler interface {
+	queueConfig() QueueConfig
+}
+
+// queueItem is a message waiting for its handler
+// Gap items carry the sequence gap the message revealed so the handler can recover in order
+type queueItem struct {
+	Message    *Message  `json:"message"`
+	ReceivedAt time.Time `json:"received_at"`
+	Gap        bool      `json:"gap,omitempty"`
+	Expected   uint64    `json:"expected,omitempty"`
+	Received   uint64    `json:"received,omitempty"`
+}
+
+// channelQueue is a bounded FIFO for one channel's messages
+// It has a single producer (the read loop) and a single consumer (processQueue)
+type channelQueue struct {
+	channel string
+	config  QueueConfig
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	items     []queueItem
+	spilled   int // Items written to disk and not yet taken by pop
+	closed    bool
+	stats     QueueStats
+	totalWait time.Duration
+
+	// spillMu guards the spill file, so disk I/O never holds mu and blocks the read loop's push
+	spillMu sync.Mutex
+	spill   *spillFile
+}
+
+func newChannelQueue(channel string, config QueueConfig) *channelQueue {
+	q := &channelQueue{
+		channel: channel,
+		config:  config,
+		items:   make([]queueItem, 0, config.Size),
+		spill:   &spillFile{dir: config.SpillDir, channel: channel},
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds an item according to the overflow policy
+// Returns false if the item was dropped or the queue is closed
+func (q *channelQueue) push(item queueItem) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if q.closed {
+			return false, nil
+		}
+
+		// Once spilling, later items go to disk too so they stay behind the spilled ones
+		if q.spilled == 0 && len(q.items) < q.config.Size {
+			q.items = append(q.items, item)
+			q.stats.Enqueued++
+			if len(q.items) > q.stats.HighWater {
+				q.stats.HighWater = len(q.items)
+			}
+			q.cond.Broadcast()
+			return true, nil
+		}
+
+		switch q.config.Overflow {
+		case OverflowDropResync:
+			q.stats.Dropped++
+			return false, nil
+		case OverflowSpill:
+			// Only push adds items, so nothing else can be queued while mu is released for the write
+			q.mu.Unlock()
+			q.spillMu.Lock()
+			err := q.spill.write(item)
+			q.spillMu.Unlock()
+			q.mu.Lock()
+			if err != nil {
+				return false, err
+			}
+			q.spilled++
+			q.stats.Enqueued++
+			q.cond.Broadcast()
+			return true, nil
+		default:
+			q.cond.Wait()
+		}
+	}
+}
+
+// pop waits for the next item in arrival order
+// After close it keeps returning what's left, in memory and on disk, then returns false
+// A spilled item that can't be read back is returned as an error, so the caller can recover the lost message
+func (q *channelQueue) pop() (queueItem, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.items) == 0 && q.spilled == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.items) == 0 && q.spilled == 0 {
+		return queueItem{}, false, nil
+	}
+
+	var item queueItem
+	if len(q.items) > 0 {
+		item = q.items[0]
+		q.items[0] = queueItem{}
+		q.items = q.items[1:]
+	} else {
+		// Spilled items are older than anything pushed to memory while this one is read
+		q.spilled--
+		q.mu.Unlock()
+		q.spillMu.Lock()
+		var err error
+		item, err = q.spill.read()
+		q.spillMu.Unlock()
+		q.mu.Lock()
+		if err != nil {
+			q.stats.Lost++
+			q.cond.Broadcast()
+			return queueItem{}, true, fmt.Errorf("%s queue: %w", q.channel, err)
+		}
+	}
+	q.cond.Broadcast()
+
+	wait := time.Since(item.ReceivedAt)
+	q.stats.Processed++
+	q.stats.LastWait = wait
+	if wait > q.stats.MaxWait {
+		q.stats.MaxWait = wait
+	}
+	q.totalWait += wait
+	return item, true, nil
+}
+
+// drop counts a message discarded without being offered to the queue
+func (q *channelQueue) drop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.stats.Dropped++
+}
+
+// close stops accepting items and wakes any blocked producer or consumer
+// Items already queued are still returned by pop, so shutdown doesn't lose accepted work
+func (q *channelQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// Stats returns a snapshot of the queue's metrics
+func (q *channelQueue) Stats() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := q.stats
+	stats.Depth = len(q.items)
+	stats.Spilled = q.spilled
+	if stats.Processed > 0 {
+		stats.AvgWait = q.totalWait / time.Duration(stats.Processed)
+	}
+	return stats
+}
+
+// spillFile is an append-only JSONL overflow file, created on first use and removed once drained
+type spillFile struct {
+	dir     string
+	channel string
+	file    *os.File
+	reader  *bufio.Reader
+	source  *os.File
+	unread  int // Items written and not yet read
+}
+
+func (s *spillFile) write(item queueItem) error {
+	if s.file == nil {
+		file, err := os.CreateTemp(s.dir, "prime-"+strings.ReplaceAll(s.channel, "/", "_")+"-spill-*.jsonl")
+		if err != nil {
+			return fmt.Errorf("failed to create spill file: %w", err)
+		}
+		source, err := os.Open(file.Name())
+		if err != nil {
+			file.Close()
+			os.Remove(file.Name())
+			return fmt.Errorf("failed to open spill file: %w", err)
+		}
+		s.file, s.source, s.reader = file, source, bufio.NewReader(source)
+		zap.L().Warn("Queue full, spilling messages to disk",
+			zap.String("channel", s.channel),
+			zap.String("file", file.Name()))
+	}
+
+	line, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to encode spilled message: %w", err)
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write spill file: %w", err)
+	}
+	s.unread++
+	return nil
+}
+
+// read returns the oldest unread item, removing the file once every item has been read
+func (s *spillFile) read() (queueItem, error) {
+	var item queueItem
+	if s.reader == nil {
+		return item, fmt.Errorf("failed to read spill file: no spilled messages")
+	}
+
+	s.unread--
+	defer func() {
+		if s.unread == 0 {
+			s.reset()
+		}
+	}()
+
+	line, err := s.reader.ReadBytes('\n')
+	if err != nil {
+		return item, fmt.Errorf("failed to read spill file: %w", err)
+	}
+	if err := json.Unmarshal(line, &item); err != nil {
+		return item, fmt.Errorf("failed to decode spilled message: %w", err)
+	}
+	return item, nil
+}
+
+// reset removes the spill file; the next write starts a new one
+func (s *spillFile) reset() {
+	if s.file == nil {
+		return
+	}
+	s.source.Close()
+	s.file.Close()
+	os.Remove(s.file.Name())
+	s.file, s.source, s.reader, s.unread = nil, nil, nil, 0
+}
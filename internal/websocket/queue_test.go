@@ -0,0 +1,467 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func queueMessage(seq int) queueItem {
+	sequence := uint64(seq)
+	return queueItem{
+		Message:    &Message{Channel: ChannelL2Data, SequenceNum: &sequence},
+		ReceivedAt: time.Now(),
+	}
+}
+
+func popSequences(t *testing.T, q *channelQueue, n int) string {
+	t.Helper()
+	var got []string
+	for i := 0; i < n; i++ {
+		item, ok, err := q.pop()
+		if !ok {
+			t.Fatalf("pop() returned closed after %d items", i)
+		}
+		if err != nil {
+			t.Fatalf("pop() error = %v", err)
+		}
+		seq, _ := item.Message.Sequence()
+		got = append(got, fmt.Sprint(seq))
+	}
+	return strings.Join(got, ",")
+}
+
+func TestChannelQueue_DropResync(t *testing.T) {
+	q := newChannelQueue("l2_data", QueueConfig{Size: 2, Overflow: OverflowDropResync})
+
+	for seq := 1; seq <= 4; seq++ {
+		accepted, err := q.push(queueMessage(seq))
+		if err != nil {
+			t.Fatalf("push() error = %v", err)
+		}
+		if want := seq <= 2; accepted != want {
+			t.Errorf("push(%d) accepted = %v, want %v", seq, accepted, want)
+		}
+	}
+
+	if got := popSequences(t, q, 2); got != "1,2" {
+		t.Errorf("popped %s, want 1,2", got)
+	}
+	stats := q.Stats()
+	if stats.Dropped != 2 || stats.Enqueued != 2 || stats.Processed != 2 || stats.HighWater != 2 || stats.Depth != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestChannelQueue_SpillPreservesOrder(t *testing.T) {
+	dir := t.TempDir()
+	q := newChannelQueue("orders", QueueConfig{Size: 2, Overflow: OverflowSpill, SpillDir: dir})
+
+	for seq := 1; seq <= 5; seq++ {
+		if accepted, err := q.push(queueMessage(seq)); !accepted || err != nil {
+			t.Fatalf("push(%d) = %v, %v", seq, accepted, err)
+		}
+	}
+	if stats := q.Stats(); stats.Depth != 2 || stats.Spilled != 3 {
+		t.Errorf("stats = %+v, want depth 2 and 3 spilled", stats)
+	}
+
+	// Room in memory must not let a new message overtake the spilled ones
+	if got := popSequences(t, q, 1); got != "1" {
+		t.Errorf("popped %s, want 1", got)
+	}
+	q.push(queueMessage(6))
+
+	if got := popSequences(t, q, 5); got != "2,3,4,5,6" {
+		t.Errorf("popped %s, want 2,3,4,5,6", got)
+	}
+
+	// The spill file is removed once drained
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 0 {
+		t.Errorf("spill files left behind: %v", files)
+	}
+
+	// Spilling starts over with a fresh file after draining
+	for seq := 7; seq <= 9; seq++ {
+		q.push(queueMessage(seq))
+	}
+	if got := popSequences(t, q, 3); got != "7,8,9" {
+		t.Errorf("popped %s, want 7,8,9", got)
+	}
+}
+
+func TestChannelQueue_BlockWaitsForRoom(t *testing.T) {
+	q := newChannelQueue("orders", QueueConfig{Size: 1, Overflow: OverflowBlock})
+	q.push(queueMessage(1))
+
+	pushed := make(chan struct{})
+	go func() {
+		q.push(queueMessage(2))
+		close(pushed)
+	}()
+
+	select {
+	case <-pushed:
+		t.Fatal("push() into a full queue should block")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if got := popSequences(t, q, 1); got != "1" {
+		t.Errorf("popped %s, want 1", got)
+	}
+	select {
+	case <-pushed:
+	case <-time.After(time.Second):
+		t.Fatal("push() still blocked after pop")
+	}
+
+	// Closing wakes blocked producers (the queue is full again); what's queued is still delivered
+	go q.close()
+	if accepted, _ := q.push(queueMessage(4)); accepted {
+		t.Error("push() after close should not be accepted")
+	}
+	if got := popSequences(t, q, 1); got != "2" {
+		t.Errorf("popped %s after close, want 2", got)
+	}
+	if _, ok, _ := q.pop(); ok {
+		t.Error("pop() on a closed, drained queue should report closed")
+	}
+}
+
+func TestChannelQueue_CloseDrainsSpilled(t *testing.T) {
+	dir := t.TempDir()
+	q := newChannelQueue("orders", QueueConfig{Size: 2, Overflow: OverflowSpill, SpillDir: dir})
+	for seq := 1; seq <= 5; seq++ {
+		q.push(queueMessage(seq))
+	}
+	q.close()
+
+	if got := popSequences(t, q, 5); got != "1,2,3,4,5" {
+		t.Errorf("popped %s after close, want 1,2,3,4,5", got)
+	}
+	if _, ok, _ := q.pop(); ok {
+		t.Error("pop() on a closed, drained queue should report closed")
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("spill files left behind: %v", files)
+	}
+}
+
+// spillWithCorruptSecond queues 1 in memory and spills 2, 3 and 4, then corrupts 2 on disk
+func spillWithCorruptSecond(t *testing.T) *channelQueue {
+	t.Helper()
+	q := newChannelQueue("orders", QueueConfig{Size: 1, Overflow: OverflowSpill, SpillDir: t.TempDir()})
+	for seq := 1; seq <= 4; seq++ {
+		q.push(queueMessage(seq))
+	}
+
+	name := q.spill.file.Name()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	first := strings.IndexByte(string(data), '\n')
+	if err := os.WriteFile(name, append([]byte(strings.Repeat("x", first)), data[first:]...), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return q
+}
+
+func TestChannelQueue_SpillReadErrorIsReturned(t *testing.T) {
+	q := spillWithCorruptSecond(t)
+
+	if got := popSequences(t, q, 1); got != "1" {
+		t.Errorf("popped %s, want 1", got)
+	}
+	if _, ok, err := q.pop(); !ok || err == nil {
+		t.Fatalf("pop() of a corrupt spilled message = %v, %v, want an error", ok, err)
+	}
+	if got := popSequences(t, q, 2); got != "3,4" {
+		t.Errorf("popped %s after the lost message, want 3,4", got)
+	}
+	if stats := q.Stats(); stats.Lost != 1 || stats.Spilled != 0 {
+		t.Errorf("stats = %+v, want 1 lost and nothing left spilled", stats)
+	}
+}
+
+// gapRecordingHandler records handled messages and gap recoveries in order
+type gapRecordingHandler struct {
+	events []string
+}
+
+func (h *gapRecordingHandler) GetChannelName() string { return ChannelOrders }
+
+func (h *gapRecordingHandler) BuildSignatureMessage(baseConfig BaseConfig, timestamp string) string {
+	return ""
+}
+
+func (h *gapRecordingHandler) BuildSubscriptionMessage(baseConfig BaseConfig, timestamp string, signature string) map[string]interface{} {
+	return map[string]interface{}{"channel": ChannelOrders}
+}
+
+func (h *gapRecordingHandler) HandleMessage(message *Message) error {
+	seq, _ := message.Sequence()
+	h.events = append(h.events, fmt.Sprint(seq))
+	return nil
+}
+
+func (h *gapRecordingHandler) DiscardOnSequenceGap() bool { return false }
+
+func (h *gapRecordingHandler) HandleSequenceGap(expected, received uint64) {
+	h.events = append(h.events, fmt.Sprintf("recover %d-%d", expected, received))
+}
+
+func TestBaseWebSocketClient_RecoversLostSpilledMessages(t *testing.T) {
+	handler := &gapRecordingHandler{}
+	client := NewBaseWebSocketClient(BaseConfig{ReconnectDelay: time.Hour})
+	q := spillWithCorruptSecond(t)
+	q.close()
+
+	// Recovery waits until the older spilled messages are handled, so they can't overwrite it
+	client.processQueue(handler, q)
+	if got := strings.Join(handler.events, ","); got != "1,3,4,recover 0-0" {
+		t.Errorf("events = %s, want 1,3,4,recover 0-0", got)
+	}
+}
+
+func TestChannelQueue_Latency(t *testing.T) {
+	q := newChannelQueue("orders", QueueConfig{Size: 4})
+	item := queueMessage(1)
+	item.ReceivedAt = time.Now().Add(-50 * time.Millisecond)
+	q.push(item)
+	q.pop()
+
+	stats := q.Stats()
+	if stats.LastWait < 50*time.Millisecond || stats.MaxWait != stats.LastWait || stats.AvgWait != stats.LastWait {
+		t.Errorf("stats = %+v, want waits of at least 50ms", stats)
+	}
+}
+
+// gatedHandler blocks on each message until released, simulating a slow handler
+type gatedHandler struct {
+	queue    QueueConfig
+	started  chan uint64
+	release  chan struct{}
+	mu       sync.Mutex
+	received []uint64
+}
+
+func (h *gatedHandler) GetChannelName() string { return "l2_data" }
+
+func (h *gatedHandler) BuildSignatureMessage(baseConfig BaseConfig, timestamp string) string {
+	return ""
+}
+
+func (h *gatedHandler) BuildSubscriptionMessage(baseConfig BaseConfig, timestamp string, signature string) map[string]interface{} {
+	return map[string]interface{}{"channel": "l2_data"}
+}
+
+func (h *gatedHandler) HandleMessage(message *Message) error {
+	seq, _ := message.Sequence()
+	h.started <- seq
+	<-h.release
+	h.mu.Lock()
+	h.received = append(h.received, seq)
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *gatedHandler) queueConfig() QueueConfig { return h.queue }
+
+func (h *gatedHandler) sequences() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return fmt.Sprint(h.received)
+}
+
+func TestBaseWebSocketClient_QueueDropResync(t *testing.T) {
+	handler := &gatedHandler{
+		queue:   QueueConfig{Size: 2, Overflow: OverflowDropResync},
+		started: make(chan uint64, 16),
+		release: make(chan struct{}),
+	}
+
+	var mu sync.Mutex
+	var types []string
+	send := make(chan int, 16)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		go func() {
+			for seq := range send {
+				conn.WriteJSON(map[string]interface{}{"channel": "l2_data", "sequence_num": seq})
+			}
+		}()
+		for {
+			var sub map[string]interface{}
+			if err := conn.ReadJSON(&sub); err != nil {
+				return
+			}
+			msgType, _ := sub["type"].(string)
+			mu.Lock()
+			types = append(types, msgType)
+			resubscribed := len(types) == 3
+			mu.Unlock()
+			if resubscribed {
+				send <- 100 // Fresh snapshot after the resync
+			}
+		}
+	}))
+	defer srv.Close()
+
+	client := NewBaseWebSocketClient(BaseConfig{
+		Url:            "ws" + strings.TrimPrefix(srv.URL, "http"),
+		ReconnectDelay: time.Hour,
+	}, handler)
+	client.Start(context.Background())
+	defer client.Stop()
+
+	waitForState(t, client.State, StateConnected)
+
+	// The handler stalls on 1 while 2 and 3 fill the queue and 4 and 5 are dropped
+	send <- 1
+	<-handler.started
+	for seq := 2; seq <= 5; seq++ {
+		send <- seq
+	}
+	waitForQueue(t, client, func(s QueueStats) bool { return s.Dropped == 2 })
+
+	// Once the queue drains, the next message is dropped too and triggers the resync
+	for i := 0; i < 3; i++ {
+		handler.release <- struct{}{}
+		if i < 2 {
+			<-handler.started
+		}
+	}
+	send <- 6
+	if seq := <-handler.started; seq != 100 {
+		t.Fatalf("next handled sequence = %d, want the resync snapshot", seq)
+	}
+	handler.release <- struct{}{}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for handler.sequences() != "[1 2 3 100]" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := handler.sequences(); got != "[1 2 3 100]" {
+		t.Errorf("handled %s, want [1 2 3 100]", got)
+	}
+	stats, _ := client.queueStats("l2_data")
+	if stats.Dropped != 3 {
+		t.Errorf("Dropped = %d, want 3", stats.Dropped)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(types, ","); got != "subscribe,unsubscribe,subscribe" {
+		t.Errorf("subscriptions = %s, want subscribe,unsubscribe,subscribe", got)
+	}
+}
+
+func waitForQueue(t *testing.T, client *BaseWebSocketClient, done func(QueueStats) bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if stats, _ := client.queueStats("l2_data"); done(stats) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	stats, _ := client.queueStats("l2_data")
+	t.Fatalf("queue stats = %+v", stats)
+}
+
+func TestOrdersClient_QueueSpillsUnderLoad(t *testing.T) {
+	var messages []map[string]interface{}
+	for seq := 1; seq <= 50; seq++ {
+		messages = append(messages, map[string]interface{}{
+			"channel":      "orders",
+			"sequence_num": seq,
+			"events":       []interface{}{},
+		})
+	}
+	server := &scriptedServer{batches: [][]map[string]interface{}{messages}}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	handler := &slowOrderHandler{delay: time.Millisecond}
+	client := NewOrdersClient(OrdersConfig{
+		CommonConfig: CommonConfig{
+			Url:            "ws" + strings.TrimPrefix(srv.URL, "http"),
+			Products:       []string{"BTC-USD"},
+			ReconnectDelay: time.Hour,
+			Queue:          QueueConfig{Size: 4, Overflow: OverflowSpill, SpillDir: dir},
+		},
+	}, handler)
+	client.Start(context.Background())
+	defer client.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(handler.received()) < 50 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	got := handler.received()
+	if len(got) != 50 {
+		t.Fatalf("handled %d messages, want 50", len(got))
+	}
+	for i, seq := range got {
+		if seq != uint64(i+1) {
+			t.Fatalf("message %d has sequence %v; order not preserved", i, seq)
+		}
+	}
+
+	stats, ok := client.QueueStats()
+	if !ok {
+		t.Fatal("QueueStats() not available")
+	}
+	if stats.HighWater != 4 || stats.Processed != 50 || stats.Dropped != 0 {
+		t.Errorf("stats = %+v, want high water 4, 50 processed and nothing dropped", stats)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("spill files left behind: %d", len(entries))
+	}
+}
+
+// slowOrderHandler records sequence numbers after a fixed delay, like a slow database write
+type slowOrderHandler struct {
+	sequenceRecordingHandler
+	delay time.Duration
+}
+
+func (h *slowOrderHandler) HandleOrderUpdate(update *Message) error {
+	time.Sleep(h.delay)
+	return h.sequenceRecordingHandler.HandleOrderUpdate(update)
+}
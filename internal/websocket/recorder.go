@@ -0,0 +1,170 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RecordedFrame is one raw websocket frame and when it was received
+type RecordedFrame struct {
+	ReceivedAt time.Time       `json:"received_at"`
+	Frame      json.RawMessage `json:"frame"`
+}
+
+const (
+	recorderFlushFrames   = 100         // Frames buffered before they are flushed to the file
+	recorderFlushInterval = time.Second // Longest a recorded frame waits in memory
+)
+
+// FrameRecorder writes every raw frame received by a client to a gzip-compressed JSONL file
+// One recorder can be shared by several clients; writes are serialized.
+// Frames are flushed every recorderFlushFrames frames and every recorderFlushInterval, so a crash
+// loses at most the last second of frames and the file replays up to that point.
+type FrameRecorder struct {
+	mu        sync.Mutex
+	file      *os.File
+	gz        *gzip.Writer
+	buf       *bufio.Writer
+	frames    int
+	unflushed int
+	stop      chan struct{}
+}
+
+// NewFrameRecorder creates (or truncates) the recording file at path
+func NewFrameRecorder(path string) (*FrameRecorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+
+	gz := gzip.NewWriter(file)
+	r := &FrameRecorder{
+		file: file,
+		gz:   gz,
+		buf:  bufio.NewWriter(gz),
+		stop: make(chan struct{}),
+	}
+	go r.flushPeriodically()
+	return r, nil
+}
+
+// flushPeriodically flushes frames that arrived since the last flush until Close
+func (r *FrameRecorder) flushPeriodically() {
+	ticker := time.NewTicker(recorderFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			if r.buf != nil && r.unflushed > 0 {
+				if err := r.flush(); err != nil {
+					zap.L().Warn("Failed to flush recording", zap.Error(err))
+				}
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+// flush pushes buffered frames through the gzip writer to the file
+// Caller must hold r.mu
+func (r *FrameRecorder) flush() error {
+	if err := r.buf.Flush(); err != nil {
+		return err
+	}
+	if err := r.gz.Flush(); err != nil {
+		return err
+	}
+	r.unflushed = 0
+	return nil
+}
+
+// Record appends a frame; frames that aren't valid JSON are stored as JSON strings
+func (r *FrameRecorder) Record(receivedAt time.Time, frame []byte) error {
+	raw := json.RawMessage(frame)
+	if !json.Valid(frame) {
+		quoted, err := json.Marshal(string(frame))
+		if err != nil {
+			return fmt.Errorf("failed to encode frame: %w", err)
+		}
+		raw = quoted
+	}
+
+	line, err := json.Marshal(RecordedFrame{ReceivedAt: receivedAt.UTC(), Frame: raw})
+	if err != nil {
+		return fmt.Errorf("failed to encode frame: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.buf == nil {
+		return fmt.Errorf("recorder is closed")
+	}
+	if _, err := r.buf.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write frame: %w", err)
+	}
+	r.frames++
+	r.unflushed++
+	if r.unflushed >= recorderFlushFrames {
+		if err := r.flush(); err != nil {
+			return fmt.Errorf("failed to flush recording: %w", err)
+		}
+	}
+	return nil
+}
+
+// Frames returns how many frames have been recorded
+func (r *FrameRecorder) Frames() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.frames
+}
+
+// Close flushes buffered frames and closes the file
+func (r *FrameRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.buf == nil {
+		return nil
+	}
+	close(r.stop)
+	buf, gz, file := r.buf, r.gz, r.file
+	r.buf, r.gz, r.file = nil, nil, nil
+
+	if err := buf.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to flush recording: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to finish recording: %w", err)
+	}
+	return file.Close()
+}
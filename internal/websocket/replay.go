@@ -0,0 +1,287 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ReplaySource feeds a FrameRecorder recording into channel handlers
+// Frames are streamed from the file on each Replay rather than held in memory
+type ReplaySource struct {
+	path     string
+	frames   int
+	first    time.Time
+	last     time.Time
+	products map[string]map[string]bool // Product IDs seen per channel
+}
+
+// NewReplaySource opens the recording at path and reads it once to summarize its frames and products
+// A recording cut short by a crash is replayed up to its last complete frame
+func NewReplaySource(path string) (*ReplaySource, error) {
+	source := &ReplaySource{path: path, products: make(map[string]map[string]bool)}
+	truncated, err := source.readFrames(func(frame RecordedFrame) error {
+		if source.frames == 0 {
+			source.first = frame.ReceivedAt
+		}
+		source.last = frame.ReceivedAt
+		source.frames++
+		source.addProducts(frame)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if truncated {
+		zap.L().Warn("Recording ends mid-frame; replaying the frames before it",
+			zap.String("file", path),
+			zap.Int("frames", source.frames))
+	}
+	return source, nil
+}
+
+// readFrames calls fn for each frame in the recording in receive order
+// Returns true when the recording ends abruptly, as it does when the recorder never closed it
+func (s *ReplaySource) readFrames(fn func(frame RecordedFrame) error) (bool, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return false, fmt.Errorf("failed to read recording: %w", err)
+	}
+	defer gz.Close()
+
+	reader := bufio.NewReader(gz)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			// A partial last line was still in the recorder's buffers
+			return true, nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return false, fmt.Errorf("failed to read recording: %w", err)
+		}
+		if len(data) > 0 {
+			var frame RecordedFrame
+			if jsonErr := json.Unmarshal(data, &frame); jsonErr != nil {
+				return false, fmt.Errorf("invalid frame on line %d: %w", line, jsonErr)
+			}
+			if fnErr := fn(frame); fnErr != nil {
+				return false, fnErr
+			}
+		}
+		if err != nil {
+			return false, nil
+		}
+	}
+}
+
+// addProducts notes the product IDs a frame carries under its channel
+func (s *ReplaySource) addProducts(frame RecordedFrame) {
+	var msg struct {
+		Channel string `json:"channel"`
+		Events  []struct {
+			ProductId string `json:"product_id"`
+			Orders    []struct {
+				ProductId string `json:"product_id"`
+			} `json:"orders"`
+		} `json:"events"`
+	}
+	if json.Unmarshal(frame.Frame, &msg) != nil || msg.Channel == "" {
+		return
+	}
+
+	seen := s.products[msg.Channel]
+	if seen == nil {
+		seen = make(map[string]bool)
+		s.products[msg.Channel] = seen
+	}
+	for _, event := range msg.Events {
+		if event.ProductId != "" {
+			seen[event.ProductId] = true
+		}
+		for _, order := range event.Orders {
+			if order.ProductId != "" {
+				seen[order.ProductId] = true
+			}
+		}
+	}
+}
+
+// Len returns how many frames the recording holds
+func (s *ReplaySource) Len() int {
+	return s.frames
+}
+
+// Span returns when the first and last frames were received
+func (s *ReplaySource) Span() (first, last time.Time) {
+	return s.first, s.last
+}
+
+// Products returns the product IDs seen on a channel, sorted
+// Useful for constructing the clients a recording is replayed into
+func (s *ReplaySource) Products(channel string) []string {
+	products := make([]string, 0, len(s.products[channel]))
+	for product := range s.products[channel] {
+		products = append(products, product)
+	}
+	sort.Strings(products)
+	return products
+}
+
+// ReplayStats summarizes a replay
+type ReplayStats struct {
+	Frames    int            // Frames read from the recording
+	Delivered map[string]int // Messages delivered per channel
+	Stale     int            // Messages dropped as duplicates or out of order
+	Gaps      int            // Sequence gaps encountered
+}
+
+// Replay delivers the recorded frames to the handler for each frame's channel
+// Speed 1 reproduces the original timing, 10 replays ten times faster, and 0 replays without delays.
+// Sequence numbers are checked the same way the live client checks them, so stale messages are dropped
+// and gap handling (including discarding the revealing l2 update) matches what happened live.
+// Resubscribes aren't sent; the recording already contains whatever the server sent after them.
+func (s *ReplaySource) Replay(ctx context.Context, speed float64, handlers ...ChannelHandler) (*ReplayStats, error) {
+	if speed < 0 {
+		return nil, fmt.Errorf("replay speed cannot be negative")
+	}
+
+	channels := make(map[string]ChannelHandler, len(handlers))
+	for _, handler := range handlers {
+		channels[handler.GetChannelName()] = handler
+	}
+
+	stats := &ReplayStats{Delivered: make(map[string]int)}
+	sequences := newSequenceTracker()
+
+	var previous time.Time
+	next := 0
+	_, err := s.readFrames(func(frame RecordedFrame) error {
+		i := next
+		next++
+		if speed > 0 && i > 0 {
+			delay := time.Duration(float64(frame.ReceivedAt.Sub(previous)) / speed)
+			if err := sleepContext(ctx, delay); err != nil {
+				return err
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+		previous = frame.ReceivedAt
+		stats.Frames++
+
+		msg, err := decodeMessage(frame.Frame)
+		if err != nil {
+			zap.L().Warn("Skipping undecodable frame", zap.Int("frame", i), zap.Error(err))
+			return nil
+		}
+
+		// The live client restarts sequence tracking on every (re)subscribe
+		if msg.Type == MessageTypeSubscriptions {
+			for _, channel := range subscribedChannels(msg) {
+				sequences.reset(channel)
+			}
+			return nil
+		}
+
+		handler, ok := channels[msg.Channel]
+		if !ok {
+			return nil
+		}
+
+		seq, sequenced := msg.Sequence()
+		if !sequenced {
+			s.deliver(handler, msg, stats)
+			return nil
+		}
+
+		result, expected := sequences.check(msg.Channel, seq)
+		switch result {
+		case sequenceOk:
+			s.deliver(handler, msg, stats)
+		case sequenceStale:
+			stats.Stale++
+		case sequenceGap:
+			stats.Gaps++
+			gapHandler, _ := handler.(SequenceGapHandler)
+			if gapHandler == nil || !gapHandler.DiscardOnSequenceGap() {
+				s.deliver(handler, msg, stats)
+			}
+			if gapHandler != nil {
+				gapHandler.HandleSequenceGap(expected, seq)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// deliver passes a message to its handler, logging handler errors as the live client does
+func (s *ReplaySource) deliver(handler ChannelHandler, msg *Message, stats *ReplayStats) {
+	if err := handler.HandleMessage(msg); err != nil {
+		zap.L().Error("Error handling replayed message",
+			zap.String("channel", handler.GetChannelName()),
+			zap.Error(err))
+	}
+	stats.Delivered[handler.GetChannelName()]++
+}
+
+// subscribedChannels lists the channels named in a subscriptions confirmation
+func subscribedChannels(msg *Message) []string {
+	var channels []string
+	for _, event := range msg.SubscriptionEvents {
+		for channel := range event.Subscriptions {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,258 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/mockws"
+)
+
+func TestFrameRecorder_RecordAndReplaySession(t *testing.T) {
+	scenario, err := mockws.ParseScenario([]byte(`{
+		"seed": 7, "tick_interval": "2ms", "heartbeat_interval": "5ms",
+		"products": [{"product_id": "BTC-USD", "mid": "100", "spread_bps": "10", "tick_size": "0.01", "depth": 5, "level_size": "1", "volatility_bps": "5"}]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseScenario() error = %v", err)
+	}
+	creds := mockws.Credentials{AccessKey: "access", Passphrase: "passphrase", SigningKey: "key", ServiceAccountId: "svc"}
+	srv := httptest.NewServer(mockws.NewServer(creds, scenario))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "session.jsonl.gz")
+	recorder, err := NewFrameRecorder(path)
+	if err != nil {
+		t.Fatalf("NewFrameRecorder() error = %v", err)
+	}
+
+	liveStore := NewOrderBookStore()
+	client := NewMarketDataClient(MarketDataConfig{
+		CommonConfig: CommonConfig{
+			Url:              "ws" + strings.TrimPrefix(srv.URL, "http"),
+			AccessKey:        creds.AccessKey,
+			Passphrase:       creds.Passphrase,
+			SigningKey:       creds.SigningKey,
+			ServiceAccountId: creds.ServiceAccountId,
+			Products:         []string{"BTC-USD"},
+			ReconnectDelay:   time.Hour,
+			HeartbeatTimeout: time.Second,
+			Recorder:         recorder,
+		},
+		MaxLevels: 10,
+	}, liveStore)
+	client.Start(context.Background())
+
+	deadline := time.Now().Add(5 * time.Second)
+	for recorder.Frames() < 30 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	client.Stop()
+	// Every recorded frame is handled before the next read, so let the last one finish
+	time.Sleep(50 * time.Millisecond)
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := recorder.Record(time.Now(), []byte(`{}`)); err == nil {
+		t.Error("Record() after Close should fail")
+	}
+
+	source, err := NewReplaySource(path)
+	if err != nil {
+		t.Fatalf("NewReplaySource() error = %v", err)
+	}
+	if source.Len() != recorder.Frames() {
+		t.Fatalf("replay has %d frames, recorded %d", source.Len(), recorder.Frames())
+	}
+	if got := strings.Join(source.Products("l2_data"), ","); got != "BTC-USD" {
+		t.Errorf("Products(l2_data) = %s, want BTC-USD", got)
+	}
+
+	replayStore := NewOrderBookStore()
+	replayClient := NewMarketDataClient(MarketDataConfig{
+		CommonConfig: CommonConfig{Products: source.Products("l2_data")},
+		MaxLevels:    10,
+	}, replayStore)
+	stats, err := source.Replay(context.Background(), 0, replayClient)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if stats.Delivered["l2_data"] == 0 {
+		t.Fatal("no l2_data messages replayed")
+	}
+
+	liveBook, _ := liveStore.Get("BTC-USD")
+	replayBook, ok := replayStore.Get("BTC-USD")
+	if !ok {
+		t.Fatal("replay produced no BTC-USD book")
+	}
+	live, replayed := liveBook.Snapshot(), replayBook.Snapshot()
+	if live.Sequence != replayed.Sequence {
+		t.Errorf("replayed sequence = %d, live = %d", replayed.Sequence, live.Sequence)
+	}
+	if fmt.Sprint(live.Bids, live.Asks) != fmt.Sprint(replayed.Bids, replayed.Asks) {
+		t.Errorf("replayed book differs from live book\nlive:     %v %v\nreplayed: %v %v", live.Bids, live.Asks, replayed.Bids, replayed.Asks)
+	}
+}
+
+// writeRecording records frames received at the given offsets from a fixed start time
+func writeRecording(t *testing.T, offsets []time.Duration, frames []string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "recording.jsonl.gz")
+	recorder, err := NewFrameRecorder(path)
+	if err != nil {
+		t.Fatalf("NewFrameRecorder() error = %v", err)
+	}
+	start := time.Date(2025, 1, 15, 14, 0, 0, 0, time.UTC)
+	for i, frame := range frames {
+		if err := recorder.Record(start.Add(offsets[i]), []byte(frame)); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return path
+}
+
+func TestReplaySource_UnclosedRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crashed.jsonl.gz")
+	recorder, err := NewFrameRecorder(path)
+	if err != nil {
+		t.Fatalf("NewFrameRecorder() error = %v", err)
+	}
+	defer recorder.Close()
+
+	// Only the first recorderFlushFrames frames have been flushed when the "crash" happens
+	start := time.Date(2025, 1, 15, 14, 0, 0, 0, time.UTC)
+	for i := 0; i < recorderFlushFrames+5; i++ {
+		frame := fmt.Sprintf(`{"channel":"orders","sequence_num":%d,"events":[]}`, i)
+		if err := recorder.Record(start.Add(time.Duration(i)*time.Millisecond), []byte(frame)); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	source, err := NewReplaySource(path)
+	if err != nil {
+		t.Fatalf("NewReplaySource() error = %v", err)
+	}
+	if source.Len() != recorderFlushFrames {
+		t.Errorf("Len() = %d, want the %d flushed frames", source.Len(), recorderFlushFrames)
+	}
+	stats, err := source.Replay(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if stats.Frames != recorderFlushFrames {
+		t.Errorf("replayed %d frames, want %d", stats.Frames, recorderFlushFrames)
+	}
+}
+
+func TestReplaySource_Speed(t *testing.T) {
+	frame := `{"channel":"orders","events":[]}`
+	path := writeRecording(t,
+		[]time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond},
+		[]string{frame, frame, frame})
+
+	source, err := NewReplaySource(path)
+	if err != nil {
+		t.Fatalf("NewReplaySource() error = %v", err)
+	}
+
+	tests := []struct {
+		speed   float64
+		atLeast time.Duration
+		atMost  time.Duration
+	}{
+		{speed: 1, atLeast: 200 * time.Millisecond, atMost: time.Second},
+		{speed: 10, atLeast: 20 * time.Millisecond, atMost: 150 * time.Millisecond},
+		{speed: 0, atLeast: 0, atMost: 50 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("speed %g", tt.speed), func(t *testing.T) {
+			handler := &sequenceRecordingHandler{}
+			client := NewOrdersClient(OrdersConfig{CommonConfig: CommonConfig{Products: []string{"BTC-USD"}}}, handler)
+
+			began := time.Now()
+			stats, err := source.Replay(context.Background(), tt.speed, client)
+			elapsed := time.Since(began)
+			if err != nil {
+				t.Fatalf("Replay() error = %v", err)
+			}
+			if stats.Delivered["orders"] != 3 {
+				t.Errorf("delivered = %d, want 3", stats.Delivered["orders"])
+			}
+			if elapsed < tt.atLeast || elapsed > tt.atMost {
+				t.Errorf("replay took %s, want between %s and %s", elapsed, tt.atLeast, tt.atMost)
+			}
+		})
+	}
+
+	if _, err := source.Replay(context.Background(), -1); err == nil {
+		t.Error("negative speed should fail")
+	}
+}
+
+func TestReplaySource_SequenceHandling(t *testing.T) {
+	order := func(seq int) string {
+		return fmt.Sprintf(`{"channel":"orders","sequence_num":%d,"events":[]}`, seq)
+	}
+	subscriptions := `{"channel":"subscriptions","type":"subscriptions","events":[{"subscriptions":{"orders":["BTC-USD"]}}]}`
+	frames := []string{
+		order(1), order(2), order(2), // Duplicate is dropped
+		order(4),                // Gap is delivered for orders
+		subscriptions, order(1), // Resubscribe restarts the sequence
+		`{"channel":"heartbeats","events":[]}`, // No handler; ignored
+		`not json`,
+	}
+	offsets := make([]time.Duration, len(frames))
+	path := writeRecording(t, offsets, frames)
+
+	source, err := NewReplaySource(path)
+	if err != nil {
+		t.Fatalf("NewReplaySource() error = %v", err)
+	}
+
+	backfiller := &recordingBackfiller{}
+	handler := &sequenceRecordingHandler{}
+	client := NewOrdersClient(OrdersConfig{
+		CommonConfig: CommonConfig{Products: []string{"BTC-USD"}},
+		Backfiller:   backfiller,
+	}, handler)
+
+	stats, err := source.Replay(context.Background(), 0, client)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+
+	if got := fmt.Sprint(handler.received()); got != "[1 2 4 1]" {
+		t.Errorf("delivered sequences = %s, want [1 2 4 1]", got)
+	}
+	if stats.Frames != len(frames) || stats.Stale != 1 || stats.Gaps != 1 {
+		t.Errorf("stats = %+v, want %d frames, 1 stale, 1 gap", stats, len(frames))
+	}
+	if backfiller.calls() != 1 {
+		t.Errorf("backfill calls = %d, want 1", backfiller.calls())
+	}
+}
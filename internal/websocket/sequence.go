@@ -0,0 +1,83 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import "sync"
+
+// SequenceGapHandler is implemented by channel handlers that need to recover state after missed messages
+type SequenceGapHandler interface {
+	// DiscardOnSequenceGap reports whether the message that revealed a gap should be dropped
+	// rather than handled (e.g., an incremental book update applied to a book that missed updates)
+	DiscardOnSequenceGap() bool
+
+	// HandleSequenceGap is called when sequence_num skips from expected to received, after the
+	// revealing message has been handled or dropped and before the channel is resubscribed
+	// expected and received are both zero when queued messages were lost locally rather than skipped by the server
+	HandleSequenceGap(expected, received uint64)
+}
+
+// sequenceCheck is the outcome of comparing a message's sequence_num with the last one seen
+type sequenceCheck int
+
+const (
+	sequenceOk    sequenceCheck = iota
+	sequenceStale               // Duplicate or older than the last message; already applied
+	sequenceGap                 // One or more messages were skipped
+)
+
+// sequenceTracker detects gaps in Prime's per-channel sequence_num
+// Subscriptions changed at runtime reset it from outside the run goroutine, so access is locked
+type sequenceTracker struct {
+	mu   sync.Mutex
+	last map[string]uint64
+}
+
+func newSequenceTracker() *sequenceTracker {
+	return &sequenceTracker{last: make(map[string]uint64)}
+}
+
+// check records seq for the channel and reports whether it follows the previous message
+// The first message after a reset is accepted as the new baseline
+func (t *sequenceTracker) check(channel string, seq uint64) (sequenceCheck, uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	last, seen := t.last[channel]
+	if !seen {
+		t.last[channel] = seq
+		return sequenceOk, seq
+	}
+
+	expected := last + 1
+	switch {
+	case seq < expected:
+		return sequenceStale, expected
+	case seq > expected:
+		t.last[channel] = seq
+		return sequenceGap, expected
+	default:
+		t.last[channel] = seq
+		return sequenceOk, expected
+	}
+}
+
+// reset forgets the channel's position, e.g., after (re)subscribing
+func (t *sequenceTracker) reset(channel string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.last, channel)
+}
@@ -0,0 +1,287 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSequenceTracker_Check(t *testing.T) {
+	tests := []struct {
+		name         string
+		sequence     []uint64
+		wantResults  []sequenceCheck
+		wantExpected uint64 // Expected value reported for the last message
+	}{
+		{"first message is baseline", []uint64{42}, []sequenceCheck{sequenceOk}, 42},
+		{"consecutive", []uint64{1, 2, 3}, []sequenceCheck{sequenceOk, sequenceOk, sequenceOk}, 3},
+		{"gap", []uint64{1, 2, 5}, []sequenceCheck{sequenceOk, sequenceOk, sequenceGap}, 3},
+		{"continues after gap", []uint64{1, 4, 5}, []sequenceCheck{sequenceOk, sequenceGap, sequenceOk}, 5},
+		{"duplicate", []uint64{1, 2, 2}, []sequenceCheck{sequenceOk, sequenceOk, sequenceStale}, 3},
+		{"older", []uint64{5, 6, 3}, []sequenceCheck{sequenceOk, sequenceOk, sequenceStale}, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newSequenceTracker()
+			var expected uint64
+			for i, seq := range tt.sequence {
+				var result sequenceCheck
+				result, expected = tracker.check("l2_data", seq)
+				if result != tt.wantResults[i] {
+					t.Errorf("check(%d) = %v, want %v", seq, result, tt.wantResults[i])
+				}
+			}
+			if expected != tt.wantExpected {
+				t.Errorf("expected = %d, want %d", expected, tt.wantExpected)
+			}
+		})
+	}
+}
+
+func TestSequenceTracker_ResetAndChannels(t *testing.T) {
+	tracker := newSequenceTracker()
+	tracker.check("l2_data", 10)
+	tracker.check("orders", 1)
+
+	// Channels are tracked independently
+	if result, _ := tracker.check("orders", 2); result != sequenceOk {
+		t.Errorf("orders check = %v, want ok", result)
+	}
+
+	// After a reset any sequence is accepted as the new baseline
+	tracker.reset("l2_data")
+	if result, _ := tracker.check("l2_data", 3); result != sequenceOk {
+		t.Errorf("check after reset = %v, want ok", result)
+	}
+}
+
+// scriptedServer replies to each subscribe with the next batch of scripted messages
+// and records the type of every subscription message it receives
+type scriptedServer struct {
+	mu      sync.Mutex
+	batches [][]map[string]interface{}
+	types   []string
+}
+
+func (s *scriptedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	for {
+		var sub map[string]interface{}
+		if err := conn.ReadJSON(&sub); err != nil {
+			return
+		}
+		msgType, _ := sub["type"].(string)
+
+		s.mu.Lock()
+		s.types = append(s.types, msgType)
+		var batch []map[string]interface{}
+		if msgType == "subscribe" && len(s.batches) > 0 {
+			batch, s.batches = s.batches[0], s.batches[1:]
+		}
+		s.mu.Unlock()
+
+		for _, msg := range batch {
+			conn.WriteJSON(msg)
+		}
+	}
+}
+
+func (s *scriptedServer) subscriptionTypes() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.Join(s.types, ",")
+}
+
+func l2Message(seq int, eventType string, updates ...map[string]string) map[string]interface{} {
+	return map[string]interface{}{
+		"channel":      "l2_data",
+		"sequence_num": seq,
+		"events": []interface{}{map[string]interface{}{
+			"type":       eventType,
+			"product_id": "BTC-USD",
+			"updates":    updates,
+		}},
+	}
+}
+
+func level(side, px, qty string) map[string]string {
+	return map[string]string{"side": side, "px": px, "qty": qty}
+}
+
+func TestMarketDataClient_SequenceGapResyncs(t *testing.T) {
+	server := &scriptedServer{batches: [][]map[string]interface{}{
+		{
+			l2Message(1, "snapshot", level("bid", "100", "1"), level("offer", "101", "1")),
+			l2Message(2, "update", level("bid", "100.5", "2")),
+			// Sequence 3 is lost; this update must not be applied
+			l2Message(4, "update", level("offer", "100.8", "9")),
+		},
+		{
+			l2Message(5, "snapshot", level("bid", "99", "3"), level("offer", "102", "4")),
+		},
+	}}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	store := NewOrderBookStore()
+	client := NewMarketDataClient(MarketDataConfig{
+		CommonConfig: CommonConfig{
+			Url:            "ws" + strings.TrimPrefix(srv.URL, "http"),
+			Products:       []string{"BTC-USD"},
+			ReconnectDelay: time.Hour,
+		},
+		MaxLevels: 10,
+	}, store)
+	client.Start(context.Background())
+	defer client.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if book, ok := store.Get("BTC-USD"); ok && book.Snapshot().Sequence == 5 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	book, _ := store.Get("BTC-USD")
+	snapshot := book.Snapshot()
+	if snapshot.Sequence != 5 {
+		t.Fatalf("Sequence = %d, want 5 from the resync snapshot", snapshot.Sequence)
+	}
+	if len(snapshot.Bids) != 1 || snapshot.Bids[0].Price.String() != "99" {
+		t.Errorf("bids = %v, want only the resync snapshot's level", snapshot.Bids)
+	}
+	if len(snapshot.Asks) != 1 || snapshot.Asks[0].Price.String() != "102" {
+		t.Errorf("asks = %v, want only the resync snapshot's level", snapshot.Asks)
+	}
+	if got := client.SequenceGaps(); got != 1 {
+		t.Errorf("SequenceGaps() = %d, want 1", got)
+	}
+	if got := server.subscriptionTypes(); got != "subscribe,unsubscribe,subscribe" {
+		t.Errorf("subscriptions = %s, want subscribe,unsubscribe,subscribe", got)
+	}
+}
+
+// recordingBackfiller records backfill calls
+type recordingBackfiller struct {
+	mu       sync.Mutex
+	products [][]string
+}
+
+func (b *recordingBackfiller) Backfill(ctx context.Context, productIds []string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.products = append(b.products, productIds)
+	return nil
+}
+
+func (b *recordingBackfiller) calls() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.products)
+}
+
+// sequenceRecordingHandler records the sequence_num of each order update it receives
+type sequenceRecordingHandler struct {
+	mu        sync.Mutex
+	sequences []uint64
+}
+
+func (h *sequenceRecordingHandler) HandleOrderUpdate(update *Message) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	seq, _ := update.Sequence()
+	h.sequences = append(h.sequences, seq)
+	return nil
+}
+
+func (h *sequenceRecordingHandler) received() []uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]uint64(nil), h.sequences...)
+}
+
+func TestOrdersClient_SequenceGapBackfills(t *testing.T) {
+	orderMessage := func(seq int) map[string]interface{} {
+		return map[string]interface{}{
+			"channel":      "orders",
+			"sequence_num": seq,
+			"events":       []interface{}{map[string]interface{}{"type": "update", "orders": []interface{}{}}},
+		}
+	}
+	server := &scriptedServer{batches: [][]map[string]interface{}{
+		{orderMessage(1), orderMessage(2), orderMessage(2), orderMessage(5)},
+	}}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	backfiller := &recordingBackfiller{}
+	handler := &sequenceRecordingHandler{}
+	client := NewOrdersClient(OrdersConfig{
+		CommonConfig: CommonConfig{
+			Url:            "ws" + strings.TrimPrefix(srv.URL, "http"),
+			Products:       []string{"BTC-USD", "ETH-USD"},
+			ReconnectDelay: time.Hour,
+		},
+		Backfiller: backfiller,
+	}, handler)
+	client.Start(context.Background())
+	defer client.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for backfiller.calls() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if backfiller.calls() != 1 {
+		t.Fatalf("backfill calls = %d, want 1", backfiller.calls())
+	}
+	if got := strings.Join(backfiller.products[0], ","); got != "BTC-USD,ETH-USD" {
+		t.Errorf("backfilled products = %s", got)
+	}
+
+	// The duplicate is dropped; the update revealing the gap is still applied
+	got := handler.received()
+	want := []uint64{1, 2, 5}
+	if len(got) != len(want) {
+		t.Fatalf("handled sequences = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("handled sequences = %v, want %v", got, want)
+			break
+		}
+	}
+	if client.SequenceGaps() != 1 {
+		t.Errorf("SequenceGaps() = %d, want 1", client.SequenceGaps())
+	}
+}
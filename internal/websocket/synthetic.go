@@ -0,0 +1,190 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+)
+
+// syntheticBook defines a cross product priced from two books, e.g. ETH-BTC from ETH-USD and BTC-USD
+// The last composed book is cached until either leg publishes a new state
+type syntheticBook struct {
+	product  string
+	baseLeg  string // BASE-X, e.g. ETH-USD
+	quoteLeg string // QUOTE-X, e.g. BTC-USD
+
+	mu         sync.Mutex
+	baseState  *bookState
+	quoteState *bookState
+	book       *OrderBook
+}
+
+// AddSynthetic registers product as a cross of baseLeg (BASE-X) and quoteLeg (QUOTE-X)
+// The legs must be streamed into the store separately; Get returns the synthetic book once both have data.
+// Synthetic books carry raw prices, so a PriceAdjuster applies the markup once, not per leg.
+func (s *OrderBookStore) AddSynthetic(product, baseLeg, quoteLeg string) error {
+	if product == baseLeg || product == quoteLeg || baseLeg == quoteLeg {
+		return fmt.Errorf("synthetic product %s needs two distinct legs, got %s and %s", product, baseLeg, quoteLeg)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.books[product]; exists {
+		return fmt.Errorf("%s already has a streamed order book", product)
+	}
+	s.synthetics[product] = &syntheticBook{product: product, baseLeg: baseLeg, quoteLeg: quoteLeg}
+	return nil
+}
+
+// Synthetics returns the registered synthetic products, sorted
+func (s *OrderBookStore) Synthetics() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	products := make([]string, 0, len(s.synthetics))
+	for product := range s.synthetics {
+		products = append(products, product)
+	}
+	slices.Sort(products)
+	return products
+}
+
+// IsSynthetic reports whether product is a registered synthetic cross
+// Synthetic books are indicative only and cannot be traded on Prime
+func (s *OrderBookStore) IsSynthetic(product string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.synthetics[product]
+	return ok
+}
+
+// syntheticsWithLeg returns the synthetic products composed from leg
+func (s *OrderBookStore) syntheticsWithLeg(leg string) []*syntheticBook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*syntheticBook
+	for _, synthetic := range s.synthetics {
+		if synthetic.baseLeg == leg || synthetic.quoteLeg == leg {
+			result = append(result, synthetic)
+		}
+	}
+	return result
+}
+
+// composeSynthetic returns the full-depth synthetic book for the legs' current states, recomposing only
+// when a leg changed. Readers call it through Get, so the work is done when a book is read, not per leg update.
+func (s *OrderBookStore) composeSynthetic(synthetic *syntheticBook) (*OrderBook, bool) {
+	legs, ok := s.syntheticLegs(synthetic)
+	if !ok {
+		return nil, false
+	}
+
+	synthetic.mu.Lock()
+	defer synthetic.mu.Unlock()
+
+	if synthetic.book != nil && synthetic.baseState == legs.baseState && synthetic.quoteState == legs.quoteState {
+		synthetic.book.SetMaxLevels(legs.maxLevels)
+		synthetic.book.confirm(legs.confirmed())
+		return synthetic.book, true
+	}
+
+	book := legs.compose(synthetic, 0)
+	synthetic.baseState, synthetic.quoteState, synthetic.book = legs.baseState, legs.quoteState, book
+	return book, true
+}
+
+// composeSyntheticTop composes only the levels readers see (the top of book when books are unlimited)
+// Used on a leg's update path, under its writer lock, so listeners don't pay for full depth on every update.
+// The result is not cached; Get still composes the full book.
+func (s *OrderBookStore) composeSyntheticTop(synthetic *syntheticBook) (*OrderBook, bool) {
+	legs, ok := s.syntheticLegs(synthetic)
+	if !ok {
+		return nil, false
+	}
+
+	levels := legs.maxLevels
+	if levels == 0 {
+		levels = 1
+	}
+	return legs.compose(synthetic, levels), true
+}
+
+// syntheticLegState is the leg books and published states a synthetic book is composed from
+type syntheticLegState struct {
+	baseBook, quoteBook   *OrderBook
+	baseState, quoteState *bookState
+	maxLevels             int
+}
+
+// syntheticLegs loads both legs' current states; false until both legs have a book
+func (s *OrderBookStore) syntheticLegs(synthetic *syntheticBook) (syntheticLegState, bool) {
+	s.mu.RLock()
+	baseBook, hasBase := s.books[synthetic.baseLeg]
+	quoteBook, hasQuote := s.books[synthetic.quoteLeg]
+	maxLevels := s.maxLevels
+	s.mu.RUnlock()
+	if !hasBase || !hasQuote {
+		return syntheticLegState{}, false
+	}
+
+	return syntheticLegState{
+		baseBook:   baseBook,
+		quoteBook:  quoteBook,
+		baseState:  baseBook.state.Load(),
+		quoteState: quoteBook.state.Load(),
+		maxLevels:  maxLevels,
+	}, true
+}
+
+// confirmed returns when the less recently confirmed leg was last known to be current
+// A live feed confirms the legs without changing them
+func (l syntheticLegState) confirmed() time.Time {
+	confirmed := l.baseBook.freshAt(l.baseState)
+	if quoteConfirmed := l.quoteBook.freshAt(l.quoteState); quoteConfirmed.Before(confirmed) {
+		confirmed = quoteConfirmed
+	}
+	return confirmed
+}
+
+// compose builds the synthetic book from the legs, up to levels per side; 0 composes full depth
+func (l syntheticLegState) compose(synthetic *syntheticBook, levels int) *OrderBook {
+	// The older leg dates the book so staleness checks see the least recent data; the sequence
+	// moves whenever either leg does
+	updateTime := l.baseState.updateTime
+	if l.quoteState.updateTime.Before(updateTime) {
+		updateTime = l.quoteState.updateTime
+	}
+
+	book := &OrderBook{Product: synthetic.product, Legs: []string{synthetic.baseLeg, synthetic.quoteLeg}}
+	book.SetMaxLevels(l.maxLevels)
+	book.state.Store(&bookState{
+		bids:       common.ComposeCrossBids(l.baseState.bids, l.quoteState.asks, levels),
+		asks:       common.ComposeCrossAsks(l.baseState.asks, l.quoteState.bids, levels),
+		updateTime: updateTime,
+		sequence:   l.baseState.sequence + l.quoteState.sequence,
+	})
+	book.confirm(l.confirmed())
+	return book
+}
@@ -0,0 +1,189 @@
+/**
+ * Copyright 2025-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase-samples/prime-trading-fees-go/internal/common"
+	"github.com/shopspring/decimal"
+)
+
+// crossStore streams ETH-USD and BTC-USD books and registers ETH-BTC from them
+func crossStore(t *testing.T) *OrderBookStore {
+	t.Helper()
+	store := NewOrderBookStore()
+	store.GetOrCreate("ETH-USD").Update(
+		[]common.PriceLevel{{Price: decimal.NewFromInt(2000), Size: decimal.NewFromInt(10)}},
+		[]common.PriceLevel{{Price: decimal.NewFromInt(2010), Size: decimal.NewFromInt(10)}},
+		1)
+	store.GetOrCreate("BTC-USD").Update(
+		[]common.PriceLevel{{Price: decimal.NewFromInt(39900), Size: decimal.NewFromInt(1)}},
+		[]common.PriceLevel{{Price: decimal.NewFromInt(40000), Size: decimal.NewFromInt(1)}},
+		1)
+	if err := store.AddSynthetic("ETH-BTC", "ETH-USD", "BTC-USD"); err != nil {
+		t.Fatalf("AddSynthetic() error = %v", err)
+	}
+	return store
+}
+
+func TestOrderBookStore_SyntheticBook(t *testing.T) {
+	store := crossStore(t)
+
+	book, ok := store.Get("ETH-BTC")
+	if !ok {
+		t.Fatal("Get(ETH-BTC) found no synthetic book")
+	}
+	snapshot := book.Snapshot()
+	if !snapshot.Indicative || !book.Indicative() {
+		t.Error("synthetic book is not flagged indicative")
+	}
+
+	// Conservative: sell ETH at its bid and buy BTC at its ask, and the reverse for the ask
+	bid, _ := book.GetBestBid()
+	ask, _ := book.GetBestAsk()
+	if !bid.Price.Equal(decimal.RequireFromString("0.05")) {
+		t.Errorf("best bid = %s, want 2000/40000 = 0.05", bid.Price)
+	}
+	if want := decimal.NewFromInt(2010).Div(decimal.NewFromInt(39900)); !ask.Price.Equal(want) {
+		t.Errorf("best ask = %s, want 2010/39900 = %s", ask.Price, want)
+	}
+
+	// The composed book is reused until a leg changes
+	if again, _ := store.Get("ETH-BTC"); again != book {
+		t.Error("Get() recomposed the synthetic book without a leg update")
+	}
+	store.GetOrCreate("BTC-USD").ApplyUpdates(
+		[]common.PriceLevel{{Price: decimal.NewFromInt(39950), Size: decimal.NewFromInt(1)}}, nil, 2)
+	updated, _ := store.Get("ETH-BTC")
+	if updated == book {
+		t.Fatal("Get() returned a stale synthetic book after a leg update")
+	}
+	if ask, _ := updated.GetBestAsk(); !ask.Price.Equal(decimal.NewFromInt(2010).Div(decimal.NewFromInt(39950))) {
+		t.Errorf("best ask after update = %s, want 2010/39950", ask.Price)
+	}
+	if updated.Snapshot().Sequence == snapshot.Sequence {
+		t.Error("synthetic sequence did not move with the leg")
+	}
+
+	store.Remove("BTC-USD")
+	if _, ok := store.Get("ETH-BTC"); ok {
+		t.Error("Get() returned a synthetic book with a missing leg")
+	}
+}
+
+func TestOrderBookStore_SyntheticNotifiesListeners(t *testing.T) {
+	store := crossStore(t)
+
+	var updated []string
+	store.AddUpdateListener(func(book *OrderBook) {
+		updated = append(updated, book.Product)
+		if book.Product == "ETH-BTC" && !book.Indicative() {
+			t.Error("listener received a synthetic book not flagged indicative")
+		}
+	})
+
+	store.GetOrCreate("BTC-USD").ApplyUpdates(
+		[]common.PriceLevel{{Price: decimal.NewFromInt(39950), Size: decimal.NewFromInt(1)}}, nil, 2)
+	store.GetOrCreate("SOL-USD").ApplyUpdates(
+		[]common.PriceLevel{{Price: decimal.NewFromInt(150), Size: decimal.NewFromInt(1)}}, nil, 1)
+
+	want := []string{"BTC-USD", "ETH-BTC", "SOL-USD"}
+	if len(updated) != len(want) {
+		t.Fatalf("listener saw %v, want %v", updated, want)
+	}
+	for i := range want {
+		if updated[i] != want[i] {
+			t.Errorf("listener saw %v, want %v", updated, want)
+			break
+		}
+	}
+}
+
+func TestOrderBookStore_SyntheticListenersGetTopOfBook(t *testing.T) {
+	store := crossStore(t)
+
+	// The second ETH bid is worth more than the first BTC ask, so the full cross has several bid levels
+	store.GetOrCreate("BTC-USD").ApplyUpdates(nil,
+		[]common.PriceLevel{{Price: decimal.NewFromInt(40100), Size: decimal.NewFromInt(10)}}, 2)
+
+	var depths []int
+	store.AddUpdateListener(func(book *OrderBook) {
+		if book.Product == "ETH-BTC" {
+			bids, asks := book.Depth()
+			depths = append(depths, bids, asks)
+		}
+	})
+
+	store.GetOrCreate("ETH-USD").ApplyUpdates(
+		[]common.PriceLevel{{Price: decimal.NewFromInt(1990), Size: decimal.NewFromInt(100)}}, nil, 2)
+
+	if len(depths) != 2 || depths[0] != 1 || depths[1] != 1 {
+		t.Errorf("listener saw synthetic depth %v, want only the top of book", depths)
+	}
+	full, _ := store.Get("ETH-BTC")
+	if bids, _ := full.Depth(); bids < 2 {
+		t.Errorf("Get() composed %d bid levels, want the full depth", bids)
+	}
+}
+
+func TestOrderBookStore_SyntheticMarkupAppliedOnce(t *testing.T) {
+	store := crossStore(t)
+	book, _ := store.Get("ETH-BTC")
+	adjuster := common.NewPriceAdjuster(common.NewFeeStrategy(decimal.RequireFromString("0.01")))
+
+	estimate, err := book.EstimateExecution("BUY", "base", decimal.NewFromInt(1), adjuster)
+	if err != nil {
+		t.Fatalf("EstimateExecution() error = %v", err)
+	}
+	if !estimate.Indicative {
+		t.Error("estimate from a synthetic book is not flagged indicative")
+	}
+	if want := estimate.Walk.Vwap.Mul(decimal.RequireFromString("1.01")); !estimate.MarkupPrice.Round(12).Equal(want.Round(12)) {
+		t.Errorf("MarkupPrice = %s, want one 1%% markup on the cross = %s", estimate.MarkupPrice, want)
+	}
+}
+
+func TestOrderBookStore_SyntheticUsesOlderLegTime(t *testing.T) {
+	store := crossStore(t)
+	eth, _ := store.Get("ETH-USD")
+	time.Sleep(10 * time.Millisecond)
+	store.GetOrCreate("BTC-USD").ApplyUpdates(
+		[]common.PriceLevel{{Price: decimal.NewFromInt(39950), Size: decimal.NewFromInt(1)}}, nil, 2)
+
+	book, _ := store.Get("ETH-BTC")
+	if got, want := book.Snapshot().UpdateTime, eth.Snapshot().UpdateTime; !got.Equal(want) {
+		t.Errorf("UpdateTime = %s, want the older leg's %s", got, want)
+	}
+}
+
+func TestOrderBookStore_AddSyntheticErrors(t *testing.T) {
+	store := crossStore(t)
+	tests := []struct {
+		product, baseLeg, quoteLeg string
+	}{
+		{product: "ETH-USD", baseLeg: "ETH-EUR", quoteLeg: "USD-EUR"},
+		{product: "ETH-BTC", baseLeg: "ETH-USD", quoteLeg: "ETH-USD"},
+		{product: "ETH-BTC", baseLeg: "ETH-BTC", quoteLeg: "BTC-USD"},
+	}
+	for _, tt := range tests {
+		if err := store.AddSynthetic(tt.product, tt.baseLeg, tt.quoteLeg); err == nil {
+			t.Errorf("AddSynthetic(%s, %s, %s) error = nil, want an error", tt.product, tt.baseLeg, tt.quoteLeg)
+		}
+	}
+}